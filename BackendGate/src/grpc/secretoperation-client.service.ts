@@ -1,6 +1,6 @@
 import { Injectable, OnModuleInit } from '@nestjs/common';
 import { Client, ClientGrpc, Transport } from '@nestjs/microservices';
-import { firstValueFrom } from 'rxjs';
+import { firstValueFrom, Observable } from 'rxjs';
 
 interface Repo {
   id: number;
@@ -11,6 +11,7 @@ interface Repo {
   private: boolean;
   ownerLogin: string;
   ownerAvatarUrl: string;
+  permission: string;
 }
 
 interface ListReposResponse {
@@ -27,6 +28,7 @@ interface GrpcRepo {
   private: boolean;
   ownerLogin: string;
   ownerAvatarUrl: string;
+  permission: string;
 }
 
 interface GrpcListReposResponse {
@@ -40,6 +42,49 @@ interface SecretVersion {
   checksum: string;
   uploadedBy: string;
   createdAt: string;
+  fileName: string;
+  path: string;
+  branch: string;
+  annotation?: VersionAnnotation;
+}
+
+interface VersionAnnotation {
+  message?: string;
+  commitSha?: string;
+  gitBranch?: string;
+  clientType?: string;
+  clientVersion?: string;
+}
+
+interface TagParent {
+  tag: string;
+  parentTag: string;
+  parentVersion: number;
+  fileName: string;
+  path: string;
+}
+
+interface KeyOrigin {
+  key: string;
+  tag: string;
+  version: number;
+  branch: string;
+  sharedSet: string;
+  engine: string;
+}
+
+interface LeaseInfo {
+  leaseId: string;
+  engineName: string;
+  engine: string;
+  expiresAt: string;
+  keys: string[];
+}
+
+interface SecretFileVersions {
+  fileName: string;
+  versions: SecretVersion[];
+  path: string;
 }
 
 interface UploadSecretRequest {
@@ -49,6 +94,34 @@ interface UploadSecretRequest {
   tag: string;
   envFileContent: Buffer;
   userLogin: string;
+  format?: string;
+  keySeparator?: string;
+  fileName?: string;
+  path?: string;
+  branch?: string;
+  keyMetadata?: KeyMetadata[];
+  annotation?: VersionAnnotation;
+}
+
+interface KeyMetadata {
+  key: string;
+  expiresAt?: string;
+  owner?: string;
+  rotationInterval?: string;
+}
+
+interface SchemaViolation {
+  key: string;
+  rule: string;
+  message: string;
+  warning: boolean;
+}
+
+interface LintFinding {
+  key: string;
+  rule: string;
+  message: string;
+  blocking: boolean;
 }
 
 interface UploadSecretResponse {
@@ -56,6 +129,9 @@ interface UploadSecretResponse {
   version: number;
   checksum: string;
   error: string;
+  violations?: SchemaViolation[];
+  lintFindings?: LintFinding[];
+  changeRequestId?: any;
 }
 
 interface ListSecretVersionsRequest {
@@ -63,11 +139,17 @@ interface ListSecretVersionsRequest {
   ownerLogin: string;
   repoName: string;
   userLogin: string;
+  fileName?: string;
+  groupByFile?: boolean;
+  path?: string;
 }
 
 interface ListSecretVersionsResponse {
   versions: SecretVersion[];
   error: string;
+  files?: SecretFileVersions[];
+  branches?: string[];
+  tagParents?: TagParent[];
 }
 
 interface DownloadSecretRequest {
@@ -77,6 +159,15 @@ interface DownloadSecretRequest {
   version?: number;
   tag?: string;
   userLogin: string;
+  format?: string;
+  resourceName?: string;
+  namespace?: string;
+  fileName?: string;
+  path?: string;
+  branch?: string;
+  includeOrigins?: boolean;
+  interpolate?: boolean;
+  leaseTtlSeconds?: number;
 }
 
 interface DownloadSecretByTagRequest {
@@ -97,6 +188,13 @@ interface DownloadSecretResponse {
   createdAt: string;
   error: string;
   isEncrypted: boolean;
+  format: string;
+  fileName: string;
+  path: string;
+  branch: string;
+  branchVersion: number;
+  origins?: KeyOrigin[];
+  leases?: LeaseInfo[];
 }
 
 interface DeleteSecretRequest {
@@ -106,6 +204,9 @@ interface DeleteSecretRequest {
   version?: number;
   tag?: string;
   userLogin: string;
+  fileName?: string;
+  path?: string;
+  branch?: string;
 }
 
 interface DeleteSecretResponse {
@@ -114,6 +215,739 @@ interface DeleteSecretResponse {
   error: string;
 }
 
+interface UploadFileRequest {
+  accessToken: string;
+  ownerLogin: string;
+  repoName: string;
+  tag: string;
+  fileName: string;
+  contentType: string;
+  content: Buffer;
+  userLogin: string;
+}
+
+interface UploadFileResponse {
+  success: boolean;
+  version: number;
+  checksum: string;
+  size: any;
+  error: string;
+}
+
+interface DownloadFileRequest {
+  accessToken: string;
+  ownerLogin: string;
+  repoName: string;
+  fileName: string;
+  version?: number;
+  tag?: string;
+  userLogin: string;
+}
+
+interface DownloadFileResponse {
+  success: boolean;
+  version: number;
+  tag: string;
+  fileName: string;
+  contentType: string;
+  content: Buffer;
+  size: any;
+  checksum: string;
+  uploadedBy: string;
+  createdAt: string;
+  error: string;
+}
+
+interface SetTagParentRequest {
+  accessToken: string;
+  ownerLogin: string;
+  repoName: string;
+  userLogin: string;
+  tag: string;
+  parentTag: string;
+  parentVersion: number;
+  fileName?: string;
+  path?: string;
+}
+
+interface SetTagParentResponse {
+  success: boolean;
+  error: string;
+  changeRequestId?: any;
+}
+
+interface ListSecretReferencesRequest {
+  accessToken: string;
+  ownerLogin: string;
+  repoName: string;
+  userLogin: string;
+  key?: string;
+  tag?: string;
+}
+
+interface SecretReference {
+  ownerLogin: string;
+  repoName: string;
+  path: string;
+  fileName: string;
+  tag: string;
+  branch: string;
+  key: string;
+  targetTag: string;
+  targetKey: string;
+}
+
+interface ListSecretReferencesResponse {
+  references?: SecretReference[];
+  hiddenReferences: number;
+  error: string;
+}
+
+interface UploadSharedSecretSetRequest {
+  accessToken: string;
+  orgLogin: string;
+  setName: string;
+  envFileContent: Buffer;
+  userLogin: string;
+  format?: string;
+  keySeparator?: string;
+}
+
+interface UploadSharedSecretSetResponse {
+  success: boolean;
+  version: number;
+  checksum: string;
+  error: string;
+}
+
+interface ListSharedSecretSetsRequest {
+  accessToken: string;
+  orgLogin: string;
+  userLogin: string;
+}
+
+interface SharedSecretSet {
+  orgLogin: string;
+  name: string;
+  createdBy: string;
+  createdAt: string;
+  versions?: Array<{
+    version: number;
+    checksum: string;
+    uploadedBy: string;
+    createdAt: string;
+  }>;
+  attachments?: Array<{
+    ownerLogin: string;
+    repoName: string;
+    pinnedVersion: number;
+    attachedBy: string;
+  }>;
+}
+
+interface ListSharedSecretSetsResponse {
+  sets?: SharedSecretSet[];
+  error: string;
+}
+
+interface AttachSharedSecretSetRequest {
+  accessToken: string;
+  orgLogin: string;
+  setName: string;
+  ownerLogin: string;
+  repoName: string;
+  userLogin: string;
+  version?: number;
+  detach?: boolean;
+}
+
+interface AttachSharedSecretSetResponse {
+  success: boolean;
+  error: string;
+}
+
+interface SetSchemaRequest {
+  accessToken: string;
+  ownerLogin: string;
+  repoName: string;
+  userLogin: string;
+  tag?: string;
+  schema: Buffer;
+}
+
+interface SetSchemaResponse {
+  success: boolean;
+  error: string;
+}
+
+interface GetSchemaRequest {
+  accessToken: string;
+  ownerLogin: string;
+  repoName: string;
+  userLogin: string;
+  tag?: string;
+}
+
+interface GetSchemaResponse {
+  schema: Buffer;
+  tag: string;
+  updatedBy: string;
+  updatedAt: string;
+  error: string;
+}
+
+interface ListLintRulesRequest {
+  accessToken: string;
+  ownerLogin: string;
+  repoName: string;
+  userLogin: string;
+}
+
+interface LintRule {
+  name: string;
+  description: string;
+  action: string;
+}
+
+interface ListLintRulesResponse {
+  rules: LintRule[];
+  error: string;
+}
+
+interface SetLintRuleRequest {
+  accessToken: string;
+  ownerLogin: string;
+  repoName: string;
+  userLogin: string;
+  rule: string;
+  action: string;
+}
+
+interface SetLintRuleResponse {
+  success: boolean;
+  error: string;
+}
+
+interface ListExpiringSecretsRequest {
+  accessToken: string;
+  userLogin: string;
+  withinDays: number;
+}
+
+interface ExpiringSecret {
+  ownerLogin: string;
+  repoName: string;
+  path: string;
+  fileName: string;
+  branch: string;
+  tag: string;
+  version: number;
+  key: string;
+  owner: string;
+  expiresAt: string;
+  rotationInterval: string;
+  rotatedAt: string;
+  dueAt: string;
+  reason: string;
+  expired: boolean;
+}
+
+interface ListExpiringSecretsResponse {
+  secrets: ExpiringSecret[];
+  error: string;
+}
+
+interface GenerateSpec {
+  key: string;
+  type: string;
+  length?: number;
+  charset?: string;
+  publicKey?: string;
+  sourceKey?: string;
+  cost?: number;
+}
+
+interface GenerateSecretValuesRequest {
+  accessToken: string;
+  ownerLogin: string;
+  repoName: string;
+  userLogin: string;
+  tag: string;
+  fileName?: string;
+  path?: string;
+  branch?: string;
+  values: GenerateSpec[];
+  overwrite?: boolean;
+  annotation?: VersionAnnotation;
+}
+
+interface GenerateSecretValuesResponse {
+  success: boolean;
+  version: number;
+  checksum: string;
+  generatedKeys: string[];
+  violations?: SchemaViolation[];
+  error: string;
+}
+
+interface SetSecretEngineRequest {
+  accessToken: string;
+  ownerLogin: string;
+  repoName: string;
+  userLogin: string;
+  name: string;
+  engine?: string;
+  tag?: string;
+  config?: Buffer;
+  defaultTtlSeconds?: number;
+  maxTtlSeconds?: number;
+  delete?: boolean;
+}
+
+interface SetSecretEngineResponse {
+  success: boolean;
+  error: string;
+}
+
+interface ListSecretEnginesRequest {
+  accessToken: string;
+  ownerLogin: string;
+  repoName: string;
+  userLogin: string;
+}
+
+interface SecretEngineInfo {
+  name: string;
+  engine: string;
+  tag: string;
+  defaultTtlSeconds: number;
+  maxTtlSeconds: number;
+  keys: string[];
+  activeLeases: number;
+  updatedBy: string;
+  updatedAt: string;
+}
+
+interface ListSecretEnginesResponse {
+  engines: SecretEngineInfo[];
+  error: string;
+}
+
+interface ListAuditEventsRequest {
+  accessToken: string;
+  userLogin: string;
+  ownerLogin?: string;
+  repoName?: string;
+  username?: string;
+  operation?: string;
+  success?: boolean;
+  since?: string;
+  until?: string;
+  pageSize?: number;
+  cursor?: string;
+}
+
+interface AuditEvent {
+  id: any;
+  operation: string;
+  ownerLogin: string;
+  repoName: string;
+  tag: string;
+  version: number;
+  username: string;
+  serviceName: string;
+  requestId: string;
+  success: boolean;
+  errorMessage: string;
+  leaseId: string;
+  createdAt: string;
+}
+
+interface ListAuditEventsResponse {
+  events: AuditEvent[];
+  nextCursor: string;
+  error: string;
+}
+
+interface VerifyAuditChainRequest {
+  accessToken: string;
+  userLogin: string;
+  checkpoint?: boolean;
+}
+
+interface VerifyAuditChainResponse {
+  valid: boolean;
+  checkedEntries: any;
+  legacyEntries: any;
+  firstBrokenId: any;
+  reason: string;
+  checkpointsVerified: number;
+  lastCheckpointId: any;
+  error: string;
+  checkpointGapId: any;
+}
+
+interface CreateWebhookRequest {
+  accessToken: string;
+  userLogin: string;
+  ownerLogin: string;
+  repoName: string;
+  orgLogin: string;
+  url: string;
+  events: string[];
+  secret: string;
+}
+
+interface CreateWebhookResponse {
+  success: boolean;
+  webhookId: any;
+  secret: string;
+  error: string;
+}
+
+interface Webhook {
+  id: any;
+  ownerLogin: string;
+  repoName: string;
+  orgLogin: string;
+  url: string;
+  events: string[];
+  active: boolean;
+  createdBy: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+interface ListWebhooksRequest {
+  accessToken: string;
+  userLogin: string;
+  ownerLogin: string;
+  repoName: string;
+  orgLogin: string;
+}
+
+interface ListWebhooksResponse {
+  webhooks: Webhook[];
+  error: string;
+}
+
+interface UpdateWebhookRequest {
+  accessToken: string;
+  userLogin: string;
+  webhookId: number;
+  url?: string;
+  events: string[];
+  active?: boolean;
+  rotateSecret: boolean;
+  secret: string;
+}
+
+interface UpdateWebhookResponse {
+  success: boolean;
+  secret: string;
+  error: string;
+}
+
+interface DeleteWebhookRequest {
+  accessToken: string;
+  userLogin: string;
+  webhookId: number;
+}
+
+interface DeleteWebhookResponse {
+  success: boolean;
+  error: string;
+}
+
+interface ListWebhookDeliveriesRequest {
+  accessToken: string;
+  userLogin: string;
+  webhookId: number;
+  limit: number;
+}
+
+interface WebhookDelivery {
+  id: any;
+  deliveryId: string;
+  event: string;
+  status: string;
+  attempts: number;
+  lastStatusCode: number;
+  lastError: string;
+  createdAt: string;
+  nextAttemptAt: string;
+  deliveredAt: string;
+  redeliveryOf: any;
+}
+
+interface ListWebhookDeliveriesResponse {
+  deliveries: WebhookDelivery[];
+  error: string;
+}
+
+interface RedeliverWebhookRequest {
+  accessToken: string;
+  userLogin: string;
+  deliveryId: number;
+}
+
+interface RedeliverWebhookResponse {
+  success: boolean;
+  deliveryId: any;
+  error: string;
+}
+
+interface WatchedRepository {
+  ownerLogin: string;
+  repoName: string;
+}
+
+interface WatchSecretsRequest {
+  accessToken: string;
+  userLogin: string;
+  repositories: WatchedRepository[];
+  tags: string[];
+  resumeToken: string;
+}
+
+export interface WatchSecretsEvent {
+  type: string;
+  resumeToken: string;
+  ownerLogin: string;
+  repoName: string;
+  tag: string;
+  version: number;
+  path: string;
+  fileName: string;
+  branch: string;
+  file: boolean;
+  checksum: string;
+  deletedVersions: number;
+  actor: string;
+  createdAt: string;
+  error: string;
+}
+
+interface SetTagProtectionRequest {
+  accessToken: string;
+  userLogin: string;
+  ownerLogin: string;
+  repoName: string;
+  tag: string;
+  protected: boolean;
+  requestTtlHours: number;
+}
+
+interface SetTagProtectionResponse {
+  success: boolean;
+  error: string;
+}
+
+interface ProtectedTag {
+  tag: string;
+  requestTtlHours: number;
+  updatedBy: string;
+  updatedAt: string;
+}
+
+interface ListChangeRequestsRequest {
+  accessToken: string;
+  userLogin: string;
+  ownerLogin: string;
+  repoName: string;
+  status: string;
+}
+
+interface ChangeRequest {
+  id: any;
+  tag: string;
+  kind: string;
+  parentTag: string;
+  parentVersion: number;
+  path: string;
+  fileName: string;
+  branch: string;
+  status: string;
+  requestedBy: string;
+  reviewedBy: string;
+  createdAt: string;
+  expiresAt: string;
+  resolvedAt: string;
+  baseVersion: number;
+  version: number;
+  checksum: string;
+  annotation?: any;
+  diff?: { key: string; change: string }[];
+  comments?: { author: string; body: string; createdAt: string }[];
+}
+
+interface ListChangeRequestsResponse {
+  changeRequests: ChangeRequest[];
+  protectedTags: ProtectedTag[];
+  error: string;
+}
+
+interface ReviewChangeRequest {
+  accessToken: string;
+  userLogin: string;
+  changeId: number;
+  comment: string;
+}
+
+interface ApproveChangeResponse {
+  success: boolean;
+  version: number;
+  checksum: string;
+  error: string;
+}
+
+interface RejectChangeResponse {
+  success: boolean;
+  error: string;
+}
+
+interface CommentChangeResponse {
+  success: boolean;
+  error: string;
+}
+
+interface CreateShareLinkRequest {
+  accessToken: string;
+  userLogin: string;
+  ownerLogin: string;
+  repoName: string;
+  tag: string;
+  version: number;
+  path: string;
+  fileName: string;
+  key: string;
+  file: boolean;
+  maxViews: number;
+  expiresInSeconds: number;
+  branch: string;
+}
+
+interface CreateShareLinkResponse {
+  success: boolean;
+  linkId: string;
+  token: string;
+  expiresAt: string;
+  maxViews: number;
+  error: string;
+}
+
+interface RedeemShareLinkRequest {
+  token: string;
+}
+
+interface RedeemShareLinkResponse {
+  success: boolean;
+  kind: string;
+  key: string;
+  content: Buffer;
+  fileName: string;
+  contentType: string;
+  sharedBy: string;
+  remainingViews: number;
+  expiresAt: string;
+  error: string;
+}
+
+interface ListSecretKeysRequest {
+  accessToken: string;
+  userLogin: string;
+  ownerLogin: string;
+  repoName: string;
+  tag: string;
+  version: number;
+  path: string;
+  fileName: string;
+  branch: string;
+}
+
+interface SecretKeyInfo {
+  key: string;
+  length: number;
+  preview: string;
+  fingerprint: string;
+  tag: string;
+  branch: string;
+}
+
+interface ListSecretKeysResponse {
+  keys: SecretKeyInfo[];
+  tag: string;
+  version: number;
+  error: string;
+  branch: string;
+  branchVersion: number;
+}
+
+interface SearchSecretKeysRequest {
+  accessToken: string;
+  userLogin: string;
+  query: string;
+  mode: string;
+  limit: number;
+}
+
+interface SecretKeyMatch {
+  ownerLogin: string;
+  repoName: string;
+  path: string;
+  fileName: string;
+  branch: string;
+  tag: string;
+  version: number;
+  key: string;
+}
+
+interface SearchSecretKeysResponse {
+  matches: SecretKeyMatch[];
+  truncated: boolean;
+  error: string;
+}
+
+interface ListFileVersionsRequest {
+  accessToken: string;
+  ownerLogin: string;
+  repoName: string;
+  userLogin: string;
+  fileName: string;
+}
+
+interface FileVersion {
+  fileName: string;
+  tag: string;
+  version: number;
+  contentType: string;
+  size: any;
+  checksum: string;
+  uploadedBy: string;
+  createdAt: string;
+}
+
+interface ListFileVersionsResponse {
+  versions: FileVersion[];
+  error: string;
+}
+
+interface DeleteFileRequest {
+  accessToken: string;
+  ownerLogin: string;
+  repoName: string;
+  userLogin: string;
+  fileName: string;
+  tag?: string;
+  version?: number;
+}
+
+interface DeleteFileResponse {
+  success: boolean;
+  deletedVersions: number;
+  error: string;
+}
+
 interface SecretsService {
   listRepos(request: { accessToken: string }): any;
   uploadSecret(request: UploadSecretRequest): any;
@@ -122,6 +956,41 @@ interface SecretsService {
   downloadSecretByTag(request: DownloadSecretByTagRequest): any;
   deleteSecret(request: DeleteSecretRequest): any;
   listAllRepositoriesWithVersions(request: { accessToken: string }): any;
+  uploadFile(request: UploadFileRequest): any;
+  downloadFile(request: DownloadFileRequest): any;
+  setTagParent(request: SetTagParentRequest): any;
+  listSecretReferences(request: ListSecretReferencesRequest): any;
+  uploadSharedSecretSet(request: UploadSharedSecretSetRequest): any;
+  listSharedSecretSets(request: ListSharedSecretSetsRequest): any;
+  attachSharedSecretSet(request: AttachSharedSecretSetRequest): any;
+  setSchema(request: SetSchemaRequest): any;
+  getSchema(request: GetSchemaRequest): any;
+  listLintRules(request: ListLintRulesRequest): any;
+  setLintRule(request: SetLintRuleRequest): any;
+  listExpiringSecrets(request: ListExpiringSecretsRequest): any;
+  generateSecretValues(request: GenerateSecretValuesRequest): any;
+  setSecretEngine(request: SetSecretEngineRequest): any;
+  listSecretEngines(request: ListSecretEnginesRequest): any;
+  listAuditEvents(request: ListAuditEventsRequest): any;
+  verifyAuditChain(request: VerifyAuditChainRequest): any;
+  createWebhook(request: CreateWebhookRequest): any;
+  listWebhooks(request: ListWebhooksRequest): any;
+  updateWebhook(request: UpdateWebhookRequest): any;
+  deleteWebhook(request: DeleteWebhookRequest): any;
+  listWebhookDeliveries(request: ListWebhookDeliveriesRequest): any;
+  redeliverWebhook(request: RedeliverWebhookRequest): any;
+  watchSecrets(request: WatchSecretsRequest): Observable<WatchSecretsEvent>;
+  setTagProtection(request: SetTagProtectionRequest): any;
+  listChangeRequests(request: ListChangeRequestsRequest): any;
+  approveChange(request: ReviewChangeRequest): any;
+  rejectChange(request: ReviewChangeRequest): any;
+  commentChange(request: ReviewChangeRequest): any;
+  createShareLink(request: CreateShareLinkRequest): any;
+  redeemShareLink(request: RedeemShareLinkRequest): any;
+  listSecretKeys(request: ListSecretKeysRequest): any;
+  searchSecretKeys(request: SearchSecretKeysRequest): any;
+  listFileVersions(request: ListFileVersionsRequest): any;
+  deleteFile(request: DeleteFileRequest): any;
 }
 
 @Injectable()
@@ -193,4 +1062,193 @@ export class SecretOperationClientService implements OnModuleInit {
     
     return response;
   }
+
+  async uploadFile(request: UploadFileRequest): Promise<UploadFileResponse> {
+    const response = await firstValueFrom(this.secretsService.uploadFile(request)) as any;
+    // Convert Long objects to regular numbers
+    if (typeof response.size === 'object' && response.size !== null) {
+      response.size = response.size.low;
+    }
+    return response as UploadFileResponse;
+  }
+
+  async downloadFile(request: DownloadFileRequest): Promise<DownloadFileResponse> {
+    const response = await firstValueFrom(this.secretsService.downloadFile(request)) as any;
+    // Convert Long objects to regular numbers
+    if (typeof response.size === 'object' && response.size !== null) {
+      response.size = response.size.low;
+    }
+    return response as DownloadFileResponse;
+  }
+
+  async setTagParent(request: SetTagParentRequest): Promise<SetTagParentResponse> {
+    const response = await firstValueFrom(this.secretsService.setTagParent(request));
+    return response as SetTagParentResponse;
+  }
+
+  async listSecretReferences(request: ListSecretReferencesRequest): Promise<ListSecretReferencesResponse> {
+    const response = await firstValueFrom(this.secretsService.listSecretReferences(request));
+    return response as ListSecretReferencesResponse;
+  }
+
+  async uploadSharedSecretSet(request: UploadSharedSecretSetRequest): Promise<UploadSharedSecretSetResponse> {
+    const response = await firstValueFrom(this.secretsService.uploadSharedSecretSet(request));
+    return response as UploadSharedSecretSetResponse;
+  }
+
+  async listSharedSecretSets(request: ListSharedSecretSetsRequest): Promise<ListSharedSecretSetsResponse> {
+    const response = await firstValueFrom(this.secretsService.listSharedSecretSets(request));
+    return response as ListSharedSecretSetsResponse;
+  }
+
+  async attachSharedSecretSet(request: AttachSharedSecretSetRequest): Promise<AttachSharedSecretSetResponse> {
+    const response = await firstValueFrom(this.secretsService.attachSharedSecretSet(request));
+    return response as AttachSharedSecretSetResponse;
+  }
+
+  async setSchema(request: SetSchemaRequest): Promise<SetSchemaResponse> {
+    const response = await firstValueFrom(this.secretsService.setSchema(request));
+    return response as SetSchemaResponse;
+  }
+
+  async getSchema(request: GetSchemaRequest): Promise<GetSchemaResponse> {
+    const response = await firstValueFrom(this.secretsService.getSchema(request));
+    return response as GetSchemaResponse;
+  }
+
+  async listLintRules(request: ListLintRulesRequest): Promise<ListLintRulesResponse> {
+    const response = await firstValueFrom(this.secretsService.listLintRules(request));
+    return response as ListLintRulesResponse;
+  }
+
+  async setLintRule(request: SetLintRuleRequest): Promise<SetLintRuleResponse> {
+    const response = await firstValueFrom(this.secretsService.setLintRule(request));
+    return response as SetLintRuleResponse;
+  }
+
+  async listExpiringSecrets(request: ListExpiringSecretsRequest): Promise<ListExpiringSecretsResponse> {
+    const response = await firstValueFrom(this.secretsService.listExpiringSecrets(request));
+    return response as ListExpiringSecretsResponse;
+  }
+
+  async generateSecretValues(request: GenerateSecretValuesRequest): Promise<GenerateSecretValuesResponse> {
+    const response = await firstValueFrom(this.secretsService.generateSecretValues(request));
+    return response as GenerateSecretValuesResponse;
+  }
+
+  async setSecretEngine(request: SetSecretEngineRequest): Promise<SetSecretEngineResponse> {
+    const response = await firstValueFrom(this.secretsService.setSecretEngine(request));
+    return response as SetSecretEngineResponse;
+  }
+
+  async listSecretEngines(request: ListSecretEnginesRequest): Promise<ListSecretEnginesResponse> {
+    const response = await firstValueFrom(this.secretsService.listSecretEngines(request));
+    return response as ListSecretEnginesResponse;
+  }
+
+  async listAuditEvents(request: ListAuditEventsRequest): Promise<ListAuditEventsResponse> {
+    const response = await firstValueFrom(this.secretsService.listAuditEvents(request));
+    return response as ListAuditEventsResponse;
+  }
+
+  async verifyAuditChain(request: VerifyAuditChainRequest): Promise<VerifyAuditChainResponse> {
+    const response = await firstValueFrom(this.secretsService.verifyAuditChain(request));
+    return response as VerifyAuditChainResponse;
+  }
+
+  async createWebhook(request: CreateWebhookRequest): Promise<CreateWebhookResponse> {
+    const response = await firstValueFrom(this.secretsService.createWebhook(request));
+    return response as CreateWebhookResponse;
+  }
+
+  async listWebhooks(request: ListWebhooksRequest): Promise<ListWebhooksResponse> {
+    const response = await firstValueFrom(this.secretsService.listWebhooks(request));
+    return response as ListWebhooksResponse;
+  }
+
+  async updateWebhook(request: UpdateWebhookRequest): Promise<UpdateWebhookResponse> {
+    const response = await firstValueFrom(this.secretsService.updateWebhook(request));
+    return response as UpdateWebhookResponse;
+  }
+
+  async deleteWebhook(request: DeleteWebhookRequest): Promise<DeleteWebhookResponse> {
+    const response = await firstValueFrom(this.secretsService.deleteWebhook(request));
+    return response as DeleteWebhookResponse;
+  }
+
+  async listWebhookDeliveries(request: ListWebhookDeliveriesRequest): Promise<ListWebhookDeliveriesResponse> {
+    const response = await firstValueFrom(this.secretsService.listWebhookDeliveries(request));
+    return response as ListWebhookDeliveriesResponse;
+  }
+
+  async redeliverWebhook(request: RedeliverWebhookRequest): Promise<RedeliverWebhookResponse> {
+    const response = await firstValueFrom(this.secretsService.redeliverWebhook(request));
+    return response as RedeliverWebhookResponse;
+  }
+
+  // Server-streaming: the call is cancelled when the subscriber unsubscribes
+  watchSecrets(request: WatchSecretsRequest): Observable<WatchSecretsEvent> {
+    return this.secretsService.watchSecrets(request);
+  }
+
+  async setTagProtection(request: SetTagProtectionRequest): Promise<SetTagProtectionResponse> {
+    const response = await firstValueFrom(this.secretsService.setTagProtection(request));
+    return response as SetTagProtectionResponse;
+  }
+
+  async listChangeRequests(request: ListChangeRequestsRequest): Promise<ListChangeRequestsResponse> {
+    const response = await firstValueFrom(this.secretsService.listChangeRequests(request));
+    return response as ListChangeRequestsResponse;
+  }
+
+  async approveChange(request: ReviewChangeRequest): Promise<ApproveChangeResponse> {
+    const response = await firstValueFrom(this.secretsService.approveChange(request));
+    return response as ApproveChangeResponse;
+  }
+
+  async rejectChange(request: ReviewChangeRequest): Promise<RejectChangeResponse> {
+    const response = await firstValueFrom(this.secretsService.rejectChange(request));
+    return response as RejectChangeResponse;
+  }
+
+  async commentChange(request: ReviewChangeRequest): Promise<CommentChangeResponse> {
+    const response = await firstValueFrom(this.secretsService.commentChange(request));
+    return response as CommentChangeResponse;
+  }
+
+  async createShareLink(request: CreateShareLinkRequest): Promise<CreateShareLinkResponse> {
+    const response = await firstValueFrom(this.secretsService.createShareLink(request));
+    return response as CreateShareLinkResponse;
+  }
+
+  async redeemShareLink(request: RedeemShareLinkRequest): Promise<RedeemShareLinkResponse> {
+    const response = await firstValueFrom(this.secretsService.redeemShareLink(request));
+    return response as RedeemShareLinkResponse;
+  }
+
+  async listSecretKeys(request: ListSecretKeysRequest): Promise<ListSecretKeysResponse> {
+    const response = await firstValueFrom(this.secretsService.listSecretKeys(request));
+    return response as ListSecretKeysResponse;
+  }
+
+  async searchSecretKeys(request: SearchSecretKeysRequest): Promise<SearchSecretKeysResponse> {
+    const response = await firstValueFrom(this.secretsService.searchSecretKeys(request));
+    return response as SearchSecretKeysResponse;
+  }
+
+  async listFileVersions(request: ListFileVersionsRequest): Promise<ListFileVersionsResponse> {
+    const response = await firstValueFrom(this.secretsService.listFileVersions(request)) as any;
+    // Convert Long objects to regular numbers
+    for (const version of response.versions || []) {
+      if (typeof version.size === 'object' && version.size !== null) {
+        version.size = version.size.low;
+      }
+    }
+    return response as ListFileVersionsResponse;
+  }
+
+  async deleteFile(request: DeleteFileRequest): Promise<DeleteFileResponse> {
+    const response = await firstValueFrom(this.secretsService.deleteFile(request));
+    return response as DeleteFileResponse;
+  }
 } 
\ No newline at end of file
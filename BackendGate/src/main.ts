@@ -1,8 +1,12 @@
 import { NestFactory } from '@nestjs/core';
+import { NestExpressApplication } from '@nestjs/platform-express';
 import { AppModule } from './app.module';
 
 async function bootstrap() {
-  const app = await NestFactory.create(AppModule);
+  const app = await NestFactory.create<NestExpressApplication>(AppModule);
+
+  // File secrets are sent base64 encoded in JSON, so allow bodies above the 100kb default
+  app.useBodyParser('json', { limit: process.env.BODY_LIMIT ?? '4mb' });
   
   // Enable CORS
   app.enableCors({
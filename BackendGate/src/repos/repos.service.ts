@@ -11,6 +11,7 @@ interface Repo {
   private: boolean;
   ownerLogin: string;
   ownerAvatarUrl: string;
+  permission: string;
 }
 
 export interface ListReposResult {
@@ -8,11 +8,26 @@ import {
   Param,
   Query,
   Res,
+  Sse,
+  MessageEvent,
   HttpStatus,
   BadRequestException,
 } from '@nestjs/common';
 import { Response } from 'express';
-import { SecretsService, UploadSecretResult, ListSecretVersionsResult, DownloadSecretResult, DeleteSecretResult } from './secrets.service';
+import { Observable } from 'rxjs';
+import { SecretsService, UploadSecretResult, ListSecretVersionsResult, DownloadSecretResult, DeleteSecretResult, UploadFileResult, SetTagParentResult, ListSecretReferencesResult, UploadSharedSecretSetResult, ListSharedSecretSetsResult, AttachSharedSecretSetResult, SetSchemaResult, GetSchemaResult, ListLintRulesResult, SetLintRuleResult, ListExpiringSecretsResult, KeyMetadataInput, GenerateSecretValuesResult, GenerateSpecInput, SetSecretEngineResult, ListSecretEnginesResult, SecretEngineInput, ListAuditEventsResult, AuditEventFilter, VerifyAuditChainResult, VersionAnnotationInput, WebhookInput, CreateWebhookResult, ListWebhooksResult, UpdateWebhookResult, ListWebhookDeliveriesResult, RedeliverWebhookResult, WatchedRepositoryInput, SetTagProtectionResult, ListChangeRequestsResult, ReviewChangeResult, ShareLinkInput, CreateShareLinkResult, ListSecretKeysResult, SearchSecretKeysResult, ListFileVersionsResult, DeleteFileResult } from './secrets.service';
+
+const FORMAT_EXTENSIONS: Record<string, string> = {
+  dotenv: 'env',
+  json: 'json',
+  yaml: 'yaml',
+  export: 'sh',
+  docker: 'env',
+  compose: 'env',
+  systemd: 'env',
+  'k8s-secret': 'yaml',
+  'k8s-configmap': 'yaml',
+};
 
 @Controller('secrets')
 export class SecretsController {
@@ -23,7 +38,7 @@ export class SecretsController {
     @Headers('authorization') authHeader: string,
     @Param('ownerLogin') ownerLogin: string,
     @Param('repoName') repoName: string,
-    @Body() body: { tag?: string; envFileContent: string },
+    @Body() body: { tag?: string; envFileContent: string; format?: string; keySeparator?: string; fileName?: string; path?: string; branch?: string; keyMetadata?: KeyMetadataInput[]; annotation?: VersionAnnotationInput },
   ): Promise<UploadSecretResult> {
     if (!authHeader || !authHeader.startsWith('Bearer ')) {
       throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
@@ -42,6 +57,13 @@ export class SecretsController {
       repoName,
       body.tag || '',
       envFileBuffer,
+      body.format || '',
+      body.keySeparator || '',
+      body.fileName || '',
+      body.path || '',
+      body.branch || '',
+      body.keyMetadata || [],
+      body.annotation,
     );
   }
 
@@ -50,6 +72,9 @@ export class SecretsController {
     @Headers('authorization') authHeader: string,
     @Param('ownerLogin') ownerLogin: string,
     @Param('repoName') repoName: string,
+    @Query('fileName') fileName: string,
+    @Query('groupByFile') groupByFile: string,
+    @Query('path') path: string,
   ): Promise<ListSecretVersionsResult> {
     if (!authHeader || !authHeader.startsWith('Bearer ')) {
       throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
@@ -57,7 +82,14 @@ export class SecretsController {
 
     const jwt = authHeader.substring(7);
 
-    return await this.secretsService.listSecretVersions(jwt, ownerLogin, repoName);
+    return await this.secretsService.listSecretVersions(
+      jwt,
+      ownerLogin,
+      repoName,
+      fileName,
+      groupByFile === 'true',
+      path,
+    );
   }
 
   @Get('download/:ownerLogin/:repoName')
@@ -67,6 +99,15 @@ export class SecretsController {
     @Param('repoName') repoName: string,
     @Query('version') version: string,
     @Query('tag') tag: string,
+    @Query('format') format: string,
+    @Query('name') resourceName: string,
+    @Query('namespace') namespace: string,
+    @Query('fileName') fileName: string,
+    @Query('path') path: string,
+    @Query('branch') branch: string,
+    @Query('origins') origins: string,
+    @Query('interpolate') interpolate: string,
+    @Query('leaseTtl') leaseTtl: string,
     @Res() res: Response,
   ): Promise<void> {
     if (!authHeader || !authHeader.startsWith('Bearer ')) {
@@ -80,19 +121,34 @@ export class SecretsController {
       throw new BadRequestException('Version must be a valid number');
     }
 
+    const leaseTtlSeconds = leaseTtl ? parseInt(leaseTtl, 10) : 0;
+    if (isNaN(leaseTtlSeconds) || leaseTtlSeconds < 0) {
+      throw new BadRequestException('leaseTtl must be a non-negative number of seconds');
+    }
+
     const result = await this.secretsService.downloadSecret(
       jwt,
       ownerLogin,
       repoName,
       versionNumber,
       tag,
+      format,
+      resourceName,
+      namespace,
+      fileName,
+      path,
+      branch,
+      origins === 'true',
+      interpolate === 'true',
+      leaseTtlSeconds,
     );
 
     if (result.success && result.envFileContent && result.version !== undefined) {
       // Set response headers for file download
+      const extension = FORMAT_EXTENSIONS[result.format || 'dotenv'] || 'env';
       const filename = tag 
-        ? `${ownerLogin}-${repoName}-${tag}-v${result.version}.env`
-        : `${ownerLogin}-${repoName}-v${result.version}.env`;
+        ? `${ownerLogin}-${repoName}-${tag}-v${result.version}.${extension}`
+        : `${ownerLogin}-${repoName}-v${result.version}.${extension}`;
       
       res.setHeader('Content-Type', 'application/octet-stream');
       res.setHeader('Content-Disposition', `attachment; filename="${filename}"`);
@@ -101,6 +157,20 @@ export class SecretsController {
       res.setHeader('X-Secret-Checksum', result.checksum || '');
       res.setHeader('X-Secret-UploadedBy', result.uploadedBy || '');
       res.setHeader('X-Secret-CreatedAt', result.createdAt || '');
+      res.setHeader('X-Secret-Format', result.format || '');
+      res.setHeader('X-Secret-FileName', result.fileName || '');
+      res.setHeader('X-Secret-Path', result.path || '');
+      res.setHeader('X-Secret-Branch', result.branch || '');
+      if (result.branch) {
+        res.setHeader('X-Secret-BranchVersion', String(result.branchVersion));
+      }
+      if (result.origins) {
+        // Base64 keeps arbitrary tag and branch names header safe
+        res.setHeader('X-Secret-Origins', Buffer.from(JSON.stringify(result.origins)).toString('base64'));
+      }
+      if (result.leases && result.leases.length > 0) {
+        res.setHeader('X-Secret-Leases', Buffer.from(JSON.stringify(result.leases)).toString('base64'));
+      }
 
       res.status(HttpStatus.OK).send(result.envFileContent);
     } else {
@@ -118,6 +188,9 @@ export class SecretsController {
     @Param('repoName') repoName: string,
     @Query('version') version: string,
     @Query('tag') tag: string,
+    @Query('fileName') fileName: string,
+    @Query('path') path: string,
+    @Query('branch') branch: string,
   ): Promise<DeleteSecretResult> {
     if (!authHeader || !authHeader.startsWith('Bearer ')) {
       throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
@@ -135,7 +208,10 @@ export class SecretsController {
       ownerLogin,
       repoName,
       versionNumber,
-      tag
+      tag,
+      fileName,
+      path,
+      branch,
     );
   }
 
@@ -146,6 +222,13 @@ export class SecretsController {
     @Param('repoName') repoName: string,
     @Query('version') version: string,
     @Query('tag') tag: string,
+    @Query('format') format: string,
+    @Query('fileName') fileName: string,
+    @Query('path') path: string,
+    @Query('branch') branch: string,
+    @Query('origins') origins: string,
+    @Query('interpolate') interpolate: string,
+    @Query('leaseTtl') leaseTtl: string,
   ): Promise<DownloadSecretResult> {
     if (!authHeader || !authHeader.startsWith('Bearer ')) {
       throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
@@ -158,12 +241,898 @@ export class SecretsController {
       throw new BadRequestException('Version must be a valid number');
     }
 
+    const leaseTtlSeconds = leaseTtl ? parseInt(leaseTtl, 10) : 0;
+    if (isNaN(leaseTtlSeconds) || leaseTtlSeconds < 0) {
+      throw new BadRequestException('leaseTtl must be a non-negative number of seconds');
+    }
+
     return await this.secretsService.downloadSecret(
       jwt,
       ownerLogin,
       repoName,
       versionNumber,
       tag,
+      format,
+      undefined,
+      undefined,
+      fileName,
+      path,
+      branch,
+      origins === 'true',
+      interpolate === 'true',
+      leaseTtlSeconds,
+    );
+  }
+
+  @Post('files/upload/:ownerLogin/:repoName')
+  async uploadFile(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Body() body: { tag?: string; fileName: string; contentType?: string; content: string },
+  ): Promise<UploadFileResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    if (!body.fileName) {
+      throw new BadRequestException('fileName is required');
+    }
+
+    if (!body.content) {
+      throw new BadRequestException('content is required');
+    }
+
+    const jwt = authHeader.substring(7);
+    const contentBuffer = Buffer.from(body.content, 'base64');
+
+    return await this.secretsService.uploadFile(
+      jwt,
+      ownerLogin,
+      repoName,
+      body.tag || '',
+      body.fileName,
+      body.contentType || '',
+      contentBuffer,
+    );
+  }
+
+  @Get('files/download/:ownerLogin/:repoName')
+  async downloadFile(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Query('fileName') fileName: string,
+    @Query('version') version: string,
+    @Query('tag') tag: string,
+    @Res() res: Response,
+  ): Promise<void> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    if (!fileName) {
+      throw new BadRequestException('fileName is required');
+    }
+
+    const jwt = authHeader.substring(7);
+    const versionNumber = version ? parseInt(version, 10) : undefined;
+
+    if (version && isNaN(versionNumber as number)) {
+      throw new BadRequestException('Version must be a valid number');
+    }
+
+    const result = await this.secretsService.downloadFile(
+      jwt,
+      ownerLogin,
+      repoName,
+      fileName,
+      versionNumber,
+      tag,
+    );
+
+    if (result.success && result.content && result.version !== undefined) {
+      const baseName = (result.fileName || fileName).split('/').pop();
+
+      res.setHeader('Content-Type', result.contentType || 'application/octet-stream');
+      res.setHeader('Content-Disposition', `attachment; filename="${baseName}"`);
+      res.setHeader('X-Secret-Version', result.version.toString());
+      res.setHeader('X-Secret-Tag', result.tag || '');
+      res.setHeader('X-Secret-Checksum', result.checksum || '');
+      res.setHeader('X-Secret-UploadedBy', result.uploadedBy || '');
+      res.setHeader('X-Secret-CreatedAt', result.createdAt || '');
+      res.setHeader('X-Secret-FileName', result.fileName || '');
+
+      res.status(HttpStatus.OK).send(result.content);
+    } else {
+      res.status(HttpStatus.BAD_REQUEST).json({
+        error: result.error || 'download_file_failed',
+        errorDescription: result.errorDescription || 'Failed to download file',
+      });
+    }
+  }
+
+  @Post('parent/:ownerLogin/:repoName')
+  async setTagParent(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Body() body: { tag: string; parentTag?: string; parentVersion?: number; fileName?: string; path?: string },
+  ): Promise<SetTagParentResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    if (!body.tag) {
+      throw new BadRequestException('tag is required');
+    }
+
+    if (body.parentVersion !== undefined && !Number.isInteger(body.parentVersion)) {
+      throw new BadRequestException('parentVersion must be a valid number');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.setTagParent(
+      jwt,
+      ownerLogin,
+      repoName,
+      body.tag,
+      body.parentTag || '',
+      body.parentVersion || 0,
+      body.fileName,
+      body.path,
+    );
+  }
+
+  @Get('references/:ownerLogin/:repoName')
+  async listSecretReferences(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Query('key') key: string,
+    @Query('tag') tag: string,
+  ): Promise<ListSecretReferencesResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.listSecretReferences(
+      jwt,
+      ownerLogin,
+      repoName,
+      key,
+      tag,
+    );
+  }
+
+  @Post('shared/:orgLogin/:setName')
+  async uploadSharedSecretSet(
+    @Headers('authorization') authHeader: string,
+    @Param('orgLogin') orgLogin: string,
+    @Param('setName') setName: string,
+    @Body() body: { envFileContent: string; format?: string; keySeparator?: string },
+  ): Promise<UploadSharedSecretSetResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    if (!body.envFileContent) {
+      throw new BadRequestException('envFileContent is required');
+    }
+
+    const jwt = authHeader.substring(7);
+    const envFileBuffer = Buffer.from(body.envFileContent, 'base64');
+
+    return await this.secretsService.uploadSharedSecretSet(
+      jwt,
+      orgLogin,
+      setName,
+      envFileBuffer,
+      body.format,
+      body.keySeparator,
+    );
+  }
+
+  @Get('shared/:orgLogin')
+  async listSharedSecretSets(
+    @Headers('authorization') authHeader: string,
+    @Param('orgLogin') orgLogin: string,
+  ): Promise<ListSharedSecretSetsResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.listSharedSecretSets(jwt, orgLogin);
+  }
+
+  @Post('shared/:orgLogin/:setName/attach/:ownerLogin/:repoName')
+  async attachSharedSecretSet(
+    @Headers('authorization') authHeader: string,
+    @Param('orgLogin') orgLogin: string,
+    @Param('setName') setName: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Body() body: { version?: number },
+  ): Promise<AttachSharedSecretSetResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    if (body.version !== undefined && !Number.isInteger(body.version)) {
+      throw new BadRequestException('version must be a valid number');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.attachSharedSecretSet(
+      jwt,
+      orgLogin,
+      setName,
+      ownerLogin,
+      repoName,
+      body.version || 0,
+    );
+  }
+
+  @Delete('shared/:orgLogin/:setName/attach/:ownerLogin/:repoName')
+  async detachSharedSecretSet(
+    @Headers('authorization') authHeader: string,
+    @Param('orgLogin') orgLogin: string,
+    @Param('setName') setName: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+  ): Promise<AttachSharedSecretSetResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.attachSharedSecretSet(
+      jwt,
+      orgLogin,
+      setName,
+      ownerLogin,
+      repoName,
+      0,
+      true,
+    );
+  }
+
+  @Post('schema/:ownerLogin/:repoName')
+  async setSchema(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Body() body: { tag?: string; schema?: string },
+  ): Promise<SetSchemaResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.setSchema(
+      jwt,
+      ownerLogin,
+      repoName,
+      body.tag || '',
+      body.schema || '',
+    );
+  }
+
+  @Get('schema/:ownerLogin/:repoName')
+  async getSchema(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Query('tag') tag: string,
+  ): Promise<GetSchemaResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.getSchema(jwt, ownerLogin, repoName, tag);
+  }
+
+  @Get('lint/:ownerLogin/:repoName')
+  async listLintRules(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+  ): Promise<ListLintRulesResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.listLintRules(jwt, ownerLogin, repoName);
+  }
+
+  @Post('lint/:ownerLogin/:repoName')
+  async setLintRule(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Body() body: { rule?: string; action?: string },
+  ): Promise<SetLintRuleResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.setLintRule(
+      jwt,
+      ownerLogin,
+      repoName,
+      body.rule || '',
+      body.action || '',
+    );
+  }
+
+  @Get('expiring')
+  async listExpiringSecrets(
+    @Headers('authorization') authHeader: string,
+    @Query('days') days: string,
+  ): Promise<ListExpiringSecretsResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const withinDays = days ? parseInt(days, 10) : 0;
+    if (isNaN(withinDays) || withinDays < 0) {
+      throw new BadRequestException('days must be a non-negative number');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.listExpiringSecrets(jwt, withinDays);
+  }
+
+  @Post('generate/:ownerLogin/:repoName')
+  async generateSecretValues(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Body() body: { tag?: string; values?: GenerateSpecInput[]; overwrite?: boolean; fileName?: string; path?: string; branch?: string; annotation?: VersionAnnotationInput },
+  ): Promise<GenerateSecretValuesResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    if (!body.values || body.values.length === 0) {
+      throw new BadRequestException('values is required');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.generateSecretValues(
+      jwt,
+      ownerLogin,
+      repoName,
+      body.tag || '',
+      body.values,
+      body.overwrite === true,
+      body.fileName || '',
+      body.path || '',
+      body.branch || '',
+      body.annotation,
     );
   }
+
+  @Post('engines/:ownerLogin/:repoName')
+  async setSecretEngine(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Body() body: SecretEngineInput & { name?: string },
+  ): Promise<SetSecretEngineResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    if (!body.name || !body.engine || !body.config) {
+      throw new BadRequestException('name, engine and config are required');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.setSecretEngine(jwt, ownerLogin, repoName, body.name, body);
+  }
+
+  @Get('engines/:ownerLogin/:repoName')
+  async listSecretEngines(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+  ): Promise<ListSecretEnginesResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.listSecretEngines(jwt, ownerLogin, repoName);
+  }
+
+  @Delete('engines/:ownerLogin/:repoName/:name')
+  async deleteSecretEngine(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Param('name') name: string,
+  ): Promise<SetSecretEngineResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.setSecretEngine(jwt, ownerLogin, repoName, name, {}, true);
+  }
+
+  @Get(['audit', 'audit/:ownerLogin/:repoName'])
+  async listAuditEvents(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Query('user') username: string,
+    @Query('operation') operation: string,
+    @Query('success') success: string,
+    @Query('since') since: string,
+    @Query('until') until: string,
+    @Query('limit') limit: string,
+    @Query('cursor') cursor: string,
+  ): Promise<ListAuditEventsResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const pageSize = limit ? parseInt(limit, 10) : 0;
+    if (isNaN(pageSize) || pageSize < 0) {
+      throw new BadRequestException('limit must be a non-negative number');
+    }
+
+    if (success && success !== 'true' && success !== 'false') {
+      throw new BadRequestException('success must be true or false');
+    }
+
+    const jwt = authHeader.substring(7);
+    const filter: AuditEventFilter = {
+      username,
+      operation,
+      success: success ? success === 'true' : undefined,
+      since,
+      until,
+      pageSize,
+      cursor,
+    };
+
+    return await this.secretsService.listAuditEvents(jwt, ownerLogin, repoName, filter);
+  }
+
+  @Post('audit/verify')
+  async verifyAuditChain(
+    @Headers('authorization') authHeader: string,
+    @Body() body: { checkpoint?: boolean },
+  ): Promise<VerifyAuditChainResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.verifyAuditChain(jwt, body.checkpoint === true);
+  }
+
+  @Post('webhooks')
+  async createWebhook(
+    @Headers('authorization') authHeader: string,
+    @Body() body: WebhookInput,
+  ): Promise<CreateWebhookResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    if (!body.url) {
+      throw new BadRequestException('url is required');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.createWebhook(jwt, body);
+  }
+
+  @Get('webhooks')
+  async listWebhooks(
+    @Headers('authorization') authHeader: string,
+    @Query('ownerLogin') ownerLogin: string,
+    @Query('repoName') repoName: string,
+    @Query('orgLogin') orgLogin: string,
+  ): Promise<ListWebhooksResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.listWebhooks(jwt, ownerLogin, repoName, orgLogin);
+  }
+
+  @Post('webhooks/:id')
+  async updateWebhook(
+    @Headers('authorization') authHeader: string,
+    @Param('id') webhookIdParam: string,
+    @Body() body: WebhookInput,
+  ): Promise<UpdateWebhookResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const webhookId = parseInt(webhookIdParam, 10);
+    if (isNaN(webhookId) || webhookId <= 0) {
+      throw new BadRequestException('webhook id must be a positive number');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.updateWebhook(jwt, webhookId, body);
+  }
+
+  @Delete('webhooks/:id')
+  async deleteWebhook(
+    @Headers('authorization') authHeader: string,
+    @Param('id') webhookIdParam: string,
+  ): Promise<UpdateWebhookResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const webhookId = parseInt(webhookIdParam, 10);
+    if (isNaN(webhookId) || webhookId <= 0) {
+      throw new BadRequestException('webhook id must be a positive number');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.deleteWebhook(jwt, webhookId);
+  }
+
+  @Get('webhooks/:id/deliveries')
+  async listWebhookDeliveries(
+    @Headers('authorization') authHeader: string,
+    @Param('id') webhookIdParam: string,
+    @Query('limit') limit: string,
+  ): Promise<ListWebhookDeliveriesResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const webhookId = parseInt(webhookIdParam, 10);
+    if (isNaN(webhookId) || webhookId <= 0) {
+      throw new BadRequestException('webhook id must be a positive number');
+    }
+
+    const pageSize = limit ? parseInt(limit, 10) : 0;
+    if (isNaN(pageSize) || pageSize < 0) {
+      throw new BadRequestException('limit must be a non-negative number');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.listWebhookDeliveries(jwt, webhookId, pageSize);
+  }
+
+  @Post('webhooks/deliveries/:id/redeliver')
+  async redeliverWebhook(
+    @Headers('authorization') authHeader: string,
+    @Param('id') deliveryIdParam: string,
+  ): Promise<RedeliverWebhookResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const deliveryId = parseInt(deliveryIdParam, 10);
+    if (isNaN(deliveryId) || deliveryId <= 0) {
+      throw new BadRequestException('delivery id must be a positive number');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.redeliverWebhook(jwt, deliveryId);
+  }
+
+  @Sse('watch')
+  watchSecrets(
+    @Headers('authorization') authHeader: string,
+    @Headers('last-event-id') lastEventId: string,
+    @Query('repos') repos: string,
+    @Query('tags') tags: string,
+    @Query('resumeToken') resumeToken: string,
+  ): Observable<MessageEvent> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    // repos=owner/repo,owner/other
+    const repositories: WatchedRepositoryInput[] = (repos || '').split(',').filter((repo) => repo).map((repo) => {
+      const [ownerLogin, repoName, ...rest] = repo.split('/');
+      if (!ownerLogin || !repoName || rest.length > 0) {
+        throw new BadRequestException(`Invalid repository ${repo}, expected owner/repo`);
+      }
+      return { ownerLogin, repoName };
+    });
+    if (repositories.length === 0) {
+      throw new BadRequestException('repos is required');
+    }
+
+    const jwt = authHeader.substring(7);
+    const tagList = (tags || '').split(',').filter((tag) => tag);
+
+    // EventSource sends the last received id as Last-Event-ID when it reconnects
+    return this.secretsService.watchSecrets(jwt, repositories, tagList, resumeToken || lastEventId || '');
+  }
+
+  @Post('protection/:ownerLogin/:repoName')
+  async setTagProtection(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Body() body: { tag?: string; protected?: boolean; requestTtlHours?: number },
+  ): Promise<SetTagProtectionResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    if (!body.tag) {
+      throw new BadRequestException('tag is required');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.setTagProtection(
+      jwt,
+      ownerLogin,
+      repoName,
+      body.tag,
+      body.protected !== false,
+      body.requestTtlHours || 0,
+    );
+  }
+
+  @Get('changes/:ownerLogin/:repoName')
+  async listChangeRequests(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Query('status') status: string,
+  ): Promise<ListChangeRequestsResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.listChangeRequests(jwt, ownerLogin, repoName, status);
+  }
+
+  @Post('changes/:id/approve')
+  async approveChange(
+    @Headers('authorization') authHeader: string,
+    @Param('id') changeIdParam: string,
+    @Body() body: { comment?: string },
+  ): Promise<ReviewChangeResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const changeId = parseInt(changeIdParam, 10);
+    if (isNaN(changeId) || changeId <= 0) {
+      throw new BadRequestException('change request id must be a positive number');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.approveChange(jwt, changeId, body.comment || '');
+  }
+
+  @Post('changes/:id/reject')
+  async rejectChange(
+    @Headers('authorization') authHeader: string,
+    @Param('id') changeIdParam: string,
+    @Body() body: { comment?: string },
+  ): Promise<ReviewChangeResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const changeId = parseInt(changeIdParam, 10);
+    if (isNaN(changeId) || changeId <= 0) {
+      throw new BadRequestException('change request id must be a positive number');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.rejectChange(jwt, changeId, body.comment || '');
+  }
+
+  @Post('changes/:id/comment')
+  async commentChange(
+    @Headers('authorization') authHeader: string,
+    @Param('id') changeIdParam: string,
+    @Body() body: { comment?: string },
+  ): Promise<ReviewChangeResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const changeId = parseInt(changeIdParam, 10);
+    if (isNaN(changeId) || changeId <= 0) {
+      throw new BadRequestException('change request id must be a positive number');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.commentChange(jwt, changeId, body.comment || '');
+  }
+
+  @Post('share/:ownerLogin/:repoName')
+  async createShareLink(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Body() body: ShareLinkInput,
+  ): Promise<CreateShareLinkResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    if (body.file && !body.fileName) {
+      throw new BadRequestException('fileName is required to share a file');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.createShareLink(jwt, ownerLogin, repoName, body);
+  }
+
+  // No Authorization header: recipients are not Envini users. POST keeps link previews from burning views.
+  @Post('share/redeem')
+  async redeemShareLink(
+    @Body() body: { token?: string },
+    @Res() res: Response,
+  ): Promise<void> {
+    if (!body.token) {
+      throw new BadRequestException('token is required');
+    }
+
+    const result = await this.secretsService.redeemShareLink(body.token);
+
+    res.setHeader('Cache-Control', 'no-store');
+    if (result.success && result.content) {
+      res.setHeader('Content-Type', result.contentType || 'application/octet-stream');
+      if (result.kind === 'file') {
+        const baseName = (result.fileName || 'shared').split('/').pop();
+        res.setHeader('Content-Disposition', `attachment; filename="${baseName}"`);
+      }
+      res.setHeader('X-Share-Kind', result.kind || '');
+      res.setHeader('X-Share-Key', result.key || '');
+      res.setHeader('X-Share-FileName', result.fileName || '');
+      res.setHeader('X-Share-SharedBy', result.sharedBy || '');
+      res.setHeader('X-Share-RemainingViews', (result.remainingViews || 0).toString());
+      res.setHeader('X-Share-ExpiresAt', result.expiresAt || '');
+
+      res.status(HttpStatus.OK).send(result.content);
+    } else {
+      res.status(HttpStatus.BAD_REQUEST).json({
+        error: result.error || 'redeem_share_link_failed',
+        errorDescription: result.errorDescription || 'Failed to redeem share link',
+      });
+    }
+  }
+
+  @Get('keys/:ownerLogin/:repoName')
+  async listSecretKeys(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Query('tag') tag: string,
+    @Query('version') version: string,
+    @Query('path') path: string,
+    @Query('fileName') fileName: string,
+    @Query('branch') branch: string,
+  ): Promise<ListSecretKeysResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const versionNumber = version ? parseInt(version, 10) : 0;
+    if (isNaN(versionNumber) || versionNumber < 0) {
+      throw new BadRequestException('Version must be a valid number');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.listSecretKeys(jwt, ownerLogin, repoName, tag, versionNumber, path, fileName, branch);
+  }
+
+  @Get('search/keys')
+  async searchSecretKeys(
+    @Headers('authorization') authHeader: string,
+    @Query('q') query: string,
+    @Query('mode') mode: string,
+    @Query('limit') limit: string,
+  ): Promise<SearchSecretKeysResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    if (!query) {
+      throw new BadRequestException('q is required');
+    }
+
+    const limitNumber = limit ? parseInt(limit, 10) : 0;
+    if (isNaN(limitNumber) || limitNumber < 0) {
+      throw new BadRequestException('limit must be a non-negative number');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.searchSecretKeys(jwt, query, mode, limitNumber);
+  }
+
+  @Get('files/versions/:ownerLogin/:repoName')
+  async listFileVersions(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Query('fileName') fileName: string,
+  ): Promise<ListFileVersionsResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.listFileVersions(jwt, ownerLogin, repoName, fileName);
+  }
+
+  @Delete('files/delete/:ownerLogin/:repoName')
+  async deleteFile(
+    @Headers('authorization') authHeader: string,
+    @Param('ownerLogin') ownerLogin: string,
+    @Param('repoName') repoName: string,
+    @Query('fileName') fileName: string,
+    @Query('tag') tag: string,
+    @Query('version') version: string,
+  ): Promise<DeleteFileResult> {
+    if (!authHeader || !authHeader.startsWith('Bearer ')) {
+      throw new BadRequestException('Authorization header must be in format: Bearer <JWT>');
+    }
+
+    if (!fileName) {
+      throw new BadRequestException('fileName is required');
+    }
+
+    const versionNumber = version ? parseInt(version, 10) : undefined;
+    if (version && (isNaN(versionNumber as number) || (versionNumber as number) <= 0)) {
+      throw new BadRequestException('Version must be a positive number');
+    }
+    if (versionNumber !== undefined && tag === undefined) {
+      throw new BadRequestException('Deleting a file version requires its tag');
+    }
+
+    const jwt = authHeader.substring(7);
+
+    return await this.secretsService.deleteFile(jwt, ownerLogin, repoName, fileName, tag, versionNumber);
+  }
 } 
\ No newline at end of file
@@ -1,32 +1,508 @@
-import { Injectable } from '@nestjs/common';
+import { Injectable, MessageEvent } from '@nestjs/common';
+import { Observable, defer, of, switchMap, map, catchError } from 'rxjs';
 import { SecretOperationClientService } from '../grpc/secretoperation-client.service';
 import { AuthService } from '../auth/auth.service';
 
+export interface SchemaViolationResult {
+  key: string;
+  rule: string;
+  message: string;
+  warning: boolean;
+}
+
+export interface LintFindingResult {
+  key: string;
+  rule: string;
+  message: string;
+  blocking: boolean;
+}
+
+export interface KeyMetadataInput {
+  key: string;
+  expiresAt?: string;
+  owner?: string;
+  rotationInterval?: string;
+}
+
+export interface VersionAnnotationInput {
+  message?: string;
+  commitSha?: string;
+  gitBranch?: string;
+  clientType?: string;
+  clientVersion?: string;
+}
+
 export interface UploadSecretResult {
   success?: boolean;
   version?: number;
   checksum?: string;
+  violations?: Array<SchemaViolationResult>;
+  lintFindings?: Array<LintFindingResult>;
+  changeRequestId?: number;
   error?: string;
   errorDescription?: string;
 }
 
+export interface SecretVersionResult {
+  version: number;
+  tag: string;
+  checksum: string;
+  uploadedBy: string;
+  createdAt: string;
+  fileName: string;
+  path: string;
+  branch: string;
+  annotation?: VersionAnnotationInput;
+}
+
+export interface TagParentResult {
+  tag: string;
+  parentTag: string;
+  parentVersion: number;
+  fileName: string;
+  path: string;
+}
+
+export interface KeyOriginResult {
+  key: string;
+  tag: string;
+  version: number;
+  branch: string;
+  sharedSet: string;
+  engine: string;
+}
+
+export interface LeaseResult {
+  leaseId: string;
+  engineName: string;
+  engine: string;
+  expiresAt: string;
+  keys: string[];
+}
+
 export interface ListSecretVersionsResult {
-  versions?: Array<{
+  versions?: Array<SecretVersionResult>;
+  files?: Array<{
+    fileName: string;
+    path: string;
+    versions: Array<SecretVersionResult>;
+  }>;
+  branches?: string[];
+  tagParents?: Array<TagParentResult>;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface DownloadSecretResult {
+  success?: boolean;
+  version?: number;
+  tag?: string;
+  envFileContent?: Buffer;
+  checksum?: string;
+  uploadedBy?: string;
+  createdAt?: string;
+  format?: string;
+  fileName?: string;
+  path?: string;
+  branch?: string;
+  branchVersion?: number;
+  origins?: Array<KeyOriginResult>;
+  leases?: Array<LeaseResult>;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface DeleteSecretResult {
+  success?: boolean;
+  deletedVersions?: number;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface SetTagParentResult {
+  success?: boolean;
+  changeRequestId?: number;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface SecretReferenceResult {
+  ownerLogin: string;
+  repoName: string;
+  path: string;
+  fileName: string;
+  tag: string;
+  branch: string;
+  key: string;
+  targetTag: string;
+  targetKey: string;
+}
+
+export interface ListSecretReferencesResult {
+  references?: Array<SecretReferenceResult>;
+  hiddenReferences?: number;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface UploadSharedSecretSetResult {
+  success?: boolean;
+  version?: number;
+  checksum?: string;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface SharedSecretSetResult {
+  orgLogin: string;
+  name: string;
+  createdBy: string;
+  createdAt: string;
+  versions: Array<{
     version: number;
-    tag: string;
     checksum: string;
     uploadedBy: string;
     createdAt: string;
   }>;
+  attachments: Array<{
+    ownerLogin: string;
+    repoName: string;
+    pinnedVersion: number;
+    attachedBy: string;
+  }>;
+}
+
+export interface ListSharedSecretSetsResult {
+  sets?: Array<SharedSecretSetResult>;
   error?: string;
   errorDescription?: string;
 }
 
-export interface DownloadSecretResult {
+export interface AttachSharedSecretSetResult {
+  success?: boolean;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface SetSchemaResult {
+  success?: boolean;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface GetSchemaResult {
+  schema?: string;
+  tag?: string;
+  updatedBy?: string;
+  updatedAt?: string;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface LintRuleResult {
+  name: string;
+  description: string;
+  action: string;
+}
+
+export interface ListLintRulesResult {
+  rules?: Array<LintRuleResult>;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface SetLintRuleResult {
+  success?: boolean;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface ExpiringSecretResult {
+  ownerLogin: string;
+  repoName: string;
+  path: string;
+  fileName: string;
+  branch: string;
+  tag: string;
+  version: number;
+  key: string;
+  owner: string;
+  expiresAt: string;
+  rotationInterval: string;
+  rotatedAt: string;
+  dueAt: string;
+  reason: string;
+  expired: boolean;
+}
+
+export interface ListExpiringSecretsResult {
+  secrets?: Array<ExpiringSecretResult>;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface GenerateSpecInput {
+  key: string;
+  type: string;
+  length?: number;
+  charset?: string;
+  publicKey?: string;
+  sourceKey?: string;
+  cost?: number;
+}
+
+export interface GenerateSecretValuesResult {
   success?: boolean;
   version?: number;
+  checksum?: string;
+  generatedKeys?: string[];
+  violations?: Array<SchemaViolationResult>;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface SecretEngineInput {
+  engine?: string;
   tag?: string;
-  envFileContent?: Buffer;
+  config?: string;
+  defaultTtlSeconds?: number;
+  maxTtlSeconds?: number;
+}
+
+export interface SetSecretEngineResult {
+  success?: boolean;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface SecretEngineResult {
+  name: string;
+  engine: string;
+  tag: string;
+  defaultTtlSeconds: number;
+  maxTtlSeconds: number;
+  keys: string[];
+  activeLeases: number;
+  updatedBy: string;
+  updatedAt: string;
+}
+
+export interface ListSecretEnginesResult {
+  engines?: Array<SecretEngineResult>;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface AuditEventFilter {
+  username?: string;
+  operation?: string;
+  success?: boolean;
+  since?: string;
+  until?: string;
+  pageSize?: number;
+  cursor?: string;
+}
+
+export interface AuditEventResult {
+  id: string;
+  operation: string;
+  ownerLogin: string;
+  repoName: string;
+  tag: string;
+  version: number;
+  username: string;
+  serviceName: string;
+  requestId: string;
+  success: boolean;
+  errorMessage: string;
+  leaseId: string;
+  createdAt: string;
+}
+
+export interface ListAuditEventsResult {
+  events?: Array<AuditEventResult>;
+  nextCursor?: string;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface VerifyAuditChainResult {
+  valid?: boolean;
+  checkedEntries?: number;
+  legacyEntries?: number;
+  firstBrokenId?: number;
+  reason?: string;
+  checkpointsVerified?: number;
+  lastCheckpointId?: number;
+  checkpointGapId?: number;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface WebhookInput {
+  ownerLogin?: string;
+  repoName?: string;
+  orgLogin?: string;
+  url?: string;
+  events?: string[];
+  secret?: string;
+  active?: boolean;
+  rotateSecret?: boolean;
+}
+
+export interface WebhookResult {
+  id: number;
+  ownerLogin: string;
+  repoName: string;
+  orgLogin: string;
+  url: string;
+  events: string[];
+  active: boolean;
+  createdBy: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export interface CreateWebhookResult {
+  success?: boolean;
+  webhookId?: number;
+  secret?: string;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface ListWebhooksResult {
+  webhooks?: WebhookResult[];
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface UpdateWebhookResult {
+  success?: boolean;
+  secret?: string;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface WebhookDeliveryResult {
+  id: number;
+  deliveryId: string;
+  event: string;
+  status: string;
+  attempts: number;
+  lastStatusCode: number;
+  lastError: string;
+  createdAt: string;
+  nextAttemptAt: string;
+  deliveredAt: string;
+  redeliveryOf: number;
+}
+
+export interface ListWebhookDeliveriesResult {
+  deliveries?: WebhookDeliveryResult[];
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface RedeliverWebhookResult {
+  success?: boolean;
+  deliveryId?: number;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface WatchedRepositoryInput {
+  ownerLogin: string;
+  repoName: string;
+}
+
+export interface WatchSecretsEventResult {
+  type: string;
+  resumeToken?: string;
+  ownerLogin?: string;
+  repoName?: string;
+  tag?: string;
+  version?: number;
+  path?: string;
+  fileName?: string;
+  branch?: string;
+  file?: boolean;
+  checksum?: string;
+  deletedVersions?: number;
+  actor?: string;
+  createdAt?: string;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface ProtectedTagResult {
+  tag: string;
+  requestTtlHours: number;
+  updatedBy: string;
+  updatedAt: string;
+}
+
+export interface ChangeRequestResult {
+  id: number;
+  tag: string;
+  kind: string;
+  parentTag: string;
+  parentVersion: number;
+  path: string;
+  fileName: string;
+  branch: string;
+  status: string;
+  requestedBy: string;
+  reviewedBy: string;
+  createdAt: string;
+  expiresAt: string;
+  resolvedAt: string;
+  baseVersion: number;
+  version: number;
+  checksum: string;
+  annotation?: any;
+  diff: { key: string; change: string }[];
+  comments: { author: string; body: string; createdAt: string }[];
+}
+
+export interface SetTagProtectionResult {
+  success?: boolean;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface ListChangeRequestsResult {
+  changeRequests?: ChangeRequestResult[];
+  protectedTags?: ProtectedTagResult[];
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface ReviewChangeResult {
+  success?: boolean;
+  version?: number;
+  checksum?: string;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface UploadFileResult {
+  success?: boolean;
+  version?: number;
+  checksum?: string;
+  size?: number;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface DownloadFileResult {
+  success?: boolean;
+  version?: number;
+  tag?: string;
+  fileName?: string;
+  contentType?: string;
+  content?: Buffer;
+  size?: number;
   checksum?: string;
   uploadedBy?: string;
   createdAt?: string;
@@ -34,7 +510,97 @@ export interface DownloadSecretResult {
   errorDescription?: string;
 }
 
-export interface DeleteSecretResult {
+export interface ShareLinkInput {
+  tag?: string;
+  version?: number;
+  path?: string;
+  fileName?: string;
+  key?: string;
+  file?: boolean;
+  maxViews?: number;
+  expiresInSeconds?: number;
+  branch?: string;
+}
+
+export interface CreateShareLinkResult {
+  success?: boolean;
+  linkId?: string;
+  token?: string;
+  expiresAt?: string;
+  maxViews?: number;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface RedeemShareLinkResult {
+  success?: boolean;
+  kind?: string;
+  key?: string;
+  content?: Buffer;
+  fileName?: string;
+  contentType?: string;
+  sharedBy?: string;
+  remainingViews?: number;
+  expiresAt?: string;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface SecretKeyResult {
+  key: string;
+  length: number;
+  preview: string;
+  fingerprint: string;
+  tag: string;
+  branch: string;
+}
+
+export interface ListSecretKeysResult {
+  keys?: SecretKeyResult[];
+  tag?: string;
+  version?: number;
+  branch?: string;
+  branchVersion?: number;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface SecretKeyMatchResult {
+  ownerLogin: string;
+  repoName: string;
+  path: string;
+  fileName: string;
+  branch: string;
+  tag: string;
+  version: number;
+  key: string;
+}
+
+export interface SearchSecretKeysResult {
+  matches?: SecretKeyMatchResult[];
+  truncated?: boolean;
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface FileVersionResult {
+  fileName: string;
+  tag: string;
+  version: number;
+  contentType: string;
+  size: number;
+  checksum: string;
+  uploadedBy: string;
+  createdAt: string;
+}
+
+export interface ListFileVersionsResult {
+  versions?: FileVersionResult[];
+  error?: string;
+  errorDescription?: string;
+}
+
+export interface DeleteFileResult {
   success?: boolean;
   deletedVersions?: number;
   error?: string;
@@ -60,6 +626,13 @@ export class SecretsService {
     repoName: string,
     tag: string,
     envFileContent: Buffer,
+    format: string = '',
+    keySeparator: string = '',
+    fileName: string = '',
+    path: string = '',
+    branch: string = '',
+    keyMetadata: Array<KeyMetadataInput> = [],
+    annotation?: VersionAnnotationInput,
   ): Promise<UploadSecretResult> {
     try {
       const authTokenResponse = await this.authService.getAuthToken(jwt);
@@ -93,18 +666,31 @@ export class SecretsService {
         tag,
         envFileContent,
         userLogin: userLoginResponse.userLogin,
+        format,
+        keySeparator,
+        fileName,
+        path,
+        branch,
+        keyMetadata,
+        annotation,
       });
 
       if (response.success) {
+        // Uploads to a protected tag only open a change request, whose id arrives as a Long
         return {
           success: true,
           version: response.version,
           checksum: response.checksum,
+          violations: response.violations || [],
+          lintFindings: response.lintFindings || [],
+          changeRequestId: response.changeRequestId ? Number(response.changeRequestId) : undefined,
         };
       } else {
         return {
           error: 'upload_failed',
           errorDescription: response.error || 'Failed to upload secret',
+          violations: response.violations || [],
+          lintFindings: response.lintFindings || [],
         };
       }
     } catch (error) {
@@ -119,6 +705,9 @@ export class SecretsService {
     jwt: string,
     ownerLogin: string,
     repoName: string,
+    fileName?: string,
+    groupByFile: boolean = false,
+    path?: string,
   ): Promise<ListSecretVersionsResult> {
     try {
       const authTokenResponse = await this.authService.getAuthToken(jwt);
@@ -150,11 +739,17 @@ export class SecretsService {
         ownerLogin,
         repoName,
         userLogin: userLoginResponse.userLogin,
+        fileName: fileName || '',
+        groupByFile,
+        path,
       });
 
       if (response.versions) {
         return {
           versions: response.versions,
+          files: response.files,
+          branches: response.branches,
+          tagParents: response.tagParents,
         };
       } else {
         return {
@@ -176,6 +771,15 @@ export class SecretsService {
     repoName: string,
     version?: number,
     tag?: string,
+    format?: string,
+    resourceName?: string,
+    namespace?: string,
+    fileName?: string,
+    path?: string,
+    branch?: string,
+    includeOrigins: boolean = false,
+    interpolate: boolean = false,
+    leaseTtlSeconds: number = 0,
   ): Promise<DownloadSecretResult> {
     try {
       const authTokenResponse = await this.authService.getAuthToken(jwt);
@@ -209,6 +813,15 @@ export class SecretsService {
         version: version || 0,
         tag: tag || '',
         userLogin: userLoginResponse.userLogin,
+        format: format || '',
+        resourceName: resourceName || '',
+        namespace: namespace || '',
+        fileName: fileName || '',
+        path: path || '',
+        branch: branch || '',
+        includeOrigins,
+        interpolate,
+        leaseTtlSeconds,
       });
 
       if (response.success) {
@@ -220,6 +833,13 @@ export class SecretsService {
           checksum: response.checksum,
           uploadedBy: response.uploadedBy,
           createdAt: response.createdAt,
+          format: response.format,
+          fileName: response.fileName,
+          path: response.path,
+          branch: response.branch,
+          branchVersion: response.branchVersion,
+          origins: response.origins,
+          leases: response.leases || [],
         };
       } else {
         return {
@@ -240,7 +860,10 @@ export class SecretsService {
       ownerLogin: string,
       repoName: string,
       version?: number,
-      tag?: string
+      tag?: string,
+      fileName?: string,
+      path?: string,
+      branch?: string,
     ): Promise<DeleteSecretResult> {
     try {
       const authTokenResponse = await this.authService.getAuthToken(jwt);
@@ -274,6 +897,9 @@ export class SecretsService {
         version: version || 0,
         tag: tag || '',
         userLogin: userLoginResponse.userLogin,
+        fileName: fileName || '',
+        path: path || '',
+        branch: branch || '',
       });
 
       if (response.success) {
@@ -294,4 +920,2147 @@ export class SecretsService {
       };
     }
   }
+
+  async uploadFile(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    tag: string,
+    fileName: string,
+    contentType: string,
+    content: Buffer,
+  ): Promise<UploadFileResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.uploadFile({
+        accessToken: authTokenResponse.accessToken,
+        ownerLogin,
+        repoName,
+        tag,
+        fileName,
+        contentType,
+        content,
+        userLogin: userLoginResponse.userLogin,
+      });
+
+      if (response.success) {
+        return {
+          success: true,
+          version: response.version,
+          checksum: response.checksum,
+          size: response.size,
+        };
+      } else {
+        return {
+          error: 'upload_file_failed',
+          errorDescription: response.error || 'Failed to upload file',
+        };
+      }
+    } catch (error) {
+      return {
+        error: 'upload_file_error',
+        errorDescription: error.message || 'Internal server error during file upload',
+      };
+    }
+  }
+
+  async downloadFile(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    fileName: string,
+    version?: number,
+    tag?: string,
+  ): Promise<DownloadFileResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.downloadFile({
+        accessToken: authTokenResponse.accessToken,
+        ownerLogin,
+        repoName,
+        fileName,
+        version: version || 0,
+        tag: tag || '',
+        userLogin: userLoginResponse.userLogin,
+      });
+
+      if (response.success) {
+        return {
+          success: true,
+          version: response.version,
+          tag: response.tag,
+          fileName: response.fileName,
+          contentType: response.contentType,
+          content: response.content,
+          size: response.size,
+          checksum: response.checksum,
+          uploadedBy: response.uploadedBy,
+          createdAt: response.createdAt,
+        };
+      } else {
+        return {
+          error: 'download_file_failed',
+          errorDescription: response.error || 'Failed to download file',
+        };
+      }
+    } catch (error) {
+      return {
+        error: 'download_file_error',
+        errorDescription: error.message || 'Internal server error during file download',
+      };
+    }
+  }
+
+  async setTagParent(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    tag: string,
+    parentTag: string,
+    parentVersion: number = 0,
+    fileName?: string,
+    path?: string,
+  ): Promise<SetTagParentResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.setTagParent({
+        accessToken: authTokenResponse.accessToken,
+        ownerLogin,
+        repoName,
+        userLogin: userLoginResponse.userLogin,
+        tag,
+        parentTag: parentTag || '',
+        parentVersion,
+        fileName: fileName || '',
+        path: path || '',
+      });
+
+      if (response.success) {
+        return {
+          success: true,
+          changeRequestId: response.changeRequestId ? Number(response.changeRequestId) : undefined,
+        };
+      } else {
+        return {
+          error: 'set_tag_parent_failed',
+          errorDescription: response.error || 'Failed to set tag parent',
+        };
+      }
+    } catch (error) {
+      return {
+        error: 'set_tag_parent_error',
+        errorDescription: error.message || 'Internal server error while setting tag parent',
+      };
+    }
+  }
+
+  async listSecretReferences(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    key?: string,
+    tag?: string,
+  ): Promise<ListSecretReferencesResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.listSecretReferences({
+        accessToken: authTokenResponse.accessToken,
+        ownerLogin,
+        repoName,
+        userLogin: userLoginResponse.userLogin,
+        key: key || '',
+        tag: tag || '',
+      });
+
+      if (!response.error) {
+        return {
+          references: response.references || [],
+          hiddenReferences: response.hiddenReferences,
+        };
+      } else {
+        return {
+          error: 'list_references_failed',
+          errorDescription: response.error,
+        };
+      }
+    } catch (error) {
+      return {
+        error: 'list_references_error',
+        errorDescription: error.message || 'Internal server error during list references',
+      };
+    }
+  }
+
+  async uploadSharedSecretSet(
+    jwt: string,
+    orgLogin: string,
+    setName: string,
+    envFileContent: Buffer,
+    format?: string,
+    keySeparator?: string,
+  ): Promise<UploadSharedSecretSetResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.uploadSharedSecretSet({
+        accessToken: authTokenResponse.accessToken,
+        orgLogin,
+        setName,
+        envFileContent,
+        userLogin: userLoginResponse.userLogin,
+        format: format || '',
+        keySeparator: keySeparator || '',
+      });
+
+      if (response.success) {
+        return {
+          success: true,
+          version: response.version,
+          checksum: response.checksum,
+        };
+      } else {
+        return {
+          error: 'upload_shared_set_failed',
+          errorDescription: response.error || 'Failed to upload shared secret set',
+        };
+      }
+    } catch (error) {
+      return {
+        error: 'upload_shared_set_error',
+        errorDescription: error.message || 'Internal server error during shared secret set upload',
+      };
+    }
+  }
+
+  async listSharedSecretSets(
+    jwt: string,
+    orgLogin: string,
+  ): Promise<ListSharedSecretSetsResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.listSharedSecretSets({
+        accessToken: authTokenResponse.accessToken,
+        orgLogin,
+        userLogin: userLoginResponse.userLogin,
+      });
+
+      if (!response.error) {
+        return {
+          sets: (response.sets || []).map(set => ({
+            ...set,
+            versions: set.versions || [],
+            attachments: set.attachments || [],
+          })),
+        };
+      } else {
+        return {
+          error: 'list_shared_sets_failed',
+          errorDescription: response.error,
+        };
+      }
+    } catch (error) {
+      return {
+        error: 'list_shared_sets_error',
+        errorDescription: error.message || 'Internal server error during list shared secret sets',
+      };
+    }
+  }
+
+  async attachSharedSecretSet(
+    jwt: string,
+    orgLogin: string,
+    setName: string,
+    ownerLogin: string,
+    repoName: string,
+    version: number = 0,
+    detach: boolean = false,
+  ): Promise<AttachSharedSecretSetResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.attachSharedSecretSet({
+        accessToken: authTokenResponse.accessToken,
+        orgLogin,
+        setName,
+        ownerLogin,
+        repoName,
+        userLogin: userLoginResponse.userLogin,
+        version,
+        detach,
+      });
+
+      if (response.success) {
+        return {
+          success: true,
+        };
+      } else {
+        return {
+          error: detach ? 'detach_shared_set_failed' : 'attach_shared_set_failed',
+          errorDescription: response.error || 'Failed to update shared secret set attachment',
+        };
+      }
+    } catch (error) {
+      return {
+        error: detach ? 'detach_shared_set_error' : 'attach_shared_set_error',
+        errorDescription: error.message || 'Internal server error while updating shared secret set attachment',
+      };
+    }
+  }
+
+  async setSchema(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    tag: string,
+    schema: string,
+  ): Promise<SetSchemaResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.setSchema({
+        accessToken: authTokenResponse.accessToken,
+        ownerLogin,
+        repoName,
+        userLogin: userLoginResponse.userLogin,
+        tag: tag || '',
+        schema: Buffer.from(schema || '', 'utf8'),
+      });
+
+      if (response.success) {
+        return {
+          success: true,
+        };
+      } else {
+        return {
+          error: 'set_schema_failed',
+          errorDescription: response.error || 'Failed to set schema',
+        };
+      }
+    } catch (error) {
+      return {
+        error: 'set_schema_error',
+        errorDescription: error.message || 'Internal server error while setting schema',
+      };
+    }
+  }
+
+  async getSchema(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    tag?: string,
+  ): Promise<GetSchemaResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.getSchema({
+        accessToken: authTokenResponse.accessToken,
+        ownerLogin,
+        repoName,
+        userLogin: userLoginResponse.userLogin,
+        tag: tag || '',
+      });
+
+      if (!response.error) {
+        return {
+          schema: response.schema ? Buffer.from(response.schema).toString('utf8') : '',
+          tag: response.tag,
+          updatedBy: response.updatedBy,
+          updatedAt: response.updatedAt,
+        };
+      } else {
+        return {
+          error: 'get_schema_failed',
+          errorDescription: response.error,
+        };
+      }
+    } catch (error) {
+      return {
+        error: 'get_schema_error',
+        errorDescription: error.message || 'Internal server error while getting schema',
+      };
+    }
+  }
+
+  async listLintRules(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+  ): Promise<ListLintRulesResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.listLintRules({
+        accessToken: authTokenResponse.accessToken,
+        ownerLogin,
+        repoName,
+        userLogin: userLoginResponse.userLogin,
+      });
+
+      if (!response.error) {
+        return {
+          rules: (response.rules || []).map(rule => ({
+            name: rule.name,
+            description: rule.description,
+            action: rule.action,
+          })),
+        };
+      } else {
+        return {
+          error: 'list_lint_rules_failed',
+          errorDescription: response.error,
+        };
+      }
+    } catch (error) {
+      return {
+        error: 'list_lint_rules_error',
+        errorDescription: error.message || 'Internal server error while listing lint rules',
+      };
+    }
+  }
+
+  async setLintRule(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    rule: string,
+    action: string,
+  ): Promise<SetLintRuleResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.setLintRule({
+        accessToken: authTokenResponse.accessToken,
+        ownerLogin,
+        repoName,
+        userLogin: userLoginResponse.userLogin,
+        rule: rule || '',
+        action: action || '',
+      });
+
+      if (response.success) {
+        return {
+          success: true,
+        };
+      } else {
+        return {
+          error: 'set_lint_rule_failed',
+          errorDescription: response.error || 'Failed to set lint rule',
+        };
+      }
+    } catch (error) {
+      return {
+        error: 'set_lint_rule_error',
+        errorDescription: error.message || 'Internal server error while setting lint rule',
+      };
+    }
+  }
+
+  async listExpiringSecrets(
+    jwt: string,
+    withinDays: number,
+  ): Promise<ListExpiringSecretsResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.listExpiringSecrets({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        withinDays,
+      });
+
+      if (!response.error) {
+        return {
+          secrets: (response.secrets || []).map(secret => ({
+            ownerLogin: secret.ownerLogin,
+            repoName: secret.repoName,
+            path: secret.path,
+            fileName: secret.fileName,
+            branch: secret.branch,
+            tag: secret.tag,
+            version: secret.version,
+            key: secret.key,
+            owner: secret.owner,
+            expiresAt: secret.expiresAt,
+            rotationInterval: secret.rotationInterval,
+            rotatedAt: secret.rotatedAt,
+            dueAt: secret.dueAt,
+            reason: secret.reason,
+            expired: secret.expired,
+          })),
+        };
+      } else {
+        return {
+          error: 'list_expiring_secrets_failed',
+          errorDescription: response.error,
+        };
+      }
+    } catch (error) {
+      return {
+        error: 'list_expiring_secrets_error',
+        errorDescription: error.message || 'Internal server error while listing expiring secrets',
+      };
+    }
+  }
+
+  async generateSecretValues(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    tag: string,
+    values: Array<GenerateSpecInput>,
+    overwrite: boolean,
+    fileName: string = '',
+    path: string = '',
+    branch: string = '',
+    annotation?: VersionAnnotationInput,
+  ): Promise<GenerateSecretValuesResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.generateSecretValues({
+        accessToken: authTokenResponse.accessToken,
+        ownerLogin,
+        repoName,
+        userLogin: userLoginResponse.userLogin,
+        tag,
+        fileName,
+        path,
+        branch,
+        values,
+        overwrite,
+        annotation,
+      });
+
+      if (response.success) {
+        return {
+          success: true,
+          version: response.version,
+          checksum: response.checksum,
+          generatedKeys: response.generatedKeys || [],
+          violations: response.violations || [],
+        };
+      } else {
+        return {
+          error: 'generate_failed',
+          errorDescription: response.error || 'Failed to generate secret values',
+          violations: response.violations || [],
+        };
+      }
+    } catch (error) {
+      return {
+        error: 'generate_error',
+        errorDescription: error.message || 'Internal server error while generating secret values',
+      };
+    }
+  }
+
+  async setSecretEngine(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    name: string,
+    engine: SecretEngineInput,
+    remove: boolean = false,
+  ): Promise<SetSecretEngineResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.setSecretEngine({
+        accessToken: authTokenResponse.accessToken,
+        ownerLogin,
+        repoName,
+        userLogin: userLoginResponse.userLogin,
+        name,
+        engine: engine.engine || '',
+        tag: engine.tag || '',
+        config: Buffer.from(engine.config || '', 'utf8'),
+        defaultTtlSeconds: engine.defaultTtlSeconds || 0,
+        maxTtlSeconds: engine.maxTtlSeconds || 0,
+        delete: remove,
+      });
+
+      if (response.success) {
+        return {
+          success: true,
+        };
+      } else {
+        return {
+          error: 'set_secret_engine_failed',
+          errorDescription: response.error || 'Failed to set secret engine',
+        };
+      }
+    } catch (error) {
+      return {
+        error: 'set_secret_engine_error',
+        errorDescription: error.message || 'Internal server error while setting secret engine',
+      };
+    }
+  }
+
+  async listSecretEngines(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+  ): Promise<ListSecretEnginesResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.listSecretEngines({
+        accessToken: authTokenResponse.accessToken,
+        ownerLogin,
+        repoName,
+        userLogin: userLoginResponse.userLogin,
+      });
+
+      if (response.error) {
+        return {
+          error: 'list_secret_engines_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      return {
+        engines: response.engines || [],
+      };
+    } catch (error) {
+      return {
+        error: 'list_secret_engines_error',
+        errorDescription: error.message || 'Internal server error while listing secret engines',
+      };
+    }
+  }
+
+  async listAuditEvents(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    filter: AuditEventFilter,
+  ): Promise<ListAuditEventsResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.listAuditEvents({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        ownerLogin: ownerLogin || '',
+        repoName: repoName || '',
+        username: filter.username || '',
+        operation: filter.operation || '',
+        success: filter.success,
+        since: filter.since || '',
+        until: filter.until || '',
+        pageSize: filter.pageSize || 0,
+        cursor: filter.cursor || '',
+      });
+
+      if (response.error) {
+        return {
+          error: 'list_audit_events_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      return {
+        // 64-bit IDs arrive as Long objects
+        events: (response.events || []).map((event) => ({ ...event, id: String(event.id) })),
+        nextCursor: response.nextCursor || '',
+      };
+    } catch (error) {
+      return {
+        error: 'list_audit_events_error',
+        errorDescription: error.message || 'Internal server error while listing audit events',
+      };
+    }
+  }
+
+  async verifyAuditChain(
+    jwt: string,
+    checkpoint: boolean,
+  ): Promise<VerifyAuditChainResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.verifyAuditChain({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        checkpoint,
+      });
+
+      if (response.error) {
+        return {
+          error: 'verify_audit_chain_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      // 64-bit counters arrive as Long objects
+      return {
+        valid: response.valid,
+        checkedEntries: Number(response.checkedEntries),
+        legacyEntries: Number(response.legacyEntries),
+        firstBrokenId: Number(response.firstBrokenId),
+        reason: response.reason,
+        checkpointsVerified: response.checkpointsVerified,
+        lastCheckpointId: Number(response.lastCheckpointId),
+        checkpointGapId: Number(response.checkpointGapId),
+      };
+    } catch (error) {
+      return {
+        error: 'verify_audit_chain_error',
+        errorDescription: error.message || 'Internal server error while verifying the audit log',
+      };
+    }
+  }
+
+  async createWebhook(
+    jwt: string,
+    webhook: WebhookInput,
+  ): Promise<CreateWebhookResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.createWebhook({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        ownerLogin: webhook.ownerLogin || '',
+        repoName: webhook.repoName || '',
+        orgLogin: webhook.orgLogin || '',
+        url: webhook.url || '',
+        events: webhook.events || [],
+        secret: webhook.secret || '',
+      });
+
+      if (response.error) {
+        return {
+          error: 'create_webhook_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      return {
+        success: response.success,
+        webhookId: Number(response.webhookId),
+        secret: response.secret || undefined,
+      };
+    } catch (error) {
+      return {
+        error: 'create_webhook_error',
+        errorDescription: error.message || 'Internal server error while creating the webhook',
+      };
+    }
+  }
+
+  async listWebhooks(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    orgLogin: string,
+  ): Promise<ListWebhooksResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.listWebhooks({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        ownerLogin: ownerLogin || '',
+        repoName: repoName || '',
+        orgLogin: orgLogin || '',
+      });
+
+      if (response.error) {
+        return {
+          error: 'list_webhooks_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      // Webhook ids arrive as Long objects
+      return {
+        webhooks: (response.webhooks || []).map((webhook) => ({
+          ...webhook,
+          id: Number(webhook.id),
+          events: webhook.events || [],
+        })),
+      };
+    } catch (error) {
+      return {
+        error: 'list_webhooks_error',
+        errorDescription: error.message || 'Internal server error while listing webhooks',
+      };
+    }
+  }
+
+  async updateWebhook(
+    jwt: string,
+    webhookId: number,
+    webhook: WebhookInput,
+  ): Promise<UpdateWebhookResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.updateWebhook({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        webhookId,
+        url: webhook.url,
+        events: webhook.events || [],
+        active: webhook.active,
+        rotateSecret: webhook.rotateSecret === true,
+        secret: webhook.secret || '',
+      });
+
+      if (response.error) {
+        return {
+          error: 'update_webhook_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      return {
+        success: response.success,
+        secret: response.secret || undefined,
+      };
+    } catch (error) {
+      return {
+        error: 'update_webhook_error',
+        errorDescription: error.message || 'Internal server error while updating the webhook',
+      };
+    }
+  }
+
+  async deleteWebhook(
+    jwt: string,
+    webhookId: number,
+  ): Promise<UpdateWebhookResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.deleteWebhook({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        webhookId,
+      });
+
+      if (response.error) {
+        return {
+          error: 'delete_webhook_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      return {
+        success: response.success,
+      };
+    } catch (error) {
+      return {
+        error: 'delete_webhook_error',
+        errorDescription: error.message || 'Internal server error while deleting the webhook',
+      };
+    }
+  }
+
+  async listWebhookDeliveries(
+    jwt: string,
+    webhookId: number,
+    limit: number,
+  ): Promise<ListWebhookDeliveriesResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.listWebhookDeliveries({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        webhookId,
+        limit,
+      });
+
+      if (response.error) {
+        return {
+          error: 'list_webhook_deliveries_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      return {
+        deliveries: (response.deliveries || []).map((delivery) => ({
+          ...delivery,
+          id: Number(delivery.id),
+          redeliveryOf: Number(delivery.redeliveryOf),
+        })),
+      };
+    } catch (error) {
+      return {
+        error: 'list_webhook_deliveries_error',
+        errorDescription: error.message || 'Internal server error while listing webhook deliveries',
+      };
+    }
+  }
+
+  async redeliverWebhook(
+    jwt: string,
+    deliveryId: number,
+  ): Promise<RedeliverWebhookResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.redeliverWebhook({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        deliveryId,
+      });
+
+      if (response.error) {
+        return {
+          error: 'redeliver_webhook_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      return {
+        success: response.success,
+        deliveryId: Number(response.deliveryId),
+      };
+    } catch (error) {
+      return {
+        error: 'redeliver_webhook_error',
+        errorDescription: error.message || 'Internal server error while redelivering the webhook',
+      };
+    }
+  }
+
+  // Streams WatchSecrets as server-sent events; each event id is its resume token
+  watchSecrets(
+    jwt: string,
+    repositories: WatchedRepositoryInput[],
+    tags: string[],
+    resumeToken: string,
+  ): Observable<MessageEvent> {
+    const toMessage = (event: WatchSecretsEventResult): MessageEvent => ({
+      id: event.resumeToken || undefined,
+      data: event,
+    });
+
+    return defer(async (): Promise<WatchSecretsEventResult | { accessToken: string; userLogin: string }> => {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          type: 'error',
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          type: 'error',
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          type: 'error',
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      return {
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+      };
+    }).pipe(
+      switchMap((auth) => {
+        if ('type' in auth) {
+          return of(toMessage(auth));
+        }
+
+        return this.secretOperationClient.watchSecrets({
+          accessToken: auth.accessToken,
+          userLogin: auth.userLogin,
+          repositories,
+          tags,
+          resumeToken,
+        }).pipe(
+          map((event) => {
+            if (event.error) {
+              return toMessage({
+                type: 'error',
+                error: 'watch_secrets_failed',
+                errorDescription: event.error,
+              });
+            }
+            return toMessage(event);
+          }),
+        );
+      }),
+      catchError((error) => of(toMessage({
+        type: 'error',
+        error: 'watch_secrets_error',
+        errorDescription: error.message || 'Internal server error while watching secrets',
+      }))),
+    );
+  }
+
+  async setTagProtection(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    tag: string,
+    isProtected: boolean,
+    requestTtlHours: number,
+  ): Promise<SetTagProtectionResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.setTagProtection({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        ownerLogin,
+        repoName,
+        tag,
+        protected: isProtected,
+        requestTtlHours,
+      });
+
+      if (response.error) {
+        return {
+          error: 'set_tag_protection_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      return {
+        success: response.success,
+      };
+    } catch (error) {
+      return {
+        error: 'set_tag_protection_error',
+        errorDescription: error.message || 'Internal server error while changing tag protection',
+      };
+    }
+  }
+
+  async listChangeRequests(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    status: string,
+  ): Promise<ListChangeRequestsResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.listChangeRequests({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        ownerLogin,
+        repoName,
+        status: status || '',
+      });
+
+      if (response.error) {
+        return {
+          error: 'list_change_requests_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      // Change request ids arrive as Long objects
+      return {
+        changeRequests: (response.changeRequests || []).map((request) => ({
+          ...request,
+          id: Number(request.id),
+          diff: request.diff || [],
+          comments: request.comments || [],
+        })),
+        protectedTags: response.protectedTags || [],
+      };
+    } catch (error) {
+      return {
+        error: 'list_change_requests_error',
+        errorDescription: error.message || 'Internal server error while listing change requests',
+      };
+    }
+  }
+
+  async approveChange(
+    jwt: string,
+    changeId: number,
+    comment: string,
+  ): Promise<ReviewChangeResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.approveChange({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        changeId,
+        comment: comment || '',
+      });
+
+      if (response.error) {
+        return {
+          error: 'approve_change_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      return {
+        success: response.success,
+        version: response.version,
+        checksum: response.checksum,
+      };
+    } catch (error) {
+      return {
+        error: 'approve_change_error',
+        errorDescription: error.message || 'Internal server error while approving the change request',
+      };
+    }
+  }
+
+  async rejectChange(
+    jwt: string,
+    changeId: number,
+    comment: string,
+  ): Promise<ReviewChangeResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.rejectChange({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        changeId,
+        comment: comment || '',
+      });
+
+      if (response.error) {
+        return {
+          error: 'reject_change_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      return {
+        success: response.success,
+      };
+    } catch (error) {
+      return {
+        error: 'reject_change_error',
+        errorDescription: error.message || 'Internal server error while rejecting the change request',
+      };
+    }
+  }
+
+  async commentChange(
+    jwt: string,
+    changeId: number,
+    comment: string,
+  ): Promise<ReviewChangeResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.commentChange({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        changeId,
+        comment: comment || '',
+      });
+
+      if (response.error) {
+        return {
+          error: 'comment_change_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      return {
+        success: response.success,
+      };
+    } catch (error) {
+      return {
+        error: 'comment_change_error',
+        errorDescription: error.message || 'Internal server error while commenting on the change request',
+      };
+    }
+  }
+
+  async createShareLink(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    share: ShareLinkInput,
+  ): Promise<CreateShareLinkResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.createShareLink({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        ownerLogin,
+        repoName,
+        tag: share.tag || '',
+        version: share.version || 0,
+        path: share.path || '',
+        fileName: share.fileName || '',
+        key: share.key || '',
+        file: share.file === true,
+        maxViews: share.maxViews || 0,
+        expiresInSeconds: share.expiresInSeconds || 0,
+        branch: share.branch || '',
+      });
+
+      if (response.error) {
+        return {
+          error: 'create_share_link_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      return {
+        success: response.success,
+        linkId: response.linkId,
+        token: response.token,
+        expiresAt: response.expiresAt,
+        maxViews: response.maxViews,
+      };
+    } catch (error) {
+      return {
+        error: 'create_share_link_error',
+        errorDescription: error.message || 'Internal server error while creating the share link',
+      };
+    }
+  }
+
+  // Redemption is unauthenticated, the token is the only credential
+  async redeemShareLink(token: string): Promise<RedeemShareLinkResult> {
+    try {
+      const response = await this.secretOperationClient.redeemShareLink({ token });
+
+      if (response.error) {
+        return {
+          error: 'redeem_share_link_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      return {
+        success: response.success,
+        kind: response.kind,
+        key: response.key,
+        content: response.content,
+        fileName: response.fileName,
+        contentType: response.contentType,
+        sharedBy: response.sharedBy,
+        remainingViews: response.remainingViews,
+        expiresAt: response.expiresAt,
+      };
+    } catch (error) {
+      return {
+        error: 'redeem_share_link_error',
+        errorDescription: error.message || 'Internal server error while redeeming the share link',
+      };
+    }
+  }
+
+  async listSecretKeys(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    tag: string,
+    version: number,
+    path: string,
+    fileName: string,
+    branch: string,
+  ): Promise<ListSecretKeysResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.listSecretKeys({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        ownerLogin,
+        repoName,
+        tag: tag || '',
+        version: version || 0,
+        path: path || '',
+        fileName: fileName || '',
+        branch: branch || '',
+      });
+
+      if (response.error) {
+        return {
+          error: 'list_secret_keys_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      return {
+        keys: response.keys || [],
+        tag: response.tag,
+        version: response.version,
+        branch: response.branch,
+        branchVersion: response.branchVersion,
+      };
+    } catch (error) {
+      return {
+        error: 'list_secret_keys_error',
+        errorDescription: error.message || 'Internal server error while listing keys',
+      };
+    }
+  }
+
+  async searchSecretKeys(
+    jwt: string,
+    query: string,
+    mode: string,
+    limit: number,
+  ): Promise<SearchSecretKeysResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.searchSecretKeys({
+        accessToken: authTokenResponse.accessToken,
+        userLogin: userLoginResponse.userLogin,
+        query,
+        mode: mode || '',
+        limit: limit || 0,
+      });
+
+      if (response.error) {
+        return {
+          error: 'search_secret_keys_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      return {
+        matches: (response.matches || []).map(match => ({
+          ownerLogin: match.ownerLogin,
+          repoName: match.repoName,
+          path: match.path,
+          fileName: match.fileName,
+          branch: match.branch,
+          tag: match.tag,
+          version: match.version,
+          key: match.key,
+        })),
+        truncated: response.truncated || false,
+      };
+    } catch (error) {
+      return {
+        error: 'search_secret_keys_error',
+        errorDescription: error.message || 'Internal server error while searching keys',
+      };
+    }
+  }
+
+  async listFileVersions(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    fileName: string,
+  ): Promise<ListFileVersionsResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.listFileVersions({
+        accessToken: authTokenResponse.accessToken,
+        ownerLogin,
+        repoName,
+        userLogin: userLoginResponse.userLogin,
+        fileName: fileName || '',
+      });
+
+      if (response.error) {
+        return {
+          error: 'list_file_versions_failed',
+          errorDescription: response.error,
+        };
+      }
+
+      return {
+        versions: response.versions || [],
+      };
+    } catch (error) {
+      return {
+        error: 'list_file_versions_error',
+        errorDescription: error.message || 'Internal server error while listing file versions',
+      };
+    }
+  }
+
+  async deleteFile(
+    jwt: string,
+    ownerLogin: string,
+    repoName: string,
+    fileName: string,
+    tag?: string,
+    version?: number,
+  ): Promise<DeleteFileResult> {
+    try {
+      const authTokenResponse = await this.authService.getAuthToken(jwt);
+      const userLoginResponse = await this.authService.getUserLogin(jwt);
+
+      if (authTokenResponse.error) {
+        return {
+          error: authTokenResponse.error,
+          errorDescription: authTokenResponse.errorDescription,
+        };
+      }
+
+      if (!authTokenResponse.accessToken) {
+        return {
+          error: 'no_access_token',
+          errorDescription: 'No access token received from auth service',
+        };
+      }
+
+      if(!userLoginResponse.userLogin) {
+        return {
+          error: 'no_user_login',
+          errorDescription: 'No user login received from auth service',
+        };
+      }
+
+      const response = await this.secretOperationClient.deleteFile({
+        accessToken: authTokenResponse.accessToken,
+        ownerLogin,
+        repoName,
+        userLogin: userLoginResponse.userLogin,
+        fileName,
+        tag,
+        version,
+      });
+
+      if (response.success) {
+        return {
+          success: true,
+          deletedVersions: response.deletedVersions,
+        };
+      } else {
+        return {
+          error: 'delete_file_failed',
+          errorDescription: response.error || 'Failed to delete file',
+        };
+      }
+    } catch (error) {
+      return {
+        error: 'delete_file_error',
+        errorDescription: error.message || 'Internal server error while deleting file',
+      };
+    }
+  }
 } 
\ No newline at end of file
@@ -0,0 +1,284 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func getBackendURL() string {
+	if url := os.Getenv("BACKEND_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3000" // default fallback
+}
+
+type StoredAuthData struct {
+	Jwt string `json:"jwt"`
+}
+
+// Filter narrows the listed events; empty fields match everything
+type Filter struct {
+	User      string
+	Operation string
+	Success   string // "true" or "false"
+	Since     string
+	Until     string
+	Limit     int
+	Cursor    string
+}
+
+type Event struct {
+	ID           string `json:"id"`
+	Operation    string `json:"operation"`
+	OwnerLogin   string `json:"ownerLogin"`
+	RepoName     string `json:"repoName"`
+	Tag          string `json:"tag"`
+	Version      int    `json:"version"`
+	Username     string `json:"username"`
+	ServiceName  string `json:"serviceName"`
+	RequestID    string `json:"requestId"`
+	Success      bool   `json:"success"`
+	ErrorMessage string `json:"errorMessage"`
+	LeaseID      string `json:"leaseId"`
+	CreatedAt    string `json:"createdAt"`
+}
+
+type ListAuditEventsResponse struct {
+	Events           []Event `json:"events"`
+	NextCursor       string  `json:"nextCursor,omitempty"`
+	Error            string  `json:"error,omitempty"`
+	ErrorDescription string  `json:"errorDescription,omitempty"`
+}
+
+func retrieveJwt() string {
+	bytes, err := os.ReadFile("./temp/auth.json")
+	if err != nil {
+		fmt.Println("No auth file found. Please authenticate first using the auth command.")
+		os.Exit(1)
+	}
+
+	var authData StoredAuthData
+	if err := json.Unmarshal(bytes, &authData); err != nil {
+		fmt.Println("Error parsing auth file:", err)
+		os.Exit(1)
+	}
+
+	return authData.Jwt
+}
+
+// ListEvents prints the audit events of a repository, or of every accessible repository when ownerLogin
+// is empty, newest first as a table or as JSON
+func ListEvents(ownerLogin string, repoName string, filter Filter, asJSON bool) {
+	jwt := retrieveJwt()
+
+	params := []string{}
+	if filter.User != "" {
+		params = append(params, "user="+neturl.QueryEscape(filter.User))
+	}
+	if filter.Operation != "" {
+		params = append(params, "operation="+neturl.QueryEscape(filter.Operation))
+	}
+	if filter.Success != "" {
+		params = append(params, "success="+filter.Success)
+	}
+	if filter.Since != "" {
+		params = append(params, "since="+neturl.QueryEscape(filter.Since))
+	}
+	if filter.Until != "" {
+		params = append(params, "until="+neturl.QueryEscape(filter.Until))
+	}
+	if filter.Limit > 0 {
+		params = append(params, fmt.Sprintf("limit=%d", filter.Limit))
+	}
+	if filter.Cursor != "" {
+		params = append(params, "cursor="+neturl.QueryEscape(filter.Cursor))
+	}
+
+	url := fmt.Sprintf("%s/secrets/audit", getBackendURL())
+	if ownerLogin != "" {
+		url += fmt.Sprintf("/%s/%s", ownerLogin, repoName)
+	}
+	if len(params) > 0 {
+		url += "?" + strings.Join(params, "&")
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response ListAuditEventsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	if asJSON {
+		if response.Events == nil {
+			response.Events = []Event{}
+		}
+		output, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			fmt.Printf("Failed to encode events: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(output))
+		return
+	}
+
+	if len(response.Events) == 0 {
+		fmt.Println("No audit events found")
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "TIME\tREPOSITORY\tOPERATION\tSECRET\tUSER\tRESULT")
+	for _, event := range response.Events {
+		result := "ok"
+		if !event.Success {
+			result = "failed: " + event.ErrorMessage
+		}
+		fmt.Fprintf(writer, "%s\t%s/%s\t%s\t%s\t%s\t%s\n", formatTime(event.CreatedAt), event.OwnerLogin, event.RepoName, event.Operation, secretLabel(event), event.Username, result)
+	}
+	writer.Flush()
+
+	if response.NextCursor != "" {
+		fmt.Printf("\nMore events: rerun with --cursor=%s\n", response.NextCursor)
+	}
+}
+
+// formatTime shows timestamps in local time
+func formatTime(value string) string {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	return parsed.Local().Format("2006-01-02 15:04:05")
+}
+
+func secretLabel(event Event) string {
+	switch {
+	case event.LeaseID != "":
+		return "lease " + event.LeaseID
+	case event.Version > 0:
+		return fmt.Sprintf("%s v%d", event.Tag, event.Version)
+	default:
+		return "-"
+	}
+}
+
+type VerifyAuditChainResponse struct {
+	Valid               bool   `json:"valid"`
+	CheckedEntries      int64  `json:"checkedEntries"`
+	LegacyEntries       int64  `json:"legacyEntries"`
+	FirstBrokenID       uint64 `json:"firstBrokenId"`
+	Reason              string `json:"reason"`
+	CheckpointsVerified int    `json:"checkpointsVerified"`
+	LastCheckpointID    uint64 `json:"lastCheckpointId"`
+	CheckpointGapID     uint64 `json:"checkpointGapId"`
+	Error               string `json:"error,omitempty"`
+	ErrorDescription    string `json:"errorDescription,omitempty"`
+}
+
+// VerifyChain checks the hash chain of the whole audit log and exits with 1 when it is broken; with
+// checkpoint the verified head is signed afterwards. Only audit admins may run it.
+func VerifyChain(checkpoint bool) {
+	jwt := retrieveJwt()
+
+	requestBody, err := json.Marshal(map[string]bool{"checkpoint": checkpoint})
+	if err != nil {
+		fmt.Printf("Failed to marshal request: %v\n", err)
+		os.Exit(1)
+	}
+
+	url := fmt.Sprintf("%s/secrets/audit/verify", getBackendURL())
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+	req.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response VerifyAuditChainResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	fmt.Printf("Checked entries: %d\n", response.CheckedEntries)
+	if response.LegacyEntries > 0 {
+		fmt.Printf("Entries from before chaining: %d (not verifiable)\n", response.LegacyEntries)
+	}
+	fmt.Printf("Verified checkpoints: %d", response.CheckpointsVerified)
+	if response.LastCheckpointID > 0 {
+		fmt.Printf(" (signed up to entry %d)", response.LastCheckpointID)
+	}
+	fmt.Println()
+	if response.CheckpointGapID > 0 {
+		fmt.Printf("⚠️  Entries from %d on were not signed on schedule; checkpoints may have been deleted\n", response.CheckpointGapID)
+	}
+
+	if !response.Valid {
+		fmt.Printf("❌ Audit log is broken at entry %d: %s\n", response.FirstBrokenID, response.Reason)
+		os.Exit(1)
+	}
+	fmt.Println("✅ Audit log is intact")
+}
@@ -0,0 +1,345 @@
+package files
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func getBackendURL() string {
+	if url := os.Getenv("BACKEND_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3000" // default fallback
+}
+
+type StoredAuthData struct {
+	Jwt string `json:"jwt"`
+}
+
+type UploadFileResponse struct {
+	Success          bool   `json:"success,omitempty"`
+	Version          int    `json:"version,omitempty"`
+	Checksum         string `json:"checksum,omitempty"`
+	Size             int64  `json:"size,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"errorDescription,omitempty"`
+}
+
+type ErrorResponse struct {
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"errorDescription,omitempty"`
+}
+
+func retrieveJwt() string {
+	bytes, err := os.ReadFile("./temp/auth.json")
+	if err != nil {
+		fmt.Println("No auth file found. Please authenticate first using the auth command.")
+		os.Exit(1)
+	}
+
+	var authData StoredAuthData
+	if err := json.Unmarshal(bytes, &authData); err != nil {
+		fmt.Println("Error parsing auth file:", err)
+		os.Exit(1)
+	}
+
+	return authData.Jwt
+}
+
+// PushFile uploads an arbitrary file (certificate, keyfile, keystore) as a new version
+func PushFile(ownerLogin string, repoName string, tag string, filePath string, fileName string, contentType string) {
+	jwt := retrieveJwt()
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		fmt.Printf("Failed to read file %s: %v\n", filePath, err)
+		os.Exit(1)
+	}
+
+	if fileName == "" {
+		fileName = filepath.ToSlash(filepath.Clean(filePath))
+	}
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(filePath))
+	}
+
+	request := map[string]string{
+		"tag":         tag,
+		"fileName":    fileName,
+		"contentType": contentType,
+		"content":     base64.StdEncoding.EncodeToString(content),
+	}
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		fmt.Printf("Failed to marshal request: %v\n", err)
+		os.Exit(1)
+	}
+
+	url := fmt.Sprintf("%s/secrets/files/upload/%s/%s", getBackendURL(), ownerLogin, repoName)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+	req.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response UploadFileResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ File uploaded successfully!\n")
+	fmt.Printf("   File: %s\n", fileName)
+	fmt.Printf("   Version: %d\n", response.Version)
+	fmt.Printf("   Tag: %s\n", tag)
+	fmt.Printf("   Size: %d bytes\n", response.Size)
+	fmt.Printf("   Checksum: %s\n", response.Checksum)
+}
+
+// PullFile downloads a stored file, by default the latest version across tags
+func PullFile(ownerLogin string, repoName string, fileName string, version int, tag string, outputPath string) {
+	jwt := retrieveJwt()
+
+	params := []string{"fileName=" + neturl.QueryEscape(fileName)}
+	if version > 0 {
+		params = append(params, fmt.Sprintf("version=%d", version))
+	}
+	if tag != "" {
+		params = append(params, "tag="+neturl.QueryEscape(tag))
+	}
+
+	url := fmt.Sprintf("%s/secrets/files/download/%s/%s?%s", getBackendURL(), ownerLogin, repoName, strings.Join(params, "&"))
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if resp.StatusCode != 200 {
+		var response ErrorResponse
+		if err := json.Unmarshal(content, &response); err == nil && response.Error != "" {
+			fmt.Printf("Error: %s", response.Error)
+			if response.ErrorDescription != "" {
+				fmt.Printf(" - %s", response.ErrorDescription)
+			}
+			fmt.Println()
+		} else {
+			fmt.Printf("Error: HTTP %d - %s\n", resp.StatusCode, string(content))
+		}
+		os.Exit(1)
+	}
+
+	if outputPath == "" {
+		outputPath = filepath.FromSlash(fileName)
+	}
+
+	if dir := filepath.Dir(outputPath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			fmt.Printf("Failed to create directory %s: %v\n", dir, err)
+			os.Exit(1)
+		}
+	}
+
+	// Files are usually keys and certificates, keep them private to the user
+	if err := os.WriteFile(outputPath, content, 0600); err != nil {
+		fmt.Printf("Failed to write file %s: %v\n", outputPath, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ File downloaded successfully!\n")
+	fmt.Printf("   Version: %s\n", resp.Header.Get("X-Secret-Version"))
+	fmt.Printf("   Tag: %s\n", resp.Header.Get("X-Secret-Tag"))
+	fmt.Printf("   Content-Type: %s\n", resp.Header.Get("Content-Type"))
+	fmt.Printf("   Saved to: %s\n", outputPath)
+}
+
+type FileVersion struct {
+	FileName    string `json:"fileName"`
+	Tag         string `json:"tag"`
+	Version     int    `json:"version"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum"`
+	UploadedBy  string `json:"uploadedBy"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+type ListFileVersionsResponse struct {
+	Versions         []FileVersion `json:"versions,omitempty"`
+	Error            string        `json:"error,omitempty"`
+	ErrorDescription string        `json:"errorDescription,omitempty"`
+}
+
+type DeleteFileResponse struct {
+	Success          bool   `json:"success,omitempty"`
+	DeletedVersions  int    `json:"deletedVersions,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"errorDescription,omitempty"`
+}
+
+// ListFiles lists the stored versions of a repository's files, or of one file when fileName is set
+func ListFiles(ownerLogin string, repoName string, fileName string) {
+	jwt := retrieveJwt()
+
+	url := fmt.Sprintf("%s/secrets/files/versions/%s/%s", getBackendURL(), ownerLogin, repoName)
+	if fileName != "" {
+		url += "?fileName=" + neturl.QueryEscape(fileName)
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response ListFileVersionsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	if len(response.Versions) == 0 {
+		fmt.Println("No files stored")
+		return
+	}
+
+	fmt.Printf("Files of %s/%s:\n", ownerLogin, repoName)
+	currentFile := ""
+	for _, version := range response.Versions {
+		if version.FileName != currentFile {
+			currentFile = version.FileName
+			fmt.Printf("📄 %s\n", currentFile)
+		}
+		fmt.Printf("   %s v%d  %d bytes  %s  by %s at %s\n", version.Tag, version.Version, version.Size, version.ContentType, version.UploadedBy, version.CreatedAt)
+	}
+}
+
+// DeleteFile deletes one version of a file within a tag, every version of a tag when version is 0, or
+// every version of the file when allTags is set
+func DeleteFile(ownerLogin string, repoName string, fileName string, tag string, version int, allTags bool) {
+	jwt := retrieveJwt()
+
+	params := []string{"fileName=" + neturl.QueryEscape(fileName)}
+	if !allTags {
+		params = append(params, "tag="+neturl.QueryEscape(tag))
+		if version > 0 {
+			params = append(params, fmt.Sprintf("version=%d", version))
+		}
+	}
+
+	url := fmt.Sprintf("%s/secrets/files/delete/%s/%s?%s", getBackendURL(), ownerLogin, repoName, strings.Join(params, "&"))
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response DeleteFileResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Deleted %d version(s) of %s\n", response.DeletedVersions, fileName)
+}
@@ -16,12 +16,86 @@ Commands:
   download <owner> <repo> [output] [--version=latest] [--tag=tag] Download with explicit repo
   delete [--version=latest] [--tag=tag]            Delete version (auto-detects repo)
   delete <owner> <repo> [--version=latest] [--tag=tag] Delete with explicit repo
-  versions                                         List all versions (auto-detects repo)
-  versions <owner> <repo>                          List versions with explicit repo
+  versions [--file=name] [--group]                 List all versions (auto-detects repo)
+  versions <owner> <repo> [--file=name] [--group]  List versions with explicit repo
+  inherit [<owner> <repo>] <tag> <parent-tag> [--parent-version=N]
+                                                   Make a tag inherit the keys it does not set from a parent tag
+  inherit [<owner> <repo>] <tag> --none            Remove a tag's parent
+  refs [<owner> <repo>] [--key=KEY] [--tag=tag]    List repositories whose secrets reference this repository
+  file push [<owner> <repo>] <file> [--tag=development] [--name=path] [--content-type=type]
+                                                   Upload an arbitrary file (certificate, keyfile, keystore)
+  file pull [<owner> <repo>] <name> [output] [--version=latest] [--tag=tag]
+                                                   Download a stored file
+  file list [<owner> <repo>] [--name=path]         List stored files with their tags and versions
+  file delete [<owner> <repo>] <name> [--tag=development] [--version=n] [--all-tags]
+                                                   Delete a file version, every version of a tag, or the whole file
+  schema set [<owner> <repo>] <schema.json> [--tag=tag]
+                                                   Validate uploads to a tag (or every tag without --tag) against a schema
+  schema get|remove [<owner> <repo>] [--tag=tag]   Show or remove the schema of a tag
+  schema validate [<owner> <repo>] <file> [--tag=tag] [--schema=schema.json]
+                                                   Check a local file against a schema without uploading it
+  generate [<owner> <repo>] KEY=type[:options]... [--tag=development] [--overwrite]
+                                                   Generate values server-side into a new version (random, hex, base64, uuid, ed25519, rsa, bcrypt)
+  expiring [--days=30]                             List keys across your repositories that expire or are due for rotation
+  audit [<owner> <repo>] [--all] [--user=login] [--operation=op] [--success=true|false] [--since=date] [--until=date]
+                                                   List audit events, newest first (--json, --limit=N, --cursor=next)
+  audit verify [--checkpoint]                      Verify the audit log hash chain (audit admins only)
+  lint [<owner> <repo>]                            List the secret hygiene rules and whether they warn, block or are off
+  lint [<owner> <repo>] <rule> <warn|block|off>    Change how a hygiene rule treats uploads
+  shared push <org> <name> <file> [--format=dotenv] Upload a new version of an organization shared secret set (org admins)
+  shared list <org>                                List an organization's shared secret sets and where they are attached
+  shared attach <org> <name> [<owner> <repo>] [--version=N]
+                                                   Merge a shared secret set into a repository's downloads (org admins)
+  shared detach <org> <name> [<owner> <repo>]      Remove a shared secret set from a repository
+  engine set [<owner> <repo>] <name> <config.json> [--tag=tag] [--ttl=1h] [--max-ttl=24h]
+                                                   Issue short-lived database credentials on every download (repo admins)
+  engine list [<owner> <repo>]                     List secret engines, the keys they set and their active leases
+  engine remove [<owner> <repo>] <name>            Revoke an engine's active leases and remove it (repo admins)
+  watch [owner/repo...] [--tag=a,b] [--resume=token] [--json]
+                                                   Stream new and deleted versions as they happen, resuming after disconnects
+  webhook add [<owner> <repo>|--org=org] <url> [--events=a,b] [--secret=value]
+                                                   Send signed HTTP notifications of secret changes to a URL
+  webhook list [<owner> <repo>|--org=org]          List the webhooks of a repository or organization
+  webhook update <id> [--url=url] [--events=a,b] [--active=true|false] [--rotate-secret]
+                                                   Change a webhook or rotate its signing secret
+  webhook remove <id>                              Remove a webhook and its delivery history
+  webhook deliveries <id> [--limit=20]             List recent deliveries with their status and attempts
+  webhook redeliver <delivery-id>                  Send a previous delivery's payload again
+  changes protect [<owner> <repo>] <tag> [--review-window=72h]
+                                                   Admins: make uploads to a tag wait for a second person's approval
+  changes unprotect [<owner> <repo>] <tag>         Admins: allow direct uploads to a tag again
+  changes list [<owner> <repo>] [--status=all]     List change requests with their key diff, and the protected tags
+  changes approve <id> [--comment=text]            Approve another collaborator's change request, creating the version
+  changes reject <id> [--comment=text]             Reject a change request, or withdraw your own
+  changes comment <id> <text>                      Discuss a pending change request with its author and reviewers
+  share create [<owner> <repo>] [--key=NAME] [--branch[=name]] [--views=1] [--expires-in=24h]
+                                                   Create a one-time link to a key or version for someone outside the repository
+  share file [<owner> <repo>] <name> [--views=1] [--expires-in=24h]
+                                                   Create a one-time link to a file secret
+  share open <token> [output-file]                 Open a share link; no login needed
+  keys [<owner> <repo>] [--tag=tag] [--branch[=name]] [--compare=other-tag]
+                                                   List keys with masked previews and fingerprints, without values
+  search <pattern> [--mode=substring] [--limit=100]
+                                                   Find keys by name across every repository you can access
 
 Options:
   --tag=value        Specify tag for upload/download/delete (default: development for latest operations)
   --version=value    Specify version number or 'latest' (default: latest)
+  --file=value       Secret file name within the repository, e.g. .env.worker (default: .env)
+  --group            Group versions output by secret file name
+  --branch[=value]   Branch overlay: bare --branch uses the current git branch, --branch= disables it
+  --origins          Print which tag, version and branch each downloaded key came from
+  --interpolate      Expand ${VAR}, ${VAR:-default} and ${VAR:?error} between keys on download
+  --lease-ttl=value  Lifetime of dynamic credentials issued on download, e.g. 15m (default: the engine's TTL)
+  --path=value       Monorepo path the secrets belong to, e.g. services/api (default: current directory in git, '.' for root)
+  --format=value     Upload format: dotenv, json, yaml, properties, compose (default: from file extension)
+                     Download format: dotenv, json, yaml, export, docker, compose, systemd, k8s-secret, k8s-configmap (default: dotenv)
+  --separator=value  Separator used to flatten nested json/yaml keys on upload (default: _)
+  --metadata=value   JSON file with per-key expiresAt, owner and rotationInterval stored with an upload
+  --message=value    Change message recorded with an upload or generated version
+  --name=value       Kubernetes manifest name for k8s-* download formats (default: <repo>-<tag>)
+  --namespace=value  Kubernetes manifest namespace for k8s-* download formats
+  --content-type=value  MIME type for file push (default: from file extension, then sniffed server-side)
 
 Notes:
   • Auto-detection uses the current git repository's remote origin URL
@@ -31,6 +105,12 @@ Notes:
   • You can combine --version and --tag for precise targeting
   • Upload always creates new versions with specified tag
   • Different tags maintain separate version sequences
+  • Each secret file (--file) has its own tags and version sequences
+  • Downloads return a tag merged with its parent tags; the tag's own keys win
+  • Values may reference other repositories as ${envini:owner/repo@tag#KEY}, resolved on download
+  • Downloads in a git checkout apply the current branch's overlay on top of the tag's values, if one exists
+  • Run from a monorepo subdirectory, commands are scoped to that path; each path has its own secret files
+  • Upload format is detected from the extension (.json, .yaml/.yml, .properties, otherwise dotenv)
 
 Examples:
   # Authentication and listing
@@ -41,14 +121,60 @@ Examples:
   # Auto-detect repository from git
   envini upload .env                              # Upload to development tag
   envini upload .env --tag=production             # Upload to production tag
+  envini upload .env --message="Rotate Stripe key" # Record why the version was created
+  envini upload config.json                       # Upload nested JSON, {"db":{"host":..}} as db_host
+  envini upload app.properties --tag=staging      # Upload Java properties file
+  envini upload docker.env --format=compose       # Upload docker-compose env_file syntax
   envini download .env.downloaded                 # Download latest from development tag
   envini download .env.downloaded --tag=production # Download latest from production tag
   envini download .env.downloaded --version=1     # Download specific version
   envini download .env.downloaded --version=2 --tag=production # Download version 2 from production tag
+  envini download env.sh --format=export          # Download as a POSIX export script
+  envini download secret.yaml --tag=production --format=k8s-secret --namespace=apps # Kubernetes Secret manifest
   envini delete                                   # Delete latest from development tag
   envini delete --tag=production                  # Delete latest from production tag
   envini delete --version=1                       # Delete specific version
   envini versions                                 # List all versions
+  envini upload .env.worker --file=.env.worker    # Manage .env.worker separately from .env
+  envini download .env.worker --file=.env.worker --tag=production
+  envini versions --group                         # List versions grouped by secret file
+  envini upload .env --path=services/api          # Upload secrets for the services/api package
+  envini versions --path=.                        # Only versions stored at the repository root
+  envini inherit production base                  # production inherits the keys it does not set from base
+  envini inherit production base --parent-version=3 # Pin the parent to base v3
+  envini download .env --tag=production --origins # Show where each key was resolved from
+  envini download .env --interpolate              # DATABASE_URL=postgres://${DB_USER}@${DB_HOST} is expanded
+  envini refs --key=SENTRY_DSN                    # Which repositories embed this repository's SENTRY_DSN
+  envini upload .env.override --branch            # Override a few values on the current branch only
+  envini schema set schema.json --tag=production  # Reject production uploads that break the schema
+  envini schema validate .env --schema=schema.json # Validate offline before uploading
+  envini generate DB_PASSWORD=random:32 JWT_KEY=ed25519 --tag=production  # Values never touch the terminal
+  envini upload .env --metadata=keys.json          # Record when vendor tokens expire and who rotates them
+  envini expiring --days=14                       # Keys that expire or need rotation in the next two weeks
+  envini audit --operation=DOWNLOAD --since=2026-10-01 # Who downloaded this repository's secrets this month
+  envini audit --all --success=false --json       # Failed operations across every repository you can access
+  envini audit verify --checkpoint                # Check the audit log was not tampered with and sign its head
+  envini lint placeholder block                   # Reject uploads that still contain changeme or xxx
+  envini shared push acme smtp smtp.env           # Org admins: store the SMTP relay credentials once
+  envini shared attach acme smtp                  # Merge them into the current repository's downloads
+  envini engine set db postgres.json --tag=staging # Every staging download gets its own Postgres role
+  envini download .env --tag=staging --lease-ttl=15m # The role is dropped 15 minutes later
+  envini watch --tag=production                   # Print every new production version as it is uploaded
+  envini watch acme/api acme/web --json           # One JSON line per change, for agents and scripts
+  envini webhook add https://ci.example.com/hooks/envini --events=secret.uploaded # Trigger a redeploy on upload
+  envini webhook add --org=acme https://chat.example.com/envini # Notify on changes in every acme repository
+  envini webhook deliveries 3                     # Why did the last notifications fail?
+  envini changes protect production               # Production uploads now need a reviewer
+  envini changes approve 12 --comment="rotated per INC-42" # Apply a colleague's pending production change
+  envini share create --tag=staging --key=STRIPE_KEY --expires-in=2h # Hand a contractor one value, readable once
+  envini share open 388e7c...KpLBq .env           # What the contractor runs, without an Envini account
+  envini keys --tag=staging --compare=production  # Which staging values differ from production?
+  envini search STRIPE_SECRET_KEY                 # Which repositories hold a Stripe secret key?
+  envini search '^AWS_.*_ID$' --mode=regex
+  envini file push certs/tls.crt --tag=production # Store a TLS certificate as a file secret
+  envini file push sa.json --name=gcp/service-account.json
+  envini file pull certs/tls.crt --tag=production # Restore it to certs/tls.crt
+  envini file delete certs/tls.crt --all-tags     # Remove every stored version of the certificate
   
   # Explicit repository specification
   envini upload kurs0n 8080-emulator .env
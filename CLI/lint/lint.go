@@ -0,0 +1,174 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+func getBackendURL() string {
+	if url := os.Getenv("BACKEND_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3000" // default fallback
+}
+
+type StoredAuthData struct {
+	Jwt string `json:"jwt"`
+}
+
+// Finding mirrors the lint findings returned by uploads
+type Finding struct {
+	Key      string `json:"key"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+	Blocking bool   `json:"blocking"`
+}
+
+type Rule struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Action      string `json:"action"`
+}
+
+type ListLintRulesResponse struct {
+	Rules            []Rule `json:"rules,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"errorDescription,omitempty"`
+}
+
+type SetLintRuleResponse struct {
+	Success          bool   `json:"success,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"errorDescription,omitempty"`
+}
+
+func retrieveJwt() string {
+	bytes, err := os.ReadFile("./temp/auth.json")
+	if err != nil {
+		fmt.Println("No auth file found. Please authenticate first using the auth command.")
+		os.Exit(1)
+	}
+
+	var authData StoredAuthData
+	if err := json.Unmarshal(bytes, &authData); err != nil {
+		fmt.Println("Error parsing auth file:", err)
+		os.Exit(1)
+	}
+
+	return authData.Jwt
+}
+
+// ListRules prints every lint rule with the action the repository applies
+func ListRules(ownerLogin string, repoName string) {
+	jwt := retrieveJwt()
+
+	url := fmt.Sprintf("%s/secrets/lint/%s/%s", getBackendURL(), ownerLogin, repoName)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response ListLintRulesResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	fmt.Printf("Lint rules of %s/%s:\n", ownerLogin, repoName)
+	for _, rule := range response.Rules {
+		fmt.Printf("   %-22s %-5s  %s\n", rule.Name, rule.Action, rule.Description)
+	}
+}
+
+// SetRule changes whether a rule warns, blocks uploads or is turned off for a repository
+func SetRule(ownerLogin string, repoName string, rule string, action string) {
+	jwt := retrieveJwt()
+
+	requestBody, err := json.Marshal(map[string]string{"rule": rule, "action": action})
+	if err != nil {
+		fmt.Printf("Failed to marshal request: %v\n", err)
+		os.Exit(1)
+	}
+
+	url := fmt.Sprintf("%s/secrets/lint/%s/%s", getBackendURL(), ownerLogin, repoName)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+	req.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response SetLintRuleResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Lint rule %s set to %s for %s/%s\n", rule, action, ownerLogin, repoName)
+}
+
+// PrintFindings lists lint findings, blocking and warnings alike
+func PrintFindings(findings []Finding) {
+	for _, finding := range findings {
+		marker := "⚠️ "
+		if finding.Blocking {
+			marker = "❌"
+		}
+		fmt.Printf("   %s %s (%s): %s\n", marker, finding.Key, finding.Rule, finding.Message)
+	}
+}
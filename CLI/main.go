@@ -1,9 +1,13 @@
 package main
 
 import (
+	"Envini-CLI/audit"
 	"Envini-CLI/auth"
+	"Envini-CLI/files"
 	"Envini-CLI/help"
+	"Envini-CLI/lint"
 	"Envini-CLI/list"
+	"Envini-CLI/schema"
 	"Envini-CLI/secrets"
 	"Envini-CLI/upload"
 	"fmt"
@@ -11,10 +15,17 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// clientType identifies this client in version annotations
+const clientType = "envini-cli"
+
+// cliVersion is set at build time with -ldflags "-X main.cliVersion=..."
+var cliVersion = "dev"
+
 func parseFlags(args []string) map[string]string {
 	flags := make(map[string]string)
 
@@ -89,6 +100,129 @@ func getGitRepoInfo() (string, string, error) {
 	return owner, repo, nil
 }
 
+// getGitPathScope returns the current directory relative to the git repository root
+func getGitPathScope() string {
+	output, err := exec.Command("git", "rev-parse", "--show-prefix").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimSpace(string(output)), "/")
+}
+
+// pathScope resolves the monorepo path of a command; --path overrides the directory detected from git
+func pathScope(flags map[string]string, detect bool) string {
+	if scopePath, ok := flags["path"]; ok {
+		return scopePath
+	}
+	if detect {
+		return getGitPathScope()
+	}
+	return ""
+}
+
+// getGitBranch returns the checked out branch, or "" for a detached HEAD
+func getGitBranch() string {
+	output, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	branch := strings.TrimSpace(string(output))
+	if branch == "HEAD" {
+		return ""
+	}
+	return branch
+}
+
+// branchScope resolves the branch overlay of a command: --branch=name selects a branch, a bare --branch
+// the current git branch and --branch= none. With detect the current branch is used when the flag is absent.
+func branchScope(flags map[string]string, detect bool) string {
+	branch, ok := flags["branch"]
+	if (!ok && detect) || branch == "true" {
+		return getGitBranch()
+	}
+	return branch
+}
+
+// getGitCommit returns the SHA of the checked out commit
+func getGitCommit() string {
+	output, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// versionAnnotation describes a new version: the --message flag, this client, and the commit and branch
+// checked out when the current directory is a checkout of ownerLogin/repoName
+func versionAnnotation(flags map[string]string, ownerLogin, repoName string) secrets.Annotation {
+	annotation := secrets.Annotation{
+		ClientType:    clientType,
+		ClientVersion: cliVersion,
+	}
+	if message := flags["message"]; message != "true" {
+		annotation.Message = message
+	}
+	if owner, repo, err := getGitRepoInfo(); err == nil && strings.EqualFold(owner, ownerLogin) && strings.EqualFold(repo, repoName) {
+		annotation.CommitSHA = getGitCommit()
+		annotation.GitBranch = getGitBranch()
+	}
+	return annotation
+}
+
+func uploadOptions(flags map[string]string, scopePath, ownerLogin, repoName string) secrets.UploadOptions {
+	return secrets.UploadOptions{
+		Format:       flags["format"],
+		KeySeparator: flags["separator"],
+		FileName:     flags["file"],
+		Path:         scopePath,
+		Branch:       branchScope(flags, false),
+		MetadataFile: flags["metadata"],
+		Annotation:   versionAnnotation(flags, ownerLogin, repoName),
+	}
+}
+
+// durationFlag reads a duration flag such as --lease-ttl=15m; a plain number is seconds
+func durationFlag(flags map[string]string, name string) time.Duration {
+	value := flags[name]
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		fmt.Printf("Invalid --%s: %s (expected e.g. 15m, 1h or seconds)\n", name, value)
+		os.Exit(1)
+	}
+	return duration
+}
+
+// splitList splits a comma separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func downloadOptions(flags map[string]string, scopePath string, detect bool) secrets.DownloadOptions {
+	return secrets.DownloadOptions{
+		Path:         scopePath,
+		Branch:       branchScope(flags, detect),
+		FileName:     flags["file"],
+		Format:       flags["format"],
+		ResourceName: flags["name"],
+		Namespace:    flags["namespace"],
+		Origins:      flags["origins"] == "true",
+		Interpolate:  flags["interpolate"] == "true",
+		LeaseTTL:     durationFlag(flags, "lease-ttl"),
+	}
+}
+
 func main() {
 	godotenv.Load()
 	if len(os.Args) < 2 {
@@ -114,6 +248,141 @@ func main() {
 			os.Exit(1)
 		}
 		list.ListReposWithVersions()
+	case "expiring":
+		flags := parseFlags(os.Args[2:])
+
+		days := 0 // Server default
+		if daysStr := flags["days"]; daysStr != "" {
+			var err error
+			days, err = strconv.Atoi(daysStr)
+			if err != nil || days < 0 {
+				fmt.Printf("Invalid number of days: %s\n", daysStr)
+				return
+			}
+		}
+
+		if auth.IfRefreshIsRequired() {
+			fmt.Println("Session expired. Please run `auth` again.")
+			os.Exit(1)
+		}
+
+		secrets.ListExpiringSecrets(days)
+	case "audit":
+		flags := parseFlags(os.Args[2:])
+		nonFlagArgs := getNonFlagArgs(os.Args[2:])
+
+		if len(nonFlagArgs) == 1 && nonFlagArgs[0] == "verify" {
+			if auth.IfRefreshIsRequired() {
+				fmt.Println("Session expired. Please run `auth` again.")
+				os.Exit(1)
+			}
+			audit.VerifyChain(flags["checkpoint"] == "true")
+			return
+		}
+
+		var ownerLogin, repoName string
+		switch {
+		case len(nonFlagArgs) == 2:
+			ownerLogin, repoName = nonFlagArgs[0], nonFlagArgs[1]
+		case len(nonFlagArgs) == 0:
+			// Every accessible repository with --all or outside a git checkout
+			if flags["all"] != "true" {
+				if owner, repo, err := getGitRepoInfo(); err == nil {
+					ownerLogin, repoName = owner, repo
+					if flags["json"] != "true" {
+						fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+					}
+				}
+			}
+		default:
+			fmt.Println("Usage: envini audit [<owner> <repo>] [--all] [--user=login] [--operation=DOWNLOAD] [--success=true|false]")
+			fmt.Println("                    [--since=2026-01-01] [--until=2026-02-01] [--limit=50] [--cursor=next] [--json]")
+			fmt.Println("       envini audit verify [--checkpoint]")
+			return
+		}
+
+		filter := audit.Filter{
+			User:      flags["user"],
+			Operation: flags["operation"],
+			Success:   flags["success"],
+			Since:     flags["since"],
+			Until:     flags["until"],
+			Cursor:    flags["cursor"],
+		}
+		if filter.Success != "" && filter.Success != "true" && filter.Success != "false" {
+			fmt.Printf("Invalid --success: %s (expected true or false)\n", filter.Success)
+			return
+		}
+		if limitStr := flags["limit"]; limitStr != "" {
+			limit, err := strconv.Atoi(limitStr)
+			if err != nil || limit <= 0 {
+				fmt.Printf("Invalid limit: %s\n", limitStr)
+				return
+			}
+			filter.Limit = limit
+		}
+
+		if auth.IfRefreshIsRequired() {
+			fmt.Println("Session expired. Please run `auth` again.")
+			os.Exit(1)
+		}
+
+		audit.ListEvents(ownerLogin, repoName, filter, flags["json"] == "true")
+	case "generate":
+		flags := parseFlags(os.Args[2:])
+		nonFlagArgs := getNonFlagArgs(os.Args[2:])
+
+		var ownerLogin, repoName string
+		specArgs := nonFlagArgs
+		detect := !(len(nonFlagArgs) >= 2 && !strings.Contains(nonFlagArgs[0], "=") && !strings.Contains(nonFlagArgs[1], "="))
+		if !detect {
+			// Explicit repository format: generate <owner> <repo> KEY=type...
+			ownerLogin, repoName, specArgs = nonFlagArgs[0], nonFlagArgs[1], nonFlagArgs[2:]
+		}
+
+		if len(specArgs) == 0 {
+			fmt.Println("Usage: envini generate [<owner> <repo>] KEY=type[:options]... [--tag=development] [--overwrite]")
+			fmt.Println("Example: envini generate DB_PASSWORD=random:32 JWT_KEY=ed25519 --tag=production")
+			return
+		}
+
+		specs := make([]secrets.GenerateSpec, len(specArgs))
+		for i, arg := range specArgs {
+			spec, err := secrets.ParseGenerateSpec(arg)
+			if err != nil {
+				fmt.Printf("Invalid value spec: %v\n", err)
+				return
+			}
+			specs[i] = spec
+		}
+
+		if detect {
+			owner, repo, err := getGitRepoInfo()
+			if err != nil {
+				fmt.Printf("Could not detect git repository (%v), pass <owner> <repo> explicitly\n", err)
+				return
+			}
+			ownerLogin, repoName = owner, repo
+			fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+		}
+
+		if auth.IfRefreshIsRequired() {
+			fmt.Println("Session expired. Please run `auth` again.")
+			os.Exit(1)
+		}
+
+		tag := flags["tag"]
+		if tag == "" {
+			tag = "development" // Default tag
+		}
+
+		secrets.GenerateSecretValues(ownerLogin, repoName, tag, specs, secrets.GenerateOptions{
+			FileName:   flags["file"],
+			Path:       pathScope(flags, detect),
+			Branch:     branchScope(flags, false),
+			Overwrite:  flags["overwrite"] == "true",
+			Annotation: versionAnnotation(flags, ownerLogin, repoName),
+		})
 	case "upload":
 		if len(os.Args) < 3 {
 			fmt.Println("Please specify a file to upload.")
@@ -126,7 +395,7 @@ func main() {
 
 		// Check if this is explicit repository format (has owner and repo) or git-auto-detect
 		if len(nonFlagArgs) >= 3 {
-			// Explicit repository format: upload <owner> <repo> <file> [--tag=development]
+			// Explicit repository format: upload <owner> <repo> <file> [--tag=development] [--format=json]
 			if len(nonFlagArgs) < 3 {
 				fmt.Println("Usage: envini upload <owner> <repo> <file> [--tag=development]")
 				fmt.Println("Example: envini upload kurs0n 8080-emulator .env --tag=production")
@@ -145,7 +414,7 @@ func main() {
 				tag = "development" // Default tag
 			}
 
-			secrets.UploadSecret(ownerLogin, repoName, tag, filePath)
+			secrets.UploadSecret(ownerLogin, repoName, tag, filePath, uploadOptions(flags, pathScope(flags, false), ownerLogin, repoName))
 		} else {
 			// Git-auto-detect format: upload <file> [--tag=development]
 			if len(nonFlagArgs) < 1 {
@@ -173,11 +442,16 @@ func main() {
 				tag = "development" // Default tag
 			}
 
+			scopePath := pathScope(flags, true)
+
 			fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+			if scopePath != "" {
+				fmt.Printf("📂 Path: %s\n", scopePath)
+			}
 			fmt.Printf("📄 Uploading: %s\n", filePath)
 			fmt.Printf("🏷️  Tag: %s\n", tag)
 
-			secrets.UploadSecret(owner, repo, tag, filePath)
+			secrets.UploadSecret(owner, repo, tag, filePath, uploadOptions(flags, scopePath, owner, repo))
 		}
 	case "download":
 		flags := parseFlags(os.Args[2:])
@@ -187,7 +461,7 @@ func main() {
 			// Try to use git repository as defaults
 			owner, repo, err := getGitRepoInfo()
 			if err != nil {
-				fmt.Println("Usage: envini download <owner> <repo> [output-file] [--version=latest]")
+				fmt.Println("Usage: envini download <owner> <repo> [output-file] [--version=latest] [--format=dotenv]")
 				fmt.Println("Example: envini download kurs0n 8080-emulator .env.downloaded --version=1")
 				return
 			}
@@ -216,7 +490,12 @@ func main() {
 				}
 			}
 
+			scopePath := pathScope(flags, true)
+
 			fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+			if scopePath != "" {
+				fmt.Printf("📂 Path: %s\n", scopePath)
+			}
 			fmt.Printf("💾 Downloading to: %s\n", outputPath)
 			if version > 0 {
 				fmt.Printf("📋 Version: %d\n", version)
@@ -226,7 +505,7 @@ func main() {
 				fmt.Printf("📋 Version: latest (development tag)\n")
 			}
 
-			secrets.DownloadSecret(owner, repo, version, tag, outputPath)
+			secrets.DownloadSecret(owner, repo, version, tag, outputPath, downloadOptions(flags, scopePath, true))
 		} else {
 			// Explicit owner/repo provided
 			if auth.IfRefreshIsRequired() {
@@ -253,7 +532,7 @@ func main() {
 				}
 			}
 
-			secrets.DownloadSecret(ownerLogin, repoName, version, tag, outputPath)
+			secrets.DownloadSecret(ownerLogin, repoName, version, tag, outputPath, downloadOptions(flags, pathScope(flags, false), false))
 		}
 	case "delete":
 		flags := parseFlags(os.Args[2:])
@@ -286,7 +565,12 @@ func main() {
 				}
 			}
 
+			scopePath := pathScope(flags, true)
+
 			fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+			if scopePath != "" {
+				fmt.Printf("📂 Path: %s\n", scopePath)
+			}
 			if version > 0 {
 				fmt.Printf("🗑️  Deleting version: %d\n", version)
 			} else if tag != "" {
@@ -295,7 +579,7 @@ func main() {
 				fmt.Printf("🗑️  Deleting latest version (development tag)\n")
 			}
 
-			secrets.DeleteSecret(owner, repo, version, tag)
+			secrets.DeleteSecret(owner, repo, version, tag, flags["file"], scopePath, branchScope(flags, false))
 		} else {
 			// Explicit owner/repo provided
 			if auth.IfRefreshIsRequired() {
@@ -318,10 +602,18 @@ func main() {
 				}
 			}
 
-			secrets.DeleteSecret(ownerLogin, repoName, version, tag)
+			secrets.DeleteSecret(ownerLogin, repoName, version, tag, flags["file"], pathScope(flags, false), branchScope(flags, false))
 		}
 	case "versions":
+		flags := parseFlags(os.Args[2:])
 		nonFlagArgs := getNonFlagArgs(os.Args[2:])
+		groupByFile := flags["group"] == "true"
+
+		// Only filter by path when asked to, or when run from a monorepo subdirectory
+		var scopePath *string
+		if explicitPath, ok := flags["path"]; ok {
+			scopePath = &explicitPath
+		}
 
 		if len(nonFlagArgs) < 2 {
 			// Try to use git repository as defaults
@@ -338,8 +630,12 @@ func main() {
 				os.Exit(1)
 			}
 
+			if detectedPath := getGitPathScope(); scopePath == nil && detectedPath != "" {
+				scopePath = &detectedPath
+			}
+
 			fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
-			secrets.ListSecretVersions(owner, repo)
+			secrets.ListSecretVersions(owner, repo, flags["file"], groupByFile, scopePath)
 		} else {
 			// Explicit owner/repo provided
 			if auth.IfRefreshIsRequired() {
@@ -349,8 +645,864 @@ func main() {
 
 			ownerLogin := nonFlagArgs[0]
 			repoName := nonFlagArgs[1]
-			secrets.ListSecretVersions(ownerLogin, repoName)
+			secrets.ListSecretVersions(ownerLogin, repoName, flags["file"], groupByFile, scopePath)
+		}
+	case "inherit":
+		flags := parseFlags(os.Args[2:])
+		nonFlagArgs := getNonFlagArgs(os.Args[2:])
+		remove := flags["none"] == "true"
+
+		// inherit [<owner> <repo>] <tag> <parent-tag>, or --none instead of the parent to remove it
+		argsPerTag := 2
+		if remove {
+			argsPerTag = 1
+		}
+
+		var ownerLogin, repoName, scopePath string
+		var tagArgs []string
+		switch len(nonFlagArgs) {
+		case argsPerTag + 2:
+			ownerLogin, repoName, tagArgs = nonFlagArgs[0], nonFlagArgs[1], nonFlagArgs[2:]
+			scopePath = pathScope(flags, false)
+		case argsPerTag:
+			owner, repo, err := getGitRepoInfo()
+			if err != nil {
+				fmt.Println("Usage: envini inherit <owner> <repo> <tag> <parent-tag> [--parent-version=N]")
+				return
+			}
+			ownerLogin, repoName, tagArgs = owner, repo, nonFlagArgs
+			scopePath = pathScope(flags, true)
+			fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+		default:
+			fmt.Println("Usage: envini inherit [<owner> <repo>] <tag> <parent-tag> [--parent-version=N]")
+			fmt.Println("       envini inherit [<owner> <repo>] <tag> --none")
+			return
+		}
+
+		if auth.IfRefreshIsRequired() {
+			fmt.Println("Session expired. Please run `auth` again.")
+			os.Exit(1)
+		}
+
+		parentTag := ""
+		if !remove {
+			parentTag = tagArgs[1]
+		}
+
+		parentVersion := 0 // Follow the parent's latest version
+		if versionStr := flags["parent-version"]; versionStr != "" && versionStr != "latest" {
+			var err error
+			parentVersion, err = strconv.Atoi(versionStr)
+			if err != nil {
+				fmt.Printf("Invalid parent version: %s\n", versionStr)
+				return
+			}
+		}
+
+		secrets.SetTagParent(ownerLogin, repoName, tagArgs[0], parentTag, parentVersion, flags["file"], scopePath)
+	case "refs":
+		flags := parseFlags(os.Args[2:])
+		nonFlagArgs := getNonFlagArgs(os.Args[2:])
+
+		var ownerLogin, repoName string
+		if len(nonFlagArgs) >= 2 {
+			ownerLogin, repoName = nonFlagArgs[0], nonFlagArgs[1]
+		} else {
+			owner, repo, err := getGitRepoInfo()
+			if err != nil {
+				fmt.Println("Usage: envini refs <owner> <repo> [--key=KEY] [--tag=tag]")
+				fmt.Println("Example: envini refs acme shared-secrets --key=SENTRY_DSN")
+				return
+			}
+			ownerLogin, repoName = owner, repo
+			fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+		}
+
+		if auth.IfRefreshIsRequired() {
+			fmt.Println("Session expired. Please run `auth` again.")
+			os.Exit(1)
+		}
+
+		secrets.ListSecretReferences(ownerLogin, repoName, flags["key"], flags["tag"])
+	case "file":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: envini file <push|pull|list|delete> ...")
+			return
+		}
+
+		flags := parseFlags(os.Args[3:])
+		nonFlagArgs := getNonFlagArgs(os.Args[3:])
+
+		switch os.Args[2] {
+		case "push":
+			var ownerLogin, repoName, filePath string
+			if len(nonFlagArgs) >= 3 {
+				// Explicit repository format: file push <owner> <repo> <file>
+				ownerLogin, repoName, filePath = nonFlagArgs[0], nonFlagArgs[1], nonFlagArgs[2]
+			} else if len(nonFlagArgs) == 1 {
+				owner, repo, err := getGitRepoInfo()
+				if err != nil {
+					fmt.Println("Usage: envini file push <owner> <repo> <file> [--tag=development] [--name=path] [--content-type=type]")
+					return
+				}
+				ownerLogin, repoName, filePath = owner, repo, nonFlagArgs[0]
+				fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+			} else {
+				fmt.Println("Usage: envini file push [<owner> <repo>] <file> [--tag=development] [--name=path] [--content-type=type]")
+				return
+			}
+
+			if auth.IfRefreshIsRequired() {
+				fmt.Println("Session expired. Please run `auth` again.")
+				os.Exit(1)
+			}
+
+			tag := flags["tag"]
+			if tag == "" {
+				tag = "development" // Default tag
+			}
+
+			files.PushFile(ownerLogin, repoName, tag, filePath, flags["name"], flags["content-type"])
+		case "pull":
+			var ownerLogin, repoName, fileName, outputPath string
+			if len(nonFlagArgs) >= 3 {
+				// Explicit repository format: file pull <owner> <repo> <name> [output]
+				ownerLogin, repoName, fileName = nonFlagArgs[0], nonFlagArgs[1], nonFlagArgs[2]
+				if len(nonFlagArgs) > 3 {
+					outputPath = nonFlagArgs[3]
+				}
+			} else if len(nonFlagArgs) >= 1 {
+				owner, repo, err := getGitRepoInfo()
+				if err != nil {
+					fmt.Println("Usage: envini file pull <owner> <repo> <name> [output] [--version=latest] [--tag=tag]")
+					return
+				}
+				ownerLogin, repoName, fileName = owner, repo, nonFlagArgs[0]
+				if len(nonFlagArgs) > 1 {
+					outputPath = nonFlagArgs[1]
+				}
+				fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+			} else {
+				fmt.Println("Usage: envini file pull [<owner> <repo>] <name> [output] [--version=latest] [--tag=tag]")
+				return
+			}
+
+			if auth.IfRefreshIsRequired() {
+				fmt.Println("Session expired. Please run `auth` again.")
+				os.Exit(1)
+			}
+
+			versionStr := flags["version"]
+			version := 0 // Default to latest version
+			if versionStr != "" && versionStr != "latest" {
+				var err error
+				version, err = strconv.Atoi(versionStr)
+				if err != nil {
+					fmt.Printf("Invalid version: %s\n", versionStr)
+					return
+				}
+			}
+
+			files.PullFile(ownerLogin, repoName, fileName, version, flags["tag"], outputPath)
+		case "list":
+			var ownerLogin, repoName string
+			switch len(nonFlagArgs) {
+			case 2:
+				ownerLogin, repoName = nonFlagArgs[0], nonFlagArgs[1]
+			case 0:
+				owner, repo, err := getGitRepoInfo()
+				if err != nil {
+					fmt.Println("Usage: envini file list <owner> <repo> [--name=path]")
+					return
+				}
+				ownerLogin, repoName = owner, repo
+				fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+			default:
+				fmt.Println("Usage: envini file list [<owner> <repo>] [--name=path]")
+				return
+			}
+
+			if auth.IfRefreshIsRequired() {
+				fmt.Println("Session expired. Please run `auth` again.")
+				os.Exit(1)
+			}
+
+			files.ListFiles(ownerLogin, repoName, flags["name"])
+		case "delete":
+			var ownerLogin, repoName, fileName string
+			switch len(nonFlagArgs) {
+			case 3:
+				ownerLogin, repoName, fileName = nonFlagArgs[0], nonFlagArgs[1], nonFlagArgs[2]
+			case 1:
+				owner, repo, err := getGitRepoInfo()
+				if err != nil {
+					fmt.Println("Usage: envini file delete <owner> <repo> <name> [--tag=development] [--version=n] [--all-tags]")
+					return
+				}
+				ownerLogin, repoName, fileName = owner, repo, nonFlagArgs[0]
+				fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+			default:
+				fmt.Println("Usage: envini file delete [<owner> <repo>] <name> [--tag=development] [--version=n] [--all-tags]")
+				return
+			}
+
+			allTags := flags["all-tags"] == "true"
+			tag := flags["tag"]
+			if tag == "" {
+				tag = "development" // Default tag, like delete
+			}
+			version := 0 // Every version of the tag
+			if versionStr := flags["version"]; versionStr != "" {
+				var err error
+				version, err = strconv.Atoi(versionStr)
+				if err != nil || version <= 0 {
+					fmt.Printf("Invalid version: %s\n", versionStr)
+					return
+				}
+			}
+			if allTags && (flags["tag"] != "" || version > 0) {
+				fmt.Println("--all-tags cannot be combined with --tag or --version")
+				return
+			}
+
+			if auth.IfRefreshIsRequired() {
+				fmt.Println("Session expired. Please run `auth` again.")
+				os.Exit(1)
+			}
+
+			files.DeleteFile(ownerLogin, repoName, fileName, tag, version, allTags)
+		default:
+			fmt.Println("Usage: envini file <push|pull|list|delete> ...")
+		}
+	case "schema":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: envini schema <set|get|remove|validate> ...")
+			return
+		}
+
+		flags := parseFlags(os.Args[3:])
+		nonFlagArgs := getNonFlagArgs(os.Args[3:])
+
+		// Number of arguments each subcommand takes besides the optional <owner> <repo>
+		fileArgs := map[string]int{"set": 1, "get": 0, "remove": 0, "validate": 1}
+		argCount, ok := fileArgs[os.Args[2]]
+		if !ok {
+			fmt.Println("Usage: envini schema <set|get|remove|validate> ...")
+			return
 		}
+
+		var ownerLogin, repoName string
+		var args []string
+		switch {
+		case len(nonFlagArgs) == argCount+2:
+			ownerLogin, repoName, args = nonFlagArgs[0], nonFlagArgs[1], nonFlagArgs[2:]
+		case len(nonFlagArgs) == argCount:
+			args = nonFlagArgs
+			// A local schema makes validation fully offline
+			if os.Args[2] != "validate" || flags["schema"] == "" {
+				owner, repo, err := getGitRepoInfo()
+				if err != nil {
+					fmt.Printf("Could not detect git repository (%v), pass <owner> <repo> explicitly\n", err)
+					return
+				}
+				ownerLogin, repoName = owner, repo
+				fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+			}
+		default:
+			fmt.Println("Usage: envini schema set [<owner> <repo>] <schema.json> [--tag=tag]")
+			fmt.Println("       envini schema get [<owner> <repo>] [--tag=tag]")
+			fmt.Println("       envini schema remove [<owner> <repo>] [--tag=tag]")
+			fmt.Println("       envini schema validate [<owner> <repo>] <file> [--tag=tag] [--schema=schema.json]")
+			return
+		}
+
+		if ownerLogin != "" && auth.IfRefreshIsRequired() {
+			fmt.Println("Session expired. Please run `auth` again.")
+			os.Exit(1)
+		}
+
+		switch os.Args[2] {
+		case "set":
+			schema.SetSchema(ownerLogin, repoName, flags["tag"], args[0])
+		case "get":
+			schema.GetSchema(ownerLogin, repoName, flags["tag"])
+		case "remove":
+			schema.SetSchema(ownerLogin, repoName, flags["tag"], "")
+		case "validate":
+			schema.ValidateFile(ownerLogin, repoName, flags["tag"], args[0], flags["format"], flags["schema"])
+		}
+	case "lint":
+		nonFlagArgs := getNonFlagArgs(os.Args[2:])
+
+		// Two arguments are <rule> <action> when the second one is an action, otherwise <owner> <repo>
+		setsRule := len(nonFlagArgs) == 4
+		if len(nonFlagArgs) == 2 {
+			switch nonFlagArgs[1] {
+			case "warn", "block", "off":
+				setsRule = true
+			}
+		}
+
+		var ownerLogin, repoName string
+		args := nonFlagArgs
+		switch {
+		case len(nonFlagArgs) == 4 || (len(nonFlagArgs) == 2 && !setsRule):
+			// Explicit repository format: lint <owner> <repo> [<rule> <action>]
+			ownerLogin, repoName, args = nonFlagArgs[0], nonFlagArgs[1], nonFlagArgs[2:]
+		case len(nonFlagArgs) == 0 || len(nonFlagArgs) == 2:
+			owner, repo, err := getGitRepoInfo()
+			if err != nil {
+				fmt.Printf("Could not detect git repository (%v), pass <owner> <repo> explicitly\n", err)
+				return
+			}
+			ownerLogin, repoName = owner, repo
+			fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+		default:
+			fmt.Println("Usage: envini lint [<owner> <repo>]")
+			fmt.Println("       envini lint [<owner> <repo>] <rule> <warn|block|off>")
+			return
+		}
+
+		if auth.IfRefreshIsRequired() {
+			fmt.Println("Session expired. Please run `auth` again.")
+			os.Exit(1)
+		}
+
+		if setsRule {
+			lint.SetRule(ownerLogin, repoName, args[0], args[1])
+		} else {
+			lint.ListRules(ownerLogin, repoName)
+		}
+	case "engine":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: envini engine <set|list|remove> ...")
+			return
+		}
+
+		flags := parseFlags(os.Args[3:])
+		nonFlagArgs := getNonFlagArgs(os.Args[3:])
+
+		// Number of arguments each subcommand takes besides the optional <owner> <repo>
+		engineArgs := map[string]int{"set": 2, "list": 0, "remove": 1}
+		argCount, ok := engineArgs[os.Args[2]]
+		if !ok {
+			fmt.Println("Usage: envini engine <set|list|remove> ...")
+			return
+		}
+
+		var ownerLogin, repoName string
+		var args []string
+		switch {
+		case len(nonFlagArgs) == argCount+2:
+			ownerLogin, repoName, args = nonFlagArgs[0], nonFlagArgs[1], nonFlagArgs[2:]
+		case len(nonFlagArgs) == argCount:
+			args = nonFlagArgs
+			owner, repo, err := getGitRepoInfo()
+			if err != nil {
+				fmt.Printf("Could not detect git repository (%v), pass <owner> <repo> explicitly\n", err)
+				return
+			}
+			ownerLogin, repoName = owner, repo
+			fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+		default:
+			fmt.Println("Usage: envini engine set [<owner> <repo>] <name> <config.json> [--engine=postgres] [--tag=tag] [--ttl=1h] [--max-ttl=24h]")
+			fmt.Println("       envini engine list [<owner> <repo>]")
+			fmt.Println("       envini engine remove [<owner> <repo>] <name>")
+			return
+		}
+
+		if auth.IfRefreshIsRequired() {
+			fmt.Println("Session expired. Please run `auth` again.")
+			os.Exit(1)
+		}
+
+		switch os.Args[2] {
+		case "set":
+			engine := flags["engine"]
+			if engine == "" {
+				engine = "postgres"
+			}
+			secrets.SetSecretEngine(ownerLogin, repoName, args[0], engine, flags["tag"], args[1], durationFlag(flags, "ttl"), durationFlag(flags, "max-ttl"))
+		case "list":
+			secrets.ListSecretEngines(ownerLogin, repoName)
+		case "remove":
+			secrets.DeleteSecretEngine(ownerLogin, repoName, args[0])
+		}
+	case "shared":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: envini shared <push|list|attach|detach> ...")
+			return
+		}
+
+		flags := parseFlags(os.Args[3:])
+		nonFlagArgs := getNonFlagArgs(os.Args[3:])
+
+		switch os.Args[2] {
+		case "push":
+			if len(nonFlagArgs) < 3 {
+				fmt.Println("Usage: envini shared push <org> <name> <file> [--format=dotenv] [--separator=_]")
+				fmt.Println("Example: envini shared push acme smtp smtp.env")
+				return
+			}
+
+			if auth.IfRefreshIsRequired() {
+				fmt.Println("Session expired. Please run `auth` again.")
+				os.Exit(1)
+			}
+
+			secrets.PushSharedSecretSet(nonFlagArgs[0], nonFlagArgs[1], nonFlagArgs[2], secrets.UploadOptions{
+				Format:       flags["format"],
+				KeySeparator: flags["separator"],
+			})
+		case "list":
+			if len(nonFlagArgs) < 1 {
+				fmt.Println("Usage: envini shared list <org>")
+				return
+			}
+
+			if auth.IfRefreshIsRequired() {
+				fmt.Println("Session expired. Please run `auth` again.")
+				os.Exit(1)
+			}
+
+			secrets.ListSharedSecretSets(nonFlagArgs[0])
+		case "attach", "detach":
+			detach := os.Args[2] == "detach"
+
+			var ownerLogin, repoName string
+			switch len(nonFlagArgs) {
+			case 4:
+				// Explicit repository format: shared attach <org> <name> <owner> <repo>
+				ownerLogin, repoName = nonFlagArgs[2], nonFlagArgs[3]
+			case 2:
+				owner, repo, err := getGitRepoInfo()
+				if err != nil {
+					fmt.Printf("Usage: envini shared %s <org> <name> <owner> <repo>\n", os.Args[2])
+					return
+				}
+				ownerLogin, repoName = owner, repo
+				fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+			default:
+				fmt.Printf("Usage: envini shared %s <org> <name> [<owner> <repo>] [--version=N]\n", os.Args[2])
+				return
+			}
+
+			if auth.IfRefreshIsRequired() {
+				fmt.Println("Session expired. Please run `auth` again.")
+				os.Exit(1)
+			}
+
+			version := 0 // Follow the set's latest version
+			if versionStr := flags["version"]; versionStr != "" && versionStr != "latest" {
+				var err error
+				version, err = strconv.Atoi(versionStr)
+				if err != nil {
+					fmt.Printf("Invalid version: %s\n", versionStr)
+					return
+				}
+			}
+
+			secrets.AttachSharedSecretSet(nonFlagArgs[0], nonFlagArgs[1], ownerLogin, repoName, version, detach)
+		default:
+			fmt.Println("Usage: envini shared <push|list|attach|detach> ...")
+		}
+	case "watch":
+		flags := parseFlags(os.Args[2:])
+		repositories := getNonFlagArgs(os.Args[2:])
+
+		if len(repositories) == 0 {
+			owner, repo, err := getGitRepoInfo()
+			if err != nil {
+				fmt.Printf("Could not detect git repository (%v), pass owner/repo explicitly\n", err)
+				return
+			}
+			repositories = []string{owner + "/" + repo}
+			if flags["json"] != "true" {
+				fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+			}
+		}
+		for _, repository := range repositories {
+			if parts := strings.Split(repository, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				fmt.Println("Usage: envini watch [owner/repo...] [--tag=production,staging] [--resume=token] [--json]")
+				return
+			}
+		}
+
+		if auth.IfRefreshIsRequired() {
+			fmt.Println("Session expired. Please run `auth` again.")
+			os.Exit(1)
+		}
+
+		secrets.Watch(repositories, splitList(flags["tag"]), flags["resume"], flags["json"] == "true")
+	case "webhook":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: envini webhook <add|list|update|remove|deliveries|redeliver> ...")
+			return
+		}
+
+		flags := parseFlags(os.Args[3:])
+		nonFlagArgs := getNonFlagArgs(os.Args[3:])
+
+		switch os.Args[2] {
+		case "add", "list":
+			// add takes the URL after the optional <owner> <repo>; --org targets an organization instead
+			argCount := 0
+			if os.Args[2] == "add" {
+				argCount = 1
+			}
+
+			var ownerLogin, repoName string
+			orgLogin := flags["org"]
+			switch {
+			case orgLogin != "" && len(nonFlagArgs) == argCount:
+			case orgLogin == "" && len(nonFlagArgs) == argCount+2:
+				ownerLogin, repoName, nonFlagArgs = nonFlagArgs[0], nonFlagArgs[1], nonFlagArgs[2:]
+			case orgLogin == "" && len(nonFlagArgs) == argCount:
+				owner, repo, err := getGitRepoInfo()
+				if err != nil {
+					fmt.Printf("Could not detect git repository (%v), pass <owner> <repo> or --org=org explicitly\n", err)
+					return
+				}
+				ownerLogin, repoName = owner, repo
+				fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+			default:
+				fmt.Println("Usage: envini webhook add [<owner> <repo>] <url> [--org=org] [--events=secret.uploaded,secret.deleted] [--secret=value]")
+				fmt.Println("       envini webhook list [<owner> <repo>] [--org=org]")
+				return
+			}
+
+			if auth.IfRefreshIsRequired() {
+				fmt.Println("Session expired. Please run `auth` again.")
+				os.Exit(1)
+			}
+
+			if os.Args[2] == "add" {
+				secrets.CreateWebhook(ownerLogin, repoName, orgLogin, nonFlagArgs[0], splitList(flags["events"]), flags["secret"])
+			} else {
+				secrets.ListWebhooks(ownerLogin, repoName, orgLogin)
+			}
+		case "update", "remove", "deliveries", "redeliver":
+			if len(nonFlagArgs) != 1 {
+				fmt.Println("Usage: envini webhook update <id> [--url=url] [--events=a,b] [--active=true|false] [--rotate-secret] [--secret=value]")
+				fmt.Println("       envini webhook remove <id>")
+				fmt.Println("       envini webhook deliveries <id> [--limit=20]")
+				fmt.Println("       envini webhook redeliver <delivery-id>")
+				return
+			}
+
+			id, err := strconv.ParseUint(nonFlagArgs[0], 10, 64)
+			if err != nil || id == 0 {
+				fmt.Printf("Invalid id: %s\n", nonFlagArgs[0])
+				return
+			}
+
+			var update secrets.WebhookUpdate
+			limit := 0
+			switch os.Args[2] {
+			case "update":
+				if hookURL, ok := flags["url"]; ok {
+					update.URL = &hookURL
+				}
+				update.Events = splitList(flags["events"])
+				if activeStr, ok := flags["active"]; ok {
+					active, err := strconv.ParseBool(activeStr)
+					if err != nil {
+						fmt.Printf("Invalid --active value: %s\n", activeStr)
+						return
+					}
+					update.Active = &active
+				}
+				update.Secret = flags["secret"]
+				update.RotateSecret = flags["rotate-secret"] == "true" || update.Secret != ""
+			case "deliveries":
+				if limitStr := flags["limit"]; limitStr != "" {
+					limit, err = strconv.Atoi(limitStr)
+					if err != nil || limit < 0 {
+						fmt.Printf("Invalid limit: %s\n", limitStr)
+						return
+					}
+				}
+			}
+
+			if auth.IfRefreshIsRequired() {
+				fmt.Println("Session expired. Please run `auth` again.")
+				os.Exit(1)
+			}
+
+			switch os.Args[2] {
+			case "update":
+				secrets.UpdateWebhook(id, update)
+			case "remove":
+				secrets.DeleteWebhook(id)
+			case "deliveries":
+				secrets.ListWebhookDeliveries(id, limit)
+			case "redeliver":
+				secrets.RedeliverWebhook(id)
+			}
+		default:
+			fmt.Println("Usage: envini webhook <add|list|update|remove|deliveries|redeliver> ...")
+		}
+	case "changes":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: envini changes <list|approve|reject|comment|protect|unprotect> ...")
+			return
+		}
+
+		flags := parseFlags(os.Args[3:])
+		nonFlagArgs := getNonFlagArgs(os.Args[3:])
+
+		switch os.Args[2] {
+		case "list", "protect", "unprotect":
+			// protect and unprotect take the tag after the optional <owner> <repo>
+			argCount := 0
+			if os.Args[2] != "list" {
+				argCount = 1
+			}
+
+			var ownerLogin, repoName string
+			switch len(nonFlagArgs) {
+			case argCount + 2:
+				ownerLogin, repoName, nonFlagArgs = nonFlagArgs[0], nonFlagArgs[1], nonFlagArgs[2:]
+			case argCount:
+				owner, repo, err := getGitRepoInfo()
+				if err != nil {
+					fmt.Printf("Could not detect git repository (%v), pass <owner> <repo> explicitly\n", err)
+					return
+				}
+				ownerLogin, repoName = owner, repo
+				fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+			default:
+				fmt.Println("Usage: envini changes list [<owner> <repo>] [--status=pending|approved|rejected|expired|all]")
+				fmt.Println("       envini changes protect [<owner> <repo>] <tag> [--review-window=72h]")
+				fmt.Println("       envini changes unprotect [<owner> <repo>] <tag>")
+				return
+			}
+
+			// The review window is sent in whole hours
+			reviewWindow := durationFlag(flags, "review-window")
+			requestTTLHours := int((reviewWindow + time.Hour - 1) / time.Hour)
+
+			if auth.IfRefreshIsRequired() {
+				fmt.Println("Session expired. Please run `auth` again.")
+				os.Exit(1)
+			}
+
+			switch os.Args[2] {
+			case "list":
+				secrets.ListChangeRequests(ownerLogin, repoName, flags["status"])
+			case "protect":
+				secrets.SetTagProtection(ownerLogin, repoName, nonFlagArgs[0], true, requestTTLHours)
+			case "unprotect":
+				secrets.SetTagProtection(ownerLogin, repoName, nonFlagArgs[0], false, 0)
+			}
+		case "approve", "reject":
+			if len(nonFlagArgs) != 1 {
+				fmt.Println("Usage: envini changes approve <id> [--comment=text]")
+				fmt.Println("       envini changes reject <id> [--comment=text]")
+				return
+			}
+
+			id, err := strconv.ParseUint(strings.TrimPrefix(nonFlagArgs[0], "#"), 10, 64)
+			if err != nil || id == 0 {
+				fmt.Printf("Invalid id: %s\n", nonFlagArgs[0])
+				return
+			}
+
+			if auth.IfRefreshIsRequired() {
+				fmt.Println("Session expired. Please run `auth` again.")
+				os.Exit(1)
+			}
+
+			if os.Args[2] == "approve" {
+				secrets.ApproveChange(id, flags["comment"])
+			} else {
+				secrets.RejectChange(id, flags["comment"])
+			}
+		case "comment":
+			if len(nonFlagArgs) != 2 {
+				fmt.Println("Usage: envini changes comment <id> <text>")
+				return
+			}
+
+			id, err := strconv.ParseUint(strings.TrimPrefix(nonFlagArgs[0], "#"), 10, 64)
+			if err != nil || id == 0 {
+				fmt.Printf("Invalid id: %s\n", nonFlagArgs[0])
+				return
+			}
+
+			if auth.IfRefreshIsRequired() {
+				fmt.Println("Session expired. Please run `auth` again.")
+				os.Exit(1)
+			}
+
+			secrets.CommentChange(id, nonFlagArgs[1])
+		default:
+			fmt.Println("Usage: envini changes <list|approve|reject|comment|protect|unprotect> ...")
+		}
+	case "share":
+		if len(os.Args) < 3 {
+			fmt.Println("Usage: envini share <create|file|open> ...")
+			return
+		}
+
+		flags := parseFlags(os.Args[3:])
+		nonFlagArgs := getNonFlagArgs(os.Args[3:])
+
+		switch os.Args[2] {
+		case "create", "file":
+			// file takes the file secret's name after the optional <owner> <repo>
+			argCount := 0
+			if os.Args[2] == "file" {
+				argCount = 1
+			}
+
+			var ownerLogin, repoName string
+			detected := false
+			switch len(nonFlagArgs) {
+			case argCount + 2:
+				ownerLogin, repoName, nonFlagArgs = nonFlagArgs[0], nonFlagArgs[1], nonFlagArgs[2:]
+			case argCount:
+				owner, repo, err := getGitRepoInfo()
+				if err != nil {
+					fmt.Printf("Could not detect git repository (%v), pass <owner> <repo> explicitly\n", err)
+					return
+				}
+				ownerLogin, repoName, detected = owner, repo, true
+				fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+			default:
+				fmt.Println("Usage: envini share create [<owner> <repo>] [--tag=tag] [--version=n] [--key=NAME] [--branch[=name]] [--views=1] [--expires-in=24h]")
+				fmt.Println("       envini share file [<owner> <repo>] <name> [--tag=tag] [--version=n] [--views=1] [--expires-in=24h]")
+				return
+			}
+
+			opts := secrets.ShareOptions{
+				Tag:       flags["tag"],
+				Key:       flags["key"],
+				ExpiresIn: durationFlag(flags, "expires-in"),
+			}
+			if versionStr := flags["version"]; versionStr != "" && versionStr != "latest" {
+				version, err := strconv.Atoi(versionStr)
+				if err != nil || version < 0 {
+					fmt.Printf("Invalid version: %s\n", versionStr)
+					return
+				}
+				opts.Version = version
+			}
+			if viewsStr := flags["views"]; viewsStr != "" {
+				views, err := strconv.Atoi(viewsStr)
+				if err != nil || views <= 0 {
+					fmt.Printf("Invalid views: %s\n", viewsStr)
+					return
+				}
+				opts.MaxViews = views
+			}
+			if os.Args[2] == "file" {
+				opts.File = true
+				opts.FileName = nonFlagArgs[0]
+			} else {
+				opts.FileName = flags["file"]
+				opts.Path = pathScope(flags, detected)
+				opts.Branch = branchScope(flags, detected)
+			}
+
+			if auth.IfRefreshIsRequired() {
+				fmt.Println("Session expired. Please run `auth` again.")
+				os.Exit(1)
+			}
+
+			secrets.CreateShareLink(ownerLogin, repoName, opts)
+		case "open":
+			// Opening a link needs no Envini account
+			if len(nonFlagArgs) < 1 || len(nonFlagArgs) > 2 {
+				fmt.Println("Usage: envini share open <token> [output-file]")
+				return
+			}
+
+			outputPath := ""
+			if len(nonFlagArgs) == 2 {
+				outputPath = nonFlagArgs[1]
+			}
+			secrets.RedeemShareLink(nonFlagArgs[0], outputPath)
+		default:
+			fmt.Println("Usage: envini share <create|file|open> ...")
+		}
+	case "keys":
+		flags := parseFlags(os.Args[2:])
+		nonFlagArgs := getNonFlagArgs(os.Args[2:])
+
+		var ownerLogin, repoName string
+		detected := false
+		switch len(nonFlagArgs) {
+		case 2:
+			ownerLogin, repoName = nonFlagArgs[0], nonFlagArgs[1]
+		case 0:
+			owner, repo, err := getGitRepoInfo()
+			if err != nil {
+				fmt.Printf("Could not detect git repository (%v), pass <owner> <repo> explicitly\n", err)
+				return
+			}
+			ownerLogin, repoName, detected = owner, repo, true
+			fmt.Printf("📁 Detected repository: %s/%s\n", owner, repo)
+		default:
+			fmt.Println("Usage: envini keys [<owner> <repo>] [--tag=tag] [--version=n] [--branch[=name]] [--compare=other-tag]")
+			return
+		}
+
+		opts := secrets.KeyListOptions{
+			Tag:        flags["tag"],
+			FileName:   flags["file"],
+			Path:       pathScope(flags, detected),
+			Branch:     branchScope(flags, detected),
+			CompareTag: flags["compare"],
+		}
+		if versionStr := flags["version"]; versionStr != "" && versionStr != "latest" {
+			version, err := strconv.Atoi(versionStr)
+			if err != nil || version < 0 {
+				fmt.Printf("Invalid version: %s\n", versionStr)
+				return
+			}
+			opts.Version = version
+		}
+
+		if auth.IfRefreshIsRequired() {
+			fmt.Println("Session expired. Please run `auth` again.")
+			os.Exit(1)
+		}
+
+		secrets.ListSecretKeys(ownerLogin, repoName, opts)
+	case "search":
+		flags := parseFlags(os.Args[2:])
+		nonFlagArgs := getNonFlagArgs(os.Args[2:])
+
+		if len(nonFlagArgs) != 1 {
+			fmt.Println("Usage: envini search <pattern> [--mode=prefix|substring|regex] [--limit=n]")
+			return
+		}
+
+		mode := flags["mode"]
+		switch mode {
+		case "", "prefix", "substring", "regex":
+		default:
+			fmt.Printf("Invalid mode: %s (use prefix, substring or regex)\n", mode)
+			return
+		}
+
+		limit := 0 // Server default
+		if limitStr := flags["limit"]; limitStr != "" {
+			var err error
+			limit, err = strconv.Atoi(limitStr)
+			if err != nil || limit <= 0 {
+				fmt.Printf("Invalid limit: %s\n", limitStr)
+				return
+			}
+		}
+
+		if auth.IfRefreshIsRequired() {
+			fmt.Println("Session expired. Please run `auth` again.")
+			os.Exit(1)
+		}
+
+		secrets.SearchSecretKeys(nonFlagArgs[0], mode, limit)
 	default:
 		help.DisplayHelp()
 	}
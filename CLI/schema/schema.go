@@ -0,0 +1,430 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	neturl "net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+func getBackendURL() string {
+	if url := os.Getenv("BACKEND_URL"); url != "" {
+		return url
+	}
+	return "http://localhost:3000" // default fallback
+}
+
+type StoredAuthData struct {
+	Jwt string `json:"jwt"`
+}
+
+type SetSchemaResponse struct {
+	Success          bool   `json:"success,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"errorDescription,omitempty"`
+}
+
+type GetSchemaResponse struct {
+	Schema           string `json:"schema,omitempty"`
+	Tag              string `json:"tag,omitempty"`
+	UpdatedBy        string `json:"updatedBy,omitempty"`
+	UpdatedAt        string `json:"updatedAt,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"errorDescription,omitempty"`
+}
+
+// Violation mirrors the structured violations returned by uploads
+type Violation struct {
+	Key     string `json:"key"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Warning bool   `json:"warning"`
+}
+
+// SecretSchema declares the keys a secret must or may contain, as stored by SetSchema
+type SecretSchema struct {
+	Keys map[string]KeySchema `json:"keys"`
+}
+
+type KeySchema struct {
+	Required    bool     `json:"required,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"`
+	Allowed     []string `json:"allowed,omitempty"`
+	Deprecated  bool     `json:"deprecated,omitempty"`
+	ReplacedBy  string   `json:"replacedBy,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+func retrieveJwt() string {
+	bytes, err := os.ReadFile("./temp/auth.json")
+	if err != nil {
+		fmt.Println("No auth file found. Please authenticate first using the auth command.")
+		os.Exit(1)
+	}
+
+	var authData StoredAuthData
+	if err := json.Unmarshal(bytes, &authData); err != nil {
+		fmt.Println("Error parsing auth file:", err)
+		os.Exit(1)
+	}
+
+	return authData.Jwt
+}
+
+// SetSchema uploads the schema uploads to tag are validated against; an empty schemaPath removes it
+func SetSchema(ownerLogin string, repoName string, tag string, schemaPath string) {
+	jwt := retrieveJwt()
+
+	request := map[string]string{"tag": tag}
+	if schemaPath != "" {
+		content, err := os.ReadFile(schemaPath)
+		if err != nil {
+			fmt.Printf("Failed to read schema %s: %v\n", schemaPath, err)
+			os.Exit(1)
+		}
+		if _, err := parseSchema(content); err != nil {
+			fmt.Printf("Invalid schema %s: %v\n", schemaPath, err)
+			os.Exit(1)
+		}
+		request["schema"] = string(content)
+	}
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		fmt.Printf("Failed to marshal request: %v\n", err)
+		os.Exit(1)
+	}
+
+	url := fmt.Sprintf("%s/secrets/schema/%s/%s", getBackendURL(), ownerLogin, repoName)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+	req.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response SetSchemaResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	if schemaPath == "" {
+		fmt.Printf("✅ Schema removed from %s\n", tagLabel(tag))
+		return
+	}
+	fmt.Printf("✅ Schema set for %s\n", tagLabel(tag))
+}
+
+// GetSchema prints the schema that applies to tag
+func GetSchema(ownerLogin string, repoName string, tag string) {
+	response := fetchSchema(ownerLogin, repoName, tag)
+	if response.Schema == "" {
+		fmt.Printf("No schema applies to %s\n", tagLabel(tag))
+		return
+	}
+
+	fmt.Printf("Schema of %s (updated by %s at %s):\n", tagLabel(response.Tag), response.UpdatedBy, response.UpdatedAt)
+	fmt.Println(response.Schema)
+}
+
+// ValidateFile checks a local secret file without uploading it. The schema is read from schemaPath,
+// or fetched from the repository when schemaPath is empty. Keys inherited from parent tags or
+// shared secret sets are not visible locally, so required keys must be present in the file.
+func ValidateFile(ownerLogin string, repoName string, tag string, filePath string, format string, schemaPath string) {
+	var content []byte
+	if schemaPath != "" {
+		var err error
+		content, err = os.ReadFile(schemaPath)
+		if err != nil {
+			fmt.Printf("Failed to read schema %s: %v\n", schemaPath, err)
+			os.Exit(1)
+		}
+	} else {
+		response := fetchSchema(ownerLogin, repoName, tag)
+		if response.Schema == "" {
+			fmt.Printf("No schema applies to %s of %s/%s\n", tagLabel(tag), ownerLogin, repoName)
+			return
+		}
+		content = []byte(response.Schema)
+	}
+
+	schema, err := parseSchema(content)
+	if err != nil {
+		fmt.Printf("Invalid schema: %v\n", err)
+		os.Exit(1)
+	}
+
+	envData, err := readSecretFile(filePath, format)
+	if err != nil {
+		fmt.Printf("Failed to read %s: %v\n", filePath, err)
+		os.Exit(1)
+	}
+
+	violations := schema.validate(envData)
+	PrintViolations(violations)
+
+	for _, violation := range violations {
+		if !violation.Warning {
+			fmt.Printf("❌ %s does not match the schema\n", filePath)
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("✅ %s matches the schema\n", filePath)
+}
+
+// PrintViolations lists schema violations, errors and warnings alike
+func PrintViolations(violations []Violation) {
+	for _, violation := range violations {
+		marker := "❌"
+		if violation.Warning {
+			marker = "⚠️ "
+		}
+		fmt.Printf("   %s %s (%s): %s\n", marker, violation.Key, violation.Rule, violation.Message)
+	}
+}
+
+func fetchSchema(ownerLogin string, repoName string, tag string) GetSchemaResponse {
+	jwt := retrieveJwt()
+
+	url := fmt.Sprintf("%s/secrets/schema/%s/%s", getBackendURL(), ownerLogin, repoName)
+	if tag != "" {
+		url += "?tag=" + neturl.QueryEscape(tag)
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response GetSchemaResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	return response
+}
+
+// tagLabel names a schema's tag, the empty tag being the repository default
+func tagLabel(tag string) string {
+	if tag == "" {
+		return "the default schema"
+	}
+	return "tag " + tag
+}
+
+// readSecretFile parses the dotenv and json files that can be validated offline
+func readSecretFile(filePath string, format string) (map[string]string, error) {
+	if format == "" {
+		format = "dotenv"
+		if strings.HasSuffix(strings.ToLower(filePath), ".json") {
+			format = "json"
+		}
+	}
+
+	switch format {
+	case "dotenv":
+		return godotenv.Read(filePath)
+	case "json":
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		var data map[string]interface{}
+		if err := json.Unmarshal(content, &data); err != nil {
+			return nil, fmt.Errorf("invalid json: %v", err)
+		}
+		envData := make(map[string]string)
+		flattenJSON("", data, envData)
+		return envData, nil
+	default:
+		return nil, fmt.Errorf("offline validation supports dotenv and json files, not %s", format)
+	}
+}
+
+// flattenJSON joins nested keys with "_", as uploads do by default
+func flattenJSON(prefix string, value interface{}, out map[string]string) {
+	joinKey := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "_" + key
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			flattenJSON(joinKey(key), item, out)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flattenJSON(joinKey(strconv.Itoa(i)), item, out)
+		}
+	case nil:
+		out[prefix] = ""
+	case string:
+		out[prefix] = v
+	case float64:
+		out[prefix] = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+}
+
+func parseSchema(content []byte) (*SecretSchema, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	var schema SecretSchema
+	if err := decoder.Decode(&schema); err != nil {
+		return nil, err
+	}
+	if len(schema.Keys) == 0 {
+		return nil, fmt.Errorf("no keys declared")
+	}
+	for key, rule := range schema.Keys {
+		if rule.Pattern != "" {
+			if _, err := regexp.Compile(rule.Pattern); err != nil {
+				return nil, fmt.Errorf("%s: invalid pattern: %v", key, err)
+			}
+		}
+	}
+	return &schema, nil
+}
+
+// validate applies the same rules as the server to a file on its own
+func (schema *SecretSchema) validate(envData map[string]string) []Violation {
+	keys := make([]string, 0, len(schema.Keys))
+	for key := range schema.Keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var violations []Violation
+	for _, key := range keys {
+		rule := schema.Keys[key]
+		value, present := envData[key]
+
+		if !present {
+			if rule.Required {
+				violations = append(violations, Violation{Key: key, Rule: "required", Message: "required key is missing"})
+			}
+			continue
+		}
+
+		if rule.Deprecated {
+			message := "key is deprecated"
+			if rule.ReplacedBy != "" {
+				message += ", use " + rule.ReplacedBy + " instead"
+			}
+			violations = append(violations, Violation{Key: key, Rule: "deprecated", Message: message, Warning: true})
+		}
+
+		// References and interpolation are only checked once resolved
+		if strings.Contains(value, "${") {
+			continue
+		}
+
+		if message := checkType(rule.Type, value); message != "" {
+			violations = append(violations, Violation{Key: key, Rule: "type", Message: message})
+		}
+		if rule.Pattern != "" && !regexp.MustCompile("^(?:"+rule.Pattern+")$").MatchString(value) {
+			violations = append(violations, Violation{Key: key, Rule: "pattern", Message: "value does not match pattern " + rule.Pattern})
+		}
+		if len(rule.Allowed) > 0 && !slices.Contains(rule.Allowed, value) {
+			violations = append(violations, Violation{Key: key, Rule: "allowed", Message: "value must be one of " + strings.Join(rule.Allowed, ", ")})
+		}
+	}
+	return violations
+}
+
+func checkType(valueType string, value string) string {
+	switch valueType {
+	case "int":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "value is not an integer"
+		}
+	case "bool":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "value is not a boolean"
+		}
+	case "url":
+		parsed, err := neturl.Parse(value)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return "value is not an absolute URL"
+		}
+	case "email":
+		address, err := mail.ParseAddress(value)
+		if err != nil || address.Address != value {
+			return "value is not an email address"
+		}
+	case "json":
+		if !json.Valid([]byte(value)) {
+			return "value is not valid JSON"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,267 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+type ChangeRequestInfo struct {
+	ID            uint64 `json:"id"`
+	Tag           string `json:"tag"`
+	Kind          string `json:"kind"`
+	ParentTag     string `json:"parentTag"`
+	ParentVersion int    `json:"parentVersion"`
+	Path          string `json:"path"`
+	FileName      string `json:"fileName"`
+	Branch        string `json:"branch"`
+	Status        string `json:"status"`
+	RequestedBy   string `json:"requestedBy"`
+	ReviewedBy    string `json:"reviewedBy"`
+	CreatedAt     string `json:"createdAt"`
+	ExpiresAt     string `json:"expiresAt"`
+	ResolvedAt    string `json:"resolvedAt"`
+	BaseVersion   int    `json:"baseVersion"`
+	Version       int    `json:"version"`
+	Checksum      string `json:"checksum"`
+	Annotation    *struct {
+		Message   string `json:"message"`
+		CommitSha string `json:"commitSha"`
+	} `json:"annotation,omitempty"`
+	Diff []struct {
+		Key    string `json:"key"`
+		Change string `json:"change"`
+	} `json:"diff"`
+	Comments []struct {
+		Author    string `json:"author"`
+		Body      string `json:"body"`
+		CreatedAt string `json:"createdAt"`
+	} `json:"comments"`
+}
+
+type ProtectedTagInfo struct {
+	Tag             string `json:"tag"`
+	RequestTTLHours int    `json:"requestTtlHours"`
+	UpdatedBy       string `json:"updatedBy"`
+	UpdatedAt       string `json:"updatedAt"`
+}
+
+type ChangeRequestResponse struct {
+	Success          bool                `json:"success,omitempty"`
+	Version          int                 `json:"version,omitempty"`
+	Checksum         string              `json:"checksum,omitempty"`
+	ChangeRequests   []ChangeRequestInfo `json:"changeRequests,omitempty"`
+	ProtectedTags    []ProtectedTagInfo  `json:"protectedTags,omitempty"`
+	Error            string              `json:"error,omitempty"`
+	ErrorDescription string              `json:"errorDescription,omitempty"`
+}
+
+// SetTagProtection protects a tag, so its uploads wait for a second collaborator's approval, or lifts the
+// protection. requestTTLHours is how long change requests stay open, 0 for the server default.
+func SetTagProtection(ownerLogin string, repoName string, tag string, protected bool, requestTTLHours int) {
+	jwt := retrieveJwt()
+
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"tag":             tag,
+		"protected":       protected,
+		"requestTtlHours": requestTTLHours,
+	})
+	if err != nil {
+		fmt.Printf("Failed to marshal request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/secrets/protection/%s/%s", getBackendURL(), ownerLogin, repoName), bytes.NewBuffer(requestBody))
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	sendChangeRequest(req, jwt)
+
+	if protected {
+		fmt.Printf("🔒 Tag %s of %s/%s is protected, uploads now need an approval\n", tag, ownerLogin, repoName)
+	} else {
+		fmt.Printf("🔓 Tag %s of %s/%s is no longer protected\n", tag, ownerLogin, repoName)
+	}
+}
+
+// ListChangeRequests prints the change requests of a repository with the given status (pending when empty,
+// or all) together with its protected tags
+func ListChangeRequests(ownerLogin string, repoName string, status string) {
+	jwt := retrieveJwt()
+
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/secrets/changes/%s/%s?%s", getBackendURL(), ownerLogin, repoName, query.Encode()), nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	response := sendChangeRequest(req, jwt)
+
+	if len(response.ProtectedTags) > 0 {
+		fmt.Printf("Protected tags of %s/%s:\n", ownerLogin, repoName)
+		for _, protected := range response.ProtectedTags {
+			fmt.Printf("   🔒 %s - requests open for %dh, set by %s\n", protected.Tag, protected.RequestTTLHours, protected.UpdatedBy)
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("Change requests of %s/%s:\n", ownerLogin, repoName)
+	if len(response.ChangeRequests) == 0 {
+		fmt.Println("   No change requests")
+	}
+
+	for _, request := range response.ChangeRequests {
+		icon := "⏳"
+		switch request.Status {
+		case "approved":
+			icon = "✅"
+		case "rejected":
+			icon = "❌"
+		case "expired":
+			icon = "⌛"
+		}
+
+		target := request.FileName
+		if request.Path != "" {
+			target = request.Path + "/" + target
+		}
+		if request.Branch != "" {
+			target += " on branch " + request.Branch
+		}
+
+		fmt.Printf("\n%s #%d %s of %s - %s\n", icon, request.ID, request.Tag, target, request.Status)
+		fmt.Printf("   Requested by %s - %s\n", request.RequestedBy, request.CreatedAt)
+		if request.Annotation != nil && request.Annotation.Message != "" {
+			fmt.Printf("   Message: %s\n", request.Annotation.Message)
+		}
+		if request.Annotation != nil && request.Annotation.CommitSha != "" {
+			fmt.Printf("   Commit: %s\n", request.Annotation.CommitSha)
+		}
+		parentChange := request.Kind == "parent"
+		switch {
+		case request.Status == "pending" && parentChange:
+			fmt.Printf("   Expires at %s\n", request.ExpiresAt)
+		case request.Status == "pending":
+			fmt.Printf("   Based on version %d, expires at %s\n", request.BaseVersion, request.ExpiresAt)
+		case request.Status == "approved" && parentChange:
+			fmt.Printf("   Approved by %s - %s\n", request.ReviewedBy, request.ResolvedAt)
+		case request.Status == "approved":
+			fmt.Printf("   Approved by %s as version %d - %s\n", request.ReviewedBy, request.Version, request.ResolvedAt)
+		case request.Status == "rejected":
+			fmt.Printf("   Rejected by %s - %s\n", request.ReviewedBy, request.ResolvedAt)
+		}
+
+		switch {
+		case parentChange && request.ParentTag == "":
+			fmt.Println("   Removes the parent of the tag")
+		case parentChange:
+			fmt.Printf("   Inherits from %s\n", parentLabel(request.ParentTag, request.ParentVersion))
+		case len(request.Diff) == 0:
+			fmt.Println("   No key changes")
+		}
+		for _, change := range request.Diff {
+			sign := "~"
+			switch change.Change {
+			case "added":
+				sign = "+"
+			case "removed":
+				sign = "-"
+			}
+			fmt.Printf("   %s %s\n", sign, change.Key)
+		}
+		for _, comment := range request.Comments {
+			fmt.Printf("   💬 %s (%s): %s\n", comment.Author, comment.CreatedAt, comment.Body)
+		}
+	}
+}
+
+// ApproveChange approves a pending change request, creating the proposed version or storing the proposed
+// parent
+func ApproveChange(changeID uint64, comment string) {
+	response := reviewChange(changeID, "approve", comment)
+
+	fmt.Printf("✅ Change request #%d approved\n", changeID)
+	if response.Version == 0 {
+		return
+	}
+	fmt.Printf("   Version: %d\n", response.Version)
+	fmt.Printf("   Checksum: %s\n", response.Checksum)
+}
+
+// RejectChange rejects a pending change request, or withdraws it when run by its author
+func RejectChange(changeID uint64, comment string) {
+	reviewChange(changeID, "reject", comment)
+
+	fmt.Printf("❌ Change request #%d rejected\n", changeID)
+}
+
+// CommentChange adds a comment to a pending change request, for its reviewers and author
+func CommentChange(changeID uint64, comment string) {
+	reviewChange(changeID, "comment", comment)
+
+	fmt.Printf("💬 Commented on change request #%d\n", changeID)
+}
+
+func reviewChange(changeID uint64, action string, comment string) ChangeRequestResponse {
+	jwt := retrieveJwt()
+
+	requestBody, err := json.Marshal(map[string]string{"comment": comment})
+	if err != nil {
+		fmt.Printf("Failed to marshal request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/secrets/changes/%d/%s", getBackendURL(), changeID, action), bytes.NewBuffer(requestBody))
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	return sendChangeRequest(req, jwt)
+}
+
+func sendChangeRequest(req *http.Request, jwt string) ChangeRequestResponse {
+	req.Header.Add("Authorization", "Bearer "+jwt)
+	req.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response ChangeRequestResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+	return response
+}
@@ -0,0 +1,208 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+type SecretEngineInfo struct {
+	Name              string   `json:"name"`
+	Engine            string   `json:"engine"`
+	Tag               string   `json:"tag"`
+	DefaultTTLSeconds int      `json:"defaultTtlSeconds"`
+	MaxTTLSeconds     int      `json:"maxTtlSeconds"`
+	Keys              []string `json:"keys"`
+	ActiveLeases      int      `json:"activeLeases"`
+	UpdatedBy         string   `json:"updatedBy"`
+	UpdatedAt         string   `json:"updatedAt"`
+}
+
+type ListSecretEnginesResponse struct {
+	Engines          []SecretEngineInfo `json:"engines,omitempty"`
+	Error            string             `json:"error,omitempty"`
+	ErrorDescription string             `json:"errorDescription,omitempty"`
+}
+
+type SetSecretEngineResponse struct {
+	Success          bool   `json:"success,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"errorDescription,omitempty"`
+}
+
+// LeaseInfo is a dynamic credential issued for a download
+type LeaseInfo struct {
+	LeaseID    string   `json:"leaseId"`
+	EngineName string   `json:"engineName"`
+	Engine     string   `json:"engine"`
+	ExpiresAt  string   `json:"expiresAt"`
+	Keys       []string `json:"keys"`
+}
+
+// SetSecretEngine stores the engine configuration read from configPath; downloads of tag, or of every
+// tag when it is empty, then get fresh credentials from it
+func SetSecretEngine(ownerLogin string, repoName string, name string, engine string, tag string, configPath string, defaultTTL time.Duration, maxTTL time.Duration) {
+	jwt := retrieveJwt()
+
+	config, err := os.ReadFile(configPath)
+	if err != nil {
+		fmt.Printf("Failed to read engine configuration %s: %v\n", configPath, err)
+		os.Exit(1)
+	}
+	if !json.Valid(config) {
+		fmt.Printf("Engine configuration %s is not valid JSON\n", configPath)
+		os.Exit(1)
+	}
+
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"name":              name,
+		"engine":            engine,
+		"tag":               tag,
+		"config":            string(config),
+		"defaultTtlSeconds": int(defaultTTL.Seconds()),
+		"maxTtlSeconds":     int(maxTTL.Seconds()),
+	})
+	if err != nil {
+		fmt.Printf("Failed to marshal request: %v\n", err)
+		os.Exit(1)
+	}
+
+	url := fmt.Sprintf("%s/secrets/engines/%s/%s", getBackendURL(), ownerLogin, repoName)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	sendSecretEngineRequest(req, jwt)
+
+	scope := "every tag"
+	if tag != "" {
+		scope = "tag " + tag
+	}
+	fmt.Printf("✅ Secret engine %s (%s) set for %s of %s/%s\n", name, engine, scope, ownerLogin, repoName)
+}
+
+// DeleteSecretEngine revokes every active lease of the engine and removes it
+func DeleteSecretEngine(ownerLogin string, repoName string, name string) {
+	jwt := retrieveJwt()
+
+	url := fmt.Sprintf("%s/secrets/engines/%s/%s/%s", getBackendURL(), ownerLogin, repoName, name)
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	sendSecretEngineRequest(req, jwt)
+
+	fmt.Printf("✅ Secret engine %s removed from %s/%s, its leases were revoked\n", name, ownerLogin, repoName)
+}
+
+func sendSecretEngineRequest(req *http.Request, jwt string) {
+	req.Header.Add("Authorization", "Bearer "+jwt)
+	req.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response SetSecretEngineResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+}
+
+// ListSecretEngines prints the engines of a repository; their configuration never leaves the server
+func ListSecretEngines(ownerLogin string, repoName string) {
+	jwt := retrieveJwt()
+
+	url := fmt.Sprintf("%s/secrets/engines/%s/%s", getBackendURL(), ownerLogin, repoName)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response ListSecretEnginesResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	fmt.Printf("Secret engines of %s/%s:\n", ownerLogin, repoName)
+	if len(response.Engines) == 0 {
+		fmt.Println("   No secret engines configured")
+	}
+
+	for _, engine := range response.Engines {
+		tag := engine.Tag
+		if tag == "" {
+			tag = "every tag"
+		}
+		fmt.Printf("\n⚙️  %s (%s) - %s\n", engine.Name, engine.Engine, tag)
+		fmt.Printf("   Keys: %s\n", strings.Join(engine.Keys, ", "))
+		fmt.Printf("   TTL: %s (max %s)\n", time.Duration(engine.DefaultTTLSeconds)*time.Second, time.Duration(engine.MaxTTLSeconds)*time.Second)
+		fmt.Printf("   Active leases: %d\n", engine.ActiveLeases)
+		fmt.Printf("   Updated by %s - %s\n", engine.UpdatedBy, engine.UpdatedAt)
+	}
+}
+
+// printLeases prints the dynamic credentials issued for a download
+func printLeases(leases []LeaseInfo) {
+	fmt.Println("   Dynamic credentials:")
+	for _, lease := range leases {
+		fmt.Printf("     %s from %s (%s), revoked after %s\n", strings.Join(lease.Keys, ", "), lease.EngineName, lease.Engine, lease.ExpiresAt)
+		fmt.Printf("       Lease: %s\n", lease.LeaseID)
+	}
+}
@@ -0,0 +1,146 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+)
+
+// KeyMetadata is the optional expiry and rotation details of one key, read from a --metadata file
+type KeyMetadata struct {
+	Key              string `json:"key"`
+	ExpiresAt        string `json:"expiresAt,omitempty"`
+	Owner            string `json:"owner,omitempty"`
+	RotationInterval string `json:"rotationInterval,omitempty"`
+}
+
+type ExpiringSecretInfo struct {
+	OwnerLogin       string `json:"ownerLogin"`
+	RepoName         string `json:"repoName"`
+	Path             string `json:"path"`
+	FileName         string `json:"fileName"`
+	Branch           string `json:"branch"`
+	Tag              string `json:"tag"`
+	Version          int    `json:"version"`
+	Key              string `json:"key"`
+	Owner            string `json:"owner"`
+	ExpiresAt        string `json:"expiresAt"`
+	RotationInterval string `json:"rotationInterval"`
+	RotatedAt        string `json:"rotatedAt"`
+	DueAt            string `json:"dueAt"`
+	Reason           string `json:"reason"`
+	Expired          bool   `json:"expired"`
+}
+
+type ListExpiringSecretsResponse struct {
+	Secrets          []ExpiringSecretInfo `json:"secrets,omitempty"`
+	Error            string               `json:"error,omitempty"`
+	ErrorDescription string               `json:"errorDescription,omitempty"`
+}
+
+// readKeyMetadata reads a JSON object mapping key names to their metadata, e.g.
+// {"STRIPE_KEY": {"expiresAt": "2026-12-31", "owner": "payments", "rotationInterval": "90d"}}
+func readKeyMetadata(filePath string) ([]KeyMetadata, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries map[string]KeyMetadata
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, err
+	}
+
+	metadata := make([]KeyMetadata, 0, len(entries))
+	for key, entry := range entries {
+		entry.Key = key
+		metadata = append(metadata, entry)
+	}
+	sort.Slice(metadata, func(i, j int) bool {
+		return metadata[i].Key < metadata[j].Key
+	})
+	return metadata, nil
+}
+
+// ListExpiringSecrets lists keys across all accessible repositories that expire or are due for rotation within days
+func ListExpiringSecrets(days int) {
+	jwt := retrieveJwt()
+
+	url := fmt.Sprintf("%s/secrets/expiring", getBackendURL())
+	if days > 0 {
+		url += "?days=" + strconv.Itoa(days)
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response ListExpiringSecretsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	if len(response.Secrets) == 0 {
+		fmt.Println("No keys expire or are due for rotation in that window")
+		return
+	}
+
+	fmt.Println("Keys expiring or due for rotation:")
+	for _, secret := range response.Secrets {
+		marker := "⏳"
+		if secret.Expired {
+			marker = "❌"
+		}
+
+		what := "expires"
+		if secret.Reason == "rotation" {
+			what = "rotation due (every " + secret.RotationInterval + ")"
+		}
+		if secret.Expired {
+			what = "expired"
+			if secret.Reason == "rotation" {
+				what = "rotation overdue (every " + secret.RotationInterval + ")"
+			}
+		}
+
+		fmt.Printf("%s %s/%s %s (%s v%d) %s\n", marker, secret.OwnerLogin, secret.RepoName,
+			path.Join(secret.Path, secret.FileName), versionLabel(SecretVersionInfo{Tag: secret.Tag, Branch: secret.Branch}), secret.Version, secret.Key)
+		fmt.Printf("     %s %s", what, secret.DueAt)
+		if secret.Owner != "" {
+			fmt.Printf(" - owner: %s", secret.Owner)
+		}
+		fmt.Println()
+	}
+}
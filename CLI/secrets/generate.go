@@ -0,0 +1,176 @@
+package secrets
+
+import (
+	"Envini-CLI/schema"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type GenerateSpec struct {
+	Key       string `json:"key"`
+	Type      string `json:"type"`
+	Length    int    `json:"length,omitempty"`
+	Charset   string `json:"charset,omitempty"`
+	PublicKey string `json:"publicKey,omitempty"`
+	SourceKey string `json:"sourceKey,omitempty"`
+	Cost      int    `json:"cost,omitempty"`
+}
+
+type GenerateOptions struct {
+	FileName   string
+	Path       string
+	Branch     string
+	Overwrite  bool
+	Annotation Annotation
+}
+
+type GenerateSecretValuesResponse struct {
+	Success          bool               `json:"success,omitempty"`
+	Version          int                `json:"version,omitempty"`
+	Checksum         string             `json:"checksum,omitempty"`
+	GeneratedKeys    []string           `json:"generatedKeys,omitempty"`
+	Violations       []schema.Violation `json:"violations,omitempty"`
+	Error            string             `json:"error,omitempty"`
+	ErrorDescription string             `json:"errorDescription,omitempty"`
+}
+
+// ParseGenerateSpec parses KEY=type[:options]:
+//
+//	KEY=random[:length[:charset]]   KEY=hex[:bytes]   KEY=base64[:bytes]   KEY=uuid
+//	KEY=ed25519[:publicKey]         KEY=rsa[:bits[:publicKey]]           KEY=bcrypt:SOURCE_KEY[:cost]
+func ParseGenerateSpec(arg string) (GenerateSpec, error) {
+	key, definition, ok := strings.Cut(arg, "=")
+	if !ok || key == "" || definition == "" {
+		return GenerateSpec{}, fmt.Errorf("expected KEY=type[:options], got %q", arg)
+	}
+
+	parts := strings.SplitN(definition, ":", 3)
+	spec := GenerateSpec{Key: key, Type: strings.ToLower(parts[0])}
+	option := func(i int) string {
+		if i < len(parts) {
+			return parts[i]
+		}
+		return ""
+	}
+	number := func(i int, name string) (int, error) {
+		if option(i) == "" {
+			return 0, nil
+		}
+		value, err := strconv.Atoi(option(i))
+		if err != nil || value <= 0 {
+			return 0, fmt.Errorf("%s: invalid %s %q", key, name, option(i))
+		}
+		return value, nil
+	}
+
+	var err error
+	switch spec.Type {
+	case "random":
+		spec.Length, err = number(1, "length")
+		spec.Charset = option(2)
+	case "hex", "base64":
+		spec.Length, err = number(1, "length")
+	case "uuid":
+	case "ed25519":
+		spec.PublicKey = option(1)
+	case "rsa":
+		spec.Length, err = number(1, "bits")
+		spec.PublicKey = option(2)
+	case "bcrypt":
+		spec.SourceKey = option(1)
+		if spec.SourceKey == "" {
+			return GenerateSpec{}, fmt.Errorf("%s: bcrypt needs the key to hash, e.g. %s=bcrypt:ADMIN_PASSWORD", key, key)
+		}
+		spec.Cost, err = number(2, "cost")
+	default:
+		return GenerateSpec{}, fmt.Errorf("%s: unsupported type %q", key, parts[0])
+	}
+	return spec, err
+}
+
+// GenerateSecretValues has the server create values and store them in a new version of tag; the values are never printed
+func GenerateSecretValues(ownerLogin string, repoName string, tag string, specs []GenerateSpec, opts GenerateOptions) {
+	jwt := retrieveJwt()
+
+	request := map[string]interface{}{
+		"tag":       tag,
+		"values":    specs,
+		"overwrite": opts.Overwrite,
+	}
+	if opts.FileName != "" {
+		request["fileName"] = opts.FileName
+	}
+	if opts.Path != "" {
+		request["path"] = opts.Path
+	}
+	if opts.Branch != "" {
+		request["branch"] = opts.Branch
+	}
+	if opts.Annotation != (Annotation{}) {
+		request["annotation"] = opts.Annotation
+	}
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		fmt.Printf("Failed to marshal request: %v\n", err)
+		os.Exit(1)
+	}
+
+	url := fmt.Sprintf("%s/secrets/generate/%s/%s", getBackendURL(), ownerLogin, repoName)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+	req.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response GenerateSecretValuesResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		schema.PrintViolations(response.Violations)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Generated %d value(s) into %s/%s\n", len(response.GeneratedKeys), ownerLogin, repoName)
+	fmt.Printf("   Version: %d\n", response.Version)
+	fmt.Printf("   Tag: %s\n", tag)
+	if opts.Branch != "" {
+		fmt.Printf("   Branch overlay: %s\n", opts.Branch)
+	}
+	fmt.Printf("   Keys: %s\n", strings.Join(response.GeneratedKeys, ", "))
+	if len(response.Violations) > 0 {
+		fmt.Println("   Schema warnings:")
+		schema.PrintViolations(response.Violations)
+	}
+}
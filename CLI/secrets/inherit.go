@@ -0,0 +1,135 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+type SetTagParentResponse struct {
+	Success          bool   `json:"success,omitempty"`
+	ChangeRequestID  uint64 `json:"changeRequestId,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"errorDescription,omitempty"`
+}
+
+type TagParentInfo struct {
+	Tag           string `json:"tag"`
+	ParentTag     string `json:"parentTag"`
+	ParentVersion int    `json:"parentVersion"`
+	FileName      string `json:"fileName"`
+	Path          string `json:"path"`
+}
+
+type KeyOriginInfo struct {
+	Key       string `json:"key"`
+	Tag       string `json:"tag"`
+	Version   int    `json:"version"`
+	Branch    string `json:"branch"`
+	SharedSet string `json:"sharedSet"`
+	Engine    string `json:"engine"`
+}
+
+// SetTagParent makes tag inherit every key it does not set itself from parentTag; an empty parentTag removes it
+func SetTagParent(ownerLogin string, repoName string, tag string, parentTag string, parentVersion int, fileName string, scopePath string) {
+	jwt := retrieveJwt()
+
+	request := map[string]interface{}{
+		"tag":           tag,
+		"parentTag":     parentTag,
+		"parentVersion": parentVersion,
+	}
+	if fileName != "" {
+		request["fileName"] = fileName
+	}
+	if scopePath != "" {
+		request["path"] = scopePath
+	}
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		fmt.Printf("Failed to marshal request: %v\n", err)
+		os.Exit(1)
+	}
+
+	url := fmt.Sprintf("%s/secrets/parent/%s/%s", getBackendURL(), ownerLogin, repoName)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+	req.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response SetTagParentResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	if response.ChangeRequestID != 0 {
+		fmt.Printf("⏳ Tag %s is protected or inherited by a protected tag: change request #%d awaits approval\n", tag, response.ChangeRequestID)
+		fmt.Printf("   Another collaborator can approve it with: envini changes approve %d\n", response.ChangeRequestID)
+		return
+	}
+
+	if parentTag == "" {
+		fmt.Printf("✅ Tag %s no longer inherits from another tag\n", tag)
+		return
+	}
+
+	fmt.Printf("✅ Tag %s now inherits from %s\n", tag, parentLabel(parentTag, parentVersion))
+}
+
+// parentLabel describes a parent tag and whether it is pinned
+func parentLabel(parentTag string, parentVersion int) string {
+	if parentVersion > 0 {
+		return fmt.Sprintf("%s (pinned to v%d)", parentTag, parentVersion)
+	}
+	return fmt.Sprintf("%s (latest)", parentTag)
+}
+
+// printKeyOrigins prints where each downloaded key was resolved from
+func printKeyOrigins(origins []KeyOriginInfo) {
+	fmt.Println("   Key origins:")
+	for _, origin := range origins {
+		source := fmt.Sprintf("%s v%d", origin.Tag, origin.Version)
+		if origin.SharedSet != "" {
+			source = fmt.Sprintf("shared set %s v%d", origin.SharedSet, origin.Version)
+		}
+		if origin.Engine != "" {
+			source = "secret engine " + origin.Engine
+		}
+		if origin.Branch != "" {
+			source += ", branch " + origin.Branch
+		}
+		fmt.Printf("     %s ← %s\n", origin.Key, source)
+	}
+}
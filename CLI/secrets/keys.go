@@ -0,0 +1,159 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+type SecretKeyInfo struct {
+	Key         string `json:"key"`
+	Length      int    `json:"length"`
+	Preview     string `json:"preview"`
+	Fingerprint string `json:"fingerprint"`
+	Tag         string `json:"tag"`
+	Branch      string `json:"branch"`
+}
+
+type ListSecretKeysResponse struct {
+	Keys             []SecretKeyInfo `json:"keys,omitempty"`
+	Tag              string          `json:"tag,omitempty"`
+	Version          int             `json:"version,omitempty"`
+	Branch           string          `json:"branch,omitempty"`
+	BranchVersion    int             `json:"branchVersion,omitempty"`
+	Error            string          `json:"error,omitempty"`
+	ErrorDescription string          `json:"errorDescription,omitempty"`
+}
+
+// KeyListOptions selects the version ListSecretKeys describes and the branch overlay applied to it;
+// CompareTag compares it with the latest version of another tag
+type KeyListOptions struct {
+	Tag        string
+	Version    int
+	Path       string
+	FileName   string
+	Branch     string
+	CompareTag string
+}
+
+// ListSecretKeys prints the keys of a version with their length, a masked preview and a fingerprint. It
+// never downloads values, so it only needs read access to the repository.
+func ListSecretKeys(ownerLogin string, repoName string, opts KeyListOptions) {
+	response := fetchSecretKeys(ownerLogin, repoName, opts.Tag, opts.Version, opts)
+
+	if opts.CompareTag != "" {
+		other := fetchSecretKeys(ownerLogin, repoName, opts.CompareTag, 0, opts)
+		printKeyComparison(response, other)
+		return
+	}
+
+	if response.Branch != "" {
+		fmt.Printf("Keys of %s version %d with branch %s version %d:\n", response.Tag, response.Version, response.Branch, response.BranchVersion)
+	} else {
+		fmt.Printf("Keys of %s version %d:\n", response.Tag, response.Version)
+	}
+	if len(response.Keys) == 0 {
+		fmt.Println("   No keys")
+	}
+	for _, key := range response.Keys {
+		fmt.Printf("   %-30s %-12s %4d chars  %s", key.Key, key.Preview, key.Length, key.Fingerprint)
+		if key.Branch != "" {
+			fmt.Printf("  (from branch %s)", key.Branch)
+		} else if key.Tag != "" {
+			fmt.Printf("  (from %s)", key.Tag)
+		}
+		fmt.Println()
+	}
+}
+
+// printKeyComparison lists every key of both versions and whether their values match, by fingerprint
+func printKeyComparison(left, right ListSecretKeysResponse) {
+	rightKeys := make(map[string]SecretKeyInfo, len(right.Keys))
+	for _, key := range right.Keys {
+		rightKeys[key.Key] = key
+	}
+
+	fmt.Printf("Comparing %s version %d with %s version %d:\n", left.Tag, left.Version, right.Tag, right.Version)
+	same := 0
+	for _, key := range left.Keys {
+		other, ok := rightKeys[key.Key]
+		delete(rightKeys, key.Key)
+		switch {
+		case !ok:
+			fmt.Printf("   - %-30s only in %s\n", key.Key, left.Tag)
+		case other.Fingerprint == key.Fingerprint:
+			same++
+			fmt.Printf("   = %-30s same value\n", key.Key)
+		default:
+			fmt.Printf("   ≠ %-30s different (%s vs %s)\n", key.Key, key.Preview, other.Preview)
+		}
+	}
+	for _, key := range right.Keys {
+		if _, ok := rightKeys[key.Key]; ok {
+			fmt.Printf("   + %-30s only in %s\n", key.Key, right.Tag)
+		}
+	}
+	fmt.Printf("%d of %d keys hold the same value\n", same, len(left.Keys))
+}
+
+func fetchSecretKeys(ownerLogin string, repoName string, tag string, version int, opts KeyListOptions) ListSecretKeysResponse {
+	jwt := retrieveJwt()
+
+	query := url.Values{}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if version > 0 {
+		query.Set("version", strconv.Itoa(version))
+	}
+	if opts.Path != "" {
+		query.Set("path", opts.Path)
+	}
+	if opts.FileName != "" {
+		query.Set("fileName", opts.FileName)
+	}
+	if opts.Branch != "" {
+		query.Set("branch", opts.Branch)
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/secrets/keys/%s/%s?%s", getBackendURL(), ownerLogin, repoName, query.Encode()), nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+	req.Header.Add("Authorization", "Bearer "+jwt)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response ListSecretKeysResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+	return response
+}
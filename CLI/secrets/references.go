@@ -0,0 +1,104 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+type SecretReferenceInfo struct {
+	OwnerLogin string `json:"ownerLogin"`
+	RepoName   string `json:"repoName"`
+	Path       string `json:"path"`
+	FileName   string `json:"fileName"`
+	Tag        string `json:"tag"`
+	Branch     string `json:"branch"`
+	Key        string `json:"key"`
+	TargetTag  string `json:"targetTag"`
+	TargetKey  string `json:"targetKey"`
+}
+
+type ListSecretReferencesResponse struct {
+	References       []SecretReferenceInfo `json:"references,omitempty"`
+	HiddenReferences int                   `json:"hiddenReferences,omitempty"`
+	Error            string                `json:"error,omitempty"`
+	ErrorDescription string                `json:"errorDescription,omitempty"`
+}
+
+// ListSecretReferences lists the repositories whose secrets reference keys of ownerLogin/repoName
+func ListSecretReferences(ownerLogin string, repoName string, key string, tag string) {
+	jwt := retrieveJwt()
+
+	params := []string{}
+	if key != "" {
+		params = append(params, "key="+neturl.QueryEscape(key))
+	}
+	if tag != "" {
+		params = append(params, "tag="+neturl.QueryEscape(tag))
+	}
+
+	url := fmt.Sprintf("%s/secrets/references/%s/%s", getBackendURL(), ownerLogin, repoName)
+	if len(params) > 0 {
+		url += "?" + strings.Join(params, "&")
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response ListSecretReferencesResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	fmt.Printf("References to %s/%s:\n", ownerLogin, repoName)
+	if len(response.References) == 0 {
+		fmt.Println("   No references found")
+	}
+
+	for _, reference := range response.References {
+		targetTag := reference.TargetTag
+		if targetTag == "" {
+			targetTag = "latest"
+		}
+		fmt.Printf("   %s@%s#%s\n", repoName, targetTag, reference.TargetKey)
+		fmt.Printf("     ← %s/%s %s (%s) %s\n", reference.OwnerLogin, reference.RepoName,
+			path.Join(reference.Path, reference.FileName), versionLabel(SecretVersionInfo{Tag: reference.Tag, Branch: reference.Branch}), reference.Key)
+	}
+
+	if response.HiddenReferences > 0 {
+		fmt.Printf("   + %d reference(s) from repositories you cannot access\n", response.HiddenReferences)
+	}
+}
@@ -0,0 +1,95 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+)
+
+type SecretKeyMatch struct {
+	OwnerLogin string `json:"ownerLogin"`
+	RepoName   string `json:"repoName"`
+	Path       string `json:"path"`
+	FileName   string `json:"fileName"`
+	Branch     string `json:"branch"`
+	Tag        string `json:"tag"`
+	Version    int    `json:"version"`
+	Key        string `json:"key"`
+}
+
+type SearchSecretKeysResponse struct {
+	Matches          []SecretKeyMatch `json:"matches,omitempty"`
+	Truncated        bool             `json:"truncated,omitempty"`
+	Error            string           `json:"error,omitempty"`
+	ErrorDescription string           `json:"errorDescription,omitempty"`
+}
+
+// SearchSecretKeys searches key names in the latest versions of every accessible repository. mode is
+// prefix, substring or regex, empty for the server default (substring); limit 0 uses the server default.
+func SearchSecretKeys(query string, mode string, limit int) {
+	jwt := retrieveJwt()
+
+	params := url.Values{}
+	params.Set("q", query)
+	if mode != "" {
+		params.Set("mode", mode)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/secrets/search/keys?%s", getBackendURL(), params.Encode()), nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+	req.Header.Add("Authorization", "Bearer "+jwt)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response SearchSecretKeysResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	if len(response.Matches) == 0 {
+		fmt.Printf("No keys match %s\n", query)
+		return
+	}
+
+	fmt.Printf("Keys matching %s:\n", query)
+	for _, match := range response.Matches {
+		fmt.Printf("   %s/%s %s (%s v%d) %s\n", match.OwnerLogin, match.RepoName,
+			path.Join(match.Path, match.FileName), versionLabel(SecretVersionInfo{Tag: match.Tag, Branch: match.Branch}), match.Version, match.Key)
+	}
+	if response.Truncated {
+		fmt.Printf("Only the first %d matches are shown, narrow the query or raise --limit\n", len(response.Matches))
+	}
+}
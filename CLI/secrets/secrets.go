@@ -1,14 +1,20 @@
 package secrets
 
 import (
+	"Envini-CLI/lint"
+	"Envini-CLI/schema"
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 func getBackendURL() string {
@@ -30,11 +36,14 @@ type UploadSecretRequest struct {
 }
 
 type UploadSecretResponse struct {
-	Success          bool   `json:"success,omitempty"`
-	SecretID         int64  `json:"secretId,omitempty"`
-	Version          int    `json:"version,omitempty"`
-	Error            string `json:"error,omitempty"`
-	ErrorDescription string `json:"errorDescription,omitempty"`
+	Success          bool               `json:"success,omitempty"`
+	SecretID         int64              `json:"secretId,omitempty"`
+	Version          int                `json:"version,omitempty"`
+	Violations       []schema.Violation `json:"violations,omitempty"`
+	LintFindings     []lint.Finding     `json:"lintFindings,omitempty"`
+	ChangeRequestID  uint64             `json:"changeRequestId,omitempty"`
+	Error            string             `json:"error,omitempty"`
+	ErrorDescription string             `json:"errorDescription,omitempty"`
 }
 
 type DeleteSecretRequest struct {
@@ -68,18 +77,40 @@ type ListSecretVersionsRequest struct {
 }
 
 type SecretVersionInfo struct {
-	Version     int    `json:"version"`
-	Tag         string `json:"tag"`
-	Checksum    string `json:"checksum"`
-	UploadedBy  string `json:"uploadedBy"`
-	CreatedAt   string `json:"createdAt"`
-	IsEncrypted bool   `json:"isEncrypted"`
+	Version     int         `json:"version"`
+	Path        string      `json:"path"`
+	FileName    string      `json:"fileName"`
+	Branch      string      `json:"branch"`
+	Tag         string      `json:"tag"`
+	Checksum    string      `json:"checksum"`
+	UploadedBy  string      `json:"uploadedBy"`
+	CreatedAt   string      `json:"createdAt"`
+	IsEncrypted bool        `json:"isEncrypted"`
+	Annotation  *Annotation `json:"annotation,omitempty"`
+}
+
+// Annotation describes why and from where a version was created
+type Annotation struct {
+	Message       string `json:"message,omitempty"`
+	CommitSHA     string `json:"commitSha,omitempty"`
+	GitBranch     string `json:"gitBranch,omitempty"`
+	ClientType    string `json:"clientType,omitempty"`
+	ClientVersion string `json:"clientVersion,omitempty"`
+}
+
+type SecretFileVersionsInfo struct {
+	Path     string              `json:"path"`
+	FileName string              `json:"fileName"`
+	Versions []SecretVersionInfo `json:"versions"`
 }
 
 type ListSecretVersionsResponse struct {
-	Versions         []SecretVersionInfo `json:"versions,omitempty"`
-	Error            string              `json:"error,omitempty"`
-	ErrorDescription string              `json:"errorDescription,omitempty"`
+	Versions         []SecretVersionInfo      `json:"versions,omitempty"`
+	Files            []SecretFileVersionsInfo `json:"files,omitempty"`
+	Branches         []string                 `json:"branches,omitempty"`
+	TagParents       []TagParentInfo          `json:"tagParents,omitempty"`
+	Error            string                   `json:"error,omitempty"`
+	ErrorDescription string                   `json:"errorDescription,omitempty"`
 }
 
 func retrieveJwt() string {
@@ -98,7 +129,32 @@ func retrieveJwt() string {
 	return authData.Jwt
 }
 
-func UploadSecret(ownerLogin string, repoName string, tag string, filePath string) {
+// DetectFormat guesses the upload format from the file extension
+func DetectFormat(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".properties":
+		return "properties"
+	default:
+		return "dotenv"
+	}
+}
+
+// UploadOptions controls how an uploaded file is parsed and where it is stored
+type UploadOptions struct {
+	Format       string
+	KeySeparator string
+	FileName     string
+	Path         string
+	Branch       string
+	MetadataFile string // JSON file with per-key expiry, owner and rotation interval
+	Annotation   Annotation
+}
+
+func UploadSecret(ownerLogin string, repoName string, tag string, filePath string, opts UploadOptions) {
 	jwt := retrieveJwt()
 
 	// Read file content
@@ -108,10 +164,39 @@ func UploadSecret(ownerLogin string, repoName string, tag string, filePath strin
 		os.Exit(1)
 	}
 
+	format := opts.Format
+	if format == "" {
+		format = DetectFormat(filePath)
+	}
+
 	// Prepare request - encode content as base64 like WebApp does
-	request := map[string]string{
+	request := map[string]interface{}{
 		"tag":            tag,
 		"envFileContent": base64.StdEncoding.EncodeToString(content),
+		"format":         format,
+	}
+	if opts.KeySeparator != "" {
+		request["keySeparator"] = opts.KeySeparator
+	}
+	if opts.FileName != "" {
+		request["fileName"] = opts.FileName
+	}
+	if opts.Path != "" {
+		request["path"] = opts.Path
+	}
+	if opts.Branch != "" {
+		request["branch"] = opts.Branch
+	}
+	if opts.MetadataFile != "" {
+		keyMetadata, err := readKeyMetadata(opts.MetadataFile)
+		if err != nil {
+			fmt.Printf("Failed to read metadata %s: %v\n", opts.MetadataFile, err)
+			os.Exit(1)
+		}
+		request["keyMetadata"] = keyMetadata
+	}
+	if opts.Annotation != (Annotation{}) {
+		request["annotation"] = opts.Annotation
 	}
 
 	requestBody, err := json.Marshal(request)
@@ -157,16 +242,41 @@ func UploadSecret(ownerLogin string, repoName string, tag string, filePath strin
 			fmt.Printf(" - %s", response.ErrorDescription)
 		}
 		fmt.Println()
+		schema.PrintViolations(response.Violations)
+		lint.PrintFindings(response.LintFindings)
 		os.Exit(1)
 	}
 
-	fmt.Printf("✅ Secret uploaded successfully!\n")
-	fmt.Printf("   Secret ID: %d\n", response.SecretID)
-	fmt.Printf("   Version: %d\n", response.Version)
-	fmt.Printf("   Tag: %s\n", tag)
+	if response.ChangeRequestID != 0 {
+		fmt.Printf("⏳ Tag %s is protected or inherited by a protected tag: change request #%d awaits approval\n", tag, response.ChangeRequestID)
+		fmt.Printf("   Another collaborator can approve it with: envini changes approve %d\n", response.ChangeRequestID)
+	} else {
+		fmt.Printf("✅ Secret uploaded successfully!\n")
+		fmt.Printf("   Secret ID: %d\n", response.SecretID)
+		fmt.Printf("   Version: %d\n", response.Version)
+		fmt.Printf("   Tag: %s\n", tag)
+		fmt.Printf("   Format: %s\n", format)
+		if opts.FileName != "" {
+			fmt.Printf("   File: %s\n", opts.FileName)
+		}
+		if opts.Path != "" {
+			fmt.Printf("   Path: %s\n", opts.Path)
+		}
+		if opts.Branch != "" {
+			fmt.Printf("   Branch overlay: %s\n", opts.Branch)
+		}
+	}
+	if len(response.Violations) > 0 {
+		fmt.Println("   Schema warnings:")
+		schema.PrintViolations(response.Violations)
+	}
+	if len(response.LintFindings) > 0 {
+		fmt.Println("   Lint warnings:")
+		lint.PrintFindings(response.LintFindings)
+	}
 }
 
-func DeleteSecret(ownerLogin string, repoName string, version int, tag string) {
+func DeleteSecret(ownerLogin string, repoName string, version int, tag string, fileName string, scopePath string, branch string) {
 	jwt := retrieveJwt()
 
 	// Make request - build URL with version and/or tag parameters like WebApp
@@ -185,6 +295,16 @@ func DeleteSecret(ownerLogin string, repoName string, version int, tag string) {
 		params = append(params, "tag=development")
 	}
 
+	if fileName != "" {
+		params = append(params, "fileName="+neturl.QueryEscape(fileName))
+	}
+	if scopePath != "" {
+		params = append(params, "path="+neturl.QueryEscape(scopePath))
+	}
+	if branch != "" {
+		params = append(params, "branch="+neturl.QueryEscape(branch))
+	}
+
 	url = fmt.Sprintf("%s/secrets/delete/%s/%s?%s", getBackendURL(), ownerLogin, repoName, strings.Join(params, "&"))
 	req, err := http.NewRequest("DELETE", url, nil)
 	if err != nil {
@@ -226,7 +346,20 @@ func DeleteSecret(ownerLogin string, repoName string, version int, tag string) {
 	fmt.Printf("✅ Secret version %d deleted successfully!\n", version)
 }
 
-func DownloadSecret(ownerLogin string, repoName string, version int, tag string, outputPath string) {
+// DownloadOptions controls how a downloaded secret is rendered
+type DownloadOptions struct {
+	Path         string
+	FileName     string
+	Branch       string // Overlay applied on top of the tag's values when it exists
+	Format       string
+	ResourceName string
+	Namespace    string
+	Origins      bool          // Print which tag, version and branch each key came from
+	Interpolate  bool          // Expand ${VAR} references between keys
+	LeaseTTL     time.Duration // TTL of credentials issued by secret engines, the engine default when zero
+}
+
+func DownloadSecret(ownerLogin string, repoName string, version int, tag string, outputPath string, opts DownloadOptions) {
 	jwt := retrieveJwt()
 
 	// Make request - build URL with version and/or tag parameters like WebApp
@@ -245,6 +378,34 @@ func DownloadSecret(ownerLogin string, repoName string, version int, tag string,
 		params = append(params, "tag=development")
 	}
 
+	if opts.Path != "" {
+		params = append(params, "path="+neturl.QueryEscape(opts.Path))
+	}
+	if opts.FileName != "" {
+		params = append(params, "fileName="+neturl.QueryEscape(opts.FileName))
+	}
+	if opts.Branch != "" {
+		params = append(params, "branch="+neturl.QueryEscape(opts.Branch))
+	}
+	if opts.Origins {
+		params = append(params, "origins=true")
+	}
+	if opts.Interpolate {
+		params = append(params, "interpolate=true")
+	}
+	if opts.LeaseTTL > 0 {
+		params = append(params, fmt.Sprintf("leaseTtl=%d", int(opts.LeaseTTL.Seconds())))
+	}
+	if opts.Format != "" {
+		params = append(params, "format="+neturl.QueryEscape(opts.Format))
+	}
+	if opts.ResourceName != "" {
+		params = append(params, "name="+neturl.QueryEscape(opts.ResourceName))
+	}
+	if opts.Namespace != "" {
+		params = append(params, "namespace="+neturl.QueryEscape(opts.Namespace))
+	}
+
 	url = fmt.Sprintf("%s/secrets/download/%s/%s?%s", getBackendURL(), ownerLogin, repoName, strings.Join(params, "&"))
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -308,18 +469,69 @@ func DownloadSecret(ownerLogin string, repoName string, version int, tag string,
 	// Get metadata from headers
 	secretVersion := resp.Header.Get("X-Secret-Version")
 	secretTag := resp.Header.Get("X-Secret-Tag")
+	secretFormat := resp.Header.Get("X-Secret-Format")
 
 	fmt.Printf("✅ Secret downloaded successfully!\n")
 	fmt.Printf("   Version: %s\n", secretVersion)
 	fmt.Printf("   Tag: %s\n", secretTag)
+	if secretFormat != "" {
+		fmt.Printf("   Format: %s\n", secretFormat)
+	}
+	if secretPath := resp.Header.Get("X-Secret-Path"); secretPath != "" {
+		fmt.Printf("   Path: %s\n", secretPath)
+	}
+	if secretBranch := resp.Header.Get("X-Secret-Branch"); secretBranch != "" {
+		fmt.Printf("   Branch overlay: %s (v%s)\n", secretBranch, resp.Header.Get("X-Secret-BranchVersion"))
+	}
 	fmt.Printf("   Saved to: %s\n", outputPath)
+
+	if encoded := resp.Header.Get("X-Secret-Origins"); encoded != "" {
+		var origins []KeyOriginInfo
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err == nil {
+			err = json.Unmarshal(decoded, &origins)
+		}
+		if err != nil {
+			fmt.Printf("Failed to parse key origins: %v\n", err)
+			os.Exit(1)
+		}
+		printKeyOrigins(origins)
+	}
+
+	if encoded := resp.Header.Get("X-Secret-Leases"); encoded != "" {
+		var leases []LeaseInfo
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err == nil {
+			err = json.Unmarshal(decoded, &leases)
+		}
+		if err != nil {
+			fmt.Printf("Failed to parse leases: %v\n", err)
+			os.Exit(1)
+		}
+		printLeases(leases)
+	}
 }
 
-func ListSecretVersions(ownerLogin string, repoName string) {
+// ListSecretVersions lists versions; a nil scopePath lists every monorepo path
+func ListSecretVersions(ownerLogin string, repoName string, fileName string, groupByFile bool, scopePath *string) {
 	jwt := retrieveJwt()
 
 	// Make request
+	params := []string{}
+	if scopePath != nil {
+		params = append(params, "path="+neturl.QueryEscape(*scopePath))
+	}
+	if fileName != "" {
+		params = append(params, "fileName="+neturl.QueryEscape(fileName))
+	}
+	if groupByFile {
+		params = append(params, "groupByFile=true")
+	}
+
 	url := fmt.Sprintf("%s/secrets/versions/%s/%s", getBackendURL(), ownerLogin, repoName)
+	if len(params) > 0 {
+		url += "?" + strings.Join(params, "&")
+	}
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		fmt.Printf("Failed to create request: %v\n", err)
@@ -362,10 +574,69 @@ func ListSecretVersions(ownerLogin string, repoName string) {
 		fmt.Println("   No versions found")
 		return
 	}
+	if len(response.Branches) > 0 {
+		fmt.Printf("   Branch overlays: %s\n\n", strings.Join(response.Branches, ", "))
+	}
+	if len(response.TagParents) > 0 {
+		fmt.Println("   Tag inheritance:")
+		for _, parent := range response.TagParents {
+			fmt.Printf("     %s: %s → %s\n", path.Join(parent.Path, parent.FileName), parent.Tag, parentLabel(parent.ParentTag, parent.ParentVersion))
+		}
+		fmt.Println()
+	}
+
+	if groupByFile {
+		for _, file := range response.Files {
+			fmt.Printf("   📄 %s\n", path.Join(file.Path, file.FileName))
+			for _, version := range file.Versions {
+				fmt.Printf("     v%d (%s) - %s\n", version.Version, versionLabel(version), version.CreatedAt)
+				fmt.Printf("       Checksum: %s\n", version.Checksum)
+				printVersionDetails(version, "       ")
+			}
+			fmt.Println()
+		}
+		return
+	}
 
 	for _, version := range response.Versions {
-		fmt.Printf("   v%d (%s) - %s\n", version.Version, version.Tag, version.CreatedAt)
+		fmt.Printf("   v%d (%s) %s - %s\n", version.Version, versionLabel(version), path.Join(version.Path, version.FileName), version.CreatedAt)
 		fmt.Printf("     Checksum: %s\n", version.Checksum)
+		printVersionDetails(version, "     ")
 		fmt.Println()
 	}
 }
+
+// printVersionDetails prints who created a version and its annotation
+func printVersionDetails(version SecretVersionInfo, indent string) {
+	if version.UploadedBy != "" {
+		fmt.Printf("%sUploaded by: %s\n", indent, version.UploadedBy)
+	}
+	annotation := version.Annotation
+	if annotation == nil {
+		return
+	}
+	if annotation.Message != "" {
+		fmt.Printf("%sMessage: %s\n", indent, annotation.Message)
+	}
+	if annotation.CommitSHA != "" {
+		commit := annotation.CommitSHA
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		if annotation.GitBranch != "" {
+			commit += " on " + annotation.GitBranch
+		}
+		fmt.Printf("%sCommit: %s\n", indent, commit)
+	}
+	if annotation.ClientType != "" {
+		fmt.Printf("%sClient: %s %s\n", indent, annotation.ClientType, annotation.ClientVersion)
+	}
+}
+
+// versionLabel shows the tag, and the branch for branch overlay versions
+func versionLabel(version SecretVersionInfo) string {
+	if version.Branch == "" {
+		return version.Tag
+	}
+	return fmt.Sprintf("%s, branch %s", version.Tag, version.Branch)
+}
@@ -0,0 +1,183 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ShareOptions selects what a share link hands out and how often
+type ShareOptions struct {
+	Tag       string
+	Version   int
+	Path      string
+	FileName  string // Secret file, or the file secret's name when File is set
+	Key       string // Share a single key instead of the whole version
+	File      bool   // Share a file secret pushed with `envini file push`
+	MaxViews  int
+	ExpiresIn time.Duration
+	Branch    string // Branch overlay applied to a shared version
+}
+
+type ShareLinkResponse struct {
+	Success          bool   `json:"success,omitempty"`
+	LinkID           string `json:"linkId,omitempty"`
+	Token            string `json:"token,omitempty"`
+	ExpiresAt        string `json:"expiresAt,omitempty"`
+	MaxViews         int    `json:"maxViews,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"errorDescription,omitempty"`
+}
+
+// CreateShareLink snapshots a key, version or file into a one-time link for someone without repository
+// access. The token is the only way to open the link and is only shown once.
+func CreateShareLink(ownerLogin string, repoName string, opts ShareOptions) {
+	jwt := retrieveJwt()
+
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"tag":              opts.Tag,
+		"version":          opts.Version,
+		"path":             opts.Path,
+		"fileName":         opts.FileName,
+		"key":              opts.Key,
+		"file":             opts.File,
+		"maxViews":         opts.MaxViews,
+		"expiresInSeconds": int64(opts.ExpiresIn / time.Second),
+		"branch":           opts.Branch,
+	})
+	if err != nil {
+		fmt.Printf("Failed to marshal request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/secrets/share/%s/%s", getBackendURL(), ownerLogin, repoName), bytes.NewBuffer(requestBody))
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+	req.Header.Add("Authorization", "Bearer "+jwt)
+	req.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response ShareLinkResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	views := "once"
+	if response.MaxViews > 1 {
+		views = fmt.Sprintf("%d times", response.MaxViews)
+	}
+	fmt.Printf("🔗 Share link %s created, it can be opened %s until %s\n", response.LinkID, views, response.ExpiresAt)
+	fmt.Printf("   Token: %s\n", response.Token)
+	fmt.Println("   Store it now, it is not shown again")
+	fmt.Printf("   Open with: envini share open <token>\n")
+	fmt.Printf("   or: curl -X POST %s/secrets/share/redeem -H 'Content-Type: application/json' -d '{\"token\":\"<token>\"}'\n", getBackendURL())
+}
+
+// RedeemShareLink opens a share link without logging in. Keys and versions are printed unless outputPath
+// is set; files are written to outputPath or their own name. Status lines go to stderr so the content can
+// be piped.
+func RedeemShareLink(token string, outputPath string) {
+	requestBody, err := json.Marshal(map[string]string{"token": token})
+	if err != nil {
+		fmt.Printf("Failed to marshal request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req, err := http.NewRequest("POST", getBackendURL()+"/secrets/share/redeem", bytes.NewBuffer(requestBody))
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var response ShareLinkResponse
+		if err := json.Unmarshal(body, &response); err != nil || response.Error == "" {
+			fmt.Printf("Error: redeeming failed with status %d\n", resp.StatusCode)
+			os.Exit(1)
+		}
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	kind := resp.Header.Get("X-Share-Kind")
+	if kind == "file" && outputPath == "" {
+		outputPath = filepath.Base(resp.Header.Get("X-Share-FileName"))
+	}
+
+	if outputPath == "" {
+		os.Stdout.Write(body)
+		if kind == "key" && !strings.HasSuffix(string(body), "\n") {
+			fmt.Println()
+		}
+	} else {
+		if err := os.WriteFile(outputPath, body, 0600); err != nil {
+			fmt.Printf("Failed to write %s: %v\n", outputPath, err)
+			os.Exit(1)
+		}
+	}
+
+	shared := kind
+	if key := resp.Header.Get("X-Share-Key"); key != "" {
+		shared = "key " + key
+	}
+	fmt.Fprintf(os.Stderr, "✅ Opened %s shared by %s", shared, resp.Header.Get("X-Share-SharedBy"))
+	if outputPath != "" {
+		fmt.Fprintf(os.Stderr, ", saved to %s", outputPath)
+	}
+	fmt.Fprintln(os.Stderr)
+	if remaining := resp.Header.Get("X-Share-RemainingViews"); remaining != "" && remaining != "0" {
+		fmt.Fprintf(os.Stderr, "   %s views left until %s\n", remaining, resp.Header.Get("X-Share-ExpiresAt"))
+	} else {
+		fmt.Fprintln(os.Stderr, "   The link is used up")
+	}
+}
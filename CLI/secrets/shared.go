@@ -0,0 +1,252 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+type SharedSecretSetVersionInfo struct {
+	Version    int    `json:"version"`
+	Checksum   string `json:"checksum"`
+	UploadedBy string `json:"uploadedBy"`
+	CreatedAt  string `json:"createdAt"`
+}
+
+type SharedSecretSetAttachmentInfo struct {
+	OwnerLogin    string `json:"ownerLogin"`
+	RepoName      string `json:"repoName"`
+	PinnedVersion int    `json:"pinnedVersion"`
+	AttachedBy    string `json:"attachedBy"`
+}
+
+type SharedSecretSetInfo struct {
+	OrgLogin    string                          `json:"orgLogin"`
+	Name        string                          `json:"name"`
+	CreatedBy   string                          `json:"createdBy"`
+	CreatedAt   string                          `json:"createdAt"`
+	Versions    []SharedSecretSetVersionInfo    `json:"versions"`
+	Attachments []SharedSecretSetAttachmentInfo `json:"attachments"`
+}
+
+type ListSharedSecretSetsResponse struct {
+	Sets             []SharedSecretSetInfo `json:"sets,omitempty"`
+	Error            string                `json:"error,omitempty"`
+	ErrorDescription string                `json:"errorDescription,omitempty"`
+}
+
+type AttachSharedSecretSetResponse struct {
+	Success          bool   `json:"success,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"errorDescription,omitempty"`
+}
+
+// PushSharedSecretSet uploads a new version of an organization's shared secret set
+func PushSharedSecretSet(orgLogin string, setName string, filePath string, opts UploadOptions) {
+	jwt := retrieveJwt()
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		fmt.Printf("Failed to read file %s: %v\n", filePath, err)
+		os.Exit(1)
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = DetectFormat(filePath)
+	}
+
+	request := map[string]string{
+		"envFileContent": base64.StdEncoding.EncodeToString(content),
+		"format":         format,
+	}
+	if opts.KeySeparator != "" {
+		request["keySeparator"] = opts.KeySeparator
+	}
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		fmt.Printf("Failed to marshal request: %v\n", err)
+		os.Exit(1)
+	}
+
+	url := fmt.Sprintf("%s/secrets/shared/%s/%s", getBackendURL(), orgLogin, setName)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+	req.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response UploadSecretResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Shared secret set %s/%s uploaded as version %d\n", orgLogin, setName, response.Version)
+}
+
+// ListSharedSecretSets lists an organization's shared secret sets with their versions and attached repositories
+func ListSharedSecretSets(orgLogin string) {
+	jwt := retrieveJwt()
+
+	url := fmt.Sprintf("%s/secrets/shared/%s", getBackendURL(), orgLogin)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response ListSharedSecretSetsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	fmt.Printf("Shared secret sets of %s:\n", orgLogin)
+	if len(response.Sets) == 0 {
+		fmt.Println("   No shared secret sets found")
+	}
+
+	for _, set := range response.Sets {
+		fmt.Printf("\n🔗 %s\n", set.Name)
+		for _, version := range set.Versions {
+			fmt.Printf("   v%d by %s - %s\n", version.Version, version.UploadedBy, version.CreatedAt)
+			fmt.Printf("     Checksum: %s\n", version.Checksum)
+		}
+		if len(set.Attachments) == 0 {
+			fmt.Println("   Not attached to any repository")
+			continue
+		}
+		fmt.Println("   Attached to:")
+		for _, attachment := range set.Attachments {
+			fmt.Printf("     %s/%s %s\n", attachment.OwnerLogin, attachment.RepoName, sharedVersionLabel(attachment.PinnedVersion))
+		}
+	}
+}
+
+// AttachSharedSecretSet merges a shared secret set into the downloads of a repository, or removes it with detach
+func AttachSharedSecretSet(orgLogin string, setName string, ownerLogin string, repoName string, version int, detach bool) {
+	jwt := retrieveJwt()
+
+	url := fmt.Sprintf("%s/secrets/shared/%s/%s/attach/%s/%s", getBackendURL(), orgLogin, setName, ownerLogin, repoName)
+
+	var req *http.Request
+	var err error
+	if detach {
+		req, err = http.NewRequest("DELETE", url, nil)
+	} else {
+		var requestBody []byte
+		requestBody, err = json.Marshal(map[string]int{"version": version})
+		if err != nil {
+			fmt.Printf("Failed to marshal request: %v\n", err)
+			os.Exit(1)
+		}
+		req, err = http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	}
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req.Header.Add("Authorization", "Bearer "+jwt)
+	req.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response AttachSharedSecretSetResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+
+	if detach {
+		fmt.Printf("✅ Shared secret set %s/%s detached from %s/%s\n", orgLogin, setName, ownerLogin, repoName)
+		return
+	}
+	fmt.Printf("✅ Shared secret set %s/%s attached to %s/%s %s\n", orgLogin, setName, ownerLogin, repoName, sharedVersionLabel(version))
+}
+
+// sharedVersionLabel describes whether an attachment is pinned
+func sharedVersionLabel(version int) string {
+	if version > 0 {
+		return fmt.Sprintf("(pinned to v%d)", version)
+	}
+	return "(latest)"
+}
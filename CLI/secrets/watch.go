@@ -0,0 +1,196 @@
+package secrets
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Reconnect backoff of Watch after the stream dropped
+const (
+	watchRetry    = time.Second
+	watchMaxRetry = 30 * time.Second
+)
+
+// WatchEvent is a server-sent event of the watch stream
+type WatchEvent struct {
+	Type             string `json:"type"`
+	ResumeToken      string `json:"resumeToken,omitempty"`
+	OwnerLogin       string `json:"ownerLogin,omitempty"`
+	RepoName         string `json:"repoName,omitempty"`
+	Tag              string `json:"tag,omitempty"`
+	Version          int    `json:"version,omitempty"`
+	Path             string `json:"path,omitempty"`
+	FileName         string `json:"fileName,omitempty"`
+	Branch           string `json:"branch,omitempty"`
+	File             bool   `json:"file,omitempty"`
+	Checksum         string `json:"checksum,omitempty"`
+	DeletedVersions  int    `json:"deletedVersions,omitempty"`
+	Actor            string `json:"actor,omitempty"`
+	CreatedAt        string `json:"createdAt,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"errorDescription,omitempty"`
+}
+
+// Watch prints new and deleted versions of the repositories (owner/repo) until interrupted. The stream is
+// resumed from the last event after a disconnect, so no event is missed; resumeToken continues an
+// earlier watch.
+func Watch(repositories []string, tags []string, resumeToken string, jsonOutput bool) {
+	retry := watchRetry
+	ready := false
+	for {
+		token, received, err := watchOnce(repositories, tags, resumeToken, jsonOutput, !ready)
+		if token != "" {
+			resumeToken = token
+		}
+		if received {
+			ready = true
+			retry = watchRetry
+		}
+		if !jsonOutput {
+			fmt.Fprintf(os.Stderr, "⚠️  Watch interrupted (%v), reconnecting in %s\n", err, retry)
+		}
+		time.Sleep(retry)
+		if retry *= 2; retry > watchMaxRetry {
+			retry = watchMaxRetry
+		}
+	}
+}
+
+// watchOnce reads one connection until it ends. It returns the last resume token and whether the server
+// got to the ready event; error events exit.
+func watchOnce(repositories []string, tags []string, resumeToken string, jsonOutput bool, announce bool) (string, bool, error) {
+	jwt := retrieveJwt()
+
+	query := url.Values{}
+	query.Set("repos", strings.Join(repositories, ","))
+	if len(tags) > 0 {
+		query.Set("tags", strings.Join(tags, ","))
+	}
+	if resumeToken != "" {
+		query.Set("resumeToken", resumeToken)
+	}
+
+	req, err := http.NewRequest("GET", getBackendURL()+"/secrets/watch?"+query.Encode(), nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+	req.Header.Add("Authorization", "Bearer "+jwt)
+	req.Header.Add("Accept", "text/event-stream")
+
+	// No timeout: the stream stays open, heartbeats arrive every 30 seconds
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return resumeToken, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var response struct {
+			Message interface{} `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&response)
+		fmt.Printf("Error: watch request failed with status %d: %v\n", resp.StatusCode, response.Message)
+		os.Exit(1)
+	}
+
+	ready := false
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "data:") {
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+
+		// A blank line ends the event
+		var event WatchEvent
+		err := json.Unmarshal([]byte(data.String()), &event)
+		data.Reset()
+		if err != nil {
+			return resumeToken, ready, fmt.Errorf("malformed event: %v", err)
+		}
+		if event.ResumeToken != "" {
+			resumeToken = event.ResumeToken
+		}
+
+		switch event.Type {
+		case "error":
+			fmt.Printf("Error: %s", event.Error)
+			if event.ErrorDescription != "" {
+				fmt.Printf(" - %s", event.ErrorDescription)
+			}
+			fmt.Println()
+			os.Exit(1)
+		case "lagged":
+			return resumeToken, ready, fmt.Errorf("fell behind the server")
+		case "heartbeat":
+		case "ready":
+			ready = true
+			if announce && !jsonOutput {
+				scope := "every tag"
+				if len(tags) > 0 {
+					scope = "tags " + strings.Join(tags, ", ")
+				}
+				fmt.Printf("👀 Watching %s (%s), press Ctrl+C to stop\n", strings.Join(repositories, ", "), scope)
+			}
+		default:
+			printWatchEvent(&event, jsonOutput)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return resumeToken, ready, err
+	}
+	return resumeToken, ready, fmt.Errorf("stream closed")
+}
+
+func printWatchEvent(event *WatchEvent, jsonOutput bool) {
+	if jsonOutput {
+		line, _ := json.Marshal(event)
+		fmt.Println(string(line))
+		return
+	}
+
+	target := event.FileName
+	if event.Path != "" {
+		target = event.Path + "/" + target
+	}
+	if event.File {
+		target = "file " + target
+	}
+	if event.Branch != "" {
+		target += " on branch " + event.Branch
+	}
+
+	tag := event.Tag
+	if tag == "" {
+		tag = "every tag"
+	}
+
+	switch event.Type {
+	case "version_created":
+		fmt.Printf("🆕 %s %s/%s %s v%d of %s by %s\n", event.CreatedAt, event.OwnerLogin, event.RepoName, tag, event.Version, target, event.Actor)
+	case "versions_deleted":
+		version := "every version"
+		if event.Version != 0 {
+			version = fmt.Sprintf("v%d", event.Version)
+		}
+		fmt.Printf("🗑️  %s %s/%s %s %s of %s deleted by %s (%d versions)\n", event.CreatedAt, event.OwnerLogin, event.RepoName, tag, version, target, event.Actor, event.DeletedVersions)
+	default:
+		fmt.Printf("%s %s/%s %s\n", event.CreatedAt, event.OwnerLogin, event.RepoName, event.Type)
+	}
+	fmt.Printf("   Resume token: %s\n", event.ResumeToken)
+}
@@ -0,0 +1,277 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+type WebhookInfo struct {
+	ID         uint64   `json:"id"`
+	OwnerLogin string   `json:"ownerLogin"`
+	RepoName   string   `json:"repoName"`
+	OrgLogin   string   `json:"orgLogin"`
+	URL        string   `json:"url"`
+	Events     []string `json:"events"`
+	Active     bool     `json:"active"`
+	CreatedBy  string   `json:"createdBy"`
+	CreatedAt  string   `json:"createdAt"`
+	UpdatedAt  string   `json:"updatedAt"`
+}
+
+type WebhookDeliveryInfo struct {
+	ID             uint64 `json:"id"`
+	DeliveryID     string `json:"deliveryId"`
+	Event          string `json:"event"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	LastStatusCode int    `json:"lastStatusCode"`
+	LastError      string `json:"lastError"`
+	CreatedAt      string `json:"createdAt"`
+	NextAttemptAt  string `json:"nextAttemptAt"`
+	DeliveredAt    string `json:"deliveredAt"`
+	RedeliveryOf   uint64 `json:"redeliveryOf"`
+}
+
+type WebhookResponse struct {
+	Success          bool                  `json:"success,omitempty"`
+	WebhookID        uint64                `json:"webhookId,omitempty"`
+	DeliveryID       uint64                `json:"deliveryId,omitempty"`
+	Secret           string                `json:"secret,omitempty"`
+	Webhooks         []WebhookInfo         `json:"webhooks,omitempty"`
+	Deliveries       []WebhookDeliveryInfo `json:"deliveries,omitempty"`
+	Error            string                `json:"error,omitempty"`
+	ErrorDescription string                `json:"errorDescription,omitempty"`
+}
+
+// WebhookUpdate holds the changes made by UpdateWebhook; nil and empty fields are left unchanged
+type WebhookUpdate struct {
+	URL          *string  `json:"url,omitempty"`
+	Events       []string `json:"events,omitempty"`
+	Active       *bool    `json:"active,omitempty"`
+	RotateSecret bool     `json:"rotateSecret,omitempty"`
+	Secret       string   `json:"secret,omitempty"`
+}
+
+// CreateWebhook subscribes hookURL to events of a repository, or of every repository of orgLogin when it
+// is set. The signing secret is only shown once.
+func CreateWebhook(ownerLogin string, repoName string, orgLogin string, hookURL string, events []string, secret string) {
+	jwt := retrieveJwt()
+
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"ownerLogin": ownerLogin,
+		"repoName":   repoName,
+		"orgLogin":   orgLogin,
+		"url":        hookURL,
+		"events":     events,
+		"secret":     secret,
+	})
+	if err != nil {
+		fmt.Printf("Failed to marshal request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req, err := http.NewRequest("POST", getBackendURL()+"/secrets/webhooks", bytes.NewBuffer(requestBody))
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	response := sendWebhookRequest(req, jwt)
+
+	scope := ownerLogin + "/" + repoName
+	if orgLogin != "" {
+		scope = "organization " + orgLogin
+	}
+	fmt.Printf("✅ Webhook %d created for %s\n", response.WebhookID, scope)
+	if response.Secret != "" {
+		fmt.Printf("   Signing secret: %s\n", response.Secret)
+		fmt.Println("   Store it now, it is not shown again")
+	}
+}
+
+// ListWebhooks prints the webhooks of a repository, or of an organization when orgLogin is set
+func ListWebhooks(ownerLogin string, repoName string, orgLogin string) {
+	jwt := retrieveJwt()
+
+	query := url.Values{}
+	scope := ownerLogin + "/" + repoName
+	if orgLogin != "" {
+		query.Set("orgLogin", orgLogin)
+		scope = "organization " + orgLogin
+	} else {
+		query.Set("ownerLogin", ownerLogin)
+		query.Set("repoName", repoName)
+	}
+
+	req, err := http.NewRequest("GET", getBackendURL()+"/secrets/webhooks?"+query.Encode(), nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	response := sendWebhookRequest(req, jwt)
+
+	fmt.Printf("Webhooks of %s:\n", scope)
+	if len(response.Webhooks) == 0 {
+		fmt.Println("   No webhooks configured")
+	}
+
+	for _, webhook := range response.Webhooks {
+		state := "active"
+		if !webhook.Active {
+			state = "paused"
+		}
+		fmt.Printf("\n🔔 %d %s (%s)\n", webhook.ID, webhook.URL, state)
+		fmt.Printf("   Events: %s\n", strings.Join(webhook.Events, ", "))
+		fmt.Printf("   Created by %s - %s\n", webhook.CreatedBy, webhook.CreatedAt)
+	}
+}
+
+// UpdateWebhook changes a webhook's URL, events or state, or rotates its signing secret
+func UpdateWebhook(webhookID uint64, update WebhookUpdate) {
+	jwt := retrieveJwt()
+
+	requestBody, err := json.Marshal(update)
+	if err != nil {
+		fmt.Printf("Failed to marshal request: %v\n", err)
+		os.Exit(1)
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/secrets/webhooks/%d", getBackendURL(), webhookID), bytes.NewBuffer(requestBody))
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	response := sendWebhookRequest(req, jwt)
+
+	fmt.Printf("✅ Webhook %d updated\n", webhookID)
+	if response.Secret != "" {
+		fmt.Printf("   New signing secret: %s\n", response.Secret)
+		fmt.Println("   Store it now, it is not shown again")
+	}
+}
+
+// DeleteWebhook removes a webhook together with its delivery history
+func DeleteWebhook(webhookID uint64) {
+	jwt := retrieveJwt()
+
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/secrets/webhooks/%d", getBackendURL(), webhookID), nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	sendWebhookRequest(req, jwt)
+
+	fmt.Printf("✅ Webhook %d removed\n", webhookID)
+}
+
+// ListWebhookDeliveries prints the most recent deliveries of a webhook, newest first
+func ListWebhookDeliveries(webhookID uint64, limit int) {
+	jwt := retrieveJwt()
+
+	url := fmt.Sprintf("%s/secrets/webhooks/%d/deliveries", getBackendURL(), webhookID)
+	if limit > 0 {
+		url += fmt.Sprintf("?limit=%d", limit)
+	}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	response := sendWebhookRequest(req, jwt)
+
+	fmt.Printf("Deliveries of webhook %d:\n", webhookID)
+	if len(response.Deliveries) == 0 {
+		fmt.Println("   No deliveries yet")
+	}
+
+	for _, delivery := range response.Deliveries {
+		icon := "⏳"
+		switch delivery.Status {
+		case "delivered":
+			icon = "✅"
+		case "failed":
+			icon = "❌"
+		}
+		fmt.Printf("\n%s %d %s - %s (%s)\n", icon, delivery.ID, delivery.Event, delivery.Status, delivery.CreatedAt)
+		fmt.Printf("   Delivery: %s\n", delivery.DeliveryID)
+		if delivery.RedeliveryOf != 0 {
+			fmt.Printf("   Redelivery of %d\n", delivery.RedeliveryOf)
+		}
+		if delivery.Attempts > 0 {
+			fmt.Printf("   Attempts: %d", delivery.Attempts)
+			if delivery.LastStatusCode != 0 {
+				fmt.Printf(", last response %d", delivery.LastStatusCode)
+			}
+			fmt.Println()
+		}
+		if delivery.LastError != "" && delivery.Status != "delivered" {
+			fmt.Printf("   Last error: %s\n", delivery.LastError)
+		}
+		switch {
+		case delivery.DeliveredAt != "":
+			fmt.Printf("   Delivered at %s\n", delivery.DeliveredAt)
+		case delivery.NextAttemptAt != "":
+			fmt.Printf("   Next attempt at %s\n", delivery.NextAttemptAt)
+		}
+	}
+}
+
+// RedeliverWebhook queues a new delivery with the payload of a previous one
+func RedeliverWebhook(deliveryID uint64) {
+	jwt := retrieveJwt()
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/secrets/webhooks/deliveries/%d/redeliver", getBackendURL(), deliveryID), nil)
+	if err != nil {
+		fmt.Printf("Failed to create request: %v\n", err)
+		os.Exit(1)
+	}
+
+	response := sendWebhookRequest(req, jwt)
+
+	fmt.Printf("✅ Delivery %d queued again as delivery %d\n", deliveryID, response.DeliveryID)
+}
+
+func sendWebhookRequest(req *http.Request, jwt string) WebhookResponse {
+	req.Header.Add("Authorization", "Bearer "+jwt)
+	req.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Printf("Failed to make request: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fmt.Printf("Failed to read response: %v\n", err)
+		os.Exit(1)
+	}
+
+	var response WebhookResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		fmt.Printf("Failed to parse response: %v\n", err)
+		os.Exit(1)
+	}
+
+	if response.Error != "" {
+		fmt.Printf("Error: %s", response.Error)
+		if response.ErrorDescription != "" {
+			fmt.Printf(" - %s", response.ErrorDescription)
+		}
+		fmt.Println()
+		os.Exit(1)
+	}
+	return response
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	secretsservice "github.com/kurs0n/SecretOperationService/proto"
+)
+
+// Limits of version annotations
+const maxAnnotationMessageLength = 4096
+
+// commitSHAPattern accepts abbreviated and full SHA-1 or SHA-256 git object names
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-f]{7,64}$`)
+
+// parseVersionAnnotation validates the optional annotation of an upload
+func parseVersionAnnotation(annotation *secretsservice.VersionAnnotation) (VersionAnnotation, error) {
+	if annotation == nil {
+		return VersionAnnotation{}, nil
+	}
+
+	parsed := VersionAnnotation{
+		Message:       strings.TrimSpace(annotation.Message),
+		CommitSHA:     strings.ToLower(strings.TrimSpace(annotation.CommitSha)),
+		ClientType:    strings.TrimSpace(annotation.ClientType),
+		ClientVersion: strings.TrimSpace(annotation.ClientVersion),
+	}
+
+	if len(parsed.Message) > maxAnnotationMessageLength {
+		return VersionAnnotation{}, fmt.Errorf("message is longer than %d bytes", maxAnnotationMessageLength)
+	}
+	if parsed.CommitSHA != "" && !commitSHAPattern.MatchString(parsed.CommitSHA) {
+		return VersionAnnotation{}, fmt.Errorf("invalid commit SHA %q", annotation.CommitSha)
+	}
+	if annotation.GitBranch != "" {
+		branch, err := normalizeBranch(annotation.GitBranch)
+		if err != nil {
+			return VersionAnnotation{}, err
+		}
+		parsed.GitBranch = branch
+	}
+	if len(parsed.ClientType) > 50 || len(parsed.ClientVersion) > 50 {
+		return VersionAnnotation{}, fmt.Errorf("client type and version must be at most 50 characters")
+	}
+	return parsed, nil
+}
+
+func annotationToProto(annotation VersionAnnotation) *secretsservice.VersionAnnotation {
+	if annotation == (VersionAnnotation{}) {
+		return nil
+	}
+	return &secretsservice.VersionAnnotation{
+		Message:       annotation.Message,
+		CommitSha:     annotation.CommitSHA,
+		GitBranch:     annotation.GitBranch,
+		ClientType:    annotation.ClientType,
+		ClientVersion: annotation.ClientVersion,
+	}
+}
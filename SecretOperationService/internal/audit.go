@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+
+	secretsservice "github.com/kurs0n/SecretOperationService/proto"
+)
+
+// Page sizes of ListAuditEvents
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+)
+
+// encodeAuditCursor returns the opaque cursor of the page after the event with the given ID
+func encodeAuditCursor(id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(id), 10)))
+}
+
+func decodeAuditCursor(cursor string) (uint, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	id, err := strconv.ParseUint(string(decoded), 10, 0)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return uint(id), nil
+}
+
+// parseAuditTime accepts an RFC 3339 timestamp or a date, which means midnight UTC; empty means no bound
+func parseAuditTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		parsed = parsed.UTC()
+		return &parsed, nil
+	}
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return &parsed, nil
+	}
+	return nil, fmt.Errorf("invalid time %q, expected YYYY-MM-DD or an RFC 3339 timestamp", value)
+}
+
+// accessibleRepositories returns the stored repositories among repos by ID; repositories that never
+// stored anything have no events
+func accessibleRepositories(repos []*secretsservice.Repo) (map[uint]Repository, error) {
+	byID := make(map[uint]Repository)
+	if len(repos) == 0 {
+		return byID, nil
+	}
+
+	names := make([][]interface{}, len(repos))
+	for i, repo := range repos {
+		names[i] = []interface{}{repo.OwnerLogin, repo.Name}
+	}
+
+	var stored []Repository
+	if result := DB.Where("(owner_login, repo_name) IN ?", names).Find(&stored); result.Error != nil {
+		return nil, fmt.Errorf("failed to get repositories: %v", result.Error)
+	}
+	for _, repo := range stored {
+		byID[repo.ID] = repo
+	}
+	return byID, nil
+}
+
+// auditedSecrets returns the tag and version of the secrets events refer to; deleted ones are missing
+func auditedSecrets(logs []AuditLog) (map[uint]Secret, error) {
+	var ids []uint
+	for _, entry := range logs {
+		if entry.SecretID != nil {
+			ids = append(ids, *entry.SecretID)
+		}
+	}
+
+	byID := make(map[uint]Secret)
+	if len(ids) == 0 {
+		return byID, nil
+	}
+
+	var secrets []Secret
+	if result := DB.Select("id", "tag", "version").Where("id IN ?", ids).Find(&secrets); result.Error != nil {
+		return nil, fmt.Errorf("failed to get secrets: %v", result.Error)
+	}
+	for _, secret := range secrets {
+		byID[secret.ID] = secret
+	}
+	return byID, nil
+}
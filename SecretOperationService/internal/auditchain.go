@@ -0,0 +1,321 @@
+package internal
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// auditChainLockID is the advisory lock that serializes appends to the audit chain across instances
+const auditChainLockID = 0x656e76696e69 // "envini"
+
+// defaultAuditCheckpointInterval is how often the chain head is signed unless AUDIT_CHECKPOINT_INTERVAL is set
+const defaultAuditCheckpointInterval = time.Hour
+
+// auditVerifyBatchSize is how many entries VerifyAuditChain loads at once
+const auditVerifyBatchSize = 1000
+
+// auditEntryContent is the canonical content of an audit entry; its JSON encoding is hashed, so the
+// field order must not change
+type auditEntryContent struct {
+	Operation    string `json:"operation"`
+	RepoID       *uint  `json:"repoId"`
+	SecretID     *uint  `json:"secretId"`
+	SharedSetID  *uint  `json:"sharedSetId"`
+	LeaseID      string `json:"leaseId"`
+	Username     string `json:"username"`
+	ServiceName  string `json:"serviceName"`
+	RequestID    string `json:"requestId"`
+	Success      bool   `json:"success"`
+	ErrorMessage string `json:"errorMessage"`
+	CreatedAt    string `json:"createdAt"`
+}
+
+// computeAuditHash returns the hex SHA-256 of the previous entry's hash and the entry's canonical content
+func computeAuditHash(entry *AuditLog) (string, error) {
+	content, err := json.Marshal(auditEntryContent{
+		Operation:    entry.Operation,
+		RepoID:       entry.RepoID,
+		SecretID:     entry.SecretID,
+		SharedSetID:  entry.SharedSetID,
+		LeaseID:      entry.LeaseID,
+		Username:     entry.Username,
+		ServiceName:  entry.ServiceName,
+		RequestID:    entry.RequestID,
+		Success:      entry.Success,
+		ErrorMessage: entry.ErrorMessage,
+		CreatedAt:    entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(entry.PrevHash))
+	hash.Write([]byte("\n"))
+	hash.Write(content)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// appendAuditLog links entry to the current head of the chain and stores it. Appends are serialized with
+// an advisory lock so concurrent requests, also on other instances, cannot fork the chain. The lock is held
+// until the insert commits, so audited requests across all instances write one at a time and audit
+// throughput is bounded by the database's commit latency.
+func appendAuditLog(entry *AuditLog) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockID).Error; err != nil {
+			return err
+		}
+
+		var head []AuditLog
+		if err := tx.Select("id", "hash").Order("id DESC").Limit(1).Find(&head).Error; err != nil {
+			return err
+		}
+		entry.PrevHash = ""
+		if len(head) > 0 {
+			entry.PrevHash = head[0].Hash
+		}
+
+		// Postgres keeps microseconds; hash exactly what is stored
+		entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		hash, err := computeAuditHash(entry)
+		if err != nil {
+			return err
+		}
+		entry.Hash = hash
+		return tx.Create(entry).Error
+	})
+}
+
+// auditSigningKey reads AUDIT_SIGNING_KEY, a base64 Ed25519 seed; without it the key is derived from the
+// master encryption key
+func auditSigningKey() (ed25519.PrivateKey, error) {
+	if value := os.Getenv("AUDIT_SIGNING_KEY"); value != "" {
+		seed, err := base64.StdEncoding.DecodeString(value)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("AUDIT_SIGNING_KEY must be a base64 encoded %d byte seed", ed25519.SeedSize)
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+
+	masterKey, err := getMasterKey()
+	if err != nil {
+		return nil, err
+	}
+	seed := sha256.Sum256(append([]byte("envini-audit-checkpoint:"), masterKey...))
+	return ed25519.NewKeyFromSeed(seed[:]), nil
+}
+
+// auditKeyID identifies a signing key by its public key
+func auditKeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+// checkpointMessage is what a checkpoint signs
+func checkpointMessage(auditLogID uint, hash string) []byte {
+	return []byte(fmt.Sprintf("envini-audit-checkpoint:v1:%d:%s", auditLogID, hash))
+}
+
+// createAuditCheckpoint signs the current head of the chain, unless it is already signed. It returns
+// nil when there is nothing new to sign.
+func createAuditCheckpoint() (*AuditCheckpoint, error) {
+	key, err := auditSigningKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var head []AuditLog
+	if err := DB.Select("id", "hash").Where("hash <> ''").Order("id DESC").Limit(1).Find(&head).Error; err != nil {
+		return nil, fmt.Errorf("failed to get chain head: %v", err)
+	}
+	if len(head) == 0 {
+		return nil, nil
+	}
+
+	latest, err := GetLatestAuditCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.AuditLogID >= head[0].ID {
+		return nil, nil
+	}
+
+	checkpoint := &AuditCheckpoint{
+		AuditLogID: head[0].ID,
+		Hash:       head[0].Hash,
+		KeyID:      auditKeyID(key.Public().(ed25519.PublicKey)),
+		Signature:  base64.StdEncoding.EncodeToString(ed25519.Sign(key, checkpointMessage(head[0].ID, head[0].Hash))),
+	}
+	if err := CreateAuditCheckpoint(checkpoint); err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// auditCheckpointInterval reads AUDIT_CHECKPOINT_INTERVAL, e.g. "15m"
+func auditCheckpointInterval() time.Duration {
+	if value := os.Getenv("AUDIT_CHECKPOINT_INTERVAL"); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil && interval > 0 {
+			return interval
+		}
+		log.Printf("Invalid AUDIT_CHECKPOINT_INTERVAL %q, using default", value)
+	}
+	return defaultAuditCheckpointInterval
+}
+
+// runAuditCheckpointer signs the chain head periodically until the process exits
+func runAuditCheckpointer(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		checkpoint, err := createAuditCheckpoint()
+		if err != nil {
+			log.Printf("Audit checkpoint: %v", err)
+			continue
+		}
+		// Checkpoints live in the audited database; logging them lets operators keep a copy elsewhere
+		if checkpoint != nil {
+			log.Printf("Audit checkpoint %d: entry %d hash %s key %s signature %s", checkpoint.ID, checkpoint.AuditLogID, checkpoint.Hash, checkpoint.KeyID, checkpoint.Signature)
+		}
+	}
+}
+
+// AuditChainReport is the outcome of VerifyAuditChain
+type AuditChainReport struct {
+	Valid               bool
+	CheckedEntries      int64
+	LegacyEntries       int64 // Entries written before chaining was introduced
+	FirstBrokenID       uint
+	Reason              string
+	CheckpointsVerified int
+	LastCheckpointID    uint // Last entry covered by a verified checkpoint
+	CheckpointGapID     uint // First entry not signed within twice the checkpoint interval, 0 if none
+}
+
+func (r *AuditChainReport) broken(id uint, reason string) *AuditChainReport {
+	r.Valid = false
+	r.FirstBrokenID = id
+	r.Reason = reason
+	return r
+}
+
+// signedInTime reports whether entry was covered by a checkpoint within grace of being written. pending
+// holds the checkpoints not matched yet, so the first one is the earliest that can cover the entry.
+func signedInTime(entry *AuditLog, pending []AuditCheckpoint, grace time.Duration, now time.Time) bool {
+	deadline := entry.CreatedAt.Add(grace)
+	if len(pending) == 0 {
+		return now.Before(deadline)
+	}
+	return !pending[0].CreatedAt.After(deadline)
+}
+
+// VerifyAuditChain walks the audit log from the oldest entry and reports the first broken link: an entry
+// whose content no longer matches its hash, whose previous hash does not match the entry before it, or
+// that disagrees with a signed checkpoint. Entries deleted after the last checkpoint cannot be detected.
+// Checkpoints are stored next to the log, so deleting them and rewriting the chain goes unnoticed by the
+// hashes alone; the report also names the first entry that was not signed on schedule.
+func VerifyAuditChain() (*AuditChainReport, error) {
+	key, err := auditSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	publicKey := key.Public().(ed25519.PublicKey)
+	keyID := auditKeyID(publicKey)
+
+	var checkpoints []AuditCheckpoint
+	if err := DB.Order("audit_log_id ASC, id ASC").Find(&checkpoints).Error; err != nil {
+		return nil, fmt.Errorf("failed to get audit checkpoints: %v", err)
+	}
+	for _, checkpoint := range checkpoints {
+		signature, err := base64.StdEncoding.DecodeString(checkpoint.Signature)
+		if checkpoint.KeyID != keyID || err != nil || !ed25519.Verify(publicKey, checkpointMessage(checkpoint.AuditLogID, checkpoint.Hash), signature) {
+			report := &AuditChainReport{}
+			return report.broken(checkpoint.AuditLogID, fmt.Sprintf("checkpoint %d has an invalid signature", checkpoint.ID)), nil
+		}
+	}
+
+	report := &AuditChainReport{Valid: true}
+	grace, now := 2*auditCheckpointInterval(), time.Now().UTC()
+	prevHash, chained, next := "", false, 0
+	var lastID uint
+	for {
+		var entries []AuditLog
+		if err := DB.Where("id > ?", lastID).Order("id ASC").Limit(auditVerifyBatchSize).Find(&entries).Error; err != nil {
+			return nil, fmt.Errorf("failed to read audit log: %v", err)
+		}
+		if len(entries) == 0 {
+			break
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+			lastID = entry.ID
+
+			// Checkpoints on entries that no longer exist
+			if next < len(checkpoints) && checkpoints[next].AuditLogID < entry.ID {
+				return report.broken(checkpoints[next].AuditLogID, fmt.Sprintf("entry signed by checkpoint %d is missing", checkpoints[next].ID)), nil
+			}
+
+			if entry.Hash == "" {
+				if chained {
+					return report.broken(entry.ID, "entry has no hash"), nil
+				}
+				report.LegacyEntries++
+				continue
+			}
+			chained = true
+
+			if entry.PrevHash != prevHash {
+				return report.broken(entry.ID, "previous hash does not match the entry before it; entries were deleted or inserted"), nil
+			}
+			hash, err := computeAuditHash(entry)
+			if err != nil {
+				return nil, err
+			}
+			if hash != entry.Hash {
+				return report.broken(entry.ID, "entry content does not match its hash; it was modified"), nil
+			}
+			if report.CheckpointGapID == 0 && !signedInTime(entry, checkpoints[next:], grace, now) {
+				report.CheckpointGapID = entry.ID
+			}
+
+			for next < len(checkpoints) && checkpoints[next].AuditLogID == entry.ID {
+				if checkpoints[next].Hash != entry.Hash {
+					return report.broken(entry.ID, fmt.Sprintf("entry does not match checkpoint %d; the chain was rewritten", checkpoints[next].ID)), nil
+				}
+				report.CheckpointsVerified++
+				report.LastCheckpointID = entry.ID
+				next++
+			}
+
+			prevHash = entry.Hash
+			report.CheckedEntries++
+		}
+	}
+
+	if next < len(checkpoints) {
+		return report.broken(checkpoints[next].AuditLogID, fmt.Sprintf("entry signed by checkpoint %d is missing", checkpoints[next].ID)), nil
+	}
+	return report, nil
+}
+
+// isAuditAdmin checks AUDIT_ADMINS, a comma separated list of GitHub logins allowed to verify the whole audit log
+func isAuditAdmin(userLogin string) bool {
+	for _, admin := range strings.Split(os.Getenv("AUDIT_ADMINS"), ",") {
+		if admin = strings.TrimSpace(admin); admin != "" && strings.EqualFold(admin, userLogin) {
+			return true
+		}
+	}
+	return false
+}
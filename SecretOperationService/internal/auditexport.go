@@ -0,0 +1,344 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Delivery of audit events to export sinks
+const (
+	defaultAuditSinkBuffer   = 1024
+	auditSinkAttempts        = 3
+	auditSinkRetryBackoff    = 200 * time.Millisecond
+	auditSinkDropLogInterval = time.Minute
+)
+
+// auditExportEvent is an audit entry as sent to export sinks
+type auditExportEvent struct {
+	ID           uint      `json:"id"`
+	Time         time.Time `json:"time"`
+	Operation    string    `json:"operation"`
+	Repository   string    `json:"repository,omitempty"` // owner/repo
+	RepoID       *uint     `json:"repoId,omitempty"`
+	SecretID     *uint     `json:"secretId,omitempty"`
+	SharedSetID  *uint     `json:"sharedSetId,omitempty"`
+	LeaseID      string    `json:"leaseId,omitempty"`
+	Username     string    `json:"username"`
+	ServiceName  string    `json:"serviceName"`
+	RequestID    string    `json:"requestId,omitempty"`
+	Success      bool      `json:"success"`
+	ErrorMessage string    `json:"errorMessage,omitempty"`
+	Hash         string    `json:"hash"`
+}
+
+// auditSink delivers formatted events to one destination. write is only called from the sink's own
+// worker, so implementations need no locking.
+type auditSink interface {
+	write(event *auditExportEvent) error
+}
+
+// auditEventFormatter encodes an event as a single line without the trailing newline
+type auditEventFormatter func(event *auditExportEvent) ([]byte, error)
+
+// auditSinkWorker buffers events for one sink so a slow or unreachable destination never blocks an RPC
+type auditSinkWorker struct {
+	name    string
+	sink    auditSink
+	queue   chan *auditExportEvent
+	dropped atomic.Uint64
+}
+
+// auditExporter resolves repository names and fans events out to every sink
+type auditExporter struct {
+	queue   chan *AuditLog
+	dropped atomic.Uint64 // Events lost before reaching any sink
+	workers []*auditSinkWorker
+	repos   map[uint]string
+}
+
+var (
+	auditExport     *auditExporter
+	auditExportOnce sync.Once
+)
+
+// startAuditExport configures the sinks listed in AUDIT_SINKS and starts delivering to them. Audit events
+// are not exported when it is unset.
+func startAuditExport() {
+	auditExportOnce.Do(func() {
+		specs := os.Getenv("AUDIT_SINKS")
+		if strings.TrimSpace(specs) == "" {
+			return
+		}
+
+		buffer := auditSinkBuffer()
+		exporter := &auditExporter{
+			queue: make(chan *AuditLog, buffer),
+			repos: make(map[uint]string),
+		}
+		for _, spec := range strings.Split(specs, ",") {
+			spec = strings.TrimSpace(spec)
+			if spec == "" {
+				continue
+			}
+			sink, err := newAuditSink(spec)
+			if err != nil {
+				log.Printf("Audit sink %s disabled: %v", redactSinkSpec(spec), err)
+				continue
+			}
+			worker := &auditSinkWorker{name: redactSinkSpec(spec), sink: sink, queue: make(chan *auditExportEvent, buffer)}
+			exporter.workers = append(exporter.workers, worker)
+			go worker.run()
+			log.Printf("Exporting audit events to %s", worker.name)
+		}
+		if len(exporter.workers) == 0 {
+			return
+		}
+
+		go exporter.run()
+		go exporter.reportDrops()
+		auditExport = exporter
+	})
+}
+
+// auditSinkBuffer reads AUDIT_SINK_BUFFER, the number of events queued per sink
+func auditSinkBuffer() int {
+	if value := os.Getenv("AUDIT_SINK_BUFFER"); value != "" {
+		if buffer, err := strconv.Atoi(value); err == nil && buffer > 0 {
+			return buffer
+		}
+		log.Printf("Invalid AUDIT_SINK_BUFFER %q, using default", value)
+	}
+	return defaultAuditSinkBuffer
+}
+
+// exportAuditEvent queues a stored audit entry for the export sinks without blocking
+func exportAuditEvent(entry *AuditLog) {
+	exporter := auditExport
+	if exporter == nil {
+		return
+	}
+
+	copied := *entry
+	select {
+	case exporter.queue <- &copied:
+	default:
+		exporter.dropped.Add(1)
+	}
+}
+
+func (e *auditExporter) run() {
+	for entry := range e.queue {
+		event := &auditExportEvent{
+			ID:           entry.ID,
+			Time:         entry.CreatedAt.UTC(),
+			Operation:    entry.Operation,
+			RepoID:       entry.RepoID,
+			SecretID:     entry.SecretID,
+			SharedSetID:  entry.SharedSetID,
+			LeaseID:      entry.LeaseID,
+			Username:     entry.Username,
+			ServiceName:  entry.ServiceName,
+			RequestID:    entry.RequestID,
+			Success:      entry.Success,
+			ErrorMessage: entry.ErrorMessage,
+			Hash:         entry.Hash,
+		}
+		if entry.RepoID != nil {
+			event.Repository = e.repositoryName(*entry.RepoID)
+		}
+
+		for _, worker := range e.workers {
+			select {
+			case worker.queue <- event:
+			default:
+				worker.dropped.Add(1)
+			}
+		}
+	}
+}
+
+// repositoryName returns owner/repo of a stored repository; names are cached as they never change
+func (e *auditExporter) repositoryName(repoID uint) string {
+	if name, ok := e.repos[repoID]; ok {
+		return name
+	}
+
+	var repo Repository
+	if result := DB.Select("owner_login", "repo_name").First(&repo, repoID); result.Error != nil {
+		return ""
+	}
+	name := repo.OwnerLogin + "/" + repo.RepoName
+	e.repos[repoID] = name
+	return name
+}
+
+// reportDrops logs how many events were dropped whenever the count grew
+func (e *auditExporter) reportDrops() {
+	ticker := time.NewTicker(auditSinkDropLogInterval)
+	defer ticker.Stop()
+
+	reported := make(map[string]uint64)
+	for range ticker.C {
+		if dropped := e.dropped.Load(); dropped > reported[""] {
+			log.Printf("Audit export: %d events dropped in total before reaching the sinks (queue full)", dropped)
+			reported[""] = dropped
+		}
+		for _, worker := range e.workers {
+			if dropped := worker.dropped.Load(); dropped > reported[worker.name] {
+				log.Printf("Audit sink %s: %d events dropped in total", worker.name, dropped)
+				reported[worker.name] = dropped
+			}
+		}
+	}
+}
+
+// run delivers queued events, retrying with backoff; an event that still fails is dropped
+func (w *auditSinkWorker) run() {
+	for event := range w.queue {
+		var err error
+		for attempt := 0; attempt < auditSinkAttempts; attempt++ {
+			if attempt > 0 {
+				time.Sleep(auditSinkRetryBackoff << (attempt - 1))
+			}
+			if err = w.sink.write(event); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			w.dropped.Add(1)
+			log.Printf("Audit sink %s: dropped event %d: %v", w.name, event.ID, err)
+		}
+	}
+}
+
+// newAuditSink creates a sink from its URL:
+//
+//	file:///var/log/envini/audit.jsonl?max_size_mb=100&max_files=5&format=jsonl
+//	syslog+udp://siem:514, syslog+tcp://siem:601 or syslog+tls://siem:6514?ca=/etc/envini/ca.pem
+//
+// format is jsonl (the default) or cef. Syslog sinks also accept facility (0-23, default 16 for local0)
+// and app_name.
+func newAuditSink(spec string) (auditSink, error) {
+	parsed, err := url.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sink URL: %v", err)
+	}
+	query := parsed.Query()
+
+	var format auditEventFormatter
+	switch query.Get("format") {
+	case "", "jsonl", "json":
+		format = formatAuditJSON
+	case "cef":
+		format = formatAuditCEF
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected jsonl or cef", query.Get("format"))
+	}
+
+	switch parsed.Scheme {
+	case "file":
+		if parsed.Path == "" {
+			return nil, fmt.Errorf("file sink needs a path")
+		}
+		maxSizeMB, err := positiveQueryInt(query, "max_size_mb", 100)
+		if err != nil {
+			return nil, err
+		}
+		maxFiles, err := positiveQueryInt(query, "max_files", 5)
+		if err != nil {
+			return nil, err
+		}
+		return newFileAuditSink(parsed.Path, int64(maxSizeMB)*1024*1024, maxFiles, format)
+	case "syslog+udp", "syslog+tcp", "syslog+tls":
+		if parsed.Host == "" {
+			return nil, fmt.Errorf("syslog sink needs a host:port")
+		}
+		facility, err := positiveQueryInt(query, "facility", 16)
+		if err != nil || facility > 23 {
+			return nil, fmt.Errorf("facility must be between 0 and 23")
+		}
+		appName := query.Get("app_name")
+		if appName == "" {
+			appName = "envini"
+		}
+		return newSyslogAuditSink(strings.TrimPrefix(parsed.Scheme, "syslog+"), parsed.Host, query.Get("ca"), facility, appName, format)
+	default:
+		return nil, fmt.Errorf("unknown sink type %q, expected file, syslog+udp, syslog+tcp or syslog+tls", parsed.Scheme)
+	}
+}
+
+func positiveQueryInt(query url.Values, name string, fallback int) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return 0, fmt.Errorf("invalid %s: %q", name, value)
+	}
+	return parsed, nil
+}
+
+// redactSinkSpec drops credentials and options from a sink URL for logging
+func redactSinkSpec(spec string) string {
+	parsed, err := url.Parse(spec)
+	if err != nil {
+		return "invalid sink"
+	}
+	if parsed.Scheme == "file" {
+		return "file://" + parsed.Path
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
+func formatAuditJSON(event *auditExportEvent) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+// formatAuditCEF encodes an event in ArcSight Common Event Format
+func formatAuditCEF(event *auditExportEvent) ([]byte, error) {
+	severity, outcome := 3, "success"
+	if !event.Success {
+		severity, outcome = 7, "failure"
+	}
+
+	extension := []string{
+		"rt=" + strconv.FormatInt(event.Time.UnixMilli(), 10),
+		"externalId=" + strconv.FormatUint(uint64(event.ID), 10),
+		"suser=" + cefExtensionValue(event.Username),
+		"outcome=" + outcome,
+		"cs1Label=repository", "cs1=" + cefExtensionValue(event.Repository),
+		"cs2Label=requestId", "cs2=" + cefExtensionValue(event.RequestID),
+		"cs3Label=serviceName", "cs3=" + cefExtensionValue(event.ServiceName),
+		"cs4Label=hash", "cs4=" + event.Hash,
+	}
+	if event.LeaseID != "" {
+		extension = append(extension, "cs5Label=leaseId", "cs5="+cefExtensionValue(event.LeaseID))
+	}
+	if event.ErrorMessage != "" {
+		extension = append(extension, "reason="+cefExtensionValue(event.ErrorMessage))
+	}
+
+	return []byte(fmt.Sprintf("CEF:0|Envini|SecretOperationService|1.0|%s|%s|%d|%s",
+		cefHeaderValue(event.Operation),
+		cefHeaderValue(strings.ToLower(strings.ReplaceAll(event.Operation, "_", " "))),
+		severity,
+		strings.Join(extension, " "),
+	)), nil
+}
+
+func cefHeaderValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ").Replace(value)
+}
+
+func cefExtensionValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`).Replace(value)
+}
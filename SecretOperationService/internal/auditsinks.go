@@ -0,0 +1,226 @@
+package internal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// auditSinkTimeout bounds connecting to and writing to a syslog collector
+const auditSinkTimeout = 5 * time.Second
+
+// fileAuditSink appends one event per line and rotates the file once it reaches maxSize, keeping
+// maxFiles rotated copies as path.1 (newest) to path.N
+type fileAuditSink struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	format   auditEventFormatter
+	file     *os.File
+	size     int64
+}
+
+func newFileAuditSink(path string, maxSize int64, maxFiles int, format auditEventFormatter) (*fileAuditSink, error) {
+	sink := &fileAuditSink{path: path, maxSize: maxSize, maxFiles: maxFiles, format: format}
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+func (s *fileAuditSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", s.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat %s: %v", s.path, err)
+	}
+	s.file, s.size = file, info.Size()
+	return nil
+}
+
+func (s *fileAuditSink) write(event *auditExportEvent) error {
+	line, err := s.format(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	written, err := s.file.Write(line)
+	s.size += int64(written)
+	if err != nil {
+		// Reopen on the next attempt, the file may have been moved away
+		s.file.Close()
+		s.file = nil
+		return fmt.Errorf("failed to write %s: %v", s.path, err)
+	}
+	return nil
+}
+
+func (s *fileAuditSink) rotate() error {
+	s.file.Close()
+	s.file = nil
+
+	if s.maxFiles == 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate %s: %v", s.path, err)
+		}
+		return s.open()
+	}
+
+	os.Remove(s.path + "." + strconv.Itoa(s.maxFiles))
+	for i := s.maxFiles - 1; i >= 1; i-- {
+		os.Rename(s.path+"."+strconv.Itoa(i), s.path+"."+strconv.Itoa(i+1))
+	}
+	if err := os.Rename(s.path, s.path+".1"); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rotate %s: %v", s.path, err)
+	}
+	return s.open()
+}
+
+// syslogAuditSink sends RFC 5424 messages over UDP, TCP or TLS. Stream transports use octet-counting
+// framing (RFC 6587); the connection is re-established after a failed write.
+type syslogAuditSink struct {
+	transport string
+	address   string
+	tlsConfig *tls.Config
+	facility  int
+	appName   string
+	hostname  string
+	format    auditEventFormatter
+	conn      net.Conn
+}
+
+func newSyslogAuditSink(transport, address, caPath string, facility int, appName string, format auditEventFormatter) (*syslogAuditSink, error) {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	sink := &syslogAuditSink{
+		transport: transport,
+		address:   address,
+		facility:  facility,
+		appName:   appName,
+		hostname:  hostname,
+		format:    format,
+	}
+
+	if transport == "tls" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q: %v", address, err)
+		}
+		sink.tlsConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+		if caPath != "" {
+			pem, err := os.ReadFile(caPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA %s: %v", caPath, err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", caPath)
+			}
+			sink.tlsConfig.RootCAs = pool
+		}
+	}
+	return sink, nil
+}
+
+func (s *syslogAuditSink) connect() error {
+	dialer := &net.Dialer{Timeout: auditSinkTimeout}
+	var conn net.Conn
+	var err error
+	switch s.transport {
+	case "tls":
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.address, s.tlsConfig)
+	default:
+		conn, err = dialer.Dial(s.transport, s.address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", s.address, err)
+	}
+	s.conn = conn
+	return nil
+}
+
+// message builds the RFC 5424 message: <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG
+func (s *syslogAuditSink) message(event *auditExportEvent) ([]byte, error) {
+	body, err := s.format(event)
+	if err != nil {
+		return nil, err
+	}
+
+	severity := 6 // informational
+	if !event.Success {
+		severity = 4 // warning
+	}
+	header := fmt.Sprintf("<%d>1 %s %s %s %d %s - ",
+		s.facility*8+severity,
+		event.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeaderField(s.hostname, 255),
+		syslogHeaderField(s.appName, 48),
+		os.Getpid(),
+		syslogHeaderField(event.Operation, 32),
+	)
+	return append([]byte(header), body...), nil
+}
+
+func (s *syslogAuditSink) write(event *auditExportEvent) error {
+	message, err := s.message(event)
+	if err != nil {
+		return err
+	}
+	if s.transport != "udp" {
+		message = append([]byte(strconv.Itoa(len(message))+" "), message...)
+	}
+
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(auditSinkTimeout))
+	if _, err := s.conn.Write(message); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return fmt.Errorf("failed to send to %s: %v", s.address, err)
+	}
+	return nil
+}
+
+// syslogHeaderField limits a header field to printable ASCII without spaces, as RFC 5424 requires
+func syslogHeaderField(value string, maxLength int) string {
+	field := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, value)
+	if field == "" {
+		return "-"
+	}
+	if len(field) > maxLength {
+		field = field[:maxLength]
+	}
+	return field
+}
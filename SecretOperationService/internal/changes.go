@@ -0,0 +1,408 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	secretsservice "github.com/kurs0n/SecretOperationService/proto"
+	"gorm.io/gorm"
+)
+
+// Change request statuses
+const (
+	changeRequestPending   = "pending"
+	changeRequestApproving = "approving" // Claimed by an approval whose transaction has not committed yet
+	changeRequestApproved  = "approved"
+	changeRequestRejected  = "rejected"
+	changeRequestExpired   = "expired"
+)
+
+// Change request kinds
+const (
+	changeRequestKindVersion = "version" // Proposes a new version of the tag
+	changeRequestKindParent  = "parent"  // Proposes a new parent for the tag, or its removal
+)
+
+// Limits of protected tags and their change requests
+const (
+	defaultChangeRequestTTLHours = 72
+	maxChangeRequestTTLHours     = 30 * 24
+	maxChangeCommentLength       = 2000
+)
+
+// changeRequestKeyChange is one entry of a change request's diff; values are never part of it
+type changeRequestKeyChange struct {
+	Key    string `json:"key"`
+	Change string `json:"change"` // added, removed or changed
+}
+
+// checkTagWritable refuses direct changes to a protected tag, which only approved change requests may
+// modify. An empty tag stands for every tag of the repository.
+func checkTagWritable(repoID uint, tag string) error {
+	if tag == "" {
+		protected, err := ListProtectedTags(repoID)
+		if err != nil {
+			return err
+		}
+		if len(protected) > 0 {
+			return fmt.Errorf("tag %s is protected, its versions can only change through approved change requests", protected[0].Tag)
+		}
+		return nil
+	}
+
+	protected, err := GetProtectedTag(repoID, tag)
+	if err != nil {
+		return err
+	}
+	if protected != nil {
+		return fmt.Errorf("tag %s is protected, its versions can only change through approved change requests", tag)
+	}
+	return nil
+}
+
+// tagProtection returns the protection a change to tag in scope falls under: the tag's own, or for base
+// versions that of the first protected tag inheriting from it, whose downloads the change would alter
+// just the same. Branch overlays only apply to their own tag. It returns nil when nothing is protected.
+func tagProtection(repoID uint, scope SecretScope, tag string) (*ProtectedTag, error) {
+	protected, err := GetProtectedTag(repoID, tag)
+	if err != nil || protected != nil || scope.Branch != "" {
+		return protected, err
+	}
+
+	visited := map[string]bool{tag: true}
+	queue := []string{tag}
+	for len(queue) > 0 {
+		children, err := ListChildTags(repoID, scope, queue[0])
+		if err != nil {
+			return nil, err
+		}
+		queue = queue[1:]
+
+		for _, child := range children {
+			if visited[child] {
+				continue
+			}
+			visited[child] = true
+
+			protected, err := GetProtectedTag(repoID, child)
+			if err != nil || protected != nil {
+				return protected, err
+			}
+			queue = append(queue, child)
+		}
+	}
+	return nil, nil
+}
+
+// checkScopedTagWritable is checkTagWritable for a tag of a secret stream, which also refuses changes to
+// the tags a protected tag inherits from
+func checkScopedTagWritable(repoID uint, scope SecretScope, tag string) error {
+	if tag == "" {
+		return checkTagWritable(repoID, tag)
+	}
+
+	protected, err := tagProtection(repoID, scope, tag)
+	if err != nil {
+		return err
+	}
+	switch {
+	case protected == nil:
+		return nil
+	case protected.Tag == tag:
+		return fmt.Errorf("tag %s is protected, its versions can only change through approved change requests", tag)
+	default:
+		return fmt.Errorf("protected tag %s inherits from %s, which can only change through approved change requests", protected.Tag, tag)
+	}
+}
+
+// diffSecretKeys lists the keys proposed adds, removes or changes compared to previous, sorted by key
+func diffSecretKeys(previous, proposed map[string]string) []changeRequestKeyChange {
+	var diff []changeRequestKeyChange
+	for _, key := range sortedKeys(mergedKeys(previous, proposed)) {
+		before, existed := previous[key]
+		after, exists := proposed[key]
+		switch {
+		case !existed:
+			diff = append(diff, changeRequestKeyChange{Key: key, Change: "added"})
+		case !exists:
+			diff = append(diff, changeRequestKeyChange{Key: key, Change: "removed"})
+		case before != after:
+			diff = append(diff, changeRequestKeyChange{Key: key, Change: "changed"})
+		}
+	}
+	return diff
+}
+
+// mergedKeys returns a map holding the keys of both maps, for sortedKeys
+func mergedKeys(a, b map[string]string) map[string]string {
+	keys := make(map[string]string, len(a)+len(b))
+	for key := range a {
+		keys[key] = ""
+	}
+	for key := range b {
+		keys[key] = ""
+	}
+	return keys
+}
+
+// latestVersionData returns the latest version of a tag in a scope with its decrypted keys, or nil and no
+// keys when the tag has no version yet
+func latestVersionData(repoID uint, scope SecretScope, tag string) (*Secret, map[string]string, error) {
+	latest, err := GetBranchOverlay(repoID, scope, tag)
+	if err != nil || latest == nil {
+		return nil, map[string]string{}, err
+	}
+
+	decryptedData, err := DecryptSecretData(latest)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt version %d: %v", latest.Version, err)
+	}
+	var envData map[string]string
+	if err := json.Unmarshal([]byte(decryptedData), &envData); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal version %d: %v", latest.Version, err)
+	}
+	return latest, envData, nil
+}
+
+// proposeChange stores an upload to a protected tag as a pending change request with its diff against the
+// tag's latest version
+func proposeChange(repoID uint, scope SecretScope, tag string, envData map[string]string, envDataJSON, checksum, requestedBy string, annotation VersionAnnotation, keyMetadata []*secretsservice.KeyMetadata, protected *ProtectedTag) (*ChangeRequest, error) {
+	latest, previousData, err := latestVersionData(repoID, scope, tag)
+	if err != nil {
+		return nil, err
+	}
+	baseVersion := 0
+	if latest != nil {
+		baseVersion = latest.Version
+	}
+
+	diff, err := json.Marshal(diffSecretKeys(previousData, envData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode diff: %v", err)
+	}
+	metadata, err := json.Marshal(keyMetadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode key metadata: %v", err)
+	}
+
+	request := &ChangeRequest{
+		RepoID:      repoID,
+		Path:        scope.Path,
+		FileName:    secretFileName(scope.FileName),
+		Branch:      scope.Branch,
+		Tag:         tag,
+		Status:      changeRequestPending,
+		Checksum:    checksum,
+		KeyMetadata: string(metadata),
+		Annotation:  annotation,
+		BaseVersion: baseVersion,
+		Diff:        string(diff),
+		RequestedBy: requestedBy,
+		ExpiresAt:   time.Now().UTC().Add(time.Duration(protected.RequestTTLHours) * time.Hour),
+	}
+	if err := CreateChangeRequest(request, envDataJSON); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// proposeTagParent stores a parent change of a protected tag, or of a tag a protected tag inherits from,
+// as a pending change request; an empty parentTag proposes removing the parent
+func proposeTagParent(repoID uint, scope SecretScope, tag, parentTag string, parentVersion int, requestedBy string, protected *ProtectedTag) (*ChangeRequest, error) {
+	request := &ChangeRequest{
+		RepoID:        repoID,
+		Path:          scope.Path,
+		FileName:      secretFileName(scope.FileName),
+		Tag:           tag,
+		Kind:          changeRequestKindParent,
+		Status:        changeRequestPending,
+		ParentTag:     parentTag,
+		ParentVersion: parentVersion,
+		RequestedBy:   requestedBy,
+		ExpiresAt:     time.Now().UTC().Add(time.Duration(protected.RequestTTLHours) * time.Hour),
+	}
+	if err := CreateChangeRequest(request, ""); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// approveChangeRequest claims the request and creates the approved version in one transaction, so the
+// version only exists once the request is approved and two reviewers cannot both apply it. Parent changes
+// create no version and return nil.
+func approveChangeRequest(request *ChangeRequest, reviewedBy, comment string) (*Secret, error) {
+	if request.Kind == changeRequestKindParent {
+		_, err := ApproveChangeRequest(request, reviewedBy, comment, func(tx *gorm.DB) (*Secret, error) {
+			return nil, applyTagParentChange(tx, request)
+		})
+		return nil, err
+	}
+
+	var references []SecretReference
+	secret, err := ApproveChangeRequest(request, reviewedBy, comment, func(tx *gorm.DB) (*Secret, error) {
+		secret, envData, err := applyChangeRequest(tx, request)
+		if err != nil {
+			return nil, err
+		}
+		references = parseSecretReferences(envData)
+		return secret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The version exists, references only feed the dependency graph
+	scope := SecretScope{Path: request.Path, FileName: request.FileName, Branch: request.Branch}
+	if err := ReplaceSecretReferences(request.RepoID, scope, request.Tag, references); err != nil {
+		log.Printf("Failed to record references of secret %d: %v", secret.ID, err)
+	}
+	return secret, nil
+}
+
+// applyChangeRequest creates the approved version in tx, as the requester's upload, and returns it with
+// its values. Every read goes through tx, so the version is computed within the claiming transaction. It
+// fails when the tag got a new version since the change was proposed, as the reviewed diff no longer
+// applies; two requests racing for the same version are stopped by the unique version index.
+func applyChangeRequest(tx *gorm.DB, request *ChangeRequest) (*Secret, map[string]string, error) {
+	scope := SecretScope{Path: request.Path, FileName: request.FileName, Branch: request.Branch}
+
+	latest, err := getBranchOverlay(tx, request.RepoID, scope, request.Tag)
+	if err != nil {
+		return nil, nil, err
+	}
+	if latest != nil && latest.Version != request.BaseVersion {
+		return nil, nil, fmt.Errorf("%s changed since the request was made (now version %d), upload the change again", request.Tag, latest.Version)
+	}
+
+	envDataJSON, err := DecryptChangeRequest(request)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt change request: %v", err)
+	}
+	var envData map[string]string
+	if err := json.Unmarshal([]byte(envDataJSON), &envData); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal change request: %v", err)
+	}
+
+	var provided []*secretsservice.KeyMetadata
+	if request.KeyMetadata != "" {
+		if err := json.Unmarshal([]byte(request.KeyMetadata), &provided); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode key metadata: %v", err)
+		}
+	}
+	keyMetadata, err := buildKeyMetadata(tx, request.RepoID, scope, request.Tag, envData, provided)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid key metadata: %v", err)
+	}
+
+	version, err := getNextVersionForTag(tx, request.RepoID, scope, request.Tag)
+	if err != nil {
+		return nil, nil, err
+	}
+	secret, err := createSecret(tx, request.RepoID, scope, version, request.Tag, envDataJSON, request.Checksum, request.RequestedBy, request.Annotation, true, keyMetadata)
+	if err != nil {
+		return nil, nil, err
+	}
+	return secret, envData, nil
+}
+
+// applyTagParentChange stores the approved parent in tx, as the requester's change. The pinned version and
+// the cycle check are repeated within the claiming transaction, as both may have changed since.
+func applyTagParentChange(tx *gorm.DB, request *ChangeRequest) error {
+	scope := SecretScope{Path: request.Path, FileName: request.FileName}
+	if request.ParentTag == "" {
+		return deleteTagParent(tx, request.RepoID, scope, request.Tag)
+	}
+
+	if request.ParentVersion > 0 {
+		if _, err := getSecretByTagAndVersion(tx, request.RepoID, scope, request.ParentTag, request.ParentVersion); err != nil {
+			return fmt.Errorf("parent version %d of tag %s not found", request.ParentVersion, request.ParentTag)
+		}
+	}
+	if err := checkTagCycle(tx, request.RepoID, scope, request.Tag, request.ParentTag); err != nil {
+		return err
+	}
+	return setTagParent(tx, request.RepoID, scope, request.Tag, request.ParentTag, request.ParentVersion, request.RequestedBy)
+}
+
+func protectedTagToProto(protected *ProtectedTag) *secretsservice.ProtectedTag {
+	return &secretsservice.ProtectedTag{
+		Tag:             protected.Tag,
+		RequestTtlHours: int32(protected.RequestTTLHours),
+		UpdatedBy:       protected.UpdatedBy,
+		UpdatedAt:       protected.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func changeRequestToProto(request *ChangeRequest) *secretsservice.ChangeRequest {
+	converted := &secretsservice.ChangeRequest{
+		Id:            uint64(request.ID),
+		Tag:           request.Tag,
+		Kind:          request.Kind,
+		ParentTag:     request.ParentTag,
+		ParentVersion: int32(request.ParentVersion),
+		Path:          request.Path,
+		FileName:      request.FileName,
+		Branch:        request.Branch,
+		Status:        request.Status,
+		RequestedBy:   request.RequestedBy,
+		ReviewedBy:    request.ReviewedBy,
+		CreatedAt:     request.CreatedAt.Format(time.RFC3339),
+		ExpiresAt:     request.ExpiresAt.Format(time.RFC3339),
+		BaseVersion:   int32(request.BaseVersion),
+		Version:       int32(request.Version),
+		Checksum:      request.Checksum,
+		Annotation:    annotationToProto(request.Annotation),
+	}
+	if request.ResolvedAt != nil {
+		converted.ResolvedAt = request.ResolvedAt.Format(time.RFC3339)
+	}
+
+	var diff []changeRequestKeyChange
+	if err := json.Unmarshal([]byte(request.Diff), &diff); err == nil {
+		for _, change := range diff {
+			converted.Diff = append(converted.Diff, &secretsservice.ChangeRequestKeyChange{Key: change.Key, Change: change.Change})
+		}
+	}
+	for _, comment := range request.Comments {
+		converted.Comments = append(converted.Comments, &secretsservice.ChangeRequestComment{
+			Author:    comment.Author,
+			Body:      comment.Body,
+			CreatedAt: comment.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return converted
+}
+
+// loadChangeRequest returns a change request after expiring overdue ones, with its repository and the
+// repositories the user can access, for HasRepoPermission
+func (s *Server) loadChangeRequest(ctx context.Context, accessToken string, changeID uint64) (*ChangeRequest, *Repository, []*secretsservice.Repo, error) {
+	if err := ExpireChangeRequests(); err != nil {
+		log.Printf("Failed to expire change requests: %v", err)
+	}
+
+	request, err := GetChangeRequest(uint(changeID))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if request == nil {
+		return nil, nil, nil, fmt.Errorf("change request %d not found", changeID)
+	}
+
+	var repo Repository
+	if result := DB.First(&repo, request.RepoID); result.Error != nil {
+		return nil, nil, nil, fmt.Errorf("repository of change request %d not found", changeID)
+	}
+
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: accessToken})
+	if err != nil || listResp.Error != "" {
+		return nil, nil, nil, fmt.Errorf("Failed to list repos: %s", listResp.Error)
+	}
+	if !HasRepoAccess(listResp.Repos, repo.OwnerLogin, repo.RepoName) {
+		// Hide requests of repositories the user cannot see
+		return nil, nil, nil, fmt.Errorf("change request %d not found", changeID)
+	}
+	return request, &repo, listResp.Repos, nil
+}
@@ -0,0 +1,237 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// GitHub looks for CODEOWNERS in these locations, first match wins
+var codeownersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+type codeownersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// codeownersEnforced reports whether path scoped writes must be made by a CODEOWNERS owner
+func codeownersEnforced() bool {
+	return os.Getenv("ENFORCE_CODEOWNERS") == "true"
+}
+
+// checkCodeowners verifies that userLogin owns filePath according to the repository's CODEOWNERS.
+// Paths without a matching rule, and repositories without a CODEOWNERS file, are unrestricted.
+func checkCodeowners(ctx context.Context, accessToken, ownerLogin, repoName, userLogin, filePath string) error {
+	return checkCodeownersPaths(ctx, accessToken, ownerLogin, repoName, userLogin, []string{filePath})
+}
+
+// checkCodeownersPaths verifies that userLogin owns every one of filePaths, fetching CODEOWNERS once
+func checkCodeownersPaths(ctx context.Context, accessToken, ownerLogin, repoName, userLogin string, filePaths []string) error {
+	if len(filePaths) == 0 {
+		return nil
+	}
+	content, err := fetchCodeowners(ctx, accessToken, ownerLogin, repoName)
+	if err != nil {
+		return err
+	}
+	if content == "" {
+		return nil
+	}
+
+	rules := parseCodeowners(content)
+	for _, filePath := range filePaths {
+		owners := matchCodeowners(rules, filePath)
+		if len(owners) == 0 {
+			continue
+		}
+		owned, err := isCodeowner(ctx, accessToken, owners, userLogin)
+		if err != nil {
+			return err
+		}
+		if !owned {
+			return fmt.Errorf("%s is not a code owner of %s (owners: %s)", userLogin, filePath, strings.Join(owners, " "))
+		}
+	}
+	return nil
+}
+
+// isCodeowner reports whether userLogin is one of owners, directly or through a team
+func isCodeowner(ctx context.Context, accessToken string, owners []string, userLogin string) (bool, error) {
+	for _, owner := range owners {
+		if !strings.HasPrefix(owner, "@") {
+			continue // Email owners cannot be mapped to a GitHub login
+		}
+		owner = strings.TrimPrefix(owner, "@")
+
+		org, team, isTeam := strings.Cut(owner, "/")
+		if !isTeam {
+			if strings.EqualFold(owner, userLogin) {
+				return true, nil
+			}
+			continue
+		}
+
+		member, err := isTeamMember(ctx, accessToken, org, team, userLogin)
+		if err != nil {
+			return false, err
+		}
+		if member {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func fetchCodeowners(ctx context.Context, accessToken, ownerLogin, repoName string) (string, error) {
+	client := &http.Client{}
+
+	for _, location := range codeownersLocations {
+		url := fmt.Sprintf("%s/repos/%s/%s/contents/%s", os.Getenv("GITHUB_API_URL"), ownerLogin, repoName, location)
+		r, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to create request: %v", err)
+		}
+
+		r.Header.Add("Authorization", "Bearer "+accessToken)
+		r.Header.Add("Accept", "application/vnd.github.raw+json")
+		r.Header.Add("X-GitHub-Api-Version", "2022-11-28")
+
+		resp, err := client.Do(r)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch CODEOWNERS: %v", err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read CODEOWNERS: %v", err)
+		}
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return string(body), nil
+		case http.StatusNotFound:
+			continue
+		default:
+			return "", fmt.Errorf("GitHub API returned status %d for %s", resp.StatusCode, location)
+		}
+	}
+
+	return "", nil
+}
+
+func isTeamMember(ctx context.Context, accessToken, org, team, userLogin string) (bool, error) {
+	client := &http.Client{}
+
+	url := fmt.Sprintf("%s/orgs/%s/teams/%s/memberships/%s", os.Getenv("GITHUB_API_URL"), org, team, userLogin)
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	r.Header.Add("Authorization", "Bearer "+accessToken)
+	r.Header.Add("Accept", "application/vnd.github+json")
+	r.Header.Add("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := client.Do(r)
+	if err != nil {
+		return false, fmt.Errorf("failed to check team membership: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+
+	var membership struct {
+		State string `json:"state"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&membership); err != nil {
+		return false, fmt.Errorf("failed to decode membership: %v", err)
+	}
+
+	return membership.State == "active", nil
+}
+
+func parseCodeowners(content string) []codeownersRule {
+	var rules []codeownersRule
+	scanner := bufio.NewScanner(strings.NewReader(content))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		pattern, err := compileCodeownersPattern(fields[0])
+		if err != nil {
+			continue // GitHub ignores invalid lines too
+		}
+		rules = append(rules, codeownersRule{pattern: pattern, owners: fields[1:]})
+	}
+
+	return rules
+}
+
+// matchCodeowners returns the owners of the last matching rule, as GitHub does
+func matchCodeowners(rules []codeownersRule, filePath string) []string {
+	var owners []string
+	for _, rule := range rules {
+		if rule.pattern.MatchString(filePath) {
+			owners = rule.owners
+		}
+	}
+	return owners
+}
+
+// compileCodeownersPattern translates gitignore style CODEOWNERS patterns into a regexp
+func compileCodeownersPattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.Trim(pattern, "/")
+
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("^(.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+		case pattern[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		}
+	}
+
+	if dirOnly {
+		b.WriteString("/.*$")
+	} else {
+		b.WriteString("(/.*)?$")
+	}
+
+	return regexp.Compile(b.String())
+}
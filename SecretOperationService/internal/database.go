@@ -5,71 +5,475 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path"
 	"sort"
+	"strings"
 	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
 // Database models using GORM
 
 type Repository struct {
-	ID          uint      `gorm:"primaryKey;autoIncrement"`
-	OwnerLogin  string    `gorm:"size:255;not null"`
-	RepoName    string    `gorm:"size:255;not null"`
-	RepoID      int64     `gorm:"not null"`
-	FullName    string    `gorm:"size:500;not null"`
-	HTMLURL     string    `gorm:"size:1000;not null"`
-	Description string    `gorm:"type:text"`
-	IsPrivate   bool      `gorm:"default:false"`
-	CreatedAt   time.Time `gorm:"autoCreateTime"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
-	Secrets     []Secret  `gorm:"foreignKey:RepoID;constraint:OnDelete:CASCADE"`
+	ID          uint                        `gorm:"primaryKey;autoIncrement"`
+	OwnerLogin  string                      `gorm:"size:255;not null"`
+	RepoName    string                      `gorm:"size:255;not null"`
+	RepoID      int64                       `gorm:"not null"`
+	FullName    string                      `gorm:"size:500;not null"`
+	HTMLURL     string                      `gorm:"size:1000;not null"`
+	Description string                      `gorm:"type:text"`
+	IsPrivate   bool                        `gorm:"default:false"`
+	CreatedAt   time.Time                   `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time                   `gorm:"autoUpdateTime"`
+	Secrets     []Secret                    `gorm:"foreignKey:RepoID;constraint:OnDelete:CASCADE"`
+	Files       []SecretFile                `gorm:"foreignKey:RepoID;constraint:OnDelete:CASCADE"`
+	TagParents  []TagParent                 `gorm:"foreignKey:RepoID;constraint:OnDelete:CASCADE"`
+	References  []SecretReference           `gorm:"foreignKey:RepoID;constraint:OnDelete:CASCADE"`
+	SharedSets  []SharedSecretSetAttachment `gorm:"foreignKey:RepoID;constraint:OnDelete:CASCADE"`
+	Schemas     []TagSchema                 `gorm:"foreignKey:RepoID;constraint:OnDelete:CASCADE"`
+	LintRules   []LintRuleSetting           `gorm:"foreignKey:RepoID;constraint:OnDelete:CASCADE"`
+	Engines     []SecretEngineConfig        `gorm:"foreignKey:RepoID;constraint:OnDelete:CASCADE"`
 }
 
 func (Repository) TableName() string {
 	return "repositories"
 }
 
+// DefaultSecretFileName is used for secrets uploaded without an explicit file name
+const DefaultSecretFileName = ".env"
+
 type Secret struct {
 	ID           uint      `gorm:"primaryKey;autoIncrement"`
 	RepoID       uint      `gorm:"not null;uniqueIndex:idx_repo_tag_version,priority:1"`
-	Tag          string    `gorm:"size:255;uniqueIndex:idx_repo_tag_version,priority:2"`
-	Version      int       `gorm:"not null;uniqueIndex:idx_repo_tag_version,priority:3"`
+	Path         string    `gorm:"size:1000;not null;default:'';uniqueIndex:idx_repo_tag_version,priority:2"` // Monorepo scope, "" is the repository root
+	FileName     string    `gorm:"size:500;not null;default:.env;uniqueIndex:idx_repo_tag_version,priority:3"`
+	Branch       string    `gorm:"size:255;not null;default:'';uniqueIndex:idx_repo_tag_version,priority:4"` // Branch overlay, "" for the base secret
+	Tag          string    `gorm:"size:255;uniqueIndex:idx_repo_tag_version,priority:5"`
+	Version      int       `gorm:"not null;uniqueIndex:idx_repo_tag_version,priority:6"`
 	EnvData      string    `gorm:"type:text;not null"` // Changed from JSONB to TEXT for encrypted data
 	Checksum     string    `gorm:"size:64;not null"`
-	UploadedBy   string    `gorm:"size:255;not null"`
+	UploadedBy   string    `gorm:"size:255;not null"` // GitHub login of the uploader
 	CreatedAt    time.Time `gorm:"autoCreateTime"`
 	EncryptedKey string    `gorm:"size:255"` // Encrypted per-secret key
+
+	Annotation  VersionAnnotation   `gorm:"embedded"`
+	KeyMetadata []SecretKeyMetadata `gorm:"foreignKey:SecretID;constraint:OnDelete:CASCADE"`
+	KeyNames    []SecretKeyName     `gorm:"foreignKey:SecretID;constraint:OnDelete:CASCADE"`
+	KeysIndexed bool                `gorm:"not null;default:false"` // KeyNames is complete; false for versions stored before the index
+}
+
+// VersionAnnotation describes why and from where a secret version was created
+type VersionAnnotation struct {
+	Message       string `gorm:"type:text;not null;default:''"`
+	CommitSHA     string `gorm:"size:64;not null;default:''"`
+	GitBranch     string `gorm:"size:255;not null;default:''"`
+	ClientType    string `gorm:"size:50;not null;default:''"`
+	ClientVersion string `gorm:"size:50;not null;default:''"`
 }
 
 func (Secret) TableName() string {
 	return "secrets"
 }
 
+// SecretKeyMetadata holds the optional expiry and rotation details of one key in a secret version
+type SecretKeyMetadata struct {
+	ID               uint       `gorm:"primaryKey;autoIncrement"`
+	SecretID         uint       `gorm:"not null;uniqueIndex:idx_secret_key_metadata,priority:1"`
+	Key              string     `gorm:"size:255;not null;uniqueIndex:idx_secret_key_metadata,priority:2"`
+	ExpiresAt        *time.Time // When the value stops working, e.g. a vendor token's expiry
+	Owner            string     `gorm:"size:255"` // Person or team responsible for rotating the value
+	RotationInterval string     `gorm:"size:32"`  // How often the value must change, e.g. "90d"
+	RotatedAt        time.Time  `gorm:"not null"` // When the value last changed; rotation is due RotationInterval later
+}
+
+func (SecretKeyMetadata) TableName() string {
+	return "secret_key_metadata"
+}
+
+// SecretKeyName indexes the key names of a secret version so keys can be searched without decrypting values
+type SecretKeyName struct {
+	ID       uint   `gorm:"primaryKey;autoIncrement"`
+	SecretID uint   `gorm:"not null;uniqueIndex:idx_secret_key_name,priority:1"`
+	Key      string `gorm:"size:255;not null;uniqueIndex:idx_secret_key_name,priority:2;index"`
+}
+
+func (SecretKeyName) TableName() string {
+	return "secret_key_names"
+}
+
+// SecretFile stores an opaque file blob (certificate, keyfile, keystore) versioned per repo, file name and tag
+type SecretFile struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement"`
+	RepoID       uint      `gorm:"not null;uniqueIndex:idx_repo_file_tag_version,priority:1"`
+	FileName     string    `gorm:"size:500;not null;uniqueIndex:idx_repo_file_tag_version,priority:2"`
+	Tag          string    `gorm:"size:255;uniqueIndex:idx_repo_file_tag_version,priority:3"`
+	Version      int       `gorm:"not null;uniqueIndex:idx_repo_file_tag_version,priority:4"`
+	ContentType  string    `gorm:"size:255;not null"`
+	Size         int64     `gorm:"not null"`
+	Data         string    `gorm:"type:text;not null"` // Base64 of the encrypted blob
+	Checksum     string    `gorm:"size:64;not null"`
+	UploadedBy   string    `gorm:"size:255;not null"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+	EncryptedKey string    `gorm:"size:255;not null"` // Encrypted per-file key
+}
+
+func (SecretFile) TableName() string {
+	return "secret_files"
+}
+
+// TagParent declares that a tag inherits the keys of a parent tag within the same path and file
+type TagParent struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	RepoID        uint      `gorm:"not null;uniqueIndex:idx_repo_scope_tag,priority:1"`
+	Path          string    `gorm:"size:1000;not null;default:'';uniqueIndex:idx_repo_scope_tag,priority:2"`
+	FileName      string    `gorm:"size:500;not null;uniqueIndex:idx_repo_scope_tag,priority:3"`
+	Tag           string    `gorm:"size:255;not null;uniqueIndex:idx_repo_scope_tag,priority:4"`
+	ParentTag     string    `gorm:"size:255;not null"`
+	ParentVersion int       `gorm:"not null;default:0"` // 0 follows the parent's latest version
+	UpdatedBy     string    `gorm:"size:255;not null"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+}
+
+func (TagParent) TableName() string {
+	return "tag_parents"
+}
+
+// SecretReference records that a key in the latest version of a secret stream references another repository's key
+type SecretReference struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	RepoID      uint      `gorm:"not null;index:idx_reference_source,priority:1"`
+	Path        string    `gorm:"size:1000;not null;default:'';index:idx_reference_source,priority:2"`
+	FileName    string    `gorm:"size:500;not null;index:idx_reference_source,priority:3"`
+	Branch      string    `gorm:"size:255;not null;default:'';index:idx_reference_source,priority:4"`
+	Tag         string    `gorm:"size:255;index:idx_reference_source,priority:5"`
+	Key         string    `gorm:"size:255;not null"`
+	TargetOwner string    `gorm:"size:255;not null;index:idx_reference_target,priority:1"`
+	TargetRepo  string    `gorm:"size:255;not null;index:idx_reference_target,priority:2"`
+	TargetTag   string    `gorm:"size:255;not null"`
+	TargetKey   string    `gorm:"size:255;not null;index:idx_reference_target,priority:3"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+}
+
+func (SecretReference) TableName() string {
+	return "secret_references"
+}
+
+// SharedSecretSet is a set of keys owned by a GitHub organization and managed by its admins
+type SharedSecretSet struct {
+	ID          uint                        `gorm:"primaryKey;autoIncrement"`
+	OrgLogin    string                      `gorm:"size:255;not null;uniqueIndex:idx_org_set_name,priority:1"`
+	Name        string                      `gorm:"size:255;not null;uniqueIndex:idx_org_set_name,priority:2"`
+	CreatedBy   string                      `gorm:"size:255;not null"`
+	CreatedAt   time.Time                   `gorm:"autoCreateTime"`
+	UpdatedAt   time.Time                   `gorm:"autoUpdateTime"`
+	Versions    []SharedSecretSetVersion    `gorm:"foreignKey:SetID;constraint:OnDelete:CASCADE"`
+	Attachments []SharedSecretSetAttachment `gorm:"foreignKey:SetID;constraint:OnDelete:CASCADE"`
+}
+
+func (SharedSecretSet) TableName() string {
+	return "shared_secret_sets"
+}
+
+// SharedSecretSetVersion is one encrypted upload of a shared secret set
+type SharedSecretSetVersion struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement"`
+	SetID        uint      `gorm:"not null;uniqueIndex:idx_set_version,priority:1"`
+	Version      int       `gorm:"not null;uniqueIndex:idx_set_version,priority:2"`
+	EnvData      string    `gorm:"type:text;not null"`
+	Checksum     string    `gorm:"size:64;not null"`
+	UploadedBy   string    `gorm:"size:255;not null"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+	EncryptedKey string    `gorm:"size:255;not null"` // Encrypted per-version key
+}
+
+func (SharedSecretSetVersion) TableName() string {
+	return "shared_secret_set_versions"
+}
+
+// SharedSecretSetAttachment merges a shared secret set into the downloads of a repository
+type SharedSecretSetAttachment struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement"`
+	SetID         uint      `gorm:"not null;uniqueIndex:idx_set_repo,priority:1"`
+	RepoID        uint      `gorm:"not null;uniqueIndex:idx_set_repo,priority:2;index"`
+	PinnedVersion int       `gorm:"not null;default:0"` // 0 follows the set's latest version
+	AttachedBy    string    `gorm:"size:255;not null"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+}
+
+func (SharedSecretSetAttachment) TableName() string {
+	return "shared_secret_set_attachments"
+}
+
+// TagSchema stores the JSON schema uploads to a tag are validated against; the "" tag applies to tags without their own
+type TagSchema struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	RepoID     uint      `gorm:"not null;uniqueIndex:idx_repo_schema_tag,priority:1"`
+	Tag        string    `gorm:"size:255;not null;default:'';uniqueIndex:idx_repo_schema_tag,priority:2"`
+	Definition string    `gorm:"type:text;not null"`
+	UpdatedBy  string    `gorm:"size:255;not null"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime"`
+}
+
+func (TagSchema) TableName() string {
+	return "tag_schemas"
+}
+
+// LintRuleSetting overrides whether a lint rule warns, blocks uploads or is off for a repository; rules warn by default
+type LintRuleSetting struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	RepoID    uint      `gorm:"not null;uniqueIndex:idx_repo_lint_rule,priority:1"`
+	Rule      string    `gorm:"size:100;not null;uniqueIndex:idx_repo_lint_rule,priority:2"`
+	Action    string    `gorm:"size:20;not null"`
+	UpdatedBy string    `gorm:"size:255;not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+func (LintRuleSetting) TableName() string {
+	return "lint_rule_settings"
+}
+
+// SecretEngineConfig configures a secret engine that issues short-lived credentials into downloads of a tag
+type SecretEngineConfig struct {
+	ID           uint          `gorm:"primaryKey;autoIncrement"`
+	RepoID       uint          `gorm:"not null;uniqueIndex:idx_repo_engine_name,priority:1"`
+	Name         string        `gorm:"size:100;not null;uniqueIndex:idx_repo_engine_name,priority:2"`
+	Engine       string        `gorm:"size:50;not null"`
+	Tag          string        `gorm:"size:255;not null;default:''"` // Tag whose downloads get credentials, "" for every tag
+	DefaultTTL   int           `gorm:"not null"`                     // Seconds
+	MaxTTL       int           `gorm:"not null"`                     // Seconds
+	Config       string        `gorm:"type:text;not null"`           // Encrypted engine specific JSON, holds the admin connection
+	EncryptedKey string        `gorm:"size:255;not null"`
+	UpdatedBy    string        `gorm:"size:255;not null"`
+	CreatedAt    time.Time     `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time     `gorm:"autoUpdateTime"`
+	Leases       []SecretLease `gorm:"foreignKey:EngineID;constraint:OnDelete:CASCADE"`
+}
+
+func (SecretEngineConfig) TableName() string {
+	return "secret_engine_configs"
+}
+
+// WebhookSubscription sends secret lifecycle events of a repository, or of every repository of an
+// organization, to a URL
+type WebhookSubscription struct {
+	ID           uint              `gorm:"primaryKey;autoIncrement"`
+	RepoID       *uint             `gorm:"index"`                              // Set for repository webhooks
+	OrgLogin     string            `gorm:"size:255;not null;default:'';index"` // Set for organization webhooks
+	URL          string            `gorm:"size:2000;not null"`
+	Events       string            `gorm:"type:text;not null"` // Comma separated event names, "*" for every event
+	Secret       string            `gorm:"type:text;not null"` // Encrypted HMAC key the payloads are signed with
+	EncryptedKey string            `gorm:"size:255;not null"`
+	Active       bool              `gorm:"not null"`
+	CreatedBy    string            `gorm:"size:255;not null"`
+	CreatedAt    time.Time         `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time         `gorm:"autoUpdateTime"`
+	Deliveries   []WebhookDelivery `gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// WebhookDelivery is an event sent, or still to be sent, to a webhook. Pending deliveries form the
+// persistent delivery queue.
+type WebhookDelivery struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement"`
+	SubscriptionID uint      `gorm:"not null;index"`
+	DeliveryID     string    `gorm:"size:36;not null;uniqueIndex"` // Sent as X-Envini-Delivery
+	Event          string    `gorm:"size:50;not null"`
+	Payload        string    `gorm:"type:text;not null"`
+	Status         string    `gorm:"size:20;not null;index:idx_webhook_delivery_queue,priority:1"`
+	NextAttemptAt  time.Time `gorm:"not null;index:idx_webhook_delivery_queue,priority:2"`
+	Attempts       int       `gorm:"not null;default:0"`
+	LastStatusCode int       `gorm:"not null;default:0"`
+	LastError      string    `gorm:"type:text"`
+	RedeliveryOf   *uint     // Delivery this one repeats
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	DeliveredAt    *time.Time
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// ProtectedTag requires uploads to a tag to be approved by a second person before they become a version
+type ProtectedTag struct {
+	ID              uint      `gorm:"primaryKey;autoIncrement"`
+	RepoID          uint      `gorm:"not null;uniqueIndex:idx_repo_protected_tag,priority:1"`
+	Tag             string    `gorm:"size:255;not null;uniqueIndex:idx_repo_protected_tag,priority:2"`
+	RequestTTLHours int       `gorm:"not null"` // How long change requests wait for a review
+	UpdatedBy       string    `gorm:"size:255;not null"`
+	CreatedAt       time.Time `gorm:"autoCreateTime"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime"`
+}
+
+func (ProtectedTag) TableName() string {
+	return "protected_tags"
+}
+
+// ChangeRequest is a proposed version or parent of a protected tag. The content stays encrypted here until a
+// reviewer approves it, and is wiped once the request is resolved.
+type ChangeRequest struct {
+	ID            uint              `gorm:"primaryKey;autoIncrement"`
+	RepoID        uint              `gorm:"not null;index"`
+	Path          string            `gorm:"size:1000;not null;default:''"`
+	FileName      string            `gorm:"size:500;not null"`
+	Branch        string            `gorm:"size:255;not null;default:''"`
+	Tag           string            `gorm:"size:255;not null"`
+	Kind          string            `gorm:"size:20;not null;default:'version'"` // version or parent
+	Status        string            `gorm:"size:20;not null;index"`
+	EnvData       string            `gorm:"type:text"` // Encrypted proposed content
+	EncryptedKey  string            `gorm:"size:255"`
+	Checksum      string            `gorm:"size:64;not null"`
+	KeyMetadata   string            `gorm:"type:text"` // Requested key metadata as JSON, applied on approval
+	Annotation    VersionAnnotation `gorm:"embedded"`
+	BaseVersion   int               `gorm:"not null"`  // Latest version when proposed; approval fails once it changed
+	Diff          string            `gorm:"type:text"` // JSON list of changed key names
+	ParentTag     string            `gorm:"size:255"`  // Proposed parent of a parent change, empty to remove it
+	ParentVersion int               // Proposed pinned parent version, 0 follows the latest
+	RequestedBy   string            `gorm:"size:255;not null"`
+	ReviewedBy    string            `gorm:"size:255"`
+	Version       int               // Version created on approval
+	SecretID      *uint             // Secret created on approval
+	ExpiresAt     time.Time         `gorm:"not null"`
+	CreatedAt     time.Time         `gorm:"autoCreateTime"`
+	ResolvedAt    *time.Time
+	Comments      []ChangeRequestComment `gorm:"foreignKey:ChangeRequestID;constraint:OnDelete:CASCADE"`
+}
+
+func (ChangeRequest) TableName() string {
+	return "change_requests"
+}
+
+// ChangeRequestComment is a note left by the requester or a reviewer
+type ChangeRequestComment struct {
+	ID              uint      `gorm:"primaryKey;autoIncrement"`
+	ChangeRequestID uint      `gorm:"not null;index"`
+	Author          string    `gorm:"size:255;not null"`
+	Body            string    `gorm:"type:text;not null"`
+	CreatedAt       time.Time `gorm:"autoCreateTime"`
+}
+
+func (ChangeRequestComment) TableName() string {
+	return "change_request_comments"
+}
+
+// ShareLink is a snapshot of a key, version or file handed out through a link. The snapshot is encrypted
+// under the link key, which only the creator receives, and is wiped once the link is used up or expires.
+type ShareLink struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement"`
+	LinkID       string    `gorm:"size:32;not null;uniqueIndex"` // Random public id, not guessable like ID
+	RepoID       uint      `gorm:"not null;index"`
+	SecretID     *uint     // Version the snapshot was taken from
+	FileID       *uint     // File secret the snapshot was taken from
+	Kind         string    `gorm:"size:20;not null"` // key, version or file
+	Tag          string    `gorm:"size:255"`
+	Version      int       `gorm:"not null"`
+	Path         string    `gorm:"size:1000;not null;default:''"`
+	FileName     string    `gorm:"size:500;not null"`
+	Branch       string    `gorm:"size:255;not null;default:''"` // Branch overlay applied to the snapshot, "" for none
+	Key          string    `gorm:"size:255"`
+	ContentType  string    `gorm:"size:255"`
+	Data         string    `gorm:"type:text"` // Base64 of the snapshot encrypted under the link key
+	MaxViews     int       `gorm:"not null"`
+	Views        int       `gorm:"not null;default:0"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedBy    string    `gorm:"size:255;not null"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+	LastViewedAt *time.Time
+}
+
+func (ShareLink) TableName() string {
+	return "share_links"
+}
+
+// SecretEvent is a new or deleted version streamed to WatchSecrets subscribers; its ID is the resume token
+type SecretEvent struct {
+	ID              uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	RepoID          uint      `gorm:"not null;index" json:"repoId"`
+	Type            string    `gorm:"size:30;not null" json:"type"`
+	Tag             string    `gorm:"size:255" json:"tag"` // Empty when a deletion covered every tag
+	Version         int       `json:"version"`             // 0 when a deletion covered every version
+	Path            string    `gorm:"size:1000" json:"path"`
+	FileName        string    `gorm:"size:500" json:"fileName"`
+	Branch          string    `gorm:"size:255" json:"branch"`
+	File            bool      `json:"file"` // A SecretFile version
+	Checksum        string    `gorm:"size:64" json:"checksum"`
+	DeletedVersions int       `json:"deletedVersions"`
+	Actor           string    `gorm:"size:255" json:"actor"`
+	CreatedAt       time.Time `gorm:"autoCreateTime;index" json:"createdAt"`
+}
+
+func (SecretEvent) TableName() string {
+	return "secret_events"
+}
+
+// SecretLease is a credential issued by a secret engine; the lease reaper revokes it once it expires
+type SecretLease struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	LeaseID   string     `gorm:"size:36;not null;uniqueIndex"`
+	EngineID  uint       `gorm:"not null;index"`
+	RepoID    uint       `gorm:"not null;index"`
+	SecretID  uint       `gorm:"not null"`          // Secret version whose download issued the lease
+	Principal string     `gorm:"size:255;not null"` // Engine specific identity to revoke, e.g. a Postgres role name
+	IssuedTo  string     `gorm:"size:255;not null"`
+	ExpiresAt time.Time  `gorm:"not null;index"`
+	RevokedAt *time.Time `gorm:"index"`
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+}
+
+func (SecretLease) TableName() string {
+	return "secret_leases"
+}
+
 type AuditLog struct {
 	ID           uint      `gorm:"primaryKey;autoIncrement"`
 	Operation    string    `gorm:"size:50;not null"`
 	RepoID       *uint     `gorm:"index"`
 	SecretID     *uint     `gorm:"index"`
+	SharedSetID  *uint     `gorm:"index"`
+	LeaseID      string    `gorm:"size:36;index"` // Set on LEASE_ISSUE and LEASE_REVOKE
 	Username     string    `gorm:"size:255;not null"`
 	ServiceName  string    `gorm:"size:100;not null"`
 	RequestID    string    `gorm:"size:255"`
 	Success      bool      `gorm:"not null"`
 	ErrorMessage string    `gorm:"type:text"`
 	CreatedAt    time.Time `gorm:"autoCreateTime;index"`
+	PrevHash     string    `gorm:"size:64;not null;default:''"` // Hash of the entry before this one
+	Hash         string    `gorm:"size:64;not null;default:''"` // SHA-256 of PrevHash and the entry's content, empty for entries older than the chain
 }
 
 func (AuditLog) TableName() string {
 	return "audit_logs"
 }
 
+// AuditCheckpoint is a signature over the hash of an audit entry, which pins every entry up to it
+type AuditCheckpoint struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	AuditLogID uint      `gorm:"not null;index"`
+	Hash       string    `gorm:"size:64;not null"`
+	KeyID      string    `gorm:"size:16;not null"`
+	Signature  string    `gorm:"type:text;not null"` // Base64 Ed25519 signature
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+func (AuditCheckpoint) TableName() string {
+	return "audit_checkpoints"
+}
+
 // Database connection
 var DB *gorm.DB
 
@@ -156,26 +560,76 @@ func decryptSecretKey(encryptedSecretKey []byte) ([]byte, error) {
 	return decryptData(encryptedSecretKey, masterKey)
 }
 
+// sealWithNewKey encrypts data under a fresh per-secret key and wraps that key with the master key.
+// Both results are base64 encoded for storage.
+func sealWithNewKey(data []byte) (string, string, error) {
+	// Generate a unique key for this secret
+	secretKey, err := generateSecretKey()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate secret key: %v", err)
+	}
+
+	// Encrypt the data
+	encryptedData, err := encryptData(data, secretKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt data: %v", err)
+	}
+
+	// Encrypt the secret key with master key
+	encryptedSecretKey, err := encryptSecretKey(secretKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt secret key: %v", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(encryptedData), base64.StdEncoding.EncodeToString(encryptedSecretKey), nil
+}
+
+// openWithKey reverses sealWithNewKey
+func openWithKey(encodedData, encodedKey string) ([]byte, error) {
+	// Decode the encrypted secret key
+	encryptedSecretKeyBytes, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted secret key: %v", err)
+	}
+
+	// Decrypt the secret key
+	secretKey, err := decryptSecretKey(encryptedSecretKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret key: %v", err)
+	}
+
+	// Decode the encrypted data
+	encryptedDataBytes, err := base64.StdEncoding.DecodeString(encodedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted data: %v", err)
+	}
+
+	// Decrypt the data
+	decryptedData, err := decryptData(encryptedDataBytes, secretKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %v", err)
+	}
+
+	return decryptedData, nil
+}
+
 // InitDatabase initializes the database connection and runs migrations
 func InitDatabase() error {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
-	)
-
 	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+	DB, err = gorm.Open(postgres.Open(databaseDSN()), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %v", err)
 	}
 
+	// The unique index gained file_name; GORM keeps an existing index as is, so drop the old one first
+	if err := dropStaleSecretIndex(); err != nil {
+		return fmt.Errorf("failed to migrate database: %v", err)
+	}
+
 	// Auto migrate the schema - GORM will handle the order automatically
-	err = DB.AutoMigrate(&Repository{}, &Secret{}, &AuditLog{})
+	err = DB.AutoMigrate(&Repository{}, &Secret{}, &SecretKeyMetadata{}, &SecretKeyName{}, &SecretFile{}, &TagParent{}, &SecretReference{}, &SharedSecretSet{}, &SharedSecretSetVersion{}, &SharedSecretSetAttachment{}, &TagSchema{}, &LintRuleSetting{}, &SecretEngineConfig{}, &SecretLease{}, &WebhookSubscription{}, &WebhookDelivery{}, &SecretEvent{}, &ProtectedTag{}, &ChangeRequest{}, &ChangeRequestComment{}, &ShareLink{}, &AuditLog{}, &AuditCheckpoint{})
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %v", err)
 	}
@@ -184,8 +638,74 @@ func InitDatabase() error {
 	return nil
 }
 
+// databaseDSN builds the connection string from the DB_* variables; the secret event listener opens its own
+// connection with it
+func databaseDSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
+		os.Getenv("DB_HOST"),
+		os.Getenv("DB_USER"),
+		os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_NAME"),
+		os.Getenv("DB_PORT"),
+	)
+}
+
+// secretIndexColumns lists the columns idx_repo_tag_version must cover besides repo_id, tag and version
+var secretIndexColumns = []string{"path", "file_name", "branch"}
+
+// dropStaleSecretIndex drops idx_repo_tag_version if it predates one of secretIndexColumns
+func dropStaleSecretIndex() error {
+	var indexDef string
+	result := DB.Raw("SELECT indexdef FROM pg_indexes WHERE indexname = ?", "idx_repo_tag_version").Scan(&indexDef)
+	if result.Error != nil {
+		return result.Error
+	}
+	if indexDef == "" {
+		return nil
+	}
+
+	for _, column := range secretIndexColumns {
+		if !strings.Contains(indexDef, column) {
+			log.Printf("Recreating idx_repo_tag_version with %s", column)
+			return DB.Migrator().DropIndex(&Secret{}, "idx_repo_tag_version")
+		}
+	}
+	return nil
+}
+
 // Database operations
 
+// secretFileName applies the backward compatible default file name
+func secretFileName(fileName string) string {
+	if fileName == "" {
+		return DefaultSecretFileName
+	}
+	return fileName
+}
+
+// SecretScope identifies one independently versioned secret stream inside a repository
+type SecretScope struct {
+	Path     string // Monorepo path, "" for the repository root
+	FileName string // Secret file name, "" for DefaultSecretFileName
+	Branch   string // Git branch overlay, "" for the base secret
+}
+
+// where narrows a query on the secrets table to this scope
+func (sc SecretScope) where(query *gorm.DB) *gorm.DB {
+	return query.Where("path = ? AND file_name = ? AND branch = ?", sc.Path, secretFileName(sc.FileName), sc.Branch)
+}
+
+// Base returns the scope without its branch overlay
+func (sc SecretScope) Base() SecretScope {
+	sc.Branch = ""
+	return sc
+}
+
+// RepoPath returns the secret file's location relative to the repository root
+func (sc SecretScope) RepoPath() string {
+	return path.Join(sc.Path, secretFileName(sc.FileName))
+}
+
 // GetOrCreateRepository gets an existing repository or creates a new one
 func GetOrCreateRepository(ownerLogin, repoName string, repoID int64, fullName, htmlURL, description string, isPrivate bool) (*Repository, error) {
 	var repo Repository
@@ -238,12 +758,17 @@ func GetNextVersion(repoID uint) (int, error) {
 	return nextVersion, nil
 }
 
-// GetNextVersionForTag gets the next version for a specific tag
+// GetNextVersionForTag gets the next version for a specific file and tag
 // If the tag doesn't exist, it returns version 1
 // If the tag exists, it returns the next version for that tag
-func GetNextVersionForTag(repoID uint, tag string) (int, error) {
+func GetNextVersionForTag(repoID uint, scope SecretScope, tag string) (int, error) {
+	return getNextVersionForTag(DB, repoID, scope, tag)
+}
+
+// getNextVersionForTag is GetNextVersionForTag on db, which may be a transaction
+func getNextVersionForTag(db *gorm.DB, repoID uint, scope SecretScope, tag string) (int, error) {
 	var maxVersion int
-	result := DB.Model(&Secret{}).
+	result := scope.where(db.Model(&Secret{})).
 		Where("repo_id = ? AND tag = ?", repoID, tag).
 		Select("COALESCE(MAX(version), 0)").
 		Scan(&maxVersion)
@@ -256,49 +781,50 @@ func GetNextVersionForTag(repoID uint, tag string) (int, error) {
 	return nextVersion, nil
 }
 
-// CreateSecret creates a new secret version with optional encryption
-func CreateSecret(repoID uint, version int, tag, envData, checksum, uploadedBy string, encrypt bool) (*Secret, error) {
+// CreateSecret creates a new secret version with optional encryption, together with its key metadata and
+// the index of its key names
+func CreateSecret(repoID uint, scope SecretScope, version int, tag, envData, checksum, uploadedBy string, annotation VersionAnnotation, encrypt bool, keyMetadata []SecretKeyMetadata) (*Secret, error) {
+	return createSecret(DB, repoID, scope, version, tag, envData, checksum, uploadedBy, annotation, encrypt, keyMetadata)
+}
+
+// createSecret is CreateSecret on db, which may be a transaction
+func createSecret(db *gorm.DB, repoID uint, scope SecretScope, version int, tag, envData, checksum, uploadedBy string, annotation VersionAnnotation, encrypt bool, keyMetadata []SecretKeyMetadata) (*Secret, error) {
+	keyNames, err := secretKeyNames(envData)
+	if err != nil {
+		return nil, err
+	}
 
 	var encryptedKey string
 	var finalEnvData string
 
 	if encrypt {
-		// Generate a unique key for this secret
-		secretKey, err := generateSecretKey()
-		if err != nil {
-			return nil, fmt.Errorf("failed to generate secret key: %v", err)
-		}
-
-		// Encrypt the env data
-		encryptedData, err := encryptData([]byte(envData), secretKey)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encrypt data: %v", err)
-		}
-
-		// Encrypt the secret key with master key
-		encryptedSecretKey, err := encryptSecretKey(secretKey)
+		// Store encrypted data and key
+		finalEnvData, encryptedKey, err = sealWithNewKey([]byte(envData))
 		if err != nil {
-			return nil, fmt.Errorf("failed to encrypt secret key: %v", err)
+			return nil, err
 		}
-
-		// Store encrypted data and key
-		finalEnvData = base64.StdEncoding.EncodeToString(encryptedData)
-		encryptedKey = base64.StdEncoding.EncodeToString(encryptedSecretKey)
 	} else {
 		finalEnvData = envData
 	}
 
 	secret := &Secret{
 		RepoID:       repoID,
+		Path:         scope.Path,
+		FileName:     secretFileName(scope.FileName),
+		Branch:       scope.Branch,
 		Version:      version,
 		Tag:          tag,
 		EnvData:      finalEnvData,
 		Checksum:     checksum,
 		UploadedBy:   uploadedBy,
 		EncryptedKey: encryptedKey,
+		Annotation:   annotation,
+		KeyMetadata:  keyMetadata,
+		KeyNames:     keyNames,
+		KeysIndexed:  true,
 	}
 
-	result := DB.Create(secret)
+	result := db.Create(secret)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to create secret: %v", result.Error)
 	}
@@ -306,10 +832,23 @@ func CreateSecret(repoID uint, version int, tag, envData, checksum, uploadedBy s
 	return secret, nil
 }
 
-// GetSecretByVersion gets a specific version of a secret
-func GetSecretByVersion(repoID uint, version int) (*Secret, error) {
+// secretKeyNames lists the keys of plaintext env data as SecretKeyName rows
+func secretKeyNames(envData string) ([]SecretKeyName, error) {
+	var data map[string]string
+	if err := json.Unmarshal([]byte(envData), &data); err != nil {
+		return nil, fmt.Errorf("failed to index key names: %v", err)
+	}
+	names := make([]SecretKeyName, 0, len(data))
+	for _, key := range sortedKeys(data) {
+		names = append(names, SecretKeyName{Key: key})
+	}
+	return names, nil
+}
+
+// GetSecretByVersion gets a specific version of a secret file
+func GetSecretByVersion(repoID uint, scope SecretScope, version int) (*Secret, error) {
 	var secret Secret
-	result := DB.Where("repo_id = ? AND version = ?", repoID, version).First(&secret)
+	result := scope.where(DB).Where("repo_id = ? AND version = ?", repoID, version).First(&secret)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to get secret: %v", result.Error)
 	}
@@ -317,9 +856,9 @@ func GetSecretByVersion(repoID uint, version int) (*Secret, error) {
 }
 
 // GetSecretByTag gets a secret by tag (returns the latest version with that tag)
-func GetSecretByTag(repoID uint, tag string) (*Secret, error) {
+func GetSecretByTag(repoID uint, scope SecretScope, tag string) (*Secret, error) {
 	var secret Secret
-	result := DB.Where("repo_id = ? AND tag = ?", repoID, tag).
+	result := scope.where(DB).Where("repo_id = ? AND tag = ?", repoID, tag).
 		Order("version DESC").
 		First(&secret)
 	if result.Error != nil {
@@ -328,19 +867,61 @@ func GetSecretByTag(repoID uint, tag string) (*Secret, error) {
 	return &secret, nil
 }
 
-func GetSecretByTagAndVersion(repoID uint, tag string, version int) (*Secret, error) {
+// GetBranchOverlay gets the latest overlay of scope.Branch for a tag, or nil if the branch has none
+func GetBranchOverlay(repoID uint, scope SecretScope, tag string) (*Secret, error) {
+	return getBranchOverlay(DB, repoID, scope, tag)
+}
+
+// getBranchOverlay is GetBranchOverlay on db, which may be a transaction
+func getBranchOverlay(db *gorm.DB, repoID uint, scope SecretScope, tag string) (*Secret, error) {
+	var secrets []Secret
+	result := scope.where(db).Where("repo_id = ? AND tag = ?", repoID, tag).
+		Order("version DESC").
+		Limit(1).
+		Find(&secrets)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get branch overlay: %v", result.Error)
+	}
+	if len(secrets) == 0 {
+		return nil, nil
+	}
+	return &secrets[0], nil
+}
+
+// GetBranchOverlayAsOf gets the latest overlay of scope.Branch for a tag created no later than asOf, or nil if there is none
+func GetBranchOverlayAsOf(repoID uint, scope SecretScope, tag string, asOf time.Time) (*Secret, error) {
+	var secrets []Secret
+	result := scope.where(DB).Where("repo_id = ? AND tag = ? AND created_at <= ?", repoID, tag, asOf).
+		Order("version DESC").
+		Limit(1).
+		Find(&secrets)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get branch overlay: %v", result.Error)
+	}
+	if len(secrets) == 0 {
+		return nil, nil
+	}
+	return &secrets[0], nil
+}
+
+func GetSecretByTagAndVersion(repoID uint, scope SecretScope, tag string, version int) (*Secret, error) {
+	return getSecretByTagAndVersion(DB, repoID, scope, tag, version)
+}
+
+// getSecretByTagAndVersion is GetSecretByTagAndVersion on db, which may be a transaction
+func getSecretByTagAndVersion(db *gorm.DB, repoID uint, scope SecretScope, tag string, version int) (*Secret, error) {
 	var secret Secret
-	result := DB.Where("repo_id = ? AND tag = ? AND version = ?", repoID, tag, version).First(&secret)
+	result := scope.where(db).Where("repo_id = ? AND tag = ? AND version = ?", repoID, tag, version).First(&secret)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to get secret by tag and version: %v", result.Error)
 	}
 	return &secret, nil
 }
 
-// GetLatestSecret gets the latest version of a secret
-func GetLatestSecret(repoID uint) (*Secret, error) {
+// GetLatestSecret gets the latest version of a secret file
+func GetLatestSecret(repoID uint, scope SecretScope) (*Secret, error) {
 	var secret Secret
-	result := DB.Where("repo_id = ?", repoID).
+	result := scope.where(DB).Where("repo_id = ?", repoID).
 		Order("version DESC").
 		First(&secret)
 	if result.Error != nil {
@@ -349,10 +930,20 @@ func GetLatestSecret(repoID uint) (*Secret, error) {
 	return &secret, nil
 }
 
-// ListSecretVersions gets all versions of secrets for a repository
-func ListSecretVersions(repoID uint) ([]Secret, error) {
+// ListSecretVersions gets all versions of secrets for a repository, optionally for a single path and/or file
+func ListSecretVersions(repoID uint, scopePath *string, fileName string) ([]Secret, error) {
 	var secrets []Secret
-	result := DB.Where("repo_id = ?", repoID).
+	query := DB.Where("repo_id = ?", repoID)
+	if scopePath != nil {
+		query = query.Where("path = ?", *scopePath)
+	}
+	if fileName != "" {
+		query = query.Where("file_name = ?", fileName)
+	}
+	result := query.
+		Order("path ASC").
+		Order("file_name ASC").
+		Order("branch ASC").
 		Order("version DESC").
 		Find(&secrets)
 	if result.Error != nil {
@@ -361,41 +952,41 @@ func ListSecretVersions(repoID uint) ([]Secret, error) {
 	return secrets, nil
 }
 
-// DecryptSecretData decrypts the secret data if it's encrypted
-func DecryptSecretData(secret *Secret) (string, error) {
-	if secret.EncryptedKey == "" { // Check if encryptedKey is empty
-		return secret.EnvData, nil
-	}
-
-	// Decode the encrypted secret key
-	encryptedSecretKeyBytes, err := base64.StdEncoding.DecodeString(secret.EncryptedKey)
-	if err != nil {
-		return "", fmt.Errorf("failed to decode encrypted secret key: %v", err)
+// ListScopedSecretPaths returns the location of every path scoped secret file of a repository, as
+// SecretScope.RepoPath does
+func ListScopedSecretPaths(repoID uint) ([]string, error) {
+	var scopes []Secret
+	result := DB.Model(&Secret{}).
+		Distinct("path", "file_name").
+		Where("repo_id = ? AND path <> ''", repoID).
+		Find(&scopes)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list secret paths: %v", result.Error)
 	}
 
-	// Decrypt the secret key
-	secretKey, err := decryptSecretKey(encryptedSecretKeyBytes)
-	if err != nil {
-		return "", fmt.Errorf("failed to decrypt secret key: %v", err)
+	paths := make([]string, 0, len(scopes))
+	for _, secret := range scopes {
+		paths = append(paths, SecretScope{Path: secret.Path, FileName: secret.FileName}.RepoPath())
 	}
+	return paths, nil
+}
 
-	// Decode the encrypted data
-	encryptedDataBytes, err := base64.StdEncoding.DecodeString(secret.EnvData)
-	if err != nil {
-		return "", fmt.Errorf("failed to decode encrypted data: %v", err)
+// DecryptSecretData decrypts the secret data if it's encrypted
+func DecryptSecretData(secret *Secret) (string, error) {
+	if secret.EncryptedKey == "" { // Check if encryptedKey is empty
+		return secret.EnvData, nil
 	}
 
-	// Decrypt the data
-	decryptedData, err := decryptData(encryptedDataBytes, secretKey)
+	decryptedData, err := openWithKey(secret.EnvData, secret.EncryptedKey)
 	if err != nil {
-		return "", fmt.Errorf("failed to decrypt data: %v", err)
+		return "", err
 	}
 
 	return string(decryptedData), nil
 }
 
-func DeleteSecretByTagAndVersion(repoID uint, tag string, version int) error {
-	result := DB.Where("repo_id = ? AND tag = ? AND version = ?", repoID, tag, version).Delete(&Secret{})
+func DeleteSecretByTagAndVersion(repoID uint, scope SecretScope, tag string, version int) error {
+	result := scope.where(DB).Where("repo_id = ? AND tag = ? AND version = ?", repoID, tag, version).Delete(&Secret{})
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete secret by tag and version: %v", result.Error)
 	}
@@ -405,41 +996,1330 @@ func DeleteSecretByTagAndVersion(repoID uint, tag string, version int) error {
 	return nil
 }
 
-func DeleteSecretsByTag(repoID uint, tag string) (int, error) {
-	result := DB.Where("repo_id = ? AND tag = ?", repoID, tag).Delete(&Secret{})
+func DeleteSecretsByTag(repoID uint, scope SecretScope, tag string) (int, error) {
+	result := scope.where(DB).Where("repo_id = ? AND tag = ?", repoID, tag).Delete(&Secret{})
 	if result.Error != nil {
 		return 0, fmt.Errorf("failed to delete secrets by tag: %v", result.Error)
 	}
 	return int(result.RowsAffected), nil
 }
 
-func DeleteAllSecrets(repoID uint) (int, error) {
-	result := DB.Where("repo_id = ?", repoID).Delete(&Secret{})
+func DeleteAllSecrets(repoID uint, scope SecretScope) (int, error) {
+	result := scope.where(DB).Where("repo_id = ?", repoID).Delete(&Secret{})
 	if result.Error != nil {
 		return 0, fmt.Errorf("failed to delete all secrets: %v", result.Error)
 	}
 	return int(result.RowsAffected), nil
 }
 
-// LogAuditEvent logs an audit event
-func LogAuditEvent(operation string, repoID *uint, secretID *uint, serviceName, requestID, username string, success bool, errorMessage string) error {
-	auditLog := &AuditLog{
-		Operation:    operation,
-		RepoID:       repoID,
-		SecretID:     secretID,
-		Username:     username,
-		ServiceName:  serviceName,
-		RequestID:    requestID,
-		Success:      success,
-		ErrorMessage: errorMessage,
-	}
+// GetNextFileVersion gets the next version for a file within a tag
+func GetNextFileVersion(repoID uint, fileName, tag string) (int, error) {
+	var maxVersion int
+	result := DB.Model(&SecretFile{}).
+		Where("repo_id = ? AND file_name = ? AND tag = ?", repoID, fileName, tag).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&maxVersion)
 
-	result := DB.Create(auditLog)
 	if result.Error != nil {
-		return fmt.Errorf("failed to log audit event: %v", result.Error)
+		return 0, fmt.Errorf("failed to get next file version: %v", result.Error)
 	}
 
-	return nil
+	return maxVersion + 1, nil
+}
+
+// CreateSecretFile encrypts and stores a new version of a file blob
+func CreateSecretFile(repoID uint, version int, tag, fileName, contentType string, content []byte, checksum, uploadedBy string) (*SecretFile, error) {
+	encryptedData, encryptedKey, err := sealWithNewKey(content)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &SecretFile{
+		RepoID:       repoID,
+		FileName:     fileName,
+		Tag:          tag,
+		Version:      version,
+		ContentType:  contentType,
+		Size:         int64(len(content)),
+		Data:         encryptedData,
+		Checksum:     checksum,
+		UploadedBy:   uploadedBy,
+		EncryptedKey: encryptedKey,
+	}
+
+	result := DB.Create(file)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to create secret file: %v", result.Error)
+	}
+
+	return file, nil
+}
+
+// GetSecretFile finds a file by name, optionally narrowed by tag and/or version (0 means latest)
+func GetSecretFile(repoID uint, fileName, tag string, version int) (*SecretFile, error) {
+	var file SecretFile
+	query := DB.Where("repo_id = ? AND file_name = ?", repoID, fileName)
+	if tag != "" {
+		query = query.Where("tag = ?", tag)
+	}
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+
+	result := query.Order("version DESC").First(&file)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get secret file: %v", result.Error)
+	}
+	return &file, nil
+}
+
+// ListSecretFileVersions lists the versions of a repository's files, optionally one file, without their
+// content
+func ListSecretFileVersions(repoID uint, fileName string) ([]SecretFile, error) {
+	var files []SecretFile
+	query := DB.Omit("data", "encrypted_key").Where("repo_id = ?", repoID)
+	if fileName != "" {
+		query = query.Where("file_name = ?", fileName)
+	}
+
+	result := query.Order("file_name ASC, tag ASC, version DESC").Find(&files)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list secret files: %v", result.Error)
+	}
+	return files, nil
+}
+
+// DeleteSecretFiles deletes versions of a file: one version of a tag, every version of a tag when version
+// is 0, or every version of the file when tag is nil
+func DeleteSecretFiles(repoID uint, fileName string, tag *string, version int) (int, error) {
+	query := DB.Where("repo_id = ? AND file_name = ?", repoID, fileName)
+	if tag != nil {
+		query = query.Where("tag = ?", *tag)
+		if version != 0 {
+			query = query.Where("version = ?", version)
+		}
+	}
+
+	result := query.Delete(&SecretFile{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete secret files: %v", result.Error)
+	}
+	return int(result.RowsAffected), nil
+}
+
+// DecryptSecretFile returns the plaintext content of a file blob
+func DecryptSecretFile(file *SecretFile) ([]byte, error) {
+	return openWithKey(file.Data, file.EncryptedKey)
+}
+
+// GetTagParent gets the parent declared for a tag, or nil if the tag does not inherit
+func GetTagParent(repoID uint, scope SecretScope, tag string) (*TagParent, error) {
+	return getTagParent(DB, repoID, scope, tag)
+}
+
+// getTagParent is GetTagParent on db, which may be a transaction
+func getTagParent(db *gorm.DB, repoID uint, scope SecretScope, tag string) (*TagParent, error) {
+	var parents []TagParent
+	result := db.Where("repo_id = ? AND path = ? AND file_name = ? AND tag = ?", repoID, scope.Path, secretFileName(scope.FileName), tag).
+		Limit(1).
+		Find(&parents)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get tag parent: %v", result.Error)
+	}
+	if len(parents) == 0 {
+		return nil, nil
+	}
+	return &parents[0], nil
+}
+
+// SetTagParent creates or replaces the parent of a tag
+func SetTagParent(repoID uint, scope SecretScope, tag, parentTag string, parentVersion int, updatedBy string) error {
+	return setTagParent(DB, repoID, scope, tag, parentTag, parentVersion, updatedBy)
+}
+
+// setTagParent is SetTagParent on db, which may be a transaction
+func setTagParent(db *gorm.DB, repoID uint, scope SecretScope, tag, parentTag string, parentVersion int, updatedBy string) error {
+	parent, err := getTagParent(db, repoID, scope, tag)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		parent = &TagParent{
+			RepoID:   repoID,
+			Path:     scope.Path,
+			FileName: secretFileName(scope.FileName),
+			Tag:      tag,
+		}
+	}
+
+	parent.ParentTag = parentTag
+	parent.ParentVersion = parentVersion
+	parent.UpdatedBy = updatedBy
+
+	if result := db.Save(parent); result.Error != nil {
+		return fmt.Errorf("failed to set tag parent: %v", result.Error)
+	}
+	return nil
+}
+
+// DeleteTagParent removes the parent of a tag
+func DeleteTagParent(repoID uint, scope SecretScope, tag string) error {
+	return deleteTagParent(DB, repoID, scope, tag)
+}
+
+// deleteTagParent is DeleteTagParent on db, which may be a transaction
+func deleteTagParent(db *gorm.DB, repoID uint, scope SecretScope, tag string) error {
+	result := db.Where("repo_id = ? AND path = ? AND file_name = ? AND tag = ?", repoID, scope.Path, secretFileName(scope.FileName), tag).
+		Delete(&TagParent{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete tag parent: %v", result.Error)
+	}
+	return nil
+}
+
+// ListChildTags gets the tags of a scope that declare parentTag as their parent
+func ListChildTags(repoID uint, scope SecretScope, parentTag string) ([]string, error) {
+	var tags []string
+	result := DB.Model(&TagParent{}).
+		Where("repo_id = ? AND path = ? AND file_name = ? AND parent_tag = ?", repoID, scope.Path, secretFileName(scope.FileName), parentTag).
+		Order("tag ASC").
+		Pluck("tag", &tags)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list child tags: %v", result.Error)
+	}
+	return tags, nil
+}
+
+// ListTagParents gets every tag parent declared in a repository
+func ListTagParents(repoID uint) ([]TagParent, error) {
+	var parents []TagParent
+	result := DB.Where("repo_id = ?", repoID).
+		Order("path ASC").
+		Order("file_name ASC").
+		Order("tag ASC").
+		Find(&parents)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list tag parents: %v", result.Error)
+	}
+	return parents, nil
+}
+
+// ReplaceSecretReferences replaces the references recorded for a secret stream with those of its newest version
+func ReplaceSecretReferences(repoID uint, scope SecretScope, tag string, references []SecretReference) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := scope.where(tx).Where("repo_id = ? AND tag = ?", repoID, tag).Delete(&SecretReference{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete secret references: %v", result.Error)
+		}
+		if len(references) == 0 {
+			return nil
+		}
+
+		for i := range references {
+			references[i].RepoID = repoID
+			references[i].Path = scope.Path
+			references[i].FileName = secretFileName(scope.FileName)
+			references[i].Branch = scope.Branch
+			references[i].Tag = tag
+		}
+		if result := tx.Create(&references); result.Error != nil {
+			return fmt.Errorf("failed to create secret references: %v", result.Error)
+		}
+		return nil
+	})
+}
+
+// SecretReferenceSource is a reference joined with the repository it was found in
+type SecretReferenceSource struct {
+	SecretReference
+	OwnerLogin string
+	RepoName   string
+}
+
+// ListSecretReferences lists references to a repository, optionally narrowed to a key and tag.
+// References whose secret stream has since been deleted are skipped.
+func ListSecretReferences(ownerLogin, repoName, key, tag string) ([]SecretReferenceSource, error) {
+	var references []SecretReferenceSource
+	query := DB.Table("secret_references").
+		Select("secret_references.*, repositories.owner_login, repositories.repo_name").
+		Joins("JOIN repositories ON repositories.id = secret_references.repo_id").
+		Where("secret_references.target_owner = ? AND secret_references.target_repo = ?", ownerLogin, repoName).
+		Where(`EXISTS (SELECT 1 FROM secrets WHERE secrets.repo_id = secret_references.repo_id
+			AND secrets.path = secret_references.path AND secrets.file_name = secret_references.file_name
+			AND secrets.branch = secret_references.branch AND secrets.tag = secret_references.tag)`)
+	if key != "" {
+		query = query.Where("secret_references.target_key = ?", key)
+	}
+	if tag != "" {
+		query = query.Where("secret_references.target_tag = ?", tag)
+	}
+
+	result := query.
+		Order("repositories.owner_login ASC").
+		Order("repositories.repo_name ASC").
+		Order("secret_references.path ASC").
+		Order("secret_references.file_name ASC").
+		Order("secret_references.tag ASC").
+		Order("secret_references.key ASC").
+		Scan(&references)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list secret references: %v", result.Error)
+	}
+	return references, nil
+}
+
+// GetSharedSecretSet gets an organization's shared secret set by name, or nil if it does not exist
+func GetSharedSecretSet(orgLogin, name string) (*SharedSecretSet, error) {
+	var sets []SharedSecretSet
+	result := DB.Where("org_login = ? AND name = ?", orgLogin, name).Limit(1).Find(&sets)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get shared secret set: %v", result.Error)
+	}
+	if len(sets) == 0 {
+		return nil, nil
+	}
+	return &sets[0], nil
+}
+
+// GetOrCreateSharedSecretSet gets an organization's shared secret set, creating it on first upload
+func GetOrCreateSharedSecretSet(orgLogin, name, createdBy string) (*SharedSecretSet, error) {
+	set, err := GetSharedSecretSet(orgLogin, name)
+	if err != nil || set != nil {
+		return set, err
+	}
+
+	set = &SharedSecretSet{
+		OrgLogin:  orgLogin,
+		Name:      name,
+		CreatedBy: createdBy,
+	}
+	if result := DB.Create(set); result.Error != nil {
+		return nil, fmt.Errorf("failed to create shared secret set: %v", result.Error)
+	}
+	return set, nil
+}
+
+// GetNextSharedSecretSetVersion gets the next version number of a shared secret set
+func GetNextSharedSecretSetVersion(setID uint) (int, error) {
+	var maxVersion int
+	result := DB.Model(&SharedSecretSetVersion{}).
+		Where("set_id = ?", setID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&maxVersion)
+
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to get next shared secret set version: %v", result.Error)
+	}
+
+	return maxVersion + 1, nil
+}
+
+// CreateSharedSecretSetVersion encrypts and stores a new version of a shared secret set
+func CreateSharedSecretSetVersion(setID uint, version int, envData, checksum, uploadedBy string) (*SharedSecretSetVersion, error) {
+	encryptedData, encryptedKey, err := sealWithNewKey([]byte(envData))
+	if err != nil {
+		return nil, err
+	}
+
+	setVersion := &SharedSecretSetVersion{
+		SetID:        setID,
+		Version:      version,
+		EnvData:      encryptedData,
+		Checksum:     checksum,
+		UploadedBy:   uploadedBy,
+		EncryptedKey: encryptedKey,
+	}
+
+	result := DB.Create(setVersion)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to create shared secret set version: %v", result.Error)
+	}
+
+	return setVersion, nil
+}
+
+// GetSharedSecretSetVersion gets a version of a shared secret set, 0 meaning the latest
+func GetSharedSecretSetVersion(setID uint, version int) (*SharedSecretSetVersion, error) {
+	var setVersion SharedSecretSetVersion
+	query := DB.Where("set_id = ?", setID)
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+
+	result := query.Order("version DESC").First(&setVersion)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get shared secret set version: %v", result.Error)
+	}
+	return &setVersion, nil
+}
+
+// DecryptSharedSecretSetVersion returns the plaintext JSON key/value map of a shared secret set version
+func DecryptSharedSecretSetVersion(setVersion *SharedSecretSetVersion) (string, error) {
+	decryptedData, err := openWithKey(setVersion.EnvData, setVersion.EncryptedKey)
+	if err != nil {
+		return "", err
+	}
+	return string(decryptedData), nil
+}
+
+// ListSharedSecretSets gets the shared secret sets of an organization with their versions, newest first
+func ListSharedSecretSets(orgLogin string) ([]SharedSecretSet, error) {
+	var sets []SharedSecretSet
+	result := DB.Where("org_login = ?", orgLogin).
+		Preload("Versions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("version DESC")
+		}).
+		Order("name ASC").
+		Find(&sets)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list shared secret sets: %v", result.Error)
+	}
+	return sets, nil
+}
+
+// AttachSharedSecretSet attaches a shared secret set to a repository, or updates the pinned version of an existing attachment
+func AttachSharedSecretSet(setID, repoID uint, pinnedVersion int, attachedBy string) error {
+	var attachments []SharedSecretSetAttachment
+	result := DB.Where("set_id = ? AND repo_id = ?", setID, repoID).Limit(1).Find(&attachments)
+	if result.Error != nil {
+		return fmt.Errorf("failed to get shared secret set attachment: %v", result.Error)
+	}
+
+	attachment := &SharedSecretSetAttachment{SetID: setID, RepoID: repoID}
+	if len(attachments) > 0 {
+		attachment = &attachments[0]
+	}
+	attachment.PinnedVersion = pinnedVersion
+	attachment.AttachedBy = attachedBy
+
+	if result := DB.Save(attachment); result.Error != nil {
+		return fmt.Errorf("failed to attach shared secret set: %v", result.Error)
+	}
+	return nil
+}
+
+// DetachSharedSecretSet removes a shared secret set from a repository and reports whether it was attached
+func DetachSharedSecretSet(setID, repoID uint) (bool, error) {
+	result := DB.Where("set_id = ? AND repo_id = ?", setID, repoID).Delete(&SharedSecretSetAttachment{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to detach shared secret set: %v", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// SharedSecretSetAttachmentInfo is an attachment joined with its set and repository
+type SharedSecretSetAttachmentInfo struct {
+	SharedSecretSetAttachment
+	OrgLogin   string
+	SetName    string
+	OwnerLogin string
+	RepoName   string
+}
+
+// ListSharedSecretSetAttachments lists attachments in the order they were made, narrowed to
+// an organization's sets and/or a repository when orgLogin or repoID are set
+func ListSharedSecretSetAttachments(orgLogin string, repoID uint) ([]SharedSecretSetAttachmentInfo, error) {
+	var attachments []SharedSecretSetAttachmentInfo
+	query := DB.Table("shared_secret_set_attachments").
+		Select(`shared_secret_set_attachments.*, shared_secret_sets.org_login, shared_secret_sets.name AS set_name,
+			repositories.owner_login, repositories.repo_name`).
+		Joins("JOIN shared_secret_sets ON shared_secret_sets.id = shared_secret_set_attachments.set_id").
+		Joins("JOIN repositories ON repositories.id = shared_secret_set_attachments.repo_id")
+	if orgLogin != "" {
+		query = query.Where("shared_secret_sets.org_login = ?", orgLogin)
+	}
+	if repoID != 0 {
+		query = query.Where("shared_secret_set_attachments.repo_id = ?", repoID)
+	}
+
+	result := query.Order("shared_secret_set_attachments.id ASC").Scan(&attachments)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list shared secret set attachments: %v", result.Error)
+	}
+	return attachments, nil
+}
+
+// GetSecretKeyMetadata gets the key metadata stored with a secret version
+func GetSecretKeyMetadata(secretID uint) ([]SecretKeyMetadata, error) {
+	return getSecretKeyMetadata(DB, secretID)
+}
+
+// getSecretKeyMetadata is GetSecretKeyMetadata on db, which may be a transaction
+func getSecretKeyMetadata(db *gorm.DB, secretID uint) ([]SecretKeyMetadata, error) {
+	var metadata []SecretKeyMetadata
+	result := db.Where("secret_id = ?", secretID).Order("key ASC").Find(&metadata)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get key metadata: %v", result.Error)
+	}
+	return metadata, nil
+}
+
+// SecretKeyMetadataInfo is key metadata with the secret version and repository it belongs to
+type SecretKeyMetadataInfo struct {
+	SecretKeyMetadata
+	OwnerLogin string
+	RepoName   string
+	Path       string
+	FileName   string
+	Branch     string
+	Tag        string
+	Version    int
+}
+
+// ListLatestKeyMetadata lists the key metadata of the latest version of every secret in the given
+// repositories, each an owner login and repository name pair, skipping superseded versions whose values
+// are no longer downloaded
+func ListLatestKeyMetadata(repos [][]interface{}) ([]SecretKeyMetadataInfo, error) {
+	var metadata []SecretKeyMetadataInfo
+	if len(repos) == 0 {
+		return metadata, nil
+	}
+
+	result := DB.Table("secret_key_metadata").
+		Select(`secret_key_metadata.*, repositories.owner_login, repositories.repo_name,
+			secrets.path, secrets.file_name, secrets.branch, secrets.tag, secrets.version`).
+		Joins("JOIN secrets ON secrets.id = secret_key_metadata.secret_id").
+		Joins("JOIN repositories ON repositories.id = secrets.repo_id").
+		Where("(repositories.owner_login, repositories.repo_name) IN ?", repos).
+		Where(`secrets.version = (SELECT MAX(latest.version) FROM secrets latest
+			WHERE latest.repo_id = secrets.repo_id AND latest.path = secrets.path AND latest.file_name = secrets.file_name
+			AND latest.branch = secrets.branch AND latest.tag = secrets.tag)`).
+		Order("repositories.owner_login ASC, repositories.repo_name ASC, secret_key_metadata.key ASC").
+		Scan(&metadata)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list key metadata: %v", result.Error)
+	}
+	return metadata, nil
+}
+
+// ListUnindexedSecrets lists up to limit secret versions after afterID that were stored before their key
+// names were indexed
+func ListUnindexedSecrets(afterID uint, limit int) ([]Secret, error) {
+	var secrets []Secret
+	result := DB.Where("keys_indexed = ? AND id > ?", false, afterID).Order("id ASC").Limit(limit).Find(&secrets)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list unindexed secrets: %v", result.Error)
+	}
+	return secrets, nil
+}
+
+// IndexSecretKeyNames records the key names of a secret version and marks it indexed. Names already
+// recorded, e.g. by another replica, are kept.
+func IndexSecretKeyNames(secretID uint, names []SecretKeyName) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if len(names) > 0 {
+			for i := range names {
+				names[i].SecretID = secretID
+			}
+			if result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&names); result.Error != nil {
+				return fmt.Errorf("failed to index key names: %v", result.Error)
+			}
+		}
+		if result := tx.Model(&Secret{}).Where("id = ?", secretID).Update("keys_indexed", true); result.Error != nil {
+			return fmt.Errorf("failed to mark secret indexed: %v", result.Error)
+		}
+		return nil
+	})
+}
+
+// SecretKeyNameInfo is an indexed key name with the secret version and repository it belongs to
+type SecretKeyNameInfo struct {
+	OwnerLogin string
+	RepoName   string
+	Path       string
+	FileName   string
+	Branch     string
+	Tag        string
+	Version    int
+	Key        string
+}
+
+// SearchLatestSecretKeyNames lists the key names of the latest version of every secret in the given
+// repositories, each an owner login and repository name pair. Names are narrowed to those matching the
+// case-insensitive LIKE pattern unless it is empty, and at most limit names are returned.
+func SearchLatestSecretKeyNames(repos [][]interface{}, pattern string, limit int) ([]SecretKeyNameInfo, error) {
+	var names []SecretKeyNameInfo
+	if len(repos) == 0 {
+		return names, nil
+	}
+
+	query := DB.Table("secret_key_names").
+		Select(`repositories.owner_login, repositories.repo_name, secrets.path, secrets.file_name,
+			secrets.branch, secrets.tag, secrets.version, secret_key_names.key`).
+		Joins("JOIN secrets ON secrets.id = secret_key_names.secret_id").
+		Joins("JOIN repositories ON repositories.id = secrets.repo_id").
+		Where("(repositories.owner_login, repositories.repo_name) IN ?", repos).
+		Where(`secrets.version = (SELECT MAX(latest.version) FROM secrets latest
+			WHERE latest.repo_id = secrets.repo_id AND latest.path = secrets.path AND latest.file_name = secrets.file_name
+			AND latest.branch = secrets.branch AND latest.tag = secrets.tag)`)
+	if pattern != "" {
+		query = query.Where("secret_key_names.key ILIKE ?", pattern)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	result := query.
+		Order("repositories.owner_login ASC, repositories.repo_name ASC").
+		Order("secrets.path ASC, secrets.file_name ASC, secrets.branch ASC, secrets.tag ASC").
+		Order("secret_key_names.key ASC").
+		Scan(&names)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to search key names: %v", result.Error)
+	}
+	return names, nil
+}
+
+// GetTagSchema gets the schema declared for exactly tag, or nil if there is none
+func GetTagSchema(repoID uint, tag string) (*TagSchema, error) {
+	var schemas []TagSchema
+	result := DB.Where("repo_id = ? AND tag = ?", repoID, tag).Limit(1).Find(&schemas)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get schema: %v", result.Error)
+	}
+	if len(schemas) == 0 {
+		return nil, nil
+	}
+	return &schemas[0], nil
+}
+
+// ResolveTagSchema gets the schema that applies to tag: its own, else the repository default, else nil
+func ResolveTagSchema(repoID uint, tag string) (*TagSchema, error) {
+	schema, err := GetTagSchema(repoID, tag)
+	if err != nil || schema != nil || tag == "" {
+		return schema, err
+	}
+	return GetTagSchema(repoID, "")
+}
+
+// SetTagSchema creates or replaces the schema of a tag
+func SetTagSchema(repoID uint, tag, definition, updatedBy string) error {
+	schema, err := GetTagSchema(repoID, tag)
+	if err != nil {
+		return err
+	}
+	if schema == nil {
+		schema = &TagSchema{RepoID: repoID, Tag: tag}
+	}
+
+	schema.Definition = definition
+	schema.UpdatedBy = updatedBy
+
+	if result := DB.Save(schema); result.Error != nil {
+		return fmt.Errorf("failed to set schema: %v", result.Error)
+	}
+	return nil
+}
+
+// DeleteTagSchema removes the schema of a tag
+func DeleteTagSchema(repoID uint, tag string) error {
+	result := DB.Where("repo_id = ? AND tag = ?", repoID, tag).Delete(&TagSchema{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete schema: %v", result.Error)
+	}
+	return nil
+}
+
+// GetLintActions gets the configured action of every overridden lint rule of a repository
+func GetLintActions(repoID uint) (map[string]string, error) {
+	var settings []LintRuleSetting
+	result := DB.Where("repo_id = ?", repoID).Find(&settings)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get lint rule settings: %v", result.Error)
+	}
+
+	actions := make(map[string]string, len(settings))
+	for _, setting := range settings {
+		actions[setting.Rule] = setting.Action
+	}
+	return actions, nil
+}
+
+// SetLintAction creates or replaces the action of a lint rule for a repository
+func SetLintAction(repoID uint, rule, action, updatedBy string) error {
+	var settings []LintRuleSetting
+	result := DB.Where("repo_id = ? AND rule = ?", repoID, rule).Limit(1).Find(&settings)
+	if result.Error != nil {
+		return fmt.Errorf("failed to get lint rule setting: %v", result.Error)
+	}
+
+	setting := &LintRuleSetting{RepoID: repoID, Rule: rule}
+	if len(settings) > 0 {
+		setting = &settings[0]
+	}
+	setting.Action = action
+	setting.UpdatedBy = updatedBy
+
+	if result := DB.Save(setting); result.Error != nil {
+		return fmt.Errorf("failed to set lint rule: %v", result.Error)
+	}
+	return nil
+}
+
+// GetSecretEngine gets a repository's engine by name, or nil if there is none
+func GetSecretEngine(repoID uint, name string) (*SecretEngineConfig, error) {
+	var engines []SecretEngineConfig
+	result := DB.Where("repo_id = ? AND name = ?", repoID, name).Limit(1).Find(&engines)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get secret engine: %v", result.Error)
+	}
+	if len(engines) == 0 {
+		return nil, nil
+	}
+	return &engines[0], nil
+}
+
+// ListSecretEngines lists a repository's engines by name
+func ListSecretEngines(repoID uint) ([]SecretEngineConfig, error) {
+	var engines []SecretEngineConfig
+	result := DB.Where("repo_id = ?", repoID).Order("name ASC").Find(&engines)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list secret engines: %v", result.Error)
+	}
+	return engines, nil
+}
+
+// ListSecretEnginesForTag lists the engines whose credentials are added to downloads of tag
+func ListSecretEnginesForTag(repoID uint, tag string) ([]SecretEngineConfig, error) {
+	var engines []SecretEngineConfig
+	result := DB.Where("repo_id = ? AND (tag = ? OR tag = '')", repoID, tag).Order("name ASC").Find(&engines)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list secret engines: %v", result.Error)
+	}
+	return engines, nil
+}
+
+// SetSecretEngine creates or replaces a repository's engine, encrypting its configuration
+func SetSecretEngine(repoID uint, name, engine, tag string, config []byte, defaultTTL, maxTTL int, updatedBy string) error {
+	sealedConfig, encryptedKey, err := sealWithNewKey(config)
+	if err != nil {
+		return err
+	}
+
+	existing, err := GetSecretEngine(repoID, name)
+	if err != nil {
+		return err
+	}
+	record := SecretEngineConfig{RepoID: repoID, Name: name}
+	if existing != nil {
+		record = *existing
+	}
+	record.Engine = engine
+	record.Tag = tag
+	record.DefaultTTL = defaultTTL
+	record.MaxTTL = maxTTL
+	record.Config = sealedConfig
+	record.EncryptedKey = encryptedKey
+	record.UpdatedBy = updatedBy
+
+	if result := DB.Save(&record); result.Error != nil {
+		return fmt.Errorf("failed to save secret engine: %v", result.Error)
+	}
+	return nil
+}
+
+// DeleteSecretEngine deletes an engine together with its lease history
+func DeleteSecretEngine(engineID uint) error {
+	if result := DB.Delete(&SecretEngineConfig{}, engineID); result.Error != nil {
+		return fmt.Errorf("failed to delete secret engine: %v", result.Error)
+	}
+	return nil
+}
+
+// DecryptSecretEngineConfig decrypts an engine's configuration
+func DecryptSecretEngineConfig(engine *SecretEngineConfig) ([]byte, error) {
+	return openWithKey(engine.Config, engine.EncryptedKey)
+}
+
+// CreateSecretLease records an issued credential
+func CreateSecretLease(lease *SecretLease) error {
+	if result := DB.Create(lease); result.Error != nil {
+		return fmt.Errorf("failed to create lease: %v", result.Error)
+	}
+	return nil
+}
+
+// ListActiveLeases lists the unrevoked leases of an engine
+func ListActiveLeases(engineID uint) ([]SecretLease, error) {
+	var leases []SecretLease
+	result := DB.Where("engine_id = ? AND revoked_at IS NULL", engineID).Order("expires_at ASC").Find(&leases)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list leases: %v", result.Error)
+	}
+	return leases, nil
+}
+
+// ListExpiredLeases lists unrevoked leases that expired at or before now
+func ListExpiredLeases(now time.Time) ([]SecretLease, error) {
+	var leases []SecretLease
+	result := DB.Where("revoked_at IS NULL AND expires_at <= ?", now).Order("expires_at ASC").Find(&leases)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list expired leases: %v", result.Error)
+	}
+	return leases, nil
+}
+
+// MarkLeaseRevoked records that a lease's credential was removed
+func MarkLeaseRevoked(leaseID uint) error {
+	now := time.Now()
+	if result := DB.Model(&SecretLease{}).Where("id = ?", leaseID).Update("revoked_at", &now); result.Error != nil {
+		return fmt.Errorf("failed to mark lease revoked: %v", result.Error)
+	}
+	return nil
+}
+
+// CreateWebhookSubscription stores a webhook with its HMAC key encrypted
+func CreateWebhookSubscription(subscription *WebhookSubscription, secret string) error {
+	encryptedSecret, encryptedKey, err := sealWithNewKey([]byte(secret))
+	if err != nil {
+		return err
+	}
+	subscription.Secret = encryptedSecret
+	subscription.EncryptedKey = encryptedKey
+
+	if result := DB.Create(subscription); result.Error != nil {
+		return fmt.Errorf("failed to create webhook: %v", result.Error)
+	}
+	return nil
+}
+
+// GetWebhookSubscription returns a webhook by ID, or nil when it does not exist
+func GetWebhookSubscription(id uint) (*WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+	if result := DB.Where("id = ?", id).Limit(1).Find(&subscriptions); result.Error != nil {
+		return nil, fmt.Errorf("failed to get webhook: %v", result.Error)
+	}
+	if len(subscriptions) == 0 {
+		return nil, nil
+	}
+	return &subscriptions[0], nil
+}
+
+// ListWebhookSubscriptions lists the webhooks of a repository, or of an organization when repoID is nil
+func ListWebhookSubscriptions(repoID *uint, orgLogin string) ([]WebhookSubscription, error) {
+	query := DB.Order("id ASC")
+	if repoID != nil {
+		query = query.Where("repo_id = ?", *repoID)
+	} else {
+		query = query.Where("repo_id IS NULL AND LOWER(org_login) = LOWER(?)", orgLogin)
+	}
+
+	var subscriptions []WebhookSubscription
+	if result := query.Find(&subscriptions); result.Error != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %v", result.Error)
+	}
+	return subscriptions, nil
+}
+
+// ListActiveWebhookSubscriptions lists the active webhooks an event of the repository or organization goes to
+func ListActiveWebhookSubscriptions(repoID *uint, orgLogin string) ([]WebhookSubscription, error) {
+	query := DB.Where("active = ?", true)
+	if repoID != nil {
+		query = query.Where("repo_id = ? OR (repo_id IS NULL AND LOWER(org_login) = LOWER(?))", *repoID, orgLogin)
+	} else {
+		query = query.Where("repo_id IS NULL AND LOWER(org_login) = LOWER(?)", orgLogin)
+	}
+
+	var subscriptions []WebhookSubscription
+	if result := query.Order("id ASC").Find(&subscriptions); result.Error != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %v", result.Error)
+	}
+	return subscriptions, nil
+}
+
+// UpdateWebhookSubscription saves the URL, events and active flag of a webhook, and its HMAC key when
+// secret is not empty
+func UpdateWebhookSubscription(subscription *WebhookSubscription, secret string) error {
+	if secret != "" {
+		encryptedSecret, encryptedKey, err := sealWithNewKey([]byte(secret))
+		if err != nil {
+			return err
+		}
+		subscription.Secret = encryptedSecret
+		subscription.EncryptedKey = encryptedKey
+	}
+
+	result := DB.Model(subscription).Select("url", "events", "active", "secret", "encrypted_key").Updates(subscription)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update webhook: %v", result.Error)
+	}
+	return nil
+}
+
+// DeleteWebhookSubscription deletes a webhook together with its delivery log
+func DeleteWebhookSubscription(id uint) error {
+	if result := DB.Select("Deliveries").Delete(&WebhookSubscription{ID: id}); result.Error != nil {
+		return fmt.Errorf("failed to delete webhook: %v", result.Error)
+	}
+	return nil
+}
+
+// GetWebhookDelivery returns a delivery by ID, or nil when it does not exist
+func GetWebhookDelivery(id uint) (*WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	if result := DB.Where("id = ?", id).Limit(1).Find(&deliveries); result.Error != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %v", result.Error)
+	}
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+	return &deliveries[0], nil
+}
+
+// ListWebhookDeliveries returns the newest deliveries of a webhook
+func ListWebhookDeliveries(subscriptionID uint, limit int) ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	result := DB.Where("subscription_id = ?", subscriptionID).Order("id DESC").Limit(limit).Find(&deliveries)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %v", result.Error)
+	}
+	return deliveries, nil
+}
+
+func CreateWebhookDelivery(delivery *WebhookDelivery) error {
+	if result := DB.Create(delivery); result.Error != nil {
+		return fmt.Errorf("failed to queue webhook delivery: %v", result.Error)
+	}
+	return nil
+}
+
+// GetProtectedTag returns the protection of a tag, or nil when it is not protected
+func GetProtectedTag(repoID uint, tag string) (*ProtectedTag, error) {
+	var protected []ProtectedTag
+	if result := DB.Where("repo_id = ? AND tag = ?", repoID, tag).Limit(1).Find(&protected); result.Error != nil {
+		return nil, fmt.Errorf("failed to get tag protection: %v", result.Error)
+	}
+	if len(protected) == 0 {
+		return nil, nil
+	}
+	return &protected[0], nil
+}
+
+func ListProtectedTags(repoID uint) ([]ProtectedTag, error) {
+	var protected []ProtectedTag
+	if result := DB.Where("repo_id = ?", repoID).Order("tag ASC").Find(&protected); result.Error != nil {
+		return nil, fmt.Errorf("failed to list protected tags: %v", result.Error)
+	}
+	return protected, nil
+}
+
+// SetProtectedTag protects a tag, or updates the review window of an already protected one
+func SetProtectedTag(repoID uint, tag string, requestTTLHours int, updatedBy string) error {
+	protected, err := GetProtectedTag(repoID, tag)
+	if err != nil {
+		return err
+	}
+	if protected == nil {
+		protected = &ProtectedTag{RepoID: repoID, Tag: tag}
+	}
+
+	protected.RequestTTLHours = requestTTLHours
+	protected.UpdatedBy = updatedBy
+
+	if result := DB.Save(protected); result.Error != nil {
+		return fmt.Errorf("failed to protect tag: %v", result.Error)
+	}
+	return nil
+}
+
+// DeleteProtectedTag removes a tag's protection; pending change requests can still be reviewed
+func DeleteProtectedTag(repoID uint, tag string) error {
+	if result := DB.Where("repo_id = ? AND tag = ?", repoID, tag).Delete(&ProtectedTag{}); result.Error != nil {
+		return fmt.Errorf("failed to remove tag protection: %v", result.Error)
+	}
+	return nil
+}
+
+// CreateChangeRequest encrypts the proposed content, if any, and stores the request
+func CreateChangeRequest(request *ChangeRequest, envData string) error {
+	if envData != "" {
+		encryptedData, encryptedKey, err := sealWithNewKey([]byte(envData))
+		if err != nil {
+			return err
+		}
+		request.EnvData = encryptedData
+		request.EncryptedKey = encryptedKey
+	}
+
+	if result := DB.Create(request); result.Error != nil {
+		return fmt.Errorf("failed to create change request: %v", result.Error)
+	}
+	return nil
+}
+
+// GetChangeRequest returns a change request with its comments, or nil when it does not exist
+func GetChangeRequest(id uint) (*ChangeRequest, error) {
+	var requests []ChangeRequest
+	result := DB.Preload("Comments", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Where("id = ?", id).Limit(1).Find(&requests)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get change request: %v", result.Error)
+	}
+	if len(requests) == 0 {
+		return nil, nil
+	}
+	return &requests[0], nil
+}
+
+// ListChangeRequests lists the change requests of a repository with the given status, or every status when
+// it is empty, newest first
+func ListChangeRequests(repoID uint, status string) ([]ChangeRequest, error) {
+	query := DB.Preload("Comments", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Where("repo_id = ?", repoID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var requests []ChangeRequest
+	if result := query.Order("id DESC").Find(&requests); result.Error != nil {
+		return nil, fmt.Errorf("failed to list change requests: %v", result.Error)
+	}
+	return requests, nil
+}
+
+// DecryptChangeRequest returns the proposed content of a pending change request
+func DecryptChangeRequest(request *ChangeRequest) (string, error) {
+	data, err := openWithKey(request.EnvData, request.EncryptedKey)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ResolveChangeRequest moves a pending request to its final status, wipes the proposed content and records
+// the reviewer's comment. It fails when another reviewer resolved the request first.
+func ResolveChangeRequest(request *ChangeRequest, status, reviewedBy, comment string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return resolveChangeRequest(tx, request, changeRequestPending, status, reviewedBy, comment)
+	})
+}
+
+// ApproveChangeRequest claims a pending request, applies it with apply in the same transaction and records
+// the approval; apply returns the created version, or nil for a parent change. A concurrent approval waits for the claim and then finds the request no longer
+// pending; when apply fails nothing is stored and the request stays pending.
+func ApproveChangeRequest(request *ChangeRequest, reviewedBy, comment string, apply func(tx *gorm.DB) (*Secret, error)) (*Secret, error) {
+	var secret *Secret
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&ChangeRequest{}).Where("id = ? AND status = ?", request.ID, changeRequestPending).Update("status", changeRequestApproving)
+		if result.Error != nil {
+			return fmt.Errorf("failed to claim change request: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("change request %d is no longer pending", request.ID)
+		}
+
+		var err error
+		if secret, err = apply(tx); err != nil {
+			return err
+		}
+		// Parent changes create no version
+		if secret != nil {
+			request.Version, request.SecretID = secret.Version, &secret.ID
+		}
+		return resolveChangeRequest(tx, request, changeRequestApproving, changeRequestApproved, reviewedBy, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// AddChangeRequestComment stores a comment on a change request that is still pending
+func AddChangeRequestComment(request *ChangeRequest, author, body string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var pending int64
+		if result := tx.Model(&ChangeRequest{}).Where("id = ? AND status = ?", request.ID, changeRequestPending).Count(&pending); result.Error != nil {
+			return fmt.Errorf("failed to get change request: %v", result.Error)
+		}
+		if pending == 0 {
+			return fmt.Errorf("change request %d is no longer pending", request.ID)
+		}
+		if result := tx.Create(&ChangeRequestComment{ChangeRequestID: request.ID, Author: author, Body: body}); result.Error != nil {
+			return fmt.Errorf("failed to store comment: %v", result.Error)
+		}
+		return nil
+	})
+}
+
+// resolveChangeRequest moves a request from one status to its final one inside tx
+func resolveChangeRequest(tx *gorm.DB, request *ChangeRequest, from, status, reviewedBy, comment string) error {
+	now := time.Now().UTC()
+	result := tx.Model(&ChangeRequest{}).Where("id = ? AND status = ?", request.ID, from).Updates(map[string]interface{}{
+		"status":        status,
+		"reviewed_by":   reviewedBy,
+		"version":       request.Version,
+		"secret_id":     request.SecretID,
+		"resolved_at":   now,
+		"env_data":      "",
+		"encrypted_key": "",
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to resolve change request: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("change request %d is no longer pending", request.ID)
+	}
+	if comment != "" {
+		if result := tx.Create(&ChangeRequestComment{ChangeRequestID: request.ID, Author: reviewedBy, Body: comment}); result.Error != nil {
+			return fmt.Errorf("failed to store comment: %v", result.Error)
+		}
+	}
+	request.Status, request.ReviewedBy, request.ResolvedAt = status, reviewedBy, &now
+	return nil
+}
+
+// ExpireChangeRequests marks pending requests past their expiry as expired and wipes their content
+func ExpireChangeRequests() error {
+	result := DB.Model(&ChangeRequest{}).Where("status = ? AND expires_at < ?", changeRequestPending, time.Now()).Updates(map[string]interface{}{
+		"status":        changeRequestExpired,
+		"env_data":      "",
+		"encrypted_key": "",
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to expire change requests: %v", result.Error)
+	}
+	return nil
+}
+
+func CreateShareLink(link *ShareLink) error {
+	if result := DB.Create(link); result.Error != nil {
+		return fmt.Errorf("failed to create share link: %v", result.Error)
+	}
+	return nil
+}
+
+// GetShareLink returns a share link by its public id, or nil when it does not exist
+func GetShareLink(linkID string) (*ShareLink, error) {
+	var links []ShareLink
+	if result := DB.Where("link_id = ?", linkID).Limit(1).Find(&links); result.Error != nil {
+		return nil, fmt.Errorf("failed to get share link: %v", result.Error)
+	}
+	if len(links) == 0 {
+		return nil, nil
+	}
+	return &links[0], nil
+}
+
+// ConsumeShareLinkView counts a view of a link and wipes the snapshot with the last one. It returns false
+// when the link was used up or expired in the meantime, so concurrent redemptions cannot exceed MaxViews.
+func ConsumeShareLinkView(link *ShareLink) (bool, error) {
+	now := time.Now().UTC()
+	result := DB.Model(&ShareLink{}).
+		Where("id = ? AND views < max_views AND expires_at > ?", link.ID, now).
+		Updates(map[string]interface{}{
+			"views":          gorm.Expr("views + 1"),
+			"last_viewed_at": now,
+			"data":           gorm.Expr("CASE WHEN views + 1 >= max_views THEN '' ELSE data END"),
+		})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record share link view: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	link.Views++
+	link.LastViewedAt = &now
+	return true, nil
+}
+
+// PurgeShareLinks wipes the snapshots of expired links; the rows stay for the audit trail
+func PurgeShareLinks() error {
+	result := DB.Model(&ShareLink{}).Where("expires_at < ? AND data <> ''", time.Now().UTC()).Update("data", "")
+	if result.Error != nil {
+		return fmt.Errorf("failed to purge share links: %v", result.Error)
+	}
+	return nil
+}
+
+// secretEventChannel is the Postgres NOTIFY channel new secret events are announced on
+const secretEventChannel = "envini_secret_events"
+
+// secretEventLockID is the advisory lock that serializes storing secret events across instances
+const secretEventLockID = 0x656e76657674 // "envevt"
+
+// CreateSecretEvent stores an event and announces it to the listener of every replica. The notification
+// carries the event itself and is only sent once the transaction commits. Events are stored one at a time
+// under an advisory lock, so IDs become visible in the order they were assigned: a reader that saw event N
+// never finds a smaller ID committed later, which resuming after an ID relies on.
+func CreateSecretEvent(event *SecretEvent) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Exec("SELECT pg_advisory_xact_lock(?)", secretEventLockID); result.Error != nil {
+			return fmt.Errorf("failed to lock secret events: %v", result.Error)
+		}
+		if result := tx.Create(event); result.Error != nil {
+			return fmt.Errorf("failed to store secret event: %v", result.Error)
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode secret event: %v", err)
+		}
+		if result := tx.Exec("SELECT pg_notify(?, ?)", secretEventChannel, string(payload)); result.Error != nil {
+			return fmt.Errorf("failed to announce secret event: %v", result.Error)
+		}
+		return nil
+	})
+}
+
+// ListSecretEvents returns up to limit events after afterID, oldest first. nil repoIDs matches every
+// repository and empty tags every tag; deletions that covered every tag always match.
+func ListSecretEvents(repoIDs []uint, tags []string, afterID uint, limit int) ([]SecretEvent, error) {
+	query := DB.Where("id > ?", afterID)
+	if repoIDs != nil {
+		query = query.Where("repo_id IN ?", repoIDs)
+	}
+	if len(tags) > 0 {
+		query = query.Where("tag IN ? OR tag = ''", tags)
+	}
+
+	var events []SecretEvent
+	if result := query.Order("id ASC").Limit(limit).Find(&events); result.Error != nil {
+		return nil, fmt.Errorf("failed to list secret events: %v", result.Error)
+	}
+	return events, nil
+}
+
+// SecretEventExists reports whether the event a resume token points to is still retained
+func SecretEventExists(id uint) (bool, error) {
+	var count int64
+	if result := DB.Model(&SecretEvent{}).Where("id = ?", id).Count(&count); result.Error != nil {
+		return false, fmt.Errorf("failed to look up secret event: %v", result.Error)
+	}
+	return count > 0, nil
+}
+
+// LatestSecretEventID returns the ID of the newest event, 0 when there is none
+func LatestSecretEventID() (uint, error) {
+	var id uint
+	if result := DB.Model(&SecretEvent{}).Select("COALESCE(MAX(id), 0)").Scan(&id); result.Error != nil {
+		return 0, fmt.Errorf("failed to look up the latest secret event: %v", result.Error)
+	}
+	return id, nil
+}
+
+// DeleteSecretEventsBefore removes events older than cutoff and returns how many were removed
+func DeleteSecretEventsBefore(cutoff time.Time) (int64, error) {
+	result := DB.Where("created_at < ?", cutoff).Delete(&SecretEvent{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune secret events: %v", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// LogAuditEvent logs an audit event
+func LogAuditEvent(operation string, repoID *uint, secretID *uint, serviceName, requestID, username string, success bool, errorMessage string) error {
+	return createAuditLog(&AuditLog{
+		Operation:    operation,
+		RepoID:       repoID,
+		SecretID:     secretID,
+		Username:     username,
+		ServiceName:  serviceName,
+		RequestID:    requestID,
+		Success:      success,
+		ErrorMessage: errorMessage,
+	})
+}
+
+// LogLeaseAuditEvent logs an audit event on a dynamic credential lease
+func LogLeaseAuditEvent(operation string, lease *SecretLease, serviceName, requestID, username string, success bool, errorMessage string) error {
+	return createAuditLog(&AuditLog{
+		Operation:    operation,
+		RepoID:       &lease.RepoID,
+		SecretID:     &lease.SecretID,
+		LeaseID:      lease.LeaseID,
+		Username:     username,
+		ServiceName:  serviceName,
+		RequestID:    requestID,
+		Success:      success,
+		ErrorMessage: errorMessage,
+	})
+}
+
+// LogSharedSetAuditEvent logs an audit event on a shared secret set, and on the repository it was used with if any
+func LogSharedSetAuditEvent(operation string, setID *uint, repoID *uint, serviceName, requestID, username string, success bool, errorMessage string) error {
+	return createAuditLog(&AuditLog{
+		Operation:    operation,
+		RepoID:       repoID,
+		SharedSetID:  setID,
+		Username:     username,
+		ServiceName:  serviceName,
+		RequestID:    requestID,
+		Success:      success,
+		ErrorMessage: errorMessage,
+	})
+}
+
+func createAuditLog(auditLog *AuditLog) error {
+	if err := appendAuditLog(auditLog); err != nil {
+		return fmt.Errorf("failed to log audit event: %v", err)
+	}
+	exportAuditEvent(auditLog)
+
+	return nil
+}
+
+// GetLatestAuditCheckpoint returns the checkpoint covering the newest entry, or nil when there is none
+func GetLatestAuditCheckpoint() (*AuditCheckpoint, error) {
+	var checkpoints []AuditCheckpoint
+	result := DB.Order("audit_log_id DESC").Limit(1).Find(&checkpoints)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get audit checkpoint: %v", result.Error)
+	}
+	if len(checkpoints) == 0 {
+		return nil, nil
+	}
+	return &checkpoints[0], nil
+}
+
+func CreateAuditCheckpoint(checkpoint *AuditCheckpoint) error {
+	if result := DB.Create(checkpoint); result.Error != nil {
+		return fmt.Errorf("failed to create audit checkpoint: %v", result.Error)
+	}
+	return nil
+}
+
+// AuditLogFilter narrows ListAuditLogs; zero values match everything except RepoIDs, which is required
+type AuditLogFilter struct {
+	RepoIDs   []uint
+	Username  string
+	Operation string
+	Success   *bool
+	Since     *time.Time // Inclusive
+	Until     *time.Time // Exclusive
+	BeforeID  uint       // Pagination cursor: only events older than this one
+}
+
+// ListAuditLogs returns up to limit matching events, newest first
+func ListAuditLogs(filter AuditLogFilter, limit int) ([]AuditLog, error) {
+	var logs []AuditLog
+	if len(filter.RepoIDs) == 0 {
+		return logs, nil
+	}
+
+	query := DB.Where("repo_id IN ?", filter.RepoIDs)
+	if filter.Username != "" {
+		query = query.Where("username = ?", filter.Username)
+	}
+	if filter.Operation != "" {
+		query = query.Where("operation = ?", filter.Operation)
+	}
+	if filter.Success != nil {
+		query = query.Where("success = ?", *filter.Success)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+	if filter.BeforeID > 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+
+	result := query.Order("id DESC").Limit(limit).Find(&logs)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list audit events: %v", result.Error)
+	}
+	return logs, nil
 }
 
 // ListAllRepositoriesWithVersions gets all repositories with their secret versions
@@ -456,9 +2336,13 @@ func ListAllRepositoriesWithVersions() ([]RepositoryWithVersions, error) {
 		for i, secret := range repo.Secrets {
 			versions[i] = SecretVersion{
 				Version:     secret.Version,
+				Path:        secret.Path,
+				FileName:    secret.FileName,
+				Branch:      secret.Branch,
 				Tag:         secret.Tag,
 				Checksum:    secret.Checksum,
 				UploadedBy:  secret.UploadedBy,
+				Annotation:  secret.Annotation,
 				CreatedAt:   secret.CreatedAt,
 				IsEncrypted: secret.EncryptedKey != "", // Determine if encrypted based on EncryptedKey
 			}
@@ -504,10 +2388,14 @@ type RepositoryWithVersions struct {
 
 // SecretVersion represents a secret version
 type SecretVersion struct {
-	Version     int       `json:"version"`
-	Tag         string    `json:"tag"`
-	Checksum    string    `json:"checksum"`
-	UploadedBy  string    `json:"uploaded_by"`
-	CreatedAt   time.Time `json:"created_at"`
-	IsEncrypted bool      `json:"is_encrypted"`
+	Version     int               `json:"version"`
+	Path        string            `json:"path"`
+	FileName    string            `json:"file_name"`
+	Branch      string            `json:"branch"`
+	Tag         string            `json:"tag"`
+	Checksum    string            `json:"checksum"`
+	UploadedBy  string            `json:"uploaded_by"`
+	Annotation  VersionAnnotation `json:"annotation"`
+	CreatedAt   time.Time         `json:"created_at"`
+	IsEncrypted bool              `json:"is_encrypted"`
 }
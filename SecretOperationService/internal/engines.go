@@ -0,0 +1,207 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// Lease TTL defaults and bounds, in seconds
+const (
+	defaultLeaseTTL = 3600
+	defaultMaxTTL   = 24 * 3600
+	minLeaseTTL     = 60
+)
+
+// defaultLeaseReaperInterval is how often expired leases are revoked unless LEASE_REAPER_INTERVAL is set
+const defaultLeaseReaperInterval = time.Minute
+
+// engineTimeout bounds a single issue or revoke call against the engine's backend
+const engineTimeout = 30 * time.Second
+
+// secretEngine issues short-lived credentials on download. Each engine parses its own JSON configuration.
+type secretEngine interface {
+	// validate checks a configuration before it is stored
+	validate(config []byte) error
+	// keys lists the keys the engine adds to a download
+	keys(config []byte) []string
+	// issue creates credentials valid until expiresAt; principal identifies them for revoke
+	issue(ctx context.Context, config []byte, expiresAt time.Time) (values map[string]string, principal string, err error)
+	// revoke removes the credentials of principal; revoking credentials that are already gone succeeds
+	revoke(ctx context.Context, config []byte, principal string) error
+}
+
+// secretEngines maps engine names to their implementation
+var secretEngines = map[string]secretEngine{
+	"postgres": postgresEngine{},
+}
+
+// issuedLease is a credential added to a download, with the keys it set
+type issuedLease struct {
+	engine *SecretEngineConfig
+	lease  *SecretLease
+	keys   []string
+}
+
+// leaseTTL picks the TTL of a new lease: the requested one, or the engine's default, capped at its maximum
+func leaseTTL(engine *SecretEngineConfig, requested int) time.Duration {
+	ttl := engine.DefaultTTL
+	if requested > 0 {
+		ttl = requested
+	}
+	if ttl < minLeaseTTL {
+		ttl = minLeaseTTL
+	}
+	if ttl > engine.MaxTTL {
+		ttl = engine.MaxTTL
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+// issueEngineCredentials asks every engine configured for the secret's tag for fresh credentials and
+// sets them in envData, overriding stored values. It returns the engine each injected key came from.
+func issueEngineCredentials(ctx context.Context, secret *Secret, envData map[string]string, requestedTTL int, userLogin string) ([]issuedLease, map[string]string, error) {
+	engines, err := ListSecretEnginesForTag(secret.RepoID, secret.Tag)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var issued []issuedLease
+	origins := make(map[string]string)
+	for i := range engines {
+		engine := &engines[i]
+		implementation, ok := secretEngines[engine.Engine]
+		if !ok {
+			return nil, nil, revokeIssuedLeases(issued, fmt.Errorf("engine %s: unsupported engine %q", engine.Name, engine.Engine))
+		}
+
+		config, err := DecryptSecretEngineConfig(engine)
+		if err != nil {
+			return nil, nil, revokeIssuedLeases(issued, fmt.Errorf("engine %s: failed to decrypt configuration: %v", engine.Name, err))
+		}
+
+		leaseID, err := generateUUID()
+		if err != nil {
+			return nil, nil, revokeIssuedLeases(issued, err)
+		}
+		expiresAt := time.Now().UTC().Add(leaseTTL(engine, requestedTTL))
+
+		issueCtx, cancel := context.WithTimeout(ctx, engineTimeout)
+		values, principal, err := implementation.issue(issueCtx, config, expiresAt)
+		cancel()
+		if err != nil {
+			// Backend errors may describe the database the engine connects to, so they are only logged
+			log.Printf("Engine %d failed to issue credentials: %v", engine.ID, err)
+			return nil, nil, revokeIssuedLeases(issued, fmt.Errorf("engine %s: the engine's backend refused or could not be reached", engine.Name))
+		}
+
+		lease := &SecretLease{
+			LeaseID:   leaseID,
+			EngineID:  engine.ID,
+			RepoID:    secret.RepoID,
+			SecretID:  secret.ID,
+			Principal: principal,
+			IssuedTo:  userLogin,
+			ExpiresAt: expiresAt,
+		}
+		if err := CreateSecretLease(lease); err != nil {
+			// Without a lease the reaper would never find the credential, so take it back right away
+			revokeCtx, cancel := context.WithTimeout(context.Background(), engineTimeout)
+			if revokeErr := implementation.revoke(revokeCtx, config, principal); revokeErr != nil {
+				log.Printf("Failed to revoke unrecorded credential %s of engine %d: %v", principal, engine.ID, revokeErr)
+			}
+			cancel()
+			return nil, nil, revokeIssuedLeases(issued, fmt.Errorf("engine %s: %v", engine.Name, err))
+		}
+
+		for _, key := range sortedKeys(values) {
+			envData[key] = values[key]
+			origins[key] = engine.Name
+		}
+		issued = append(issued, issuedLease{engine: engine, lease: lease, keys: sortedKeys(values)})
+	}
+	return issued, origins, nil
+}
+
+// revokeIssuedLeases takes back the credentials of a download that failed and returns err
+func revokeIssuedLeases(issued []issuedLease, err error) error {
+	for _, lease := range issued {
+		if revokeErr := revokeLease(lease.engine, lease.lease); revokeErr != nil {
+			log.Printf("Failed to revoke lease %s, the reaper will retry: %v", lease.lease.LeaseID, revokeErr)
+		}
+	}
+	return err
+}
+
+// revokeLease removes a lease's credential and marks it revoked
+func revokeLease(engine *SecretEngineConfig, lease *SecretLease) error {
+	implementation, ok := secretEngines[engine.Engine]
+	if !ok {
+		return fmt.Errorf("unsupported engine %q", engine.Engine)
+	}
+
+	config, err := DecryptSecretEngineConfig(engine)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt configuration: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), engineTimeout)
+	defer cancel()
+	if err := implementation.revoke(ctx, config, lease.Principal); err != nil {
+		return err
+	}
+	return MarkLeaseRevoked(lease.ID)
+}
+
+// leaseReaperInterval reads LEASE_REAPER_INTERVAL, e.g. "30s"
+func leaseReaperInterval() time.Duration {
+	if value := os.Getenv("LEASE_REAPER_INTERVAL"); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil && interval > 0 {
+			return interval
+		}
+		log.Printf("Invalid LEASE_REAPER_INTERVAL %q, using default", value)
+	}
+	return defaultLeaseReaperInterval
+}
+
+// runLeaseReaper revokes expired leases until the process exits. Failed revocations are retried on the next run.
+func runLeaseReaper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		reapExpiredLeases()
+		<-ticker.C
+	}
+}
+
+func reapExpiredLeases() {
+	leases, err := ListExpiredLeases(time.Now().UTC())
+	if err != nil {
+		log.Printf("Lease reaper: %v", err)
+		return
+	}
+
+	engines := make(map[uint]*SecretEngineConfig)
+	for i := range leases {
+		lease := &leases[i]
+		engine, ok := engines[lease.EngineID]
+		if !ok {
+			engine = &SecretEngineConfig{}
+			if result := DB.First(engine, lease.EngineID); result.Error != nil {
+				log.Printf("Lease reaper: engine %d of lease %s: %v", lease.EngineID, lease.LeaseID, result.Error)
+				continue
+			}
+			engines[lease.EngineID] = engine
+		}
+
+		if err := revokeLease(engine, lease); err != nil {
+			log.Printf("Lease reaper: failed to revoke lease %s: %v", lease.LeaseID, err)
+			LogLeaseAuditEvent("LEASE_REVOKE", lease, "lease-reaper", "", lease.IssuedTo, false, err.Error())
+			continue
+		}
+		LogLeaseAuditEvent("LEASE_REVOKE", lease, "lease-reaper", "", lease.IssuedTo, true, "")
+	}
+}
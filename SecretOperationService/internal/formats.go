@@ -0,0 +1,327 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported input formats for UploadSecret
+const (
+	FormatDotenv     = "dotenv"
+	FormatJSON       = "json"
+	FormatYAML       = "yaml"
+	FormatProperties = "properties"
+	FormatCompose    = "compose"
+)
+
+const defaultKeySeparator = "_"
+
+// parseSecretFile parses uploaded file content in the given format into a flat key/value map. Keys of the
+// structured formats are limited to the characters every download format can carry, see checkUploadedKeys;
+// dotenv uploads accept the same keys as they always have.
+func (s *Server) parseSecretFile(content []byte, format, separator string) (map[string]string, error) {
+	if separator == "" {
+		separator = defaultKeySeparator
+	}
+
+	var envData map[string]string
+	var err error
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", FormatDotenv, "env":
+		return s.parseEnvFile(content)
+	case FormatJSON:
+		envData, err = parseJSONFile(content, separator)
+	case FormatYAML, "yml":
+		envData, err = parseYAMLFile(content, separator)
+	case FormatProperties:
+		envData, err = parsePropertiesFile(content)
+	case FormatCompose:
+		envData, err = parseComposeEnvFile(content)
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUploadedKeys(envData); err != nil {
+		return nil, err
+	}
+	return envData, nil
+}
+
+// checkUploadedKeys rejects keys with characters outside letters, digits, '_', '.' and '-'. Dotted keys
+// stay allowed for properties files and Kubernetes, as for generated keys; the export, docker and systemd renderers
+// additionally refuse keys that are not environment variable names.
+func checkUploadedKeys(envData map[string]string) error {
+	for _, key := range sortedKeys(envData) {
+		if !variableNamePattern.MatchString(key) {
+			return fmt.Errorf("invalid key %q: keys may only contain letters, digits, '_', '.' and '-'", key)
+		}
+	}
+	return nil
+}
+
+func parseJSONFile(content []byte, separator string) (map[string]string, error) {
+	// Keep numbers as written; float64 would turn large integers into 1.2345678901234567e+19
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("invalid json: %v", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid json: unexpected data after the root object")
+	}
+	if _, ok := data.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("json root must be an object")
+	}
+
+	envData := make(map[string]string)
+	if err := flattenValue("", data, separator, envData); err != nil {
+		return nil, err
+	}
+	return envData, nil
+}
+
+func parseYAMLFile(content []byte, separator string) (map[string]string, error) {
+	var data interface{}
+	if err := yaml.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("invalid yaml: %v", err)
+	}
+	if data == nil {
+		return make(map[string]string), nil
+	}
+	if _, ok := data.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("yaml root must be a mapping")
+	}
+
+	envData := make(map[string]string)
+	if err := flattenValue("", data, separator, envData); err != nil {
+		return nil, err
+	}
+	return envData, nil
+}
+
+// flattenValue walks nested maps and lists, joining keys with separator. Two paths flattening to the
+// same key, e.g. {"a_b": 1, "a": {"b": 2}}, are an error rather than one silently replacing the other.
+func flattenValue(prefix string, value interface{}, separator string, out map[string]string) error {
+	joinKey := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + separator + key
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := flattenValue(joinKey(key), v[key], separator, out); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = item
+		}
+		return flattenValue(prefix, converted, separator, out)
+	case []interface{}:
+		for i, item := range v {
+			if err := flattenValue(joinKey(strconv.Itoa(i)), item, separator, out); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if _, exists := out[prefix]; exists {
+		return fmt.Errorf("key %s is defined twice after flattening nested keys with %q", prefix, separator)
+	}
+	switch v := value.(type) {
+	case nil:
+		out[prefix] = ""
+	case string:
+		out[prefix] = v
+	case float64:
+		out[prefix] = strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		out[prefix] = v.String()
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+	return nil
+}
+
+// parsePropertiesFile parses Java .properties syntax (key=value, key: value, key value)
+func parsePropertiesFile(content []byte) (map[string]string, error) {
+	envData := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+
+	var logical strings.Builder
+	for scanner.Scan() {
+		line := strings.TrimLeft(scanner.Text(), " \t\f")
+		if logical.Len() == 0 && (line == "" || line[0] == '#' || line[0] == '!') {
+			continue
+		}
+
+		// An odd number of trailing backslashes continues the line
+		trailing := len(line) - len(strings.TrimRight(line, "\\"))
+		if trailing%2 == 1 {
+			logical.WriteString(line[:len(line)-1])
+			continue
+		}
+		logical.WriteString(line)
+
+		key, value := splitPropertiesLine(logical.String())
+		logical.Reset()
+
+		key, err := unescapeProperties(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err = unescapeProperties(value)
+		if err != nil {
+			return nil, err
+		}
+		envData[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if logical.Len() > 0 {
+		key, value := splitPropertiesLine(logical.String())
+		key, err := unescapeProperties(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err = unescapeProperties(value)
+		if err != nil {
+			return nil, err
+		}
+		envData[key] = value
+	}
+
+	return envData, nil
+}
+
+func splitPropertiesLine(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':', ' ', '\t', '\f':
+			key := line[:i]
+			rest := strings.TrimLeft(line[i:], " \t\f")
+			if line[i] == ' ' || line[i] == '\t' || line[i] == '\f' {
+				if len(rest) > 0 && (rest[0] == '=' || rest[0] == ':') {
+					rest = rest[1:]
+				}
+			} else {
+				rest = rest[1:]
+			}
+			return key, strings.TrimLeft(rest, " \t\f")
+		}
+	}
+	return line, ""
+}
+
+func unescapeProperties(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", fmt.Errorf("malformed \\u escape in %q", s)
+			}
+			code, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\u escape in %q", s)
+			}
+			b.WriteRune(rune(code))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// parseComposeEnvFile parses docker-compose env_file syntax
+func parseComposeEnvFile(content []byte) (map[string]string, error) {
+	envData := make(map[string]string)
+	lines := strings.Split(string(content), "\n")
+
+	for i, line := range lines {
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\r"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", i+1, key)
+		}
+		// A bare VAR takes its value from the shell at compose time, nothing to store
+		if len(parts) == 1 {
+			continue
+		}
+
+		value := strings.TrimSpace(parts[1])
+		switch {
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			value = unescapeDoubleQuoted(value[1 : len(value)-1])
+		default:
+			// Unquoted values may carry an inline comment preceded by whitespace
+			if idx := strings.Index(value, " #"); idx >= 0 {
+				value = strings.TrimSpace(value[:idx])
+			}
+			value = strings.ReplaceAll(value, "$$", "$")
+		}
+		envData[key] = value
+	}
+
+	return envData, nil
+}
+
+// unescapeDoubleQuoted decodes the escapes docker compose accepts in double-quoted env_file values. A
+// literal $ is written $$ in compose, as the rest of the value is subject to interpolation.
+func unescapeDoubleQuoted(s string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`, `$$`, `$`)
+	return replacer.Replace(s)
+}
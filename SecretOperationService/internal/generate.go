@@ -0,0 +1,234 @@
+package internal
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+
+	secretsservice "github.com/kurs0n/SecretOperationService/proto"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Value types GenerateSecretValues can create
+const (
+	generateTypeRandom  = "random"
+	generateTypeHex     = "hex"
+	generateTypeBase64  = "base64"
+	generateTypeUUID    = "uuid"
+	generateTypeEd25519 = "ed25519"
+	generateTypeRSA     = "rsa"
+	generateTypeBcrypt  = "bcrypt"
+)
+
+// Defaults and limits per type; lengths are characters for random strings, bytes for hex and base64 keys
+const (
+	defaultRandomLength = 32
+	maxRandomLength     = 4096
+	defaultKeyBytes     = 32
+	maxKeyBytes         = 1024
+	defaultRSABits      = 3072
+	maxBcryptCost       = 14 // Higher costs take seconds per hash
+)
+
+// Named character sets for random strings; any other charset is used as the literal set of characters
+var generateCharsets = map[string]string{
+	"alphanumeric": "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	"alpha":        "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	"lower":        "abcdefghijklmnopqrstuvwxyz0123456789",
+	"upper":        "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789",
+	"numeric":      "0123456789",
+	"symbols":      "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!#%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+// generateSecretValues creates the requested values in order. A bcrypt spec hashes the value of its
+// source key, which may have been generated earlier in the same request or exist in envData.
+// Keypairs add the public key under spec.PublicKey, or <KEY>_PUBLIC by default.
+func generateSecretValues(specs []*secretsservice.GenerateSpec, envData map[string]string) (map[string]string, error) {
+	generated := make(map[string]string)
+	for _, spec := range specs {
+		if !variableNamePattern.MatchString(spec.Key) {
+			return nil, fmt.Errorf("invalid key name: %q", spec.Key)
+		}
+
+		var value, publicKey string
+		var err error
+		switch strings.ToLower(spec.Type) {
+		case generateTypeRandom:
+			value, err = generateRandomString(spec.Charset, int(spec.Length))
+		case generateTypeHex, generateTypeBase64:
+			value, err = generateKey(strings.ToLower(spec.Type), int(spec.Length))
+		case generateTypeUUID:
+			value, err = generateUUID()
+		case generateTypeEd25519:
+			value, publicKey, err = generateEd25519Keypair()
+		case generateTypeRSA:
+			value, publicKey, err = generateRSAKeypair(int(spec.Length))
+		case generateTypeBcrypt:
+			source, ok := generated[spec.SourceKey]
+			if !ok {
+				source, ok = envData[spec.SourceKey]
+			}
+			if spec.SourceKey == "" || !ok {
+				return nil, fmt.Errorf("%s: bcrypt needs an existing source key to hash, got %q", spec.Key, spec.SourceKey)
+			}
+			value, err = generateBcryptHash(source, int(spec.Cost))
+		default:
+			return nil, fmt.Errorf("%s: unsupported type %q", spec.Key, spec.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", spec.Key, err)
+		}
+
+		if err := addGeneratedValue(generated, spec.Key, value); err != nil {
+			return nil, err
+		}
+		if publicKey != "" {
+			publicKeyName := spec.PublicKey
+			if publicKeyName == "" {
+				publicKeyName = spec.Key + "_PUBLIC"
+			}
+			if !variableNamePattern.MatchString(publicKeyName) {
+				return nil, fmt.Errorf("invalid key name: %q", publicKeyName)
+			}
+			if err := addGeneratedValue(generated, publicKeyName, publicKey); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return generated, nil
+}
+
+func addGeneratedValue(generated map[string]string, key, value string) error {
+	if _, duplicate := generated[key]; duplicate {
+		return fmt.Errorf("%s is generated more than once", key)
+	}
+	generated[key] = value
+	return nil
+}
+
+// generateRandomString picks length characters uniformly from charset
+func generateRandomString(charset string, length int) (string, error) {
+	if length == 0 {
+		length = defaultRandomLength
+	}
+	if length < 0 || length > maxRandomLength {
+		return "", fmt.Errorf("length must be between 1 and %d", maxRandomLength)
+	}
+
+	if charset == "" {
+		charset = "alphanumeric"
+	}
+	if named, ok := generateCharsets[charset]; ok {
+		charset = named
+	}
+	characters := []rune(charset)
+	if len(characters) < 2 {
+		return "", fmt.Errorf("charset needs at least two characters")
+	}
+
+	var builder strings.Builder
+	charsetSize := big.NewInt(int64(len(characters)))
+	for i := 0; i < length; i++ {
+		index, err := rand.Int(rand.Reader, charsetSize)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random value: %v", err)
+		}
+		builder.WriteRune(characters[index.Int64()])
+	}
+	return builder.String(), nil
+}
+
+// generateKey returns size random bytes, hex or standard base64 encoded
+func generateKey(encoding string, size int) (string, error) {
+	if size == 0 {
+		size = defaultKeyBytes
+	}
+	if size < 0 || size > maxKeyBytes {
+		return "", fmt.Errorf("length must be between 1 and %d bytes", maxKeyBytes)
+	}
+
+	key := make([]byte, size)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %v", err)
+	}
+	if encoding == generateTypeHex {
+		return hex.EncodeToString(key), nil
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// generateUUID returns a random version 4 UUID
+func generateUUID() (string, error) {
+	uuid := make([]byte, 16)
+	if _, err := rand.Read(uuid); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %v", err)
+	}
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:]), nil
+}
+
+// generateEd25519Keypair returns a PKCS #8 private key and a PKIX public key, PEM encoded
+func generateEd25519Keypair() (string, string, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate key: %v", err)
+	}
+	return encodeKeypair(privateKey, publicKey)
+}
+
+// generateRSAKeypair returns a PKCS #8 private key and a PKIX public key, PEM encoded
+func generateRSAKeypair(bits int) (string, string, error) {
+	if bits == 0 {
+		bits = defaultRSABits
+	}
+	switch bits {
+	case 2048, 3072, 4096:
+	default:
+		return "", "", fmt.Errorf("RSA keys must be 2048, 3072 or 4096 bits")
+	}
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate key: %v", err)
+	}
+	return encodeKeypair(privateKey, &privateKey.PublicKey)
+}
+
+func encodeKeypair(privateKey, publicKey any) (string, string, error) {
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode private key: %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode public key: %v", err)
+	}
+
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	return string(privatePEM), string(publicPEM), nil
+}
+
+// generateBcryptHash hashes value with the given cost, bcrypt.DefaultCost when zero
+func generateBcryptHash(value string, cost int) (string, error) {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	if cost < bcrypt.MinCost || cost > maxBcryptCost {
+		return "", fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, maxBcryptCost)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(value), cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash value: %v", err)
+	}
+	return string(hash), nil
+}
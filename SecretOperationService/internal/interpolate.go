@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// interpolator expands ${VAR}, ${VAR:-default} and ${VAR:?error} using the other keys of one secret.
+// $${ escapes a literal ${.
+type interpolator struct {
+	env      map[string]string
+	resolved map[string]string
+	active   map[string]bool
+}
+
+// interpolateEnv returns envData with every reference to another key expanded
+func interpolateEnv(envData map[string]string) (map[string]string, error) {
+	ip := &interpolator{
+		env:      envData,
+		resolved: make(map[string]string, len(envData)),
+		active:   make(map[string]bool),
+	}
+
+	for _, key := range sortedKeys(envData) {
+		if _, err := ip.resolveKey(key, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ip.resolved, nil
+}
+
+func (ip *interpolator) resolveKey(key string, stack []string) (string, error) {
+	if value, ok := ip.resolved[key]; ok {
+		return value, nil
+	}
+	if ip.active[key] {
+		return "", fmt.Errorf("interpolation cycle: %s -> %s", strings.Join(stack, " -> "), key)
+	}
+
+	ip.active[key] = true
+	value, err := ip.expand(ip.env[key], append(stack, key))
+	ip.active[key] = false
+	if err != nil {
+		return "", err
+	}
+
+	ip.resolved[key] = value
+	return value, nil
+}
+
+func (ip *interpolator) expand(value string, stack []string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(value); {
+		switch {
+		case strings.HasPrefix(value[i:], "$${"):
+			b.WriteString("${")
+			i += 3
+		case strings.HasPrefix(value[i:], "${"):
+			end := matchingBrace(value, i+2)
+			if end < 0 {
+				return "", fmt.Errorf("%s: unterminated ${ in value", stack[len(stack)-1])
+			}
+			expanded, err := ip.evaluate(value[i+2:end], stack)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(expanded)
+			i = end + 1
+		default:
+			b.WriteByte(value[i])
+			i++
+		}
+	}
+	return b.String(), nil
+}
+
+// evaluate expands one expression found between ${ and }
+func (ip *interpolator) evaluate(expr string, stack []string) (string, error) {
+	name, operand, operator := expr, "", ""
+	if idx := strings.Index(expr, ":"); idx >= 0 {
+		name = expr[:idx]
+		rest := expr[idx+1:]
+		if len(rest) == 0 || (rest[0] != '-' && rest[0] != '?') {
+			return "", fmt.Errorf("%s: unsupported expression ${%s}", stack[len(stack)-1], expr)
+		}
+		operator, operand = rest[:1], rest[1:]
+	}
+
+	if !variableNamePattern.MatchString(name) {
+		return "", fmt.Errorf("%s: invalid variable name in ${%s}", stack[len(stack)-1], expr)
+	}
+
+	var value string
+	if _, ok := ip.env[name]; ok {
+		var err error
+		if value, err = ip.resolveKey(name, stack); err != nil {
+			return "", err
+		}
+	}
+
+	if value != "" {
+		return value, nil
+	}
+
+	switch operator {
+	case "-":
+		return ip.expand(operand, stack)
+	case "?":
+		message, err := ip.expand(operand, stack)
+		if err != nil {
+			return "", err
+		}
+		if message == "" {
+			message = "is required"
+		}
+		return "", fmt.Errorf("%s: %s %s", stack[len(stack)-1], name, message)
+	}
+
+	// Like a shell, an unset variable expands to an empty string
+	return "", nil
+}
+
+// escapeInterpolation escapes every ${ in a value so interpolateEnv returns it unchanged
+func escapeInterpolation(value string) string {
+	return strings.ReplaceAll(value, "${", "$${")
+}
+
+// matchingBrace returns the index of the } closing an expression that starts at start, allowing nested ${...}
+func matchingBrace(value string, start int) int {
+	depth := 1
+	for i := start; i < len(value); i++ {
+		switch {
+		case strings.HasPrefix(value[i:], "${"):
+			depth++
+			i++
+		case value[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	secretsservice "github.com/kurs0n/SecretOperationService/proto"
+)
+
+// fingerprintContext separates the fingerprint key derived from the master key from any other use of it
+const fingerprintContext = "envini secret value fingerprint v1"
+
+// maskValue keeps up to 4 leading and 2 trailing characters of a value, e.g. sk_l…9f for a 32 character
+// key, and fewer for shorter values so at most 3/16 of a value shows; values under 8 characters show
+// nothing but the ellipsis
+func maskValue(value string) string {
+	runes := []rune(value)
+	if len(runes) < 8 {
+		return "…"
+	}
+	prefix := min(4, len(runes)/8)
+	suffix := min(2, len(runes)/16)
+	return string(runes[:prefix]) + "…" + string(runes[len(runes)-suffix:])
+}
+
+// fingerprintKey derives a repository's fingerprint key from the master key. Each repository gets its own
+// key, so equal fingerprints only reveal equal values within a repository and a collaborator cannot match
+// values against the fingerprints of another repository.
+func fingerprintKey(repoID uint) ([]byte, error) {
+	masterKey, err := getMasterKey()
+	if err != nil {
+		return nil, err
+	}
+	derive := hmac.New(sha256.New, masterKey)
+	fmt.Fprintf(derive, "%s:%d", fingerprintContext, repoID)
+	return derive.Sum(nil), nil
+}
+
+// fingerprintValue returns a keyed HMAC-SHA256 of a value, truncated to 64 bits, so equal values can be
+// compared across tags while a fingerprint cannot be checked against guessed values outside the service
+func fingerprintValue(key []byte, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// listSecretKeys resolves a version like DownloadSecret, with its inherited tags and the overlay of
+// scope.Branch, and describes each key without revealing its value. It returns the overlay applied too.
+func listSecretKeys(repoID uint, scope SecretScope, tag string, version int) (*Secret, *Secret, []*secretsservice.SecretKeyInfo, error) {
+	secret, overlay, chain, err := resolveSecretChain(repoID, scope, scope.Branch, tag, version)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	layers, err := loadLayers(chain)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	envData, origins := mergeLayers(layers)
+	fingerprints, err := fingerprintKey(repoID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to derive the fingerprint key: %v", err)
+	}
+
+	keys := make([]*secretsservice.SecretKeyInfo, 0, len(envData))
+	for _, key := range sortedKeys(envData) {
+		value := envData[key]
+		info := &secretsservice.SecretKeyInfo{
+			Key:         key,
+			Length:      int32(len([]rune(value))),
+			Preview:     maskValue(value),
+			Fingerprint: fingerprintValue(fingerprints, value),
+		}
+		if origin := origins[key]; origin != nil {
+			if origin.Tag != secret.Tag {
+				info.Tag = origin.Tag
+			}
+			info.Branch = origin.Branch
+		}
+		keys = append(keys, info)
+	}
+	return secret, overlay, keys, nil
+}
@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// maxTagInheritanceDepth bounds parent chains so a corrupted graph cannot loop forever
+const maxTagInheritanceDepth = 32
+
+// secretLayer is one stored secret contributing keys to a download, applied in order
+type secretLayer struct {
+	secret *Secret
+	data   map[string]string
+}
+
+// loadSecretData decrypts a secret and decodes its key/value map
+func loadSecretData(secret *Secret) (map[string]string, error) {
+	decryptedData, err := DecryptSecretData(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %v", err)
+	}
+
+	var envData map[string]string
+	if err := json.Unmarshal([]byte(decryptedData), &envData); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal env data: %v", err)
+	}
+	return envData, nil
+}
+
+// loadLayers decrypts every secret of a chain, keeping its order
+func loadLayers(chain []*Secret) ([]secretLayer, error) {
+	layers := make([]secretLayer, len(chain))
+	for i, secret := range chain {
+		data, err := loadSecretData(secret)
+		if err != nil {
+			return nil, err
+		}
+		layers[i] = secretLayer{secret: secret, data: data}
+	}
+	return layers, nil
+}
+
+// resolveTagChain returns secret preceded by the secrets of its ancestor tags, root first
+func resolveTagChain(repoID uint, scope SecretScope, secret *Secret) ([]*Secret, error) {
+	chain := []*Secret{secret}
+	visited := map[string]bool{secret.Tag: true}
+	tags := []string{secret.Tag}
+
+	current := secret
+	for {
+		parent, err := GetTagParent(repoID, scope, current.Tag)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			return chain, nil
+		}
+
+		tags = append(tags, parent.ParentTag)
+		if visited[parent.ParentTag] || len(tags) > maxTagInheritanceDepth {
+			return nil, fmt.Errorf("tag inheritance cycle: %s", strings.Join(tags, " -> "))
+		}
+		visited[parent.ParentTag] = true
+
+		var parentSecret *Secret
+		if parent.ParentVersion > 0 {
+			parentSecret, err = GetSecretByTagAndVersion(repoID, scope, parent.ParentTag, parent.ParentVersion)
+		} else {
+			parentSecret, err = GetSecretByTag(repoID, scope, parent.ParentTag)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve parent tag %s of %s: %v", parent.ParentTag, current.Tag, err)
+		}
+
+		chain = append([]*Secret{parentSecret}, chain...)
+		current = parentSecret
+	}
+}
+
+// checkTagCycle reports an error if making parentTag the parent of tag would close a cycle, reading the
+// parents through db, which may be a transaction
+func checkTagCycle(db *gorm.DB, repoID uint, scope SecretScope, tag, parentTag string) error {
+	tags := []string{tag, parentTag}
+
+	for current := parentTag; current != tag; {
+		parent, err := getTagParent(db, repoID, scope, current)
+		if err != nil {
+			return err
+		}
+		if parent == nil {
+			return nil
+		}
+		if len(tags) > maxTagInheritanceDepth {
+			return fmt.Errorf("tag inheritance is deeper than %d levels", maxTagInheritanceDepth)
+		}
+		tags = append(tags, parent.ParentTag)
+		current = parent.ParentTag
+	}
+
+	return fmt.Errorf("tag inheritance cycle: %s", strings.Join(tags, " -> "))
+}
+
+// mergeLayers merges layers in order, later layers overriding earlier ones, and records each key's origin
+func mergeLayers(layers []secretLayer) (map[string]string, map[string]*Secret) {
+	envData := make(map[string]string)
+	origins := make(map[string]*Secret)
+	for _, layer := range layers {
+		for key, value := range layer.data {
+			envData[key] = value
+			origins[key] = layer.secret
+		}
+	}
+	return envData, origins
+}
+
+// selectSecret picks a version the way DownloadSecret does: by tag and version, the latest of a tag, a
+// version of any tag, or the latest version
+func selectSecret(repoID uint, scope SecretScope, tag string, version int) (*Secret, error) {
+	switch {
+	case tag != "" && version != 0:
+		return GetSecretByTagAndVersion(repoID, scope, tag, version)
+	case tag != "":
+		return GetSecretByTag(repoID, scope, tag)
+	case version != 0:
+		return GetSecretByVersion(repoID, scope, version)
+	default:
+		return GetLatestSecret(repoID, scope)
+	}
+}
+
+// resolveSecretChain selects a version like selectSecret and resolves what a download merges: the tag's
+// ancestors, root first, the version and the branch overlay on top. A tag that only has versions on the
+// branch is served from its latest overlay, still inheriting the tag's parents. A pinned version applies
+// the overlay as it stood when that version was created, so the result stays reproducible. It returns the
+// selected version, the overlay applied or nil, and the chain.
+func resolveSecretChain(repoID uint, scope SecretScope, branch, tag string, version int) (*Secret, *Secret, []*Secret, error) {
+	scope = scope.Base()
+	overlayScope := scope
+	overlayScope.Branch = branch
+
+	secret, err := selectSecret(repoID, scope, tag, version)
+	var overlay *Secret
+	if err != nil && branch != "" && version == 0 {
+		var fallback *Secret
+		var fallbackErr error
+		if tag != "" {
+			fallback, fallbackErr = GetBranchOverlay(repoID, overlayScope, tag)
+		} else {
+			fallback, fallbackErr = GetLatestSecret(repoID, overlayScope)
+		}
+		if fallbackErr == nil && fallback != nil {
+			secret, overlay, err = fallback, fallback, nil
+		}
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	chain, err := resolveTagChain(repoID, scope, secret)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to resolve tag inheritance: %v", err)
+	}
+
+	if overlay == nil && branch != "" {
+		if version != 0 {
+			overlay, err = GetBranchOverlayAsOf(repoID, overlayScope, secret.Tag, secret.CreatedAt)
+		} else {
+			overlay, err = GetBranchOverlay(repoID, overlayScope, secret.Tag)
+		}
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if overlay != nil {
+			chain = append(chain, overlay)
+		}
+	}
+	return secret, overlay, chain, nil
+}
@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+
+	secretsservice "github.com/kurs0n/SecretOperationService/proto"
+)
+
+// Actions a repository can configure for a lint rule
+const (
+	lintActionWarn  = "warn"
+	lintActionBlock = "block"
+	lintActionOff   = "off"
+)
+
+// lintRule checks a single key/value pair and returns a message when the pair looks risky
+type lintRule struct {
+	name        string
+	description string
+	check       func(key, value string) string
+}
+
+// LintFinding is a key flagged by a lint rule; blocking findings reject the upload
+type LintFinding struct {
+	Key      string
+	Rule     string
+	Message  string
+	Blocking bool
+}
+
+var (
+	placeholderPattern = regexp.MustCompile(`(?i)^(change[-_ ]?me|replace[-_ ]?me|todo|tbd|fixme|placeholder|dummy|example|x{3,}|\*{3,}|<[^<>]*>|your[-_ ].*[-_ ]here)$`)
+	privateKeyPattern  = regexp.MustCompile(`-----BEGIN ([A-Z]+ )*PRIVATE KEY-----`)
+	jwtPattern         = regexp.MustCompile(`^eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*$`)
+)
+
+// Key name fragments under which private keys, tokens and passwords are expected
+var (
+	privateKeyNames = []string{"KEY", "PEM", "CERT", "SSH", "TLS", "SSL"}
+	jwtNames        = []string{"TOKEN", "JWT", "BEARER", "AUTH", "SESSION", "CREDENTIAL"}
+	passwordNames   = []string{"PASSWORD", "PASSWD", "PWD", "PASS", "SECRET"}
+)
+
+// weakPasswords are common passwords that appear at the top of every breach list
+var weakPasswords = map[string]bool{
+	"password": true, "password1": true, "passw0rd": true, "p@ssw0rd": true, "123456": true, "12345678": true,
+	"123456789": true, "1234567890": true, "111111": true, "000000": true, "qwerty": true, "qwerty123": true,
+	"abc123": true, "letmein": true, "welcome": true, "admin": true, "administrator": true, "root": true,
+	"toor": true, "secret": true, "default": true, "guest": true, "test": true, "iloveyou": true,
+	"monkey": true, "dragon": true, "master": true, "postgres": true, "mysql": true,
+}
+
+// lintRules lists the rules in the order findings are reported
+var lintRules = []lintRule{
+	{
+		name:        "empty-value",
+		description: "Key has an empty value",
+		check: func(key, value string) string {
+			if strings.TrimSpace(value) == "" {
+				return "value is empty"
+			}
+			return ""
+		},
+	},
+	{
+		name:        "placeholder",
+		description: "Value is a placeholder such as changeme or xxx",
+		check: func(key, value string) string {
+			if placeholderPattern.MatchString(strings.TrimSpace(value)) {
+				return "value looks like a placeholder"
+			}
+			return ""
+		},
+	},
+	{
+		name:        "misplaced-private-key",
+		description: "Private key stored under a key not named like one",
+		check: func(key, value string) string {
+			if privateKeyPattern.MatchString(value) && !keyNameContains(key, privateKeyNames) {
+				return "value is a private key but the key name does not say so"
+			}
+			return ""
+		},
+	},
+	{
+		name:        "misplaced-jwt",
+		description: "JWT stored under a key not named like a token",
+		check: func(key, value string) string {
+			if jwtPattern.MatchString(strings.TrimSpace(value)) && !keyNameContains(key, jwtNames) {
+				return "value is a JWT but the key name does not say so"
+			}
+			return ""
+		},
+	},
+	{
+		name:        "weak-password",
+		description: "Password is a well known low-entropy password",
+		check: func(key, value string) string {
+			if keyNameContains(key, passwordNames) && weakPasswords[strings.ToLower(value)] {
+				return "value is a well known weak password"
+			}
+			return ""
+		},
+	},
+}
+
+// lintSecret runs every enabled rule over envData, using the repository's configured actions.
+// Values holding ${...} references or interpolation are only checked for emptiness.
+func lintSecret(envData map[string]string, actions map[string]string) []LintFinding {
+	var findings []LintFinding
+	for _, key := range sortedKeys(envData) {
+		value := envData[key]
+		for _, rule := range lintRules {
+			action := actions[rule.name]
+			if action == "" {
+				action = lintActionWarn
+			}
+			if action == lintActionOff {
+				continue
+			}
+			if rule.name != "empty-value" && strings.Contains(value, "${") {
+				continue
+			}
+
+			if message := rule.check(key, value); message != "" {
+				findings = append(findings, LintFinding{
+					Key:      key,
+					Rule:     rule.name,
+					Message:  message,
+					Blocking: action == lintActionBlock,
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// hasBlockingFindings reports whether any finding rejects the upload
+func hasBlockingFindings(findings []LintFinding) bool {
+	for _, finding := range findings {
+		if finding.Blocking {
+			return true
+		}
+	}
+	return false
+}
+
+// isLintRule reports whether name is a known rule
+func isLintRule(name string) bool {
+	for _, rule := range lintRules {
+		if rule.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// keyNameContains reports whether the upper-cased key contains one of the fragments
+func keyNameContains(key string, fragments []string) bool {
+	upper := strings.ToUpper(key)
+	for _, fragment := range fragments {
+		if strings.Contains(upper, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// findingsToProto converts findings for a response
+func findingsToProto(findings []LintFinding) []*secretsservice.LintFinding {
+	protoFindings := make([]*secretsservice.LintFinding, len(findings))
+	for i, finding := range findings {
+		protoFindings[i] = &secretsservice.LintFinding{
+			Key:      finding.Key,
+			Rule:     finding.Rule,
+			Message:  finding.Message,
+			Blocking: finding.Blocking,
+		}
+	}
+	return protoFindings
+}
@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	secretsservice "github.com/kurs0n/SecretOperationService/proto"
+	"gorm.io/gorm"
+)
+
+// Reasons a key is reported by ListExpiringSecrets
+const (
+	dueReasonExpiry   = "expiry"
+	dueReasonRotation = "rotation"
+)
+
+// defaultExpiringWithinDays is used when ListExpiringSecrets is called without a window
+const defaultExpiringWithinDays = 30
+
+// parseRotationInterval accepts day and week suffixes ("90d", "12w") besides Go durations ("720h")
+func parseRotationInterval(value string) (time.Duration, error) {
+	var interval time.Duration
+	switch {
+	case strings.HasSuffix(value, "d"), strings.HasSuffix(value, "w"):
+		count, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid rotation interval: %s", value)
+		}
+		interval = time.Duration(count) * 24 * time.Hour
+		if strings.HasSuffix(value, "w") {
+			interval *= 7
+		}
+	default:
+		var err error
+		if interval, err = time.ParseDuration(value); err != nil {
+			return 0, fmt.Errorf("invalid rotation interval: %s", value)
+		}
+	}
+
+	if interval <= 0 {
+		return 0, fmt.Errorf("rotation interval must be positive: %s", value)
+	}
+	return interval, nil
+}
+
+// parseExpiresAt accepts an RFC 3339 timestamp or a date, which means midnight UTC
+func parseExpiresAt(value string) (time.Time, error) {
+	if expiresAt, err := time.Parse(time.RFC3339, value); err == nil {
+		return expiresAt.UTC(), nil
+	}
+	if expiresAt, err := time.Parse(time.DateOnly, value); err == nil {
+		return expiresAt, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid expiry %q, expected YYYY-MM-DD or an RFC 3339 timestamp", value)
+}
+
+// buildKeyMetadata resolves the metadata stored with a new version. Keys without an entry in provided
+// keep the metadata of the previous version of the same scope and tag; an entry with every field
+// empty clears it. RotatedAt only moves forward when a key's value actually changes. The previous version
+// is read through db, which may be a transaction.
+func buildKeyMetadata(db *gorm.DB, repoID uint, scope SecretScope, tag string, envData map[string]string, provided []*secretsservice.KeyMetadata) ([]SecretKeyMetadata, error) {
+	entries := make(map[string]*secretsservice.KeyMetadata, len(provided))
+	for _, entry := range provided {
+		if _, ok := envData[entry.Key]; !ok {
+			return nil, fmt.Errorf("metadata for %s, which is not in the secret", entry.Key)
+		}
+		if _, duplicate := entries[entry.Key]; duplicate {
+			return nil, fmt.Errorf("metadata for %s is given more than once", entry.Key)
+		}
+		entries[entry.Key] = entry
+	}
+
+	// The latest version of the same scope and tag, if any
+	previous, err := getBranchOverlay(db, repoID, scope, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	previousMetadata := make(map[string]SecretKeyMetadata)
+	var previousData map[string]string
+	if previous != nil {
+		records, err := getSecretKeyMetadata(db, previous.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			previousMetadata[record.Key] = record
+		}
+
+		if len(records) > 0 || len(entries) > 0 {
+			decryptedData, err := DecryptSecretData(previous)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt previous version: %v", err)
+			}
+			if err := json.Unmarshal([]byte(decryptedData), &previousData); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal previous version: %v", err)
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	var metadata []SecretKeyMetadata
+	for _, key := range sortedKeys(envData) {
+		record, carried := previousMetadata[key]
+		if entry, ok := entries[key]; ok {
+			record = SecretKeyMetadata{Key: key, Owner: strings.TrimSpace(entry.Owner), RotatedAt: record.RotatedAt}
+			if entry.ExpiresAt != "" {
+				expiresAt, err := parseExpiresAt(entry.ExpiresAt)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %v", key, err)
+				}
+				record.ExpiresAt = &expiresAt
+			}
+			if entry.RotationInterval != "" {
+				if _, err := parseRotationInterval(entry.RotationInterval); err != nil {
+					return nil, fmt.Errorf("%s: %v", key, err)
+				}
+				record.RotationInterval = entry.RotationInterval
+			}
+			if record.ExpiresAt == nil && record.Owner == "" && record.RotationInterval == "" {
+				continue
+			}
+		} else if !carried {
+			continue
+		}
+
+		previousValue, existed := previousData[key]
+		switch {
+		case !existed || previousValue != envData[key]:
+			record.RotatedAt = now
+		case record.RotatedAt.IsZero():
+			// Unchanged since at least the previous version
+			record.RotatedAt = previous.CreatedAt
+		}
+
+		record.ID = 0
+		record.SecretID = 0
+		metadata = append(metadata, record)
+	}
+	return metadata, nil
+}
+
+// dueAt returns when a key next needs attention, and why; ok is false when neither an expiry
+// nor a rotation interval is set
+func (m *SecretKeyMetadata) dueAt() (due time.Time, reason string, ok bool) {
+	if m.RotationInterval != "" {
+		if interval, err := parseRotationInterval(m.RotationInterval); err == nil {
+			due, reason, ok = m.RotatedAt.Add(interval), dueReasonRotation, true
+		}
+	}
+	if m.ExpiresAt != nil && (!ok || m.ExpiresAt.Before(due)) {
+		due, reason, ok = *m.ExpiresAt, dueReasonExpiry, true
+	}
+	return due, reason, ok
+}
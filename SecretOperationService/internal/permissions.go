@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	secretsservice "github.com/kurs0n/SecretOperationService/proto"
+)
+
+// GitHub repository permissions, lowest first
+const (
+	repoPermissionNone     = "" // Repository visible to the user without any of the permissions below
+	repoPermissionRead     = "read"
+	repoPermissionTriage   = "triage"
+	repoPermissionWrite    = "write"
+	repoPermissionMaintain = "maintain"
+	repoPermissionAdmin    = "admin"
+)
+
+var repoPermissionRanks = map[string]int{
+	repoPermissionNone:     0,
+	repoPermissionRead:     1,
+	repoPermissionTriage:   2,
+	repoPermissionWrite:    3,
+	repoPermissionMaintain: 4,
+	repoPermissionAdmin:    5,
+}
+
+// listKeysPermission is needed for ListSecretKeys, which never reveals values: seeing the repository is
+// enough. It ranks below every valid downloadPermission, which needs at least read.
+const listKeysPermission = repoPermissionNone
+
+// githubPermission names the highest permission set in a GitHub permissions object
+func githubPermission(admin, maintain, push, triage, pull bool) string {
+	switch {
+	case admin:
+		return repoPermissionAdmin
+	case maintain:
+		return repoPermissionMaintain
+	case push:
+		return repoPermissionWrite
+	case triage:
+		return repoPermissionTriage
+	case pull:
+		return repoPermissionRead
+	}
+	return repoPermissionNone
+}
+
+// downloadPermission is the permission needed to read secret values, from DOWNLOAD_PERMISSION (read by
+// default, so every collaborator can download); an invalid value falls back to write rather than opening
+// downloads up
+func downloadPermission() string {
+	permission := os.Getenv("DOWNLOAD_PERMISSION")
+	if permission == "" {
+		return repoPermissionRead
+	}
+	if _, ok := repoPermissionRanks[permission]; !ok {
+		log.Printf("Invalid DOWNLOAD_PERMISSION %q, using %s", permission, repoPermissionWrite)
+		return repoPermissionWrite
+	}
+	return permission
+}
+
+// downloadPermissionError explains a download refused for lack of permission
+func downloadPermissionError() string {
+	return fmt.Sprintf("Reading secret values requires %s permission on the repository, listing keys only needs access to it", downloadPermission())
+}
+
+// writePermission is the permission needed to upload, generate or delete secrets. It is write whatever
+// DOWNLOAD_PERMISSION says, so loosening downloads never lets read-only collaborators change values.
+func writePermission() string {
+	return repoPermissionWrite
+}
+
+// writePermissionError explains a write refused for lack of permission
+func writePermissionError() string {
+	return fmt.Sprintf("Changing secrets requires %s permission on the repository", writePermission())
+}
+
+// HasRepoPermission reports whether the user has at least the given permission on a repository
+func HasRepoPermission(repos []*secretsservice.Repo, ownerLogin, name, permission string) bool {
+	for _, repo := range repos {
+		if repo.OwnerLogin == ownerLogin && repo.Name == name {
+			return repoPermissionRanks[repo.Permission] >= repoPermissionRanks[permission]
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Default keys the postgres engine sets in a download
+const (
+	defaultPostgresUsernameKey = "DB_USER"
+	defaultPostgresPasswordKey = "DB_PASSWORD"
+)
+
+// defaultPostgresRevocation ends the role's sessions and drops it together with everything it was granted.
+// It succeeds when the role no longer exists.
+const defaultPostgresRevocation = `SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE usename = '{{name}}';
+DO $$
+BEGIN
+	IF EXISTS (SELECT FROM pg_roles WHERE rolname = '{{name}}') THEN
+		EXECUTE 'DROP OWNED BY "{{name}}"';
+		EXECUTE 'DROP ROLE "{{name}}"';
+	END IF;
+END
+$$;`
+
+// postgresEngineConfig is the JSON configuration of the postgres engine. Statements are templates
+// where {{name}}, {{password}} and {{expiration}} are replaced by the generated role name, its password
+// and the lease expiry as a timestamp, e.g.
+//
+//	CREATE ROLE "{{name}}" WITH LOGIN PASSWORD '{{password}}' VALID UNTIL '{{expiration}}';
+//	GRANT SELECT ON ALL TABLES IN SCHEMA public TO "{{name}}";
+type postgresEngineConfig struct {
+	ConnectionURL        string `json:"connectionUrl"` // Role allowed to create and drop roles
+	CreationStatements   string `json:"creationStatements"`
+	RevocationStatements string `json:"revocationStatements,omitempty"`
+	UsernameKey          string `json:"usernameKey,omitempty"`
+	PasswordKey          string `json:"passwordKey,omitempty"`
+	URLKey               string `json:"urlKey,omitempty"` // Optional key for connectionUrl with the issued credentials
+}
+
+// postgresEngine creates a Postgres role per download and drops it when the lease expires
+type postgresEngine struct{}
+
+func (postgresEngine) parse(config []byte) (*postgresEngineConfig, error) {
+	decoder := json.NewDecoder(bytes.NewReader(config))
+	decoder.DisallowUnknownFields()
+
+	var parsed postgresEngineConfig
+	if err := decoder.Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("invalid postgres engine configuration: %v", err)
+	}
+	if parsed.UsernameKey == "" {
+		parsed.UsernameKey = defaultPostgresUsernameKey
+	}
+	if parsed.PasswordKey == "" {
+		parsed.PasswordKey = defaultPostgresPasswordKey
+	}
+	if parsed.RevocationStatements == "" {
+		parsed.RevocationStatements = defaultPostgresRevocation
+	}
+	return &parsed, nil
+}
+
+func (engine postgresEngine) validate(config []byte) error {
+	parsed, err := engine.parse(config)
+	if err != nil {
+		return err
+	}
+
+	if parsed.ConnectionURL == "" {
+		return fmt.Errorf("connectionUrl is required")
+	}
+	connConfig, err := pgx.ParseConfig(parsed.ConnectionURL)
+	if err != nil {
+		return fmt.Errorf("invalid connectionUrl: %v", err)
+	}
+	if err := checkPostgresHosts(connConfig); err != nil {
+		return err
+	}
+	if parsed.URLKey != "" {
+		if connURL, err := url.Parse(parsed.ConnectionURL); err != nil || (connURL.Scheme != "postgres" && connURL.Scheme != "postgresql") {
+			return fmt.Errorf("urlKey needs connectionUrl in postgres:// form")
+		}
+	}
+
+	if !strings.Contains(parsed.CreationStatements, "{{name}}") || !strings.Contains(parsed.CreationStatements, "{{password}}") {
+		return fmt.Errorf("creationStatements must use {{name}} and {{password}}")
+	}
+	if !strings.Contains(parsed.RevocationStatements, "{{name}}") {
+		return fmt.Errorf("revocationStatements must use {{name}}")
+	}
+
+	for _, key := range engine.keys(config) {
+		if !variableNamePattern.MatchString(key) {
+			return fmt.Errorf("invalid key name: %q", key)
+		}
+	}
+	return nil
+}
+
+func (engine postgresEngine) keys(config []byte) []string {
+	parsed, err := engine.parse(config)
+	if err != nil {
+		return nil
+	}
+	keys := []string{parsed.UsernameKey, parsed.PasswordKey}
+	if parsed.URLKey != "" {
+		keys = append(keys, parsed.URLKey)
+	}
+	return keys
+}
+
+func (engine postgresEngine) issue(ctx context.Context, config []byte, expiresAt time.Time) (map[string]string, string, error) {
+	parsed, err := engine.parse(config)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Both are drawn from charsets that need no quoting inside the statements
+	suffix, err := generateRandomString("abcdefghijklmnopqrstuvwxyz0123456789", 16)
+	if err != nil {
+		return nil, "", err
+	}
+	name := "envini_" + suffix
+	password, err := generateRandomString("alphanumeric", 32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	statements := strings.NewReplacer(
+		"{{name}}", name,
+		"{{password}}", password,
+		"{{expiration}}", expiresAt.UTC().Format("2006-01-02 15:04:05-07"),
+	).Replace(parsed.CreationStatements)
+	if err := execPostgres(ctx, parsed.ConnectionURL, statements); err != nil {
+		return nil, "", fmt.Errorf("failed to create role: %v", err)
+	}
+
+	values := map[string]string{
+		parsed.UsernameKey: name,
+		parsed.PasswordKey: password,
+	}
+	if parsed.URLKey != "" {
+		connURL, err := url.Parse(parsed.ConnectionURL)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid connectionUrl: %v", err)
+		}
+		connURL.User = url.UserPassword(name, password)
+		values[parsed.URLKey] = connURL.String()
+	}
+	return values, name, nil
+}
+
+func (engine postgresEngine) revoke(ctx context.Context, config []byte, principal string) error {
+	parsed, err := engine.parse(config)
+	if err != nil {
+		return err
+	}
+
+	statements := strings.ReplaceAll(parsed.RevocationStatements, "{{name}}", principal)
+	if err := execPostgres(ctx, parsed.ConnectionURL, statements); err != nil {
+		return fmt.Errorf("failed to drop role: %v", err)
+	}
+	return nil
+}
+
+// execPostgres runs statements in one implicit transaction on a short-lived connection. The hosts are
+// checked again, as the allowlist may have changed since the engine was configured.
+func execPostgres(ctx context.Context, connectionURL, statements string) error {
+	connConfig, err := pgx.ParseConfig(connectionURL)
+	if err != nil {
+		return fmt.Errorf("invalid connectionUrl: %v", err)
+	}
+	if err := checkPostgresHosts(connConfig); err != nil {
+		return err
+	}
+
+	conn, err := pgx.ConnectConfig(ctx, connConfig)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, statements)
+	return err
+}
+
+// checkPostgresHosts checks every host pgx may dial: the primary host and the fallbacks of a multi-host
+// URL such as postgres://db1,db2:5432/app
+func checkPostgresHosts(connConfig *pgx.ConnConfig) error {
+	if !postgresHostAllowed(connConfig.Host, connConfig.Port) {
+		return fmt.Errorf("host %s is not in POSTGRES_ENGINE_ALLOWED_HOSTS", connConfig.Host)
+	}
+	for _, fallback := range connConfig.Fallbacks {
+		if !postgresHostAllowed(fallback.Host, fallback.Port) {
+			return fmt.Errorf("host %s is not in POSTGRES_ENGINE_ALLOWED_HOSTS", fallback.Host)
+		}
+	}
+	return nil
+}
+
+// postgresHostAllowed checks POSTGRES_ENGINE_ALLOWED_HOSTS, a comma separated list of host or host:port
+// entries; no host is allowed when it is unset, so engines cannot reach internal services by default
+func postgresHostAllowed(host string, port uint16) bool {
+	allowed := os.Getenv("POSTGRES_ENGINE_ALLOWED_HOSTS")
+	for _, entry := range strings.Split(allowed, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == host || entry == fmt.Sprintf("%s:%d", host, port) {
+			return true
+		}
+	}
+	return false
+}
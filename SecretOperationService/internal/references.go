@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	secretsservice "github.com/kurs0n/SecretOperationService/proto"
+)
+
+// maxReferenceDepth bounds chains of references that resolve to further references
+const maxReferenceDepth = 8
+
+// referencePattern matches ${envini:owner/repo@tag#KEY}; the @tag part is optional and defaults to the latest version
+var referencePattern = regexp.MustCompile(`\$\{envini:([A-Za-z0-9-]+)/([A-Za-z0-9._-]+)(?:@([^#}]+))?#([^}]+)\}`)
+
+// parseSecretReferences extracts the cross-repository references of every key, sorted by key
+func parseSecretReferences(envData map[string]string) []SecretReference {
+	var references []SecretReference
+	for _, key := range sortedKeys(envData) {
+		for _, match := range referencePattern.FindAllStringSubmatch(envData[key], -1) {
+			references = append(references, SecretReference{
+				Key:         key,
+				TargetOwner: match[1],
+				TargetRepo:  match[2],
+				TargetTag:   match[3],
+				TargetKey:   match[4],
+			})
+		}
+	}
+	return references
+}
+
+// referenceResolver resolves ${envini:...} references for one download, on behalf of the downloading user
+type referenceResolver struct {
+	repos       []*secretsservice.Repo // Repositories the user can access
+	serviceName string
+	requestID   string
+	userLogin   string
+	cache       map[string]map[string]string
+}
+
+func newReferenceResolver(repos []*secretsservice.Repo, serviceName, requestID, userLogin string) *referenceResolver {
+	return &referenceResolver{
+		repos:       repos,
+		serviceName: serviceName,
+		requestID:   requestID,
+		userLogin:   userLogin,
+		cache:       make(map[string]map[string]string),
+	}
+}
+
+// resolve replaces every reference in envData with the referenced value. When the result is interpolated
+// afterwards, referenced values are escaped so they stay literal and cannot expand the downloading
+// secret's own keys.
+func (r *referenceResolver) resolve(envData map[string]string, interpolated bool) (map[string]string, error) {
+	resolved := make(map[string]string, len(envData))
+	for key, value := range envData {
+		resolvedValue, err := r.resolveValue(value, nil, interpolated)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %v", key, err)
+		}
+		resolved[key] = resolvedValue
+	}
+	return resolved, nil
+}
+
+func (r *referenceResolver) resolveValue(value string, stack []string, interpolated bool) (string, error) {
+	var resolveErr error
+	resolved := referencePattern.ReplaceAllStringFunc(value, func(reference string) string {
+		if resolveErr != nil {
+			return reference
+		}
+
+		for _, seen := range stack {
+			if seen == reference {
+				resolveErr = fmt.Errorf("reference cycle: %s -> %s", strings.Join(stack, " -> "), reference)
+				return reference
+			}
+		}
+		if len(stack) >= maxReferenceDepth {
+			resolveErr = fmt.Errorf("references nested deeper than %d levels", maxReferenceDepth)
+			return reference
+		}
+
+		match := referencePattern.FindStringSubmatch(reference)
+		envData, err := r.load(match[1], match[2], match[3])
+		if err != nil {
+			resolveErr = fmt.Errorf("failed to resolve %s: %v", reference, err)
+			return reference
+		}
+
+		target, ok := envData[match[4]]
+		if !ok {
+			resolveErr = fmt.Errorf("failed to resolve %s: key %s not found", reference, match[4])
+			return reference
+		}
+
+		target, resolveErr = r.resolveValue(target, append(stack, reference), false)
+		if interpolated {
+			target = escapeInterpolation(target)
+		}
+		return target
+	})
+	return resolved, resolveErr
+}
+
+// load returns the merged key/value map of the referenced repository and tag, checking access first
+func (r *referenceResolver) load(ownerLogin, repoName, tag string) (map[string]string, error) {
+	cacheKey := ownerLogin + "/" + repoName + "@" + tag
+	if envData, ok := r.cache[cacheKey]; ok {
+		return envData, nil
+	}
+
+	if !HasRepoAccess(r.repos, ownerLogin, repoName) {
+		return nil, fmt.Errorf("no access to repository %s/%s", ownerLogin, repoName)
+	}
+	if !HasRepoPermission(r.repos, ownerLogin, repoName, downloadPermission()) {
+		return nil, fmt.Errorf("reading values of %s/%s requires %s permission", ownerLogin, repoName, downloadPermission())
+	}
+
+	var repo Repository
+	if result := DB.Where("owner_login = ? AND repo_name = ?", ownerLogin, repoName).First(&repo); result.Error != nil {
+		return nil, fmt.Errorf("repository %s/%s not found in database", ownerLogin, repoName)
+	}
+
+	// References always point at the base secret of the repository root's default file
+	scope := SecretScope{}
+	var secret *Secret
+	var err error
+	if tag != "" {
+		secret, err = GetSecretByTag(repo.ID, scope, tag)
+	} else {
+		secret, err = GetLatestSecret(repo.ID, scope)
+	}
+	if err != nil {
+		LogAuditEvent("RESOLVE_REFERENCE", &repo.ID, nil, r.serviceName, r.requestID, r.userLogin, false, err.Error())
+		return nil, err
+	}
+
+	chain, err := resolveTagChain(repo.ID, scope, secret)
+	if err != nil {
+		LogAuditEvent("RESOLVE_REFERENCE", &repo.ID, &secret.ID, r.serviceName, r.requestID, r.userLogin, false, err.Error())
+		return nil, err
+	}
+
+	layers, err := loadLayers(chain)
+	if err != nil {
+		LogAuditEvent("RESOLVE_REFERENCE", &repo.ID, &secret.ID, r.serviceName, r.requestID, r.userLogin, false, err.Error())
+		return nil, err
+	}
+	envData, _ := mergeLayers(layers)
+
+	// Reading another repository's secret is audited against that repository
+	LogAuditEvent("RESOLVE_REFERENCE", &repo.ID, &secret.ID, r.serviceName, r.requestID, r.userLogin, true, "")
+
+	r.cache[cacheKey] = envData
+	return envData, nil
+}
@@ -0,0 +1,237 @@
+package internal
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported output formats for DownloadSecret
+const (
+	RenderDotenv       = "dotenv"
+	RenderJSON         = "json"
+	RenderYAML         = "yaml"
+	RenderExport       = "export"
+	RenderDocker       = "docker"
+	RenderCompose      = "compose"
+	RenderSystemd      = "systemd"
+	RenderK8sSecret    = "k8s-secret"
+	RenderK8sConfigMap = "k8s-configmap"
+)
+
+const (
+	maxK8sResourceName  = 253
+	defaultResourceName = "envini"
+)
+
+var (
+	k8sKeyPattern      = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+	k8sInvalidNameChar = regexp.MustCompile(`[^a-z0-9.-]+`)
+	// envKeyPattern is a POSIX environment variable name; keys are written unquoted by export, docker and
+	// systemd, so anything else could inject shell syntax or extra assignments
+	envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// RenderOptions carries per-request settings for renderers that need them
+type RenderOptions struct {
+	ResourceName string
+	Namespace    string
+}
+
+// renderSecret renders the key/value map in the requested output format
+func (s *Server) renderSecret(envData map[string]string, format string, opts RenderOptions) (string, string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" || format == "env" {
+		format = RenderDotenv
+	}
+
+	var content string
+	var err error
+
+	switch format {
+	case RenderDotenv:
+		content = s.convertToEnvFormat(envData)
+	case RenderJSON:
+		content, err = renderJSON(envData)
+	case RenderYAML:
+		content, err = renderYAML(envData)
+	case RenderExport:
+		content, err = renderExport(envData)
+	case RenderDocker:
+		content, err = renderDockerEnvFile(envData)
+	case RenderCompose:
+		content, err = renderComposeEnvFile(envData)
+	case RenderSystemd:
+		content, err = renderSystemd(envData)
+	case RenderK8sSecret, RenderK8sConfigMap:
+		content, err = renderKubernetes(envData, format, opts)
+	default:
+		return "", "", fmt.Errorf("unsupported format: %s", format)
+	}
+
+	return content, format, err
+}
+
+func sortedKeys(envData map[string]string) []string {
+	keys := make([]string, 0, len(envData))
+	for key := range envData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func renderJSON(envData map[string]string) (string, error) {
+	data, err := json.MarshalIndent(envData, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+func renderYAML(envData map[string]string) (string, error) {
+	return marshalYAML(envData)
+}
+
+func marshalYAML(value interface{}) (string, error) {
+	var b strings.Builder
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// checkEnvKeys rejects keys that are not environment variable names, for renderers that write keys unquoted
+func checkEnvKeys(envData map[string]string, format string) error {
+	for _, key := range sortedKeys(envData) {
+		if !envKeyPattern.MatchString(key) {
+			return fmt.Errorf("key %q is not a valid environment variable name for %s output", key, format)
+		}
+	}
+	return nil
+}
+
+// renderExport renders a POSIX shell script; single quotes need no escaping except for ' itself
+func renderExport(envData map[string]string) (string, error) {
+	if err := checkEnvKeys(envData, RenderExport); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, key := range sortedKeys(envData) {
+		value := strings.ReplaceAll(envData[key], "'", `'\''`)
+		fmt.Fprintf(&b, "export %s='%s'\n", key, value)
+	}
+	return b.String(), nil
+}
+
+// renderDockerEnvFile renders docker --env-file syntax, which takes values verbatim with no quoting
+func renderDockerEnvFile(envData map[string]string) (string, error) {
+	if err := checkEnvKeys(envData, RenderDocker); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, key := range sortedKeys(envData) {
+		value := envData[key]
+		if strings.ContainsAny(value, "\r\n") {
+			return "", fmt.Errorf("key %s contains a newline, which docker --env-file cannot represent", key)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", key, value)
+	}
+	return b.String(), nil
+}
+
+// renderComposeEnvFile renders docker compose env_file syntax: double quotes with backslash escapes, and
+// $$ for a literal $ since compose interpolates the rest. The compose upload format reads it back unchanged.
+func renderComposeEnvFile(envData map[string]string) (string, error) {
+	if err := checkEnvKeys(envData, RenderCompose); err != nil {
+		return "", err
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	var b strings.Builder
+	for _, key := range sortedKeys(envData) {
+		fmt.Fprintf(&b, "%s=\"%s\"\n", key, replacer.Replace(envData[key]))
+	}
+	return b.String(), nil
+}
+
+// renderSystemd renders a systemd EnvironmentFile with C-style escapes inside double quotes
+func renderSystemd(envData map[string]string) (string, error) {
+	if err := checkEnvKeys(envData, RenderSystemd); err != nil {
+		return "", err
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	var b strings.Builder
+	for _, key := range sortedKeys(envData) {
+		fmt.Fprintf(&b, "%s=\"%s\"\n", key, replacer.Replace(envData[key]))
+	}
+	return b.String(), nil
+}
+
+type k8sMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+type k8sManifest struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Type       string            `yaml:"type,omitempty"`
+	Data       map[string]string `yaml:"data"`
+}
+
+// renderKubernetes renders a Secret (base64 data) or ConfigMap manifest
+func renderKubernetes(envData map[string]string, format string, opts RenderOptions) (string, error) {
+	for key := range envData {
+		if !k8sKeyPattern.MatchString(key) {
+			return "", fmt.Errorf("key %s is not a valid Kubernetes data key", key)
+		}
+	}
+
+	manifest := k8sManifest{
+		APIVersion: "v1",
+		Metadata: k8sMetadata{
+			Name:      sanitizeResourceName(opts.ResourceName),
+			Namespace: opts.Namespace,
+		},
+		Data: make(map[string]string, len(envData)),
+	}
+
+	if format == RenderK8sSecret {
+		manifest.Kind = "Secret"
+		manifest.Type = "Opaque"
+		for key, value := range envData {
+			manifest.Data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+	} else {
+		manifest.Kind = "ConfigMap"
+		for key, value := range envData {
+			manifest.Data[key] = value
+		}
+	}
+
+	return marshalYAML(manifest)
+}
+
+// sanitizeResourceName lowercases the name and strips characters Kubernetes rejects
+func sanitizeResourceName(name string) string {
+	name = k8sInvalidNameChar.ReplaceAllString(strings.ToLower(name), "-")
+	name = strings.Trim(name, "-.")
+	if len(name) > maxK8sResourceName {
+		name = strings.Trim(name[:maxK8sResourceName], "-.")
+	}
+	if name == "" {
+		return defaultResourceName
+	}
+	return name
+}
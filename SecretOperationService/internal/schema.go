@@ -0,0 +1,266 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	secretsservice "github.com/kurs0n/SecretOperationService/proto"
+)
+
+// Value types a schema can require
+const (
+	schemaTypeString = "string"
+	schemaTypeInt    = "int"
+	schemaTypeBool   = "bool"
+	schemaTypeURL    = "url"
+	schemaTypeEmail  = "email"
+	schemaTypeJSON   = "json"
+)
+
+// Rules reported in schema violations
+const (
+	schemaRuleRequired   = "required"
+	schemaRuleType       = "type"
+	schemaRulePattern    = "pattern"
+	schemaRuleAllowed    = "allowed"
+	schemaRuleDeprecated = "deprecated"
+)
+
+// SecretSchema declares the keys a secret must or may contain
+type SecretSchema struct {
+	Keys map[string]KeySchema `json:"keys"`
+}
+
+// KeySchema constrains a single key; every field is optional
+type KeySchema struct {
+	Required    bool     `json:"required,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"` // Must match the whole value
+	Allowed     []string `json:"allowed,omitempty"`
+	Deprecated  bool     `json:"deprecated,omitempty"`
+	ReplacedBy  string   `json:"replacedBy,omitempty"`
+	Description string   `json:"description,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// SchemaViolation is a key that does not satisfy a rule; warnings do not reject the upload
+type SchemaViolation struct {
+	Key     string
+	Rule    string
+	Message string
+	Warning bool
+}
+
+// parseSecretSchema decodes and checks a JSON schema, compiling its patterns
+func parseSecretSchema(content []byte) (*SecretSchema, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+
+	var schema SecretSchema
+	if err := decoder.Decode(&schema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	if len(schema.Keys) == 0 {
+		return nil, fmt.Errorf("invalid schema: no keys declared")
+	}
+
+	for key, rule := range schema.Keys {
+		if key == "" {
+			return nil, fmt.Errorf("invalid schema: empty key name")
+		}
+
+		switch rule.Type {
+		case "", schemaTypeString, schemaTypeInt, schemaTypeBool, schemaTypeURL, schemaTypeEmail, schemaTypeJSON:
+		default:
+			return nil, fmt.Errorf("invalid schema: %s: unsupported type %q", key, rule.Type)
+		}
+
+		if rule.Pattern != "" {
+			pattern, err := regexp.Compile("^(?:" + rule.Pattern + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid schema: %s: invalid pattern: %v", key, err)
+			}
+			rule.pattern = pattern
+		}
+
+		if rule.Required && rule.Deprecated {
+			return nil, fmt.Errorf("invalid schema: %s cannot be both required and deprecated", key)
+		}
+		if rule.ReplacedBy != "" && !rule.Deprecated {
+			return nil, fmt.Errorf("invalid schema: %s: replacedBy is only valid on deprecated keys", key)
+		}
+
+		schema.Keys[key] = rule
+	}
+
+	return &schema, nil
+}
+
+// validate checks envData against the schema. Required keys are satisfied by envData or inherited;
+// checkRequired is false for partial uploads such as branch overlays.
+// Values holding ${...} references or interpolation are only checked once resolved, so they are skipped here.
+func (schema *SecretSchema) validate(envData map[string]string, inherited map[string]bool, checkRequired bool) []SchemaViolation {
+	var violations []SchemaViolation
+
+	for _, key := range sortedSchemaKeys(schema.Keys) {
+		rule := schema.Keys[key]
+		value, present := envData[key]
+
+		if !present {
+			if checkRequired && rule.Required && !inherited[key] {
+				violations = append(violations, SchemaViolation{Key: key, Rule: schemaRuleRequired, Message: "required key is missing"})
+			}
+			continue
+		}
+
+		if rule.Deprecated {
+			message := "key is deprecated"
+			if rule.ReplacedBy != "" {
+				message += ", use " + rule.ReplacedBy + " instead"
+			}
+			violations = append(violations, SchemaViolation{Key: key, Rule: schemaRuleDeprecated, Message: message, Warning: true})
+		}
+
+		if strings.Contains(value, "${") {
+			continue
+		}
+
+		if err := checkSchemaType(rule.Type, value); err != nil {
+			violations = append(violations, SchemaViolation{Key: key, Rule: schemaRuleType, Message: err.Error()})
+		}
+		if rule.pattern != nil && !rule.pattern.MatchString(value) {
+			violations = append(violations, SchemaViolation{Key: key, Rule: schemaRulePattern, Message: "value does not match pattern " + rule.Pattern})
+		}
+		if len(rule.Allowed) > 0 && !slices.Contains(rule.Allowed, value) {
+			violations = append(violations, SchemaViolation{Key: key, Rule: schemaRuleAllowed, Message: "value must be one of " + strings.Join(rule.Allowed, ", ")})
+		}
+	}
+
+	return violations
+}
+
+// checkSchemaType reports an error if value is not of the declared type
+func checkSchemaType(valueType, value string) error {
+	switch valueType {
+	case schemaTypeInt:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("value is not an integer")
+		}
+	case schemaTypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("value is not a boolean")
+		}
+	case schemaTypeURL:
+		parsed, err := url.Parse(value)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("value is not an absolute URL")
+		}
+	case schemaTypeEmail:
+		address, err := mail.ParseAddress(value)
+		if err != nil || address.Address != value {
+			return fmt.Errorf("value is not an email address")
+		}
+	case schemaTypeJSON:
+		if !json.Valid([]byte(value)) {
+			return fmt.Errorf("value is not valid JSON")
+		}
+	}
+	return nil
+}
+
+// hasSchemaErrors reports whether any violation rejects the upload
+func hasSchemaErrors(violations []SchemaViolation) bool {
+	for _, violation := range violations {
+		if !violation.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// violationsToProto converts violations for a response
+func violationsToProto(violations []SchemaViolation) []*secretsservice.SchemaViolation {
+	protoViolations := make([]*secretsservice.SchemaViolation, len(violations))
+	for i, violation := range violations {
+		protoViolations[i] = &secretsservice.SchemaViolation{
+			Key:     violation.Key,
+			Rule:    violation.Rule,
+			Message: violation.Message,
+			Warning: violation.Warning,
+		}
+	}
+	return protoViolations
+}
+
+// checkUploadSchema validates an upload against the schema declared for its tag, if any.
+// Required keys may be inherited from parent tags or attached shared secret sets; branch overlays
+// only carry the keys that differ, so they are not checked for required keys.
+func checkUploadSchema(repoID uint, scope SecretScope, tag string, envData map[string]string) ([]SchemaViolation, error) {
+	record, err := ResolveTagSchema(repoID, tag)
+	if err != nil || record == nil {
+		return nil, err
+	}
+
+	schema, err := parseSecretSchema([]byte(record.Definition))
+	if err != nil {
+		return nil, fmt.Errorf("stored schema of tag %q is invalid: %v", record.Tag, err)
+	}
+
+	if scope.Branch != "" {
+		return schema.validate(envData, nil, false), nil
+	}
+
+	inherited, err := inheritedKeys(repoID, scope, tag)
+	if err != nil {
+		return nil, err
+	}
+	return schema.validate(envData, inherited, true), nil
+}
+
+// inheritedKeys lists the keys a download of tag would get from its ancestor tags and shared secret sets
+func inheritedKeys(repoID uint, scope SecretScope, tag string) (map[string]bool, error) {
+	keys := make(map[string]bool)
+
+	// A parent without any version yet contributes nothing; the download reports it
+	if chain, err := resolveTagChain(repoID, scope, &Secret{Tag: tag}); err == nil {
+		layers, err := loadLayers(chain[:len(chain)-1])
+		if err != nil {
+			return nil, err
+		}
+		for _, layer := range layers {
+			for key := range layer.data {
+				keys[key] = true
+			}
+		}
+	}
+
+	sharedLayers, err := loadSharedLayers(repoID)
+	if err != nil {
+		return nil, err
+	}
+	for _, layer := range sharedLayers {
+		for key := range layer.data {
+			keys[key] = true
+		}
+	}
+
+	return keys, nil
+}
+
+func sortedSchemaKeys(keys map[string]KeySchema) []string {
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
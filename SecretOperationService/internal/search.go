@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	secretsservice "github.com/kurs0n/SecretOperationService/proto"
+)
+
+// How SearchSecretKeys matches key names
+const (
+	keySearchPrefix    = "prefix"
+	keySearchSubstring = "substring"
+	keySearchRegex     = "regex"
+)
+
+// Limits of SearchSecretKeys
+const (
+	defaultKeySearchLimit = 100
+	maxKeySearchLimit     = 1000
+	maxKeySearchQuery     = 255
+)
+
+// keyIndexBatchSize is how many versions the key name backfill decrypts per query
+const keyIndexBatchSize = 100
+
+// keySearchLimit validates the requested number of matches, 0 meaning the default
+func keySearchLimit(limit int32) (int, error) {
+	if limit == 0 {
+		return defaultKeySearchLimit, nil
+	}
+	if limit < 0 || limit > maxKeySearchLimit {
+		return 0, fmt.Errorf("Limit must be between 1 and %d", maxKeySearchLimit)
+	}
+	return int(limit), nil
+}
+
+// repoNamePairs lists the owner login and name of each repository for an (owner_login, repo_name) IN query
+func repoNamePairs(repos []*secretsservice.Repo) [][]interface{} {
+	pairs := make([][]interface{}, 0, len(repos))
+	for _, repo := range repos {
+		pairs = append(pairs, []interface{}{repo.OwnerLogin, repo.Name})
+	}
+	return pairs
+}
+
+// likePattern turns a prefix or substring query into a LIKE pattern matching it literally
+func likePattern(query, mode string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(query)
+	if mode == keySearchPrefix {
+		return escaped + "%"
+	}
+	return "%" + escaped + "%"
+}
+
+// searchSecretKeys searches the key names of the latest versions in the given repositories. Prefix and
+// substring queries are matched by the database; regular expressions use Go's syntax and are matched
+// here, so a pattern cannot make the database backtrack. Only the key name index is read, never a value.
+// One match more than limit is returned so the caller can tell the results were truncated.
+func searchSecretKeys(repos []*secretsservice.Repo, query, mode string, limit int) ([]SecretKeyNameInfo, error) {
+	pairs := repoNamePairs(repos)
+
+	switch mode {
+	case keySearchPrefix, keySearchSubstring:
+		return SearchLatestSecretKeyNames(pairs, likePattern(query, mode), limit+1)
+	case keySearchRegex:
+		pattern, err := regexp.Compile(query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %v", err)
+		}
+		names, err := SearchLatestSecretKeyNames(pairs, "", 0)
+		if err != nil {
+			return nil, err
+		}
+		var matches []SecretKeyNameInfo
+		for _, name := range names {
+			if pattern.MatchString(name.Key) {
+				matches = append(matches, name)
+				if len(matches) > limit {
+					break
+				}
+			}
+		}
+		return matches, nil
+	}
+	return nil, fmt.Errorf("unknown search mode %q, use %s, %s or %s", mode, keySearchPrefix, keySearchSubstring, keySearchRegex)
+}
+
+// backfillSecretKeyNames indexes the key names of versions stored before the index existed. It runs once
+// at startup; a version that fails to decrypt is logged and left unindexed for the next start.
+func backfillSecretKeyNames() {
+	var afterID uint
+	indexed := 0
+	for {
+		secrets, err := ListUnindexedSecrets(afterID, keyIndexBatchSize)
+		if err != nil {
+			log.Printf("Key name index: %v", err)
+			return
+		}
+		if len(secrets) == 0 {
+			break
+		}
+
+		for i := range secrets {
+			secret := &secrets[i]
+			afterID = secret.ID
+
+			decrypted, err := DecryptSecretData(secret)
+			if err != nil {
+				log.Printf("Key name index: failed to decrypt secret %d: %v", secret.ID, err)
+				continue
+			}
+			names, err := secretKeyNames(decrypted)
+			if err != nil {
+				log.Printf("Key name index: secret %d: %v", secret.ID, err)
+				continue
+			}
+			if err := IndexSecretKeyNames(secret.ID, names); err != nil {
+				log.Printf("Key name index: secret %d: %v", secret.ID, err)
+				continue
+			}
+			indexed++
+		}
+	}
+	if indexed > 0 {
+		log.Printf("Key name index: indexed %d existing secret versions", indexed)
+	}
+}
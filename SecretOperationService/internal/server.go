@@ -8,9 +8,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"mime"
 	"net"
 	"net/http"
 	"os"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -66,6 +70,13 @@ func (s *Server) ListRepos(ctx context.Context, req *secretsservice.ListReposReq
 			Login     string `json:"login"`
 			AvatarURL string `json:"avatar_url"`
 		} `json:"owner"`
+		Permissions struct {
+			Admin    bool `json:"admin"`
+			Maintain bool `json:"maintain"`
+			Push     bool `json:"push"`
+			Triage   bool `json:"triage"`
+			Pull     bool `json:"pull"`
+		} `json:"permissions"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&githubRepos); err != nil {
@@ -85,6 +96,7 @@ func (s *Server) ListRepos(ctx context.Context, req *secretsservice.ListReposReq
 			Private:        repo.Private,
 			OwnerLogin:     repo.Owner.Login,
 			OwnerAvatarUrl: repo.Owner.AvatarURL,
+			Permission:     githubPermission(repo.Permissions.Admin, repo.Permissions.Maintain, repo.Permissions.Push, repo.Permissions.Triage, repo.Permissions.Pull),
 		}
 	}
 
@@ -115,6 +127,14 @@ func (s *Server) UploadSecret(ctx context.Context, req *secretsservice.UploadSec
 		}, nil
 	}
 
+	if !HasRepoPermission(listResp.Repos, req.OwnerLogin, req.RepoName, writePermission()) {
+		LogAuditEvent("UPLOAD", nil, nil, serviceName, requestID, req.UserLogin, false, writePermissionError())
+		return &secretsservice.UploadSecretResponse{
+			Success: false,
+			Error:   writePermissionError(),
+		}, nil
+	}
+
 	// 2. Find the repository in the list to get its details
 	var targetRepo *secretsservice.Repo
 	for _, repo := range listResp.Repos {
@@ -132,13 +152,61 @@ func (s *Server) UploadSecret(ctx context.Context, req *secretsservice.UploadSec
 		}, nil
 	}
 
-	// 3. Parse .env file content
-	envData, err := s.parseEnvFile(req.EnvFileContent)
+	// 3. Validate the secret file name and path, then parse content according to the requested format
+	if req.FileName != "" {
+		if req.FileName, err = normalizeFileName(req.FileName); err != nil {
+			LogAuditEvent("UPLOAD", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.UploadSecretResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+	}
+
+	scopePath, err := normalizePath(req.Path)
+	if err != nil {
+		LogAuditEvent("UPLOAD", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.UploadSecretResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	branch, err := normalizeBranch(req.Branch)
+	if err != nil {
+		LogAuditEvent("UPLOAD", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.UploadSecretResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	scope := SecretScope{Path: scopePath, FileName: req.FileName, Branch: branch}
+
+	annotation, err := parseVersionAnnotation(req.Annotation)
+	if err != nil {
+		LogAuditEvent("UPLOAD", nil, nil, serviceName, requestID, req.UserLogin, false, "Invalid annotation: "+err.Error())
+		return &secretsservice.UploadSecretResponse{
+			Success: false,
+			Error:   "Invalid annotation: " + err.Error(),
+		}, nil
+	}
+
+	// Path scoped secrets in a monorepo may be restricted to the CODEOWNERS of that path
+	if scope.Path != "" && codeownersEnforced() {
+		if err := checkCodeowners(ctx, req.AccessToken, req.OwnerLogin, req.RepoName, req.UserLogin, scope.RepoPath()); err != nil {
+			LogAuditEvent("UPLOAD", nil, nil, serviceName, requestID, req.UserLogin, false, "CODEOWNERS check failed: "+err.Error())
+			return &secretsservice.UploadSecretResponse{
+				Success: false,
+				Error:   "CODEOWNERS check failed: " + err.Error(),
+			}, nil
+		}
+	}
+
+	envData, err := s.parseSecretFile(req.EnvFileContent, req.Format, req.KeySeparator)
 	if err != nil {
-		LogAuditEvent("UPLOAD", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to parse .env file: "+err.Error())
+		LogAuditEvent("UPLOAD", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to parse secret file: "+err.Error())
 		return &secretsservice.UploadSecretResponse{
 			Success: false,
-			Error:   "Failed to parse .env file: " + err.Error(),
+			Error:   "Failed to parse secret file: " + err.Error(),
 		}, nil
 	}
 
@@ -173,8 +241,88 @@ func (s *Server) UploadSecret(ctx context.Context, req *secretsservice.UploadSec
 		}, nil
 	}
 
-	// 7. Get next version number for this specific tag
-	version, err := GetNextVersionForTag(repo.ID, req.Tag)
+	// 7. Validate against the schema declared for the tag; warnings such as deprecated keys do not reject the upload
+	violations, err := checkUploadSchema(repo.ID, scope, req.Tag, envData)
+	if err != nil {
+		LogAuditEvent("UPLOAD", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to validate schema: "+err.Error())
+		return &secretsservice.UploadSecretResponse{
+			Success: false,
+			Error:   "Failed to validate schema: " + err.Error(),
+		}, nil
+	}
+	if hasSchemaErrors(violations) {
+		message := fmt.Sprintf("Secret does not match the schema: %d violation(s)", len(violations))
+		LogAuditEvent("UPLOAD", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, message)
+		return &secretsservice.UploadSecretResponse{
+			Success:    false,
+			Error:      message,
+			Violations: violationsToProto(violations),
+		}, nil
+	}
+
+	// 8. Lint values for risky content; each repository decides which rules warn, block or are off
+	lintActions, err := GetLintActions(repo.ID)
+	if err != nil {
+		LogAuditEvent("UPLOAD", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.UploadSecretResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	findings := lintSecret(envData, lintActions)
+	if hasBlockingFindings(findings) {
+		message := fmt.Sprintf("Secret was blocked by lint rules: %d finding(s)", len(findings))
+		LogAuditEvent("UPLOAD", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, message)
+		return &secretsservice.UploadSecretResponse{
+			Success:      false,
+			Error:        message,
+			Violations:   violationsToProto(violations),
+			LintFindings: findingsToProto(findings),
+		}, nil
+	}
+
+	// 9. Resolve per-key expiry and rotation metadata
+	keyMetadata, err := buildKeyMetadata(DB, repo.ID, scope, req.Tag, envData, req.KeyMetadata)
+	if err != nil {
+		LogAuditEvent("UPLOAD", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Invalid key metadata: "+err.Error())
+		return &secretsservice.UploadSecretResponse{
+			Success: false,
+			Error:   "Invalid key metadata: " + err.Error(),
+		}, nil
+	}
+
+	// 10. Uploads to a protected tag, or to a tag one inherits from, wait for a second person's approval
+	// instead of creating a version
+	protected, err := tagProtection(repo.ID, scope, req.Tag)
+	if err != nil {
+		LogAuditEvent("UPLOAD", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.UploadSecretResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	if protected != nil {
+		request, err := proposeChange(repo.ID, scope, req.Tag, envData, string(envDataJSON), checksum, req.UserLogin, annotation, req.KeyMetadata, protected)
+		if err != nil {
+			LogAuditEvent("CREATE_CHANGE_REQUEST", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to create change request: "+err.Error())
+			return &secretsservice.UploadSecretResponse{
+				Success: false,
+				Error:   "Failed to create change request: " + err.Error(),
+			}, nil
+		}
+
+		LogAuditEvent("CREATE_CHANGE_REQUEST", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+		return &secretsservice.UploadSecretResponse{
+			Success:         true,
+			Checksum:        checksum,
+			Violations:      violationsToProto(violations),
+			LintFindings:    findingsToProto(findings),
+			ChangeRequestId: uint64(request.ID),
+		}, nil
+	}
+
+	// 11. Get next version number for this specific tag
+	version, err := GetNextVersionForTag(repo.ID, scope, req.Tag)
 	if err != nil {
 		LogAuditEvent("UPLOAD", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to get next version for tag: "+err.Error())
 		return &secretsservice.UploadSecretResponse{
@@ -183,8 +331,8 @@ func (s *Server) UploadSecret(ctx context.Context, req *secretsservice.UploadSec
 		}, nil
 	}
 
-	// 8. Create secret in database (with encryption enabled)
-	secret, err := CreateSecret(repo.ID, version, req.Tag, string(envDataJSON), checksum, serviceName, true)
+	// 12. Create secret in database (with encryption enabled), recorded as uploaded by the authenticated user
+	secret, err := CreateSecret(repo.ID, scope, version, req.Tag, string(envDataJSON), checksum, req.UserLogin, annotation, true, keyMetadata)
 	if err != nil {
 		LogAuditEvent("UPLOAD", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to create secret: "+err.Error())
 		return &secretsservice.UploadSecretResponse{
@@ -193,13 +341,22 @@ func (s *Server) UploadSecret(ctx context.Context, req *secretsservice.UploadSec
 		}, nil
 	}
 
-	// 9. Log successful operation
+	// 13. Record cross-repository references for the dependency graph; the upload itself already succeeded
+	if err := ReplaceSecretReferences(repo.ID, scope, req.Tag, parseSecretReferences(envData)); err != nil {
+		log.Printf("Failed to record references of secret %d: %v", secret.ID, err)
+	}
+
+	// 14. Log successful operation and notify webhooks
 	LogAuditEvent("UPLOAD", &repo.ID, &secret.ID, serviceName, requestID, req.UserLogin, true, "")
+	emitWebhookEvent(&repo.ID, secretWebhookPayload(webhookEventSecretUploaded, req.OwnerLogin, req.RepoName, req.UserLogin, requestID, secret))
+	publishSecretEvent(secretVersionEvent(secret, req.UserLogin))
 
 	return &secretsservice.UploadSecretResponse{
-		Success:  true,
-		Version:  int32(version),
-		Checksum: checksum,
+		Success:      true,
+		Version:      int32(version),
+		Checksum:     checksum,
+		Violations:   violationsToProto(violations),
+		LintFindings: findingsToProto(findings),
 	}, nil
 }
 
@@ -232,8 +389,28 @@ func (s *Server) ListSecretVersions(ctx context.Context, req *secretsservice.Lis
 		}, nil
 	}
 
-	// 3. List secret versions
-	secrets, err := ListSecretVersions(repo.ID)
+	// 3. List secret versions, optionally restricted to one monorepo path
+	var scopePath *string
+	if req.Path != nil {
+		normalized, err := normalizePath(*req.Path)
+		if err != nil {
+			LogAuditEvent("LIST_VERSIONS", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.ListSecretVersionsResponse{
+				Error: err.Error(),
+			}, nil
+		}
+		scopePath = &normalized
+	}
+	if req.FileName != "" {
+		if req.FileName, err = normalizeFileName(req.FileName); err != nil {
+			LogAuditEvent("LIST_VERSIONS", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.ListSecretVersionsResponse{
+				Error: err.Error(),
+			}, nil
+		}
+	}
+
+	secrets, err := ListSecretVersions(repo.ID, scopePath, req.FileName)
 	if err != nil {
 		LogAuditEvent("LIST_VERSIONS", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to list secret versions: "+err.Error())
 		return &secretsservice.ListSecretVersionsResponse{
@@ -250,14 +427,66 @@ func (s *Server) ListSecretVersions(ctx context.Context, req *secretsservice.Lis
 			Checksum:   secret.Checksum,
 			UploadedBy: secret.UploadedBy,
 			CreatedAt:  secret.CreatedAt.Format(time.RFC3339),
+			FileName:   secret.FileName,
+			Path:       secret.Path,
+			Branch:     secret.Branch,
+			Annotation: annotationToProto(secret.Annotation),
 		}
 	}
 
-	// 5. Log successful operation
+	// 5. Group by file if requested (secrets are already ordered by path and file name)
+	var files []*secretsservice.SecretFileVersions
+	if req.GroupByFile {
+		for _, version := range versions {
+			if len(files) == 0 || files[len(files)-1].FileName != version.FileName || files[len(files)-1].Path != version.Path {
+				files = append(files, &secretsservice.SecretFileVersions{FileName: version.FileName, Path: version.Path})
+			}
+			files[len(files)-1].Versions = append(files[len(files)-1].Versions, version)
+		}
+	}
+
+	// 6. Collect the branches that have overlays
+	var branches []string
+	seenBranches := make(map[string]bool)
+	for _, version := range versions {
+		if version.Branch != "" && !seenBranches[version.Branch] {
+			seenBranches[version.Branch] = true
+			branches = append(branches, version.Branch)
+		}
+	}
+	sort.Strings(branches)
+
+	// 7. Include declared tag inheritance
+	parents, err := ListTagParents(repo.ID)
+	if err != nil {
+		LogAuditEvent("LIST_VERSIONS", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListSecretVersionsResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	var tagParents []*secretsservice.TagParent
+	for _, parent := range parents {
+		if (scopePath != nil && parent.Path != *scopePath) || (req.FileName != "" && parent.FileName != req.FileName) {
+			continue
+		}
+		tagParents = append(tagParents, &secretsservice.TagParent{
+			Tag:           parent.Tag,
+			ParentTag:     parent.ParentTag,
+			ParentVersion: int32(parent.ParentVersion),
+			FileName:      parent.FileName,
+			Path:          parent.Path,
+		})
+	}
+
+	// 8. Log successful operation
 	LogAuditEvent("LIST_VERSIONS", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
 
 	return &secretsservice.ListSecretVersionsResponse{
-		Versions: versions,
+		Versions:   versions,
+		Files:      files,
+		Branches:   branches,
+		TagParents: tagParents,
 	}, nil
 }
 
@@ -282,6 +511,14 @@ func (s *Server) DownloadSecret(ctx context.Context, req *secretsservice.Downloa
 		}, nil
 	}
 
+	if !HasRepoPermission(listResp.Repos, req.OwnerLogin, req.RepoName, downloadPermission()) {
+		LogAuditEvent("DOWNLOAD", nil, nil, serviceName, requestID, req.UserLogin, false, downloadPermissionError())
+		return &secretsservice.DownloadSecretResponse{
+			Success: false,
+			Error:   downloadPermissionError(),
+		}, nil
+	}
+
 	// 2. Get repository from database
 	var repo Repository
 	result := DB.Where("owner_login = ? AND repo_name = ?", req.OwnerLogin, req.RepoName).First(&repo)
@@ -293,60 +530,132 @@ func (s *Server) DownloadSecret(ctx context.Context, req *secretsservice.Downloa
 		}, nil
 	}
 
-	// 3. Get secret based on provided parameters
-	var secret *Secret
-	var err2 error
+	// 3. Validate the path, branch and secret file name
+	scopePath, err := normalizePath(req.Path)
+	if err != nil {
+		LogAuditEvent("DOWNLOAD", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.DownloadSecretResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	branch, err := normalizeBranch(req.Branch)
+	if err != nil {
+		LogAuditEvent("DOWNLOAD", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.DownloadSecretResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	if req.FileName != "" {
+		if req.FileName, err = normalizeFileName(req.FileName); err != nil {
+			LogAuditEvent("DOWNLOAD", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.DownloadSecretResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+	}
+	scope := SecretScope{Path: scopePath, FileName: req.FileName}
 
-	switch {
-	case *req.Tag != "" && *req.Version != 0:
-		// Both tag and version provided - get specific version with tag
-		secret, err2 = GetSecretByTagAndVersion(repo.ID, *req.Tag, int(*req.Version))
-	case *req.Tag != "":
-		// Only tag provided - get latest version with tag
-		secret, err2 = GetSecretByTag(repo.ID, *req.Tag)
-	case *req.Version != 0:
-		// Only version provided - get specific version
-		secret, err2 = GetSecretByVersion(repo.ID, int(*req.Version))
-	default:
-		// Neither provided - get latest version
-		secret, err2 = GetLatestSecret(repo.ID)
+	// 4. Select the version and resolve inherited tags, root ancestor first, then the branch overlay on top
+	secret, overlay, chain, err := resolveSecretChain(repo.ID, scope, branch, *req.Tag, int(*req.Version))
+	if err != nil {
+		LogAuditEvent("DOWNLOAD", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to get secret: "+err.Error())
+		return &secretsservice.DownloadSecretResponse{
+			Success: false,
+			Error:   "Failed to get secret: " + err.Error(),
+		}, nil
 	}
 
-	if err2 != nil {
-		LogAuditEvent("DOWNLOAD", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to get secret: "+err2.Error())
+	// 5. Decrypt every layer and merge them; keys fall back from the branch overlay to the tag and its ancestors
+	layers, err := loadLayers(chain)
+	if err != nil {
+		LogAuditEvent("DOWNLOAD", &repo.ID, &secret.ID, serviceName, requestID, req.UserLogin, false, "Failed to load secret: "+err.Error())
+		return &secretsservice.DownloadSecretResponse{
+			Success: false,
+			Error:   "Failed to load secret: " + err.Error(),
+		}, nil
+	}
+	envData, keyOrigins := mergeLayers(layers)
+
+	// 6. Merge the organization shared secret sets attached to the repository underneath the repository's own keys
+	sharedLayers, err := loadSharedLayers(repo.ID)
+	if err != nil {
+		LogAuditEvent("DOWNLOAD", &repo.ID, &secret.ID, serviceName, requestID, req.UserLogin, false, "Failed to load shared secret sets: "+err.Error())
 		return &secretsservice.DownloadSecretResponse{
 			Success: false,
-			Error:   "Failed to get secret: " + err2.Error(),
+			Error:   "Failed to load shared secret sets: " + err.Error(),
 		}, nil
 	}
+	sharedOrigins := mergeSharedLayers(envData, sharedLayers)
 
-	// 4. Decrypt secret data if encrypted
-	decryptedData, err := DecryptSecretData(secret)
+	// 7. Resolve ${envini:owner/repo@tag#KEY} references; the user needs access to every referenced repository.
+	// Referenced values are literal, so they are escaped when the download is interpolated below.
+	envData, err = newReferenceResolver(listResp.Repos, serviceName, requestID, req.UserLogin).resolve(envData, req.Interpolate)
 	if err != nil {
-		LogAuditEvent("DOWNLOAD", &repo.ID, &secret.ID, serviceName, requestID, req.UserLogin, false, "Failed to decrypt secret: "+err.Error())
+		LogAuditEvent("DOWNLOAD", &repo.ID, &secret.ID, serviceName, requestID, req.UserLogin, false, "Failed to resolve references: "+err.Error())
 		return &secretsservice.DownloadSecretResponse{
 			Success: false,
-			Error:   "Failed to decrypt secret: " + err.Error(),
+			Error:   "Failed to resolve references: " + err.Error(),
 		}, nil
 	}
 
-	// 5. Convert JSON back to .env format
-	var envData map[string]string
-	if err := json.Unmarshal([]byte(decryptedData), &envData); err != nil {
-		LogAuditEvent("DOWNLOAD", &repo.ID, &secret.ID, serviceName, requestID, req.UserLogin, false, "Failed to unmarshal env data: "+err.Error())
+	// 8. Issue short-lived credentials from the secret engines configured for the tag; they override stored keys
+	issued, engineOrigins, err := issueEngineCredentials(ctx, secret, envData, int(req.LeaseTtlSeconds), req.UserLogin)
+	if err != nil {
+		LogAuditEvent("DOWNLOAD", &repo.ID, &secret.ID, serviceName, requestID, req.UserLogin, false, "Failed to issue credentials: "+err.Error())
 		return &secretsservice.DownloadSecretResponse{
 			Success: false,
-			Error:   "Failed to unmarshal env data: " + err.Error(),
+			Error:   "Failed to issue credentials: " + err.Error(),
 		}, nil
 	}
 
-	// 6. Convert to .env format
-	envContent := s.convertToEnvFormat(envData)
+	// 9. Expand ${VAR} style interpolation when the caller asks for resolved values. Issued credentials are
+	// literal, a generated password containing ${ must come out as issued while other keys may still use it.
+	if req.Interpolate {
+		for key := range engineOrigins {
+			envData[key] = escapeInterpolation(envData[key])
+		}
+		envData, err = interpolateEnv(envData)
+		if err != nil {
+			err = revokeIssuedLeases(issued, err)
+			LogAuditEvent("DOWNLOAD", &repo.ID, &secret.ID, serviceName, requestID, req.UserLogin, false, "Failed to interpolate secret: "+err.Error())
+			return &secretsservice.DownloadSecretResponse{
+				Success: false,
+				Error:   "Failed to interpolate secret: " + err.Error(),
+			}, nil
+		}
+	}
+
+	// 10. Render in the requested format
+	resourceName := req.ResourceName
+	if resourceName == "" {
+		resourceName = repo.RepoName + "-" + secret.Tag
+	}
+	envContent, format, err := s.renderSecret(envData, req.Format, RenderOptions{
+		ResourceName: resourceName,
+		Namespace:    req.Namespace,
+	})
+	if err != nil {
+		err = revokeIssuedLeases(issued, err)
+		LogAuditEvent("DOWNLOAD", &repo.ID, &secret.ID, serviceName, requestID, req.UserLogin, false, "Failed to render secret: "+err.Error())
+		return &secretsservice.DownloadSecretResponse{
+			Success: false,
+			Error:   "Failed to render secret: " + err.Error(),
+		}, nil
+	}
 
-	// 7. Log successful operation
+	// 11. Log successful operation, the read of every shared secret set on the set itself, and every issued lease
 	LogAuditEvent("DOWNLOAD", &repo.ID, &secret.ID, serviceName, requestID, req.UserLogin, true, "")
+	for _, layer := range sharedLayers {
+		LogSharedSetAuditEvent("SHARED_SET_READ", &layer.attachment.SetID, &repo.ID, serviceName, requestID, req.UserLogin, true, "")
+	}
+	for _, lease := range issued {
+		LogLeaseAuditEvent("LEASE_ISSUE", lease.lease, serviceName, requestID, req.UserLogin, true, "")
+	}
 
-	return &secretsservice.DownloadSecretResponse{
+	response := &secretsservice.DownloadSecretResponse{
 		Success:        true,
 		Version:        int32(secret.Version),
 		Tag:            secret.Tag,
@@ -354,7 +663,51 @@ func (s *Server) DownloadSecret(ctx context.Context, req *secretsservice.Downloa
 		Checksum:       secret.Checksum,
 		UploadedBy:     secret.UploadedBy,
 		CreatedAt:      secret.CreatedAt.Format(time.RFC3339),
-	}, nil
+		Format:         format,
+		FileName:       secret.FileName,
+		Path:           secret.Path,
+	}
+	if overlay != nil {
+		response.Branch = overlay.Branch
+		response.BranchVersion = int32(overlay.Version)
+	}
+	for _, lease := range issued {
+		response.Leases = append(response.Leases, &secretsservice.LeaseInfo{
+			LeaseId:    lease.lease.LeaseID,
+			EngineName: lease.engine.Name,
+			Engine:     lease.engine.Engine,
+			ExpiresAt:  lease.lease.ExpiresAt.Format(time.RFC3339),
+			Keys:       lease.keys,
+		})
+	}
+	if req.IncludeOrigins {
+		for _, key := range sortedKeys(envData) {
+			if engineName, dynamic := engineOrigins[key]; dynamic {
+				response.Origins = append(response.Origins, &secretsservice.KeyOrigin{
+					Key:    key,
+					Engine: engineName,
+				})
+				continue
+			}
+			if layer, shared := sharedOrigins[key]; shared {
+				response.Origins = append(response.Origins, &secretsservice.KeyOrigin{
+					Key:       key,
+					Version:   int32(layer.version.Version),
+					SharedSet: layer.label(),
+				})
+				continue
+			}
+			origin := keyOrigins[key]
+			response.Origins = append(response.Origins, &secretsservice.KeyOrigin{
+				Key:     key,
+				Tag:     origin.Tag,
+				Version: int32(origin.Version),
+				Branch:  origin.Branch,
+			})
+		}
+	}
+
+	return response, nil
 }
 
 func (s *Server) DeleteSecret(ctx context.Context, req *secretsservice.DeleteSecretRequest) (*secretsservice.DeleteSecretResponse, error) {
@@ -378,6 +731,14 @@ func (s *Server) DeleteSecret(ctx context.Context, req *secretsservice.DeleteSec
 		}, nil
 	}
 
+	if !HasRepoPermission(listResp.Repos, req.OwnerLogin, req.RepoName, writePermission()) {
+		LogAuditEvent("DELETE", nil, nil, serviceName, requestID, req.UserLogin, false, writePermissionError())
+		return &secretsservice.DeleteSecretResponse{
+			Success: false,
+			Error:   writePermissionError(),
+		}, nil
+	}
+
 	// 2. Get repository from database
 	var repo Repository
 	result := DB.Where("owner_login = ? AND repo_name = ?", req.OwnerLogin, req.RepoName).First(&repo)
@@ -389,27 +750,72 @@ func (s *Server) DeleteSecret(ctx context.Context, req *secretsservice.DeleteSec
 		}, nil
 	}
 
-	// 3. Delete secrets based on provided parameters
+	// 3. Resolve the path scope; deleting under a monorepo path may require CODEOWNERS ownership
+	scopePath, err := normalizePath(req.Path)
+	if err != nil {
+		LogAuditEvent("DELETE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.DeleteSecretResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	branch, err := normalizeBranch(req.Branch)
+	if err != nil {
+		LogAuditEvent("DELETE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.DeleteSecretResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	if req.FileName != "" {
+		if req.FileName, err = normalizeFileName(req.FileName); err != nil {
+			LogAuditEvent("DELETE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.DeleteSecretResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+	}
+	scope := SecretScope{Path: scopePath, FileName: req.FileName, Branch: branch}
+
+	if scope.Path != "" && codeownersEnforced() {
+		if err := checkCodeowners(ctx, req.AccessToken, req.OwnerLogin, req.RepoName, req.UserLogin, scope.RepoPath()); err != nil {
+			LogAuditEvent("DELETE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "CODEOWNERS check failed: "+err.Error())
+			return &secretsservice.DeleteSecretResponse{
+				Success: false,
+				Error:   "CODEOWNERS check failed: " + err.Error(),
+			}, nil
+		}
+	}
+
+	// Without a tag every tag is deleted, so any protected tag refuses it
+	if err := checkScopedTagWritable(repo.ID, scope, *req.Tag); err != nil {
+		LogAuditEvent("DELETE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.DeleteSecretResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 4. Delete secrets based on provided parameters
 	var deletedVersions int32
 	var err2 error
 
-	fmt.Println("Deleting secrets for repo:", repo.ID, "Tag:", *req.Tag, "Version:", *req.Version)
-
 	switch {
 	case *req.Tag != "" && *req.Version != 0:
-		err2 = DeleteSecretByTagAndVersion(repo.ID, *req.Tag, int(*req.Version))
+		err2 = DeleteSecretByTagAndVersion(repo.ID, scope, *req.Tag, int(*req.Version))
 		if err2 == nil {
 			deletedVersions = 1
 		}
 	case *req.Tag != "":
 		var count int
-		count, err2 = DeleteSecretsByTag(repo.ID, *req.Tag)
+		count, err2 = DeleteSecretsByTag(repo.ID, scope, *req.Tag)
 		if err2 == nil {
 			deletedVersions = int32(count)
 		}
 	default:
 		var count int
-		count, err2 = DeleteAllSecrets(repo.ID)
+		count, err2 = DeleteAllSecrets(repo.ID, scope)
 		if err2 == nil {
 			deletedVersions = int32(count)
 		}
@@ -423,8 +829,29 @@ func (s *Server) DeleteSecret(ctx context.Context, req *secretsservice.DeleteSec
 		}, nil
 	}
 
-	// 4. Log successful operation
+	// 5. Log successful operation and notify webhooks
 	LogAuditEvent("DELETE", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+	payload := newWebhookPayload(webhookEventSecretDeleted, req.OwnerLogin, req.RepoName, req.UserLogin, requestID)
+	payload.Tag = *req.Tag
+	payload.Version = int(*req.Version)
+	payload.Path = scope.Path
+	payload.FileName = secretFileName(scope.FileName)
+	payload.Branch = scope.Branch
+	payload.DeletedVersions = int(deletedVersions)
+	emitWebhookEvent(&repo.ID, payload)
+	if deletedVersions > 0 {
+		publishSecretEvent(&SecretEvent{
+			RepoID:          repo.ID,
+			Type:            secretEventVersionsDeleted,
+			Tag:             *req.Tag,
+			Version:         int(*req.Version),
+			Path:            scope.Path,
+			FileName:        secretFileName(scope.FileName),
+			Branch:          scope.Branch,
+			DeletedVersions: int(deletedVersions),
+			Actor:           req.UserLogin,
+		})
+	}
 
 	return &secretsservice.DeleteSecretResponse{
 		Success:         true,
@@ -467,6 +894,10 @@ func (s *Server) ListAllRepositoriesWithVersions(ctx context.Context, req *secre
 					Checksum:   version.Checksum,
 					UploadedBy: version.UploadedBy,
 					CreatedAt:  version.CreatedAt.Format(time.RFC3339),
+					FileName:   version.FileName,
+					Path:       version.Path,
+					Branch:     version.Branch,
+					Annotation: annotationToProto(version.Annotation),
 				}
 			}
 
@@ -494,53 +925,3109 @@ func (s *Server) ListAllRepositoriesWithVersions(ctx context.Context, req *secre
 	}, nil
 }
 
-func RunGRPCServer() {
-	// Initialize database
-	if err := InitDatabase(); err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+func (s *Server) UploadFile(ctx context.Context, req *secretsservice.UploadFileRequest) (*secretsservice.UploadFileResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Validate file name, size and content type before touching GitHub
+	fileName, err := normalizeFileName(req.FileName)
+	if err != nil {
+		LogAuditEvent("UPLOAD_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.UploadFileResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
 	}
 
-	lis, err := net.Listen("tcp", ":50053")
+	maxSize := maxSecretFileSize()
+	if int64(len(req.Content)) > maxSize {
+		errMsg := fmt.Sprintf("File exceeds maximum size of %d bytes", maxSize)
+		LogAuditEvent("UPLOAD_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, errMsg)
+		return &secretsservice.UploadFileResponse{
+			Success: false,
+			Error:   errMsg,
+		}, nil
+	}
+
+	contentType, err := resolveContentType(req.ContentType, req.Content)
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		LogAuditEvent("UPLOAD_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.UploadFileResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
 	}
-	grpcServer := grpc.NewServer()
-	secretsservice.RegisterSecretsServiceServer(grpcServer, NewServer())
-	log.Println("gRPC SecretsService server listening on :50053")
-	if err := grpcServer.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+
+	// 2. Check if user has access to the repository
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("UPLOAD_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.UploadFileResponse{
+			Success: false,
+			Error:   "Failed to list repos: " + listResp.Error,
+		}, nil
 	}
-}
 
-func HasRepoAccess(repos []*secretsservice.Repo, ownerLogin, name string) bool {
-	for _, repo := range repos {
-		if repo.OwnerLogin == ownerLogin && repo.Name == name {
-			return true
+	var targetRepo *secretsservice.Repo
+	for _, repo := range listResp.Repos {
+		if repo.OwnerLogin == req.OwnerLogin && repo.Name == req.RepoName {
+			targetRepo = repo
+			break
 		}
 	}
-	return false
-}
 
-// Helper functions
+	if targetRepo == nil {
+		LogAuditEvent("UPLOAD_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.UploadFileResponse{
+			Success: false,
+			Error:   "No access to repository",
+		}, nil
+	}
 
-func (s *Server) getAuditInfo(ctx context.Context) (serviceName, requestID string) {
-	// Extract service name and request ID from context
-	md, ok := metadata.FromIncomingContext(ctx)
-	if ok {
-		if serviceNames := md.Get("service-name"); len(serviceNames) > 0 {
-			serviceName = serviceNames[0]
-		}
-		if requestIDs := md.Get("request-id"); len(requestIDs) > 0 {
-			requestID = requestIDs[0]
+	if !HasRepoPermission(listResp.Repos, req.OwnerLogin, req.RepoName, writePermission()) {
+		LogAuditEvent("UPLOAD_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, writePermissionError())
+		return &secretsservice.UploadFileResponse{
+			Success: false,
+			Error:   writePermissionError(),
+		}, nil
+	}
+
+	// Files stored under a directory may be restricted to the CODEOWNERS of that directory, like path
+	// scoped secrets
+	if path.Dir(fileName) != "." && codeownersEnforced() {
+		if err := checkCodeowners(ctx, req.AccessToken, req.OwnerLogin, req.RepoName, req.UserLogin, fileName); err != nil {
+			LogAuditEvent("UPLOAD_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, "CODEOWNERS check failed: "+err.Error())
+			return &secretsservice.UploadFileResponse{
+				Success: false,
+				Error:   "CODEOWNERS check failed: " + err.Error(),
+			}, nil
 		}
 	}
 
-	// Set defaults if not provided
-	if serviceName == "" {
-		serviceName = "BackendGate" // Default service name
+	// 3. Get or create repository in database
+	repo, err := GetOrCreateRepository(
+		req.OwnerLogin,
+		req.RepoName,
+		targetRepo.Id,
+		targetRepo.FullName,
+		targetRepo.HtmlUrl,
+		targetRepo.Description,
+		targetRepo.Private,
+	)
+	if err != nil {
+		LogAuditEvent("UPLOAD_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to get/create repository: "+err.Error())
+		return &secretsservice.UploadFileResponse{
+			Success: false,
+			Error:   "Failed to get/create repository: " + err.Error(),
+		}, nil
 	}
-	if requestID == "" {
-		requestID = generateRequestID() // Generate a unique request ID
+
+	// Files of a protected tag cannot go through change requests, so they are refused
+	if err := checkTagWritable(repo.ID, req.Tag); err != nil {
+		LogAuditEvent("UPLOAD_FILE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.UploadFileResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 4. Get next version number for this file and tag
+	version, err := GetNextFileVersion(repo.ID, fileName, req.Tag)
+	if err != nil {
+		LogAuditEvent("UPLOAD_FILE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to get next file version: "+err.Error())
+		return &secretsservice.UploadFileResponse{
+			Success: false,
+			Error:   "Failed to get next file version: " + err.Error(),
+		}, nil
+	}
+
+	// 5. Encrypt and store the blob
+	checksum := s.calculateChecksum(req.Content)
+	file, err := CreateSecretFile(repo.ID, version, req.Tag, fileName, contentType, req.Content, checksum, req.UserLogin)
+	if err != nil {
+		LogAuditEvent("UPLOAD_FILE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to create secret file: "+err.Error())
+		return &secretsservice.UploadFileResponse{
+			Success: false,
+			Error:   "Failed to create secret file: " + err.Error(),
+		}, nil
+	}
+
+	// 6. Log successful operation and notify webhooks
+	LogAuditEvent("UPLOAD_FILE", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+	payload := newWebhookPayload(webhookEventFileUploaded, req.OwnerLogin, req.RepoName, req.UserLogin, requestID)
+	payload.Tag = file.Tag
+	payload.Version = file.Version
+	payload.FileName = file.FileName
+	payload.Checksum = checksum
+	emitWebhookEvent(&repo.ID, payload)
+	publishSecretEvent(&SecretEvent{
+		RepoID:   repo.ID,
+		Type:     secretEventVersionCreated,
+		Tag:      file.Tag,
+		Version:  file.Version,
+		FileName: file.FileName,
+		File:     true,
+		Checksum: checksum,
+		Actor:    req.UserLogin,
+	})
+
+	return &secretsservice.UploadFileResponse{
+		Success:  true,
+		Version:  int32(file.Version),
+		Checksum: checksum,
+		Size:     file.Size,
+	}, nil
+}
+
+func (s *Server) DownloadFile(ctx context.Context, req *secretsservice.DownloadFileRequest) (*secretsservice.DownloadFileResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	fileName, err := normalizeFileName(req.FileName)
+	if err != nil {
+		LogAuditEvent("DOWNLOAD_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.DownloadFileResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 1. Check if user has access to the repository
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("DOWNLOAD_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.DownloadFileResponse{
+			Success: false,
+			Error:   "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	if !HasRepoAccess(listResp.Repos, req.OwnerLogin, req.RepoName) {
+		LogAuditEvent("DOWNLOAD_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.DownloadFileResponse{
+			Success: false,
+			Error:   "No access to repository",
+		}, nil
+	}
+
+	if !HasRepoPermission(listResp.Repos, req.OwnerLogin, req.RepoName, downloadPermission()) {
+		LogAuditEvent("DOWNLOAD_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, downloadPermissionError())
+		return &secretsservice.DownloadFileResponse{
+			Success: false,
+			Error:   downloadPermissionError(),
+		}, nil
+	}
+
+	// 2. Get repository from database
+	var repo Repository
+	result := DB.Where("owner_login = ? AND repo_name = ?", req.OwnerLogin, req.RepoName).First(&repo)
+	if result.Error != nil {
+		LogAuditEvent("DOWNLOAD_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, "Repository not found in database")
+		return &secretsservice.DownloadFileResponse{
+			Success: false,
+			Error:   "Repository not found in database",
+		}, nil
+	}
+
+	// 3. Find the requested file version
+	file, err := GetSecretFile(repo.ID, fileName, req.GetTag(), int(req.GetVersion()))
+	if err != nil {
+		LogAuditEvent("DOWNLOAD_FILE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to get file: "+err.Error())
+		return &secretsservice.DownloadFileResponse{
+			Success: false,
+			Error:   "Failed to get file: " + err.Error(),
+		}, nil
+	}
+
+	// 4. Decrypt
+	content, err := DecryptSecretFile(file)
+	if err != nil {
+		LogAuditEvent("DOWNLOAD_FILE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to decrypt file: "+err.Error())
+		return &secretsservice.DownloadFileResponse{
+			Success: false,
+			Error:   "Failed to decrypt file: " + err.Error(),
+		}, nil
+	}
+
+	// 5. Log successful operation
+	LogAuditEvent("DOWNLOAD_FILE", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.DownloadFileResponse{
+		Success:     true,
+		Version:     int32(file.Version),
+		Tag:         file.Tag,
+		FileName:    file.FileName,
+		ContentType: file.ContentType,
+		Content:     content,
+		Size:        file.Size,
+		Checksum:    file.Checksum,
+		UploadedBy:  file.UploadedBy,
+		CreatedAt:   file.CreatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+func (s *Server) SetTagParent(ctx context.Context, req *secretsservice.SetTagParentRequest) (*secretsservice.SetTagParentResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Check if user has access to the repository
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("SET_TAG_PARENT", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.SetTagParentResponse{
+			Success: false,
+			Error:   "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	if !HasRepoAccess(listResp.Repos, req.OwnerLogin, req.RepoName) {
+		LogAuditEvent("SET_TAG_PARENT", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.SetTagParentResponse{
+			Success: false,
+			Error:   "No access to repository",
+		}, nil
+	}
+
+	if !HasRepoPermission(listResp.Repos, req.OwnerLogin, req.RepoName, writePermission()) {
+		LogAuditEvent("SET_TAG_PARENT", nil, nil, serviceName, requestID, req.UserLogin, false, writePermissionError())
+		return &secretsservice.SetTagParentResponse{
+			Success: false,
+			Error:   writePermissionError(),
+		}, nil
+	}
+
+	// 2. Get repository from database
+	var repo Repository
+	result := DB.Where("owner_login = ? AND repo_name = ?", req.OwnerLogin, req.RepoName).First(&repo)
+	if result.Error != nil {
+		LogAuditEvent("SET_TAG_PARENT", nil, nil, serviceName, requestID, req.UserLogin, false, "Repository not found in database")
+		return &secretsservice.SetTagParentResponse{
+			Success: false,
+			Error:   "Repository not found in database",
+		}, nil
+	}
+
+	// 3. Validate the request
+	scopePath, err := normalizePath(req.Path)
+	if err != nil {
+		LogAuditEvent("SET_TAG_PARENT", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.SetTagParentResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	if req.FileName != "" {
+		if req.FileName, err = normalizeFileName(req.FileName); err != nil {
+			LogAuditEvent("SET_TAG_PARENT", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.SetTagParentResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+	}
+	scope := SecretScope{Path: scopePath, FileName: req.FileName}
+
+	var errMsg string
+	switch {
+	case req.Tag == "":
+		errMsg = "Tag is required"
+	case req.Tag == req.ParentTag:
+		errMsg = "A tag cannot inherit from itself"
+	case req.ParentVersion < 0:
+		errMsg = "Parent version must not be negative"
+	case req.ParentTag == "" && req.ParentVersion != 0:
+		errMsg = "Parent version requires a parent tag"
+	}
+	if errMsg != "" {
+		LogAuditEvent("SET_TAG_PARENT", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, errMsg)
+		return &secretsservice.SetTagParentResponse{
+			Success: false,
+			Error:   errMsg,
+		}, nil
+	}
+	// A parent changes what the path downloads, so it needs the same owners as an upload
+	if scope.Path != "" && codeownersEnforced() {
+		if err := checkCodeowners(ctx, req.AccessToken, req.OwnerLogin, req.RepoName, req.UserLogin, scope.RepoPath()); err != nil {
+			LogAuditEvent("SET_TAG_PARENT", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "CODEOWNERS check failed: "+err.Error())
+			return &secretsservice.SetTagParentResponse{
+				Success: false,
+				Error:   "CODEOWNERS check failed: " + err.Error(),
+			}, nil
+		}
+	}
+
+	// 4. A parent changes the resolved values of a protected tag just like a new version would, so for a
+	// protected tag, or one a protected tag inherits from, it waits for a second person's approval
+	protected, err := tagProtection(repo.ID, scope, req.Tag)
+	if err != nil {
+		LogAuditEvent("SET_TAG_PARENT", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.SetTagParentResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 5. An empty parent removes the inheritance
+	if req.ParentTag == "" && protected == nil {
+		if err := DeleteTagParent(repo.ID, scope, req.Tag); err != nil {
+			LogAuditEvent("SET_TAG_PARENT", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.SetTagParentResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+
+		LogAuditEvent("SET_TAG_PARENT", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+		emitWebhookEvent(&repo.ID, tagParentWebhookPayload(req, scope, requestID))
+		return &secretsservice.SetTagParentResponse{Success: true}, nil
+	}
+
+	// 6. A pinned parent version must exist, and the new edge must not close a cycle
+	if req.ParentVersion > 0 {
+		if _, err := GetSecretByTagAndVersion(repo.ID, scope, req.ParentTag, int(req.ParentVersion)); err != nil {
+			errMsg := fmt.Sprintf("Parent version %d of tag %s not found", req.ParentVersion, req.ParentTag)
+			LogAuditEvent("SET_TAG_PARENT", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, errMsg)
+			return &secretsservice.SetTagParentResponse{
+				Success: false,
+				Error:   errMsg,
+			}, nil
+		}
+	}
+
+	if req.ParentTag != "" {
+		if err := checkTagCycle(DB, repo.ID, scope, req.Tag, req.ParentTag); err != nil {
+			LogAuditEvent("SET_TAG_PARENT", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.SetTagParentResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+	}
+
+	// 7. Propose the change of a protected tag instead of storing it
+	if protected != nil {
+		request, err := proposeTagParent(repo.ID, scope, req.Tag, req.ParentTag, int(req.ParentVersion), req.UserLogin, protected)
+		if err != nil {
+			LogAuditEvent("CREATE_CHANGE_REQUEST", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to create change request: "+err.Error())
+			return &secretsservice.SetTagParentResponse{
+				Success: false,
+				Error:   "Failed to create change request: " + err.Error(),
+			}, nil
+		}
+
+		LogAuditEvent("CREATE_CHANGE_REQUEST", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+		return &secretsservice.SetTagParentResponse{
+			Success:         true,
+			ChangeRequestId: uint64(request.ID),
+		}, nil
+	}
+
+	// 8. Store the parent
+	if err := SetTagParent(repo.ID, scope, req.Tag, req.ParentTag, int(req.ParentVersion), req.UserLogin); err != nil {
+		LogAuditEvent("SET_TAG_PARENT", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.SetTagParentResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 9. Log successful operation and notify webhooks
+	LogAuditEvent("SET_TAG_PARENT", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+	emitWebhookEvent(&repo.ID, tagParentWebhookPayload(req, scope, requestID))
+
+	return &secretsservice.SetTagParentResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *Server) ListSecretReferences(ctx context.Context, req *secretsservice.ListSecretReferencesRequest) (*secretsservice.ListSecretReferencesResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Check if user has access to the referenced repository
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("LIST_REFERENCES", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.ListSecretReferencesResponse{
+			Error: "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	if !HasRepoAccess(listResp.Repos, req.OwnerLogin, req.RepoName) {
+		LogAuditEvent("LIST_REFERENCES", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.ListSecretReferencesResponse{
+			Error: "No access to repository",
+		}, nil
+	}
+
+	// 2. Get repository from database
+	var repo Repository
+	result := DB.Where("owner_login = ? AND repo_name = ?", req.OwnerLogin, req.RepoName).First(&repo)
+	if result.Error != nil {
+		LogAuditEvent("LIST_REFERENCES", nil, nil, serviceName, requestID, req.UserLogin, false, "Repository not found in database")
+		return &secretsservice.ListSecretReferencesResponse{
+			Error: "Repository not found in database",
+		}, nil
+	}
+
+	// 3. List references to the repository
+	sources, err := ListSecretReferences(req.OwnerLogin, req.RepoName, req.Key, req.Tag)
+	if err != nil {
+		LogAuditEvent("LIST_REFERENCES", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListSecretReferencesResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	// 4. Only reveal referencing repositories the user can access, and count the rest
+	var references []*secretsservice.SecretReference
+	var hidden int32
+	for _, source := range sources {
+		if !HasRepoAccess(listResp.Repos, source.OwnerLogin, source.RepoName) {
+			hidden++
+			continue
+		}
+		references = append(references, &secretsservice.SecretReference{
+			OwnerLogin: source.OwnerLogin,
+			RepoName:   source.RepoName,
+			Path:       source.Path,
+			FileName:   source.FileName,
+			Tag:        source.Tag,
+			Branch:     source.Branch,
+			Key:        source.Key,
+			TargetTag:  source.TargetTag,
+			TargetKey:  source.TargetKey,
+		})
+	}
+
+	// 5. Log successful operation
+	LogAuditEvent("LIST_REFERENCES", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.ListSecretReferencesResponse{
+		References:       references,
+		HiddenReferences: hidden,
+	}, nil
+}
+
+func (s *Server) UploadSharedSecretSet(ctx context.Context, req *secretsservice.UploadSharedSecretSetRequest) (*secretsservice.UploadSharedSecretSetResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Validate the organization and set name
+	if req.OrgLogin == "" || !sharedSetNamePattern.MatchString(req.SetName) {
+		LogSharedSetAuditEvent("SHARED_SET_UPLOAD", nil, nil, serviceName, requestID, req.UserLogin, false, "Invalid organization or shared secret set name")
+		return &secretsservice.UploadSharedSecretSetResponse{
+			Success: false,
+			Error:   "Invalid organization or shared secret set name",
+		}, nil
+	}
+
+	// 2. Only organization admins manage shared secret sets
+	role, err := getOrgRole(ctx, req.AccessToken, req.OrgLogin)
+	if err != nil {
+		LogSharedSetAuditEvent("SHARED_SET_UPLOAD", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to check organization membership: "+err.Error())
+		return &secretsservice.UploadSharedSecretSetResponse{
+			Success: false,
+			Error:   "Failed to check organization membership: " + err.Error(),
+		}, nil
+	}
+	if role != orgRoleAdmin {
+		LogSharedSetAuditEvent("SHARED_SET_UPLOAD", nil, nil, serviceName, requestID, req.UserLogin, false, "Only organization admins can manage shared secret sets")
+		return &secretsservice.UploadSharedSecretSetResponse{
+			Success: false,
+			Error:   "Only organization admins can manage shared secret sets",
+		}, nil
+	}
+
+	// 3. Parse content according to the requested format and convert it to JSON
+	envData, err := s.parseSecretFile(req.EnvFileContent, req.Format, req.KeySeparator)
+	if err != nil {
+		LogSharedSetAuditEvent("SHARED_SET_UPLOAD", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to parse secret file: "+err.Error())
+		return &secretsservice.UploadSharedSecretSetResponse{
+			Success: false,
+			Error:   "Failed to parse secret file: " + err.Error(),
+		}, nil
+	}
+
+	envDataJSON, err := json.Marshal(envData)
+	if err != nil {
+		LogSharedSetAuditEvent("SHARED_SET_UPLOAD", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to marshal env data: "+err.Error())
+		return &secretsservice.UploadSharedSecretSetResponse{
+			Success: false,
+			Error:   "Failed to marshal env data: " + err.Error(),
+		}, nil
+	}
+	checksum := s.calculateChecksum(req.EnvFileContent)
+
+	// 4. Get or create the set and store the next version
+	set, err := GetOrCreateSharedSecretSet(req.OrgLogin, req.SetName, req.UserLogin)
+	if err != nil {
+		LogSharedSetAuditEvent("SHARED_SET_UPLOAD", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.UploadSharedSecretSetResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	version, err := GetNextSharedSecretSetVersion(set.ID)
+	if err != nil {
+		LogSharedSetAuditEvent("SHARED_SET_UPLOAD", &set.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.UploadSharedSecretSetResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	if _, err := CreateSharedSecretSetVersion(set.ID, version, string(envDataJSON), checksum, req.UserLogin); err != nil {
+		LogSharedSetAuditEvent("SHARED_SET_UPLOAD", &set.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.UploadSharedSecretSetResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 5. Log successful operation and notify the organization's webhooks
+	LogSharedSetAuditEvent("SHARED_SET_UPLOAD", &set.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+	payload := newWebhookPayload(webhookEventSharedSetUploaded, req.OrgLogin, "", req.UserLogin, requestID)
+	payload.SharedSet = req.SetName
+	payload.Version = version
+	payload.Checksum = checksum
+	emitWebhookEvent(nil, payload)
+
+	return &secretsservice.UploadSharedSecretSetResponse{
+		Success:  true,
+		Version:  int32(version),
+		Checksum: checksum,
+	}, nil
+}
+
+func (s *Server) ListSharedSecretSets(ctx context.Context, req *secretsservice.ListSharedSecretSetsRequest) (*secretsservice.ListSharedSecretSetsResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Any organization member can see the sets
+	role, err := getOrgRole(ctx, req.AccessToken, req.OrgLogin)
+	if err != nil {
+		LogSharedSetAuditEvent("SHARED_SET_LIST", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to check organization membership: "+err.Error())
+		return &secretsservice.ListSharedSecretSetsResponse{
+			Error: "Failed to check organization membership: " + err.Error(),
+		}, nil
+	}
+	if role != orgRoleAdmin && role != orgRoleMember {
+		LogSharedSetAuditEvent("SHARED_SET_LIST", nil, nil, serviceName, requestID, req.UserLogin, false, "Not a member of the organization")
+		return &secretsservice.ListSharedSecretSetsResponse{
+			Error: "Not a member of the organization",
+		}, nil
+	}
+
+	// 2. Attachments are only listed for repositories the user can access
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogSharedSetAuditEvent("SHARED_SET_LIST", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.ListSharedSecretSetsResponse{
+			Error: "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	// 3. Load the sets with their versions and attachments
+	sets, err := ListSharedSecretSets(req.OrgLogin)
+	if err != nil {
+		LogSharedSetAuditEvent("SHARED_SET_LIST", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListSharedSecretSetsResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	attachments, err := ListSharedSecretSetAttachments(req.OrgLogin, 0)
+	if err != nil {
+		LogSharedSetAuditEvent("SHARED_SET_LIST", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListSharedSecretSetsResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	// 4. Convert to proto format
+	attachmentsBySet := make(map[uint][]*secretsservice.SharedSecretSetAttachment)
+	for _, attachment := range attachments {
+		if !HasRepoAccess(listResp.Repos, attachment.OwnerLogin, attachment.RepoName) {
+			continue
+		}
+		attachmentsBySet[attachment.SetID] = append(attachmentsBySet[attachment.SetID], &secretsservice.SharedSecretSetAttachment{
+			OwnerLogin:    attachment.OwnerLogin,
+			RepoName:      attachment.RepoName,
+			PinnedVersion: int32(attachment.PinnedVersion),
+			AttachedBy:    attachment.AttachedBy,
+		})
+	}
+
+	protoSets := make([]*secretsservice.SharedSecretSet, len(sets))
+	for i, set := range sets {
+		versions := make([]*secretsservice.SharedSecretSetVersion, len(set.Versions))
+		for j, version := range set.Versions {
+			versions[j] = &secretsservice.SharedSecretSetVersion{
+				Version:    int32(version.Version),
+				Checksum:   version.Checksum,
+				UploadedBy: version.UploadedBy,
+				CreatedAt:  version.CreatedAt.Format(time.RFC3339),
+			}
+		}
+		protoSets[i] = &secretsservice.SharedSecretSet{
+			OrgLogin:    set.OrgLogin,
+			Name:        set.Name,
+			CreatedBy:   set.CreatedBy,
+			CreatedAt:   set.CreatedAt.Format(time.RFC3339),
+			Versions:    versions,
+			Attachments: attachmentsBySet[set.ID],
+		}
+	}
+
+	// 5. Log successful operation
+	LogSharedSetAuditEvent("SHARED_SET_LIST", nil, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.ListSharedSecretSetsResponse{
+		Sets: protoSets,
+	}, nil
+}
+
+func (s *Server) AttachSharedSecretSet(ctx context.Context, req *secretsservice.AttachSharedSecretSetRequest) (*secretsservice.AttachSharedSecretSetResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	operation := "SHARED_SET_ATTACH"
+	if req.Detach {
+		operation = "SHARED_SET_DETACH"
+	}
+
+	// 1. Check if user has access to the repository
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogSharedSetAuditEvent(operation, nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.AttachSharedSecretSetResponse{
+			Success: false,
+			Error:   "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	var targetRepo *secretsservice.Repo
+	for _, repo := range listResp.Repos {
+		if repo.OwnerLogin == req.OwnerLogin && repo.Name == req.RepoName {
+			targetRepo = repo
+			break
+		}
+	}
+	if targetRepo == nil {
+		LogSharedSetAuditEvent(operation, nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.AttachSharedSecretSetResponse{
+			Success: false,
+			Error:   "No access to repository",
+		}, nil
+	}
+
+	// 2. Sets can only be attached to repositories of their own organization, by its admins
+	if !strings.EqualFold(req.OwnerLogin, req.OrgLogin) {
+		LogSharedSetAuditEvent(operation, nil, nil, serviceName, requestID, req.UserLogin, false, "Repository does not belong to organization "+req.OrgLogin)
+		return &secretsservice.AttachSharedSecretSetResponse{
+			Success: false,
+			Error:   "Repository does not belong to organization " + req.OrgLogin,
+		}, nil
+	}
+
+	role, err := getOrgRole(ctx, req.AccessToken, req.OrgLogin)
+	if err != nil {
+		LogSharedSetAuditEvent(operation, nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to check organization membership: "+err.Error())
+		return &secretsservice.AttachSharedSecretSetResponse{
+			Success: false,
+			Error:   "Failed to check organization membership: " + err.Error(),
+		}, nil
+	}
+	if role != orgRoleAdmin {
+		LogSharedSetAuditEvent(operation, nil, nil, serviceName, requestID, req.UserLogin, false, "Only organization admins can manage shared secret sets")
+		return &secretsservice.AttachSharedSecretSetResponse{
+			Success: false,
+			Error:   "Only organization admins can manage shared secret sets",
+		}, nil
+	}
+
+	// 3. Find the set and the repository
+	set, err := GetSharedSecretSet(req.OrgLogin, req.SetName)
+	if err == nil && set == nil {
+		err = fmt.Errorf("shared secret set %s/%s not found", req.OrgLogin, req.SetName)
+	}
+	if err != nil {
+		LogSharedSetAuditEvent(operation, nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.AttachSharedSecretSetResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	repo, err := GetOrCreateRepository(
+		req.OwnerLogin,
+		req.RepoName,
+		targetRepo.Id,
+		targetRepo.FullName,
+		targetRepo.HtmlUrl,
+		targetRepo.Description,
+		targetRepo.Private,
+	)
+	if err != nil {
+		LogSharedSetAuditEvent(operation, &set.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to get/create repository: "+err.Error())
+		return &secretsservice.AttachSharedSecretSetResponse{
+			Success: false,
+			Error:   "Failed to get/create repository: " + err.Error(),
+		}, nil
+	}
+
+	// A set is merged into every path of the repository, so the admin must own all path scoped secrets
+	if codeownersEnforced() {
+		paths, err := ListScopedSecretPaths(repo.ID)
+		if err == nil {
+			err = checkCodeownersPaths(ctx, req.AccessToken, req.OwnerLogin, req.RepoName, req.UserLogin, paths)
+		}
+		if err != nil {
+			LogSharedSetAuditEvent(operation, &set.ID, &repo.ID, serviceName, requestID, req.UserLogin, false, "CODEOWNERS check failed: "+err.Error())
+			return &secretsservice.AttachSharedSecretSetResponse{
+				Success: false,
+				Error:   "CODEOWNERS check failed: " + err.Error(),
+			}, nil
+		}
+	}
+
+	// 4. Detach, or attach after checking that a pinned version exists
+	if req.Detach {
+		detached, err := DetachSharedSecretSet(set.ID, repo.ID)
+		if err == nil && !detached {
+			err = fmt.Errorf("shared secret set %s/%s is not attached to %s/%s", req.OrgLogin, req.SetName, req.OwnerLogin, req.RepoName)
+		}
+		if err != nil {
+			LogSharedSetAuditEvent(operation, &set.ID, &repo.ID, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.AttachSharedSecretSetResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+	} else {
+		if req.Version < 0 {
+			LogSharedSetAuditEvent(operation, &set.ID, &repo.ID, serviceName, requestID, req.UserLogin, false, "Version must not be negative")
+			return &secretsservice.AttachSharedSecretSetResponse{
+				Success: false,
+				Error:   "Version must not be negative",
+			}, nil
+		}
+		if _, err := GetSharedSecretSetVersion(set.ID, int(req.Version)); err != nil {
+			LogSharedSetAuditEvent(operation, &set.ID, &repo.ID, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.AttachSharedSecretSetResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+		if err := AttachSharedSecretSet(set.ID, repo.ID, int(req.Version), req.UserLogin); err != nil {
+			LogSharedSetAuditEvent(operation, &set.ID, &repo.ID, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.AttachSharedSecretSetResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+	}
+
+	// 5. Log successful operation and notify the repository's webhooks, the set changes what it downloads
+	LogSharedSetAuditEvent(operation, &set.ID, &repo.ID, serviceName, requestID, req.UserLogin, true, "")
+	payload := newWebhookPayload(webhookEventSharedSetAttached, req.OwnerLogin, req.RepoName, req.UserLogin, requestID)
+	if req.Detach {
+		payload.Event = webhookEventSharedSetDetached
+	} else {
+		payload.Version = int(req.Version) // 0 follows the latest version
+	}
+	payload.SharedSet = req.SetName
+	emitWebhookEvent(&repo.ID, payload)
+
+	return &secretsservice.AttachSharedSecretSetResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *Server) SetSchema(ctx context.Context, req *secretsservice.SetSchemaRequest) (*secretsservice.SetSchemaResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Check if user has access to the repository
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("SET_SCHEMA", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.SetSchemaResponse{
+			Success: false,
+			Error:   "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	var targetRepo *secretsservice.Repo
+	for _, repo := range listResp.Repos {
+		if repo.OwnerLogin == req.OwnerLogin && repo.Name == req.RepoName {
+			targetRepo = repo
+			break
+		}
+	}
+	if targetRepo == nil {
+		LogAuditEvent("SET_SCHEMA", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.SetSchemaResponse{
+			Success: false,
+			Error:   "No access to repository",
+		}, nil
+	}
+
+	// 2. Get or create repository in database; a schema may be declared before the first upload
+	repo, err := GetOrCreateRepository(
+		req.OwnerLogin,
+		req.RepoName,
+		targetRepo.Id,
+		targetRepo.FullName,
+		targetRepo.HtmlUrl,
+		targetRepo.Description,
+		targetRepo.Private,
+	)
+	if err != nil {
+		LogAuditEvent("SET_SCHEMA", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to get/create repository: "+err.Error())
+		return &secretsservice.SetSchemaResponse{
+			Success: false,
+			Error:   "Failed to get/create repository: " + err.Error(),
+		}, nil
+	}
+
+	// 3. An empty schema removes it
+	if len(req.Schema) == 0 {
+		if err := DeleteTagSchema(repo.ID, req.Tag); err != nil {
+			LogAuditEvent("SET_SCHEMA", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.SetSchemaResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+
+		LogAuditEvent("SET_SCHEMA", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+		return &secretsservice.SetSchemaResponse{
+			Success: true,
+		}, nil
+	}
+
+	// 4. Validate the schema and store it normalized
+	schema, err := parseSecretSchema(req.Schema)
+	if err != nil {
+		LogAuditEvent("SET_SCHEMA", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.SetSchemaResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	definition, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		LogAuditEvent("SET_SCHEMA", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to marshal schema: "+err.Error())
+		return &secretsservice.SetSchemaResponse{
+			Success: false,
+			Error:   "Failed to marshal schema: " + err.Error(),
+		}, nil
+	}
+
+	if err := SetTagSchema(repo.ID, req.Tag, string(definition), req.UserLogin); err != nil {
+		LogAuditEvent("SET_SCHEMA", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.SetSchemaResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 5. Log successful operation
+	LogAuditEvent("SET_SCHEMA", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.SetSchemaResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *Server) GetSchema(ctx context.Context, req *secretsservice.GetSchemaRequest) (*secretsservice.GetSchemaResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Check if user has access to the repository
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("GET_SCHEMA", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.GetSchemaResponse{
+			Error: "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	if !HasRepoAccess(listResp.Repos, req.OwnerLogin, req.RepoName) {
+		LogAuditEvent("GET_SCHEMA", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.GetSchemaResponse{
+			Error: "No access to repository",
+		}, nil
+	}
+
+	// 2. Get repository from database
+	var repo Repository
+	result := DB.Where("owner_login = ? AND repo_name = ?", req.OwnerLogin, req.RepoName).First(&repo)
+	if result.Error != nil {
+		LogAuditEvent("GET_SCHEMA", nil, nil, serviceName, requestID, req.UserLogin, false, "Repository not found in database")
+		return &secretsservice.GetSchemaResponse{
+			Error: "Repository not found in database",
+		}, nil
+	}
+
+	// 3. Find the schema that applies to the tag
+	schema, err := ResolveTagSchema(repo.ID, req.Tag)
+	if err != nil {
+		LogAuditEvent("GET_SCHEMA", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.GetSchemaResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	// 4. Log successful operation
+	LogAuditEvent("GET_SCHEMA", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	if schema == nil {
+		return &secretsservice.GetSchemaResponse{}, nil
+	}
+	return &secretsservice.GetSchemaResponse{
+		Schema:    []byte(schema.Definition),
+		Tag:       schema.Tag,
+		UpdatedBy: schema.UpdatedBy,
+		UpdatedAt: schema.UpdatedAt.Format(time.RFC3339),
+	}, nil
+}
+
+func (s *Server) ListLintRules(ctx context.Context, req *secretsservice.ListLintRulesRequest) (*secretsservice.ListLintRulesResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Check if user has access to the repository
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("LIST_LINT_RULES", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.ListLintRulesResponse{
+			Error: "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	if !HasRepoAccess(listResp.Repos, req.OwnerLogin, req.RepoName) {
+		LogAuditEvent("LIST_LINT_RULES", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.ListLintRulesResponse{
+			Error: "No access to repository",
+		}, nil
+	}
+
+	// 2. Load the repository's overrides; a repository without secrets yet uses the defaults
+	var repoID *uint
+	actions := map[string]string{}
+	var repo Repository
+	if result := DB.Where("owner_login = ? AND repo_name = ?", req.OwnerLogin, req.RepoName).First(&repo); result.Error == nil {
+		repoID = &repo.ID
+		if actions, err = GetLintActions(repo.ID); err != nil {
+			LogAuditEvent("LIST_LINT_RULES", repoID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.ListLintRulesResponse{
+				Error: err.Error(),
+			}, nil
+		}
+	}
+
+	// 3. Convert to proto format
+	rules := make([]*secretsservice.LintRule, len(lintRules))
+	for i, rule := range lintRules {
+		action := actions[rule.name]
+		if action == "" {
+			action = lintActionWarn
+		}
+		rules[i] = &secretsservice.LintRule{
+			Name:        rule.name,
+			Description: rule.description,
+			Action:      action,
+		}
+	}
+
+	// 4. Log successful operation
+	LogAuditEvent("LIST_LINT_RULES", repoID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.ListLintRulesResponse{
+		Rules: rules,
+	}, nil
+}
+
+func (s *Server) SetLintRule(ctx context.Context, req *secretsservice.SetLintRuleRequest) (*secretsservice.SetLintRuleResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Check if user has access to the repository
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("SET_LINT_RULE", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.SetLintRuleResponse{
+			Success: false,
+			Error:   "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	var targetRepo *secretsservice.Repo
+	for _, repo := range listResp.Repos {
+		if repo.OwnerLogin == req.OwnerLogin && repo.Name == req.RepoName {
+			targetRepo = repo
+			break
+		}
+	}
+	if targetRepo == nil {
+		LogAuditEvent("SET_LINT_RULE", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.SetLintRuleResponse{
+			Success: false,
+			Error:   "No access to repository",
+		}, nil
+	}
+
+	// 2. Validate the rule and action
+	if !isLintRule(req.Rule) {
+		LogAuditEvent("SET_LINT_RULE", nil, nil, serviceName, requestID, req.UserLogin, false, "Unknown lint rule: "+req.Rule)
+		return &secretsservice.SetLintRuleResponse{
+			Success: false,
+			Error:   "Unknown lint rule: " + req.Rule,
+		}, nil
+	}
+	switch req.Action {
+	case lintActionWarn, lintActionBlock, lintActionOff:
+	default:
+		LogAuditEvent("SET_LINT_RULE", nil, nil, serviceName, requestID, req.UserLogin, false, "Action must be warn, block or off")
+		return &secretsservice.SetLintRuleResponse{
+			Success: false,
+			Error:   "Action must be warn, block or off",
+		}, nil
+	}
+
+	// 3. Get or create repository in database; rules may be configured before the first upload
+	repo, err := GetOrCreateRepository(
+		req.OwnerLogin,
+		req.RepoName,
+		targetRepo.Id,
+		targetRepo.FullName,
+		targetRepo.HtmlUrl,
+		targetRepo.Description,
+		targetRepo.Private,
+	)
+	if err != nil {
+		LogAuditEvent("SET_LINT_RULE", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to get/create repository: "+err.Error())
+		return &secretsservice.SetLintRuleResponse{
+			Success: false,
+			Error:   "Failed to get/create repository: " + err.Error(),
+		}, nil
+	}
+
+	// 4. Store the action
+	if err := SetLintAction(repo.ID, req.Rule, req.Action, req.UserLogin); err != nil {
+		LogAuditEvent("SET_LINT_RULE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.SetLintRuleResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 5. Log successful operation
+	LogAuditEvent("SET_LINT_RULE", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.SetLintRuleResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *Server) ListExpiringSecrets(ctx context.Context, req *secretsservice.ListExpiringSecretsRequest) (*secretsservice.ListExpiringSecretsResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Get the repositories the user has access to
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("LIST_EXPIRING", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.ListExpiringSecretsResponse{
+			Error: "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	withinDays := int(req.WithinDays)
+	if withinDays <= 0 {
+		withinDays = defaultExpiringWithinDays
+	}
+
+	// 2. Get the key metadata of the latest versions in the accessible repositories
+	records, err := ListLatestKeyMetadata(repoNamePairs(listResp.Repos))
+	if err != nil {
+		LogAuditEvent("LIST_EXPIRING", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListExpiringSecretsResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	// 3. Keep the keys due within the window, expired ones included
+	now := time.Now().UTC()
+	deadline := now.AddDate(0, 0, withinDays)
+
+	type dueKey struct {
+		record SecretKeyMetadataInfo
+		due    time.Time
+		reason string
+	}
+	var dueKeys []dueKey
+	for _, record := range records {
+		due, reason, ok := record.dueAt()
+		if !ok || due.After(deadline) {
+			continue
+		}
+		dueKeys = append(dueKeys, dueKey{record: record, due: due, reason: reason})
+	}
+	sort.SliceStable(dueKeys, func(i, j int) bool {
+		return dueKeys[i].due.Before(dueKeys[j].due)
+	})
+
+	// 4. Convert to proto format
+	secrets := make([]*secretsservice.ExpiringSecret, len(dueKeys))
+	for i, dueKey := range dueKeys {
+		record := dueKey.record
+		var expiresAt string
+		if record.ExpiresAt != nil {
+			expiresAt = record.ExpiresAt.Format(time.RFC3339)
+		}
+		secrets[i] = &secretsservice.ExpiringSecret{
+			OwnerLogin:       record.OwnerLogin,
+			RepoName:         record.RepoName,
+			Path:             record.Path,
+			FileName:         record.FileName,
+			Branch:           record.Branch,
+			Tag:              record.Tag,
+			Version:          int32(record.Version),
+			Key:              record.Key,
+			Owner:            record.Owner,
+			ExpiresAt:        expiresAt,
+			RotationInterval: record.RotationInterval,
+			RotatedAt:        record.RotatedAt.Format(time.RFC3339),
+			DueAt:            dueKey.due.Format(time.RFC3339),
+			Reason:           dueKey.reason,
+			Expired:          dueKey.due.Before(now),
+		}
+	}
+
+	// 5. Log successful operation
+	LogAuditEvent("LIST_EXPIRING", nil, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.ListExpiringSecretsResponse{
+		Secrets: secrets,
+	}, nil
+}
+
+func (s *Server) GenerateSecretValues(ctx context.Context, req *secretsservice.GenerateSecretValuesRequest) (*secretsservice.GenerateSecretValuesResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Check if user has access to the repository
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("GENERATE", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success: false,
+			Error:   "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	var targetRepo *secretsservice.Repo
+	for _, repo := range listResp.Repos {
+		if repo.OwnerLogin == req.OwnerLogin && repo.Name == req.RepoName {
+			targetRepo = repo
+			break
+		}
+	}
+	if targetRepo == nil {
+		LogAuditEvent("GENERATE", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success: false,
+			Error:   "No access to repository",
+		}, nil
+	}
+
+	if !HasRepoPermission(listResp.Repos, req.OwnerLogin, req.RepoName, writePermission()) {
+		LogAuditEvent("GENERATE", nil, nil, serviceName, requestID, req.UserLogin, false, writePermissionError())
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success: false,
+			Error:   writePermissionError(),
+		}, nil
+	}
+
+	if len(req.Values) == 0 {
+		LogAuditEvent("GENERATE", nil, nil, serviceName, requestID, req.UserLogin, false, "No values to generate")
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success: false,
+			Error:   "No values to generate",
+		}, nil
+	}
+
+	// 2. Validate the secret file name, path and branch
+	if req.FileName != "" {
+		if req.FileName, err = normalizeFileName(req.FileName); err != nil {
+			LogAuditEvent("GENERATE", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.GenerateSecretValuesResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+	}
+
+	scopePath, err := normalizePath(req.Path)
+	if err != nil {
+		LogAuditEvent("GENERATE", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	branch, err := normalizeBranch(req.Branch)
+	if err != nil {
+		LogAuditEvent("GENERATE", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	scope := SecretScope{Path: scopePath, FileName: req.FileName, Branch: branch}
+
+	annotation, err := parseVersionAnnotation(req.Annotation)
+	if err != nil {
+		LogAuditEvent("GENERATE", nil, nil, serviceName, requestID, req.UserLogin, false, "Invalid annotation: "+err.Error())
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success: false,
+			Error:   "Invalid annotation: " + err.Error(),
+		}, nil
+	}
+
+	if scope.Path != "" && codeownersEnforced() {
+		if err := checkCodeowners(ctx, req.AccessToken, req.OwnerLogin, req.RepoName, req.UserLogin, scope.RepoPath()); err != nil {
+			LogAuditEvent("GENERATE", nil, nil, serviceName, requestID, req.UserLogin, false, "CODEOWNERS check failed: "+err.Error())
+			return &secretsservice.GenerateSecretValuesResponse{
+				Success: false,
+				Error:   "CODEOWNERS check failed: " + err.Error(),
+			}, nil
+		}
+	}
+
+	// 3. Get or create repository in database
+	repo, err := GetOrCreateRepository(
+		req.OwnerLogin,
+		req.RepoName,
+		targetRepo.Id,
+		targetRepo.FullName,
+		targetRepo.HtmlUrl,
+		targetRepo.Description,
+		targetRepo.Private,
+	)
+	if err != nil {
+		LogAuditEvent("GENERATE", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to get/create repository: "+err.Error())
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success: false,
+			Error:   "Failed to get/create repository: " + err.Error(),
+		}, nil
+	}
+
+	// Generated values of a protected tag would never be seen by a reviewer
+	if err := checkScopedTagWritable(repo.ID, scope, req.Tag); err != nil {
+		LogAuditEvent("GENERATE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 4. Start from the tag's latest version so its other keys are kept
+	envData := make(map[string]string)
+	previous, err := GetBranchOverlay(repo.ID, scope, req.Tag)
+	if err != nil {
+		LogAuditEvent("GENERATE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	if previous != nil {
+		decryptedData, err := DecryptSecretData(previous)
+		if err == nil {
+			err = json.Unmarshal([]byte(decryptedData), &envData)
+		}
+		if err != nil {
+			LogAuditEvent("GENERATE", &repo.ID, &previous.ID, serviceName, requestID, req.UserLogin, false, "Failed to read previous version: "+err.Error())
+			return &secretsservice.GenerateSecretValuesResponse{
+				Success: false,
+				Error:   "Failed to read previous version: " + err.Error(),
+			}, nil
+		}
+	}
+
+	// 5. Generate the values; existing keys are only replaced when asked to
+	generated, err := generateSecretValues(req.Values, envData)
+	if err != nil {
+		LogAuditEvent("GENERATE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to generate values: "+err.Error())
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success: false,
+			Error:   "Failed to generate values: " + err.Error(),
+		}, nil
+	}
+
+	generatedKeys := sortedKeys(generated)
+	for _, key := range generatedKeys {
+		if _, exists := envData[key]; exists && !req.Overwrite {
+			LogAuditEvent("GENERATE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, key+" already exists")
+			return &secretsservice.GenerateSecretValuesResponse{
+				Success: false,
+				Error:   key + " already exists, set overwrite to replace it",
+			}, nil
+		}
+		envData[key] = generated[key]
+	}
+
+	// 6. Validate against the schema declared for the tag
+	violations, err := checkUploadSchema(repo.ID, scope, req.Tag, envData)
+	if err != nil {
+		LogAuditEvent("GENERATE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to validate schema: "+err.Error())
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success: false,
+			Error:   "Failed to validate schema: " + err.Error(),
+		}, nil
+	}
+	if hasSchemaErrors(violations) {
+		message := fmt.Sprintf("Secret does not match the schema: %d violation(s)", len(violations))
+		LogAuditEvent("GENERATE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, message)
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success:    false,
+			Error:      message,
+			Violations: violationsToProto(violations),
+		}, nil
+	}
+
+	// 7. Carry the key metadata forward; generated keys count as rotated
+	keyMetadata, err := buildKeyMetadata(DB, repo.ID, scope, req.Tag, envData, nil)
+	if err != nil {
+		LogAuditEvent("GENERATE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	envDataJSON, err := json.Marshal(envData)
+	if err != nil {
+		LogAuditEvent("GENERATE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to marshal env data: "+err.Error())
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success: false,
+			Error:   "Failed to marshal env data: " + err.Error(),
+		}, nil
+	}
+	checksum := s.calculateChecksum([]byte(s.convertToEnvFormat(envData)))
+
+	// 8. Create the new version
+	version, err := GetNextVersionForTag(repo.ID, scope, req.Tag)
+	if err != nil {
+		LogAuditEvent("GENERATE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to get next version for tag: "+err.Error())
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success: false,
+			Error:   "Failed to get next version for tag: " + err.Error(),
+		}, nil
+	}
+
+	secret, err := CreateSecret(repo.ID, scope, version, req.Tag, string(envDataJSON), checksum, req.UserLogin, annotation, true, keyMetadata)
+	if err != nil {
+		LogAuditEvent("GENERATE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to create secret: "+err.Error())
+		return &secretsservice.GenerateSecretValuesResponse{
+			Success: false,
+			Error:   "Failed to create secret: " + err.Error(),
+		}, nil
+	}
+
+	if err := ReplaceSecretReferences(repo.ID, scope, req.Tag, parseSecretReferences(envData)); err != nil {
+		log.Printf("Failed to record references of secret %d: %v", secret.ID, err)
+	}
+
+	// 9. Log successful operation and notify webhooks; the audit trail names the keys, never the values
+	LogAuditEvent("GENERATE", &repo.ID, &secret.ID, serviceName, requestID, req.UserLogin, true, "Generated keys: "+strings.Join(generatedKeys, ", "))
+	emitWebhookEvent(&repo.ID, secretWebhookPayload(webhookEventSecretGenerated, req.OwnerLogin, req.RepoName, req.UserLogin, requestID, secret))
+	publishSecretEvent(secretVersionEvent(secret, req.UserLogin))
+
+	return &secretsservice.GenerateSecretValuesResponse{
+		Success:       true,
+		Version:       int32(version),
+		Checksum:      checksum,
+		GeneratedKeys: generatedKeys,
+		Violations:    violationsToProto(violations),
+	}, nil
+}
+
+func (s *Server) SetSecretEngine(ctx context.Context, req *secretsservice.SetSecretEngineRequest) (*secretsservice.SetSecretEngineResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	operation := "SET_SECRET_ENGINE"
+	if req.Delete {
+		operation = "DELETE_SECRET_ENGINE"
+	}
+
+	// 1. Check if user has access to the repository
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent(operation, nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.SetSecretEngineResponse{
+			Success: false,
+			Error:   "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	var targetRepo *secretsservice.Repo
+	for _, repo := range listResp.Repos {
+		if repo.OwnerLogin == req.OwnerLogin && repo.Name == req.RepoName {
+			targetRepo = repo
+			break
+		}
+	}
+	if targetRepo == nil {
+		LogAuditEvent(operation, nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.SetSecretEngineResponse{
+			Success: false,
+			Error:   "No access to repository",
+		}, nil
+	}
+
+	// Engines connect to infrastructure with stored credentials, so only repository admins manage them
+	if !HasRepoPermission(listResp.Repos, req.OwnerLogin, req.RepoName, repoPermissionAdmin) {
+		LogAuditEvent(operation, nil, nil, serviceName, requestID, req.UserLogin, false, "Managing secret engines requires admin permission")
+		return &secretsservice.SetSecretEngineResponse{
+			Success: false,
+			Error:   "Managing secret engines requires admin permission on the repository",
+		}, nil
+	}
+
+	if !sharedSetNamePattern.MatchString(req.Name) {
+		LogAuditEvent(operation, nil, nil, serviceName, requestID, req.UserLogin, false, "Invalid engine name")
+		return &secretsservice.SetSecretEngineResponse{
+			Success: false,
+			Error:   "Invalid engine name",
+		}, nil
+	}
+
+	// 2. Get or create repository in database; engines may be configured before the first upload
+	repo, err := GetOrCreateRepository(
+		req.OwnerLogin,
+		req.RepoName,
+		targetRepo.Id,
+		targetRepo.FullName,
+		targetRepo.HtmlUrl,
+		targetRepo.Description,
+		targetRepo.Private,
+	)
+	if err != nil {
+		LogAuditEvent(operation, nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to get/create repository: "+err.Error())
+		return &secretsservice.SetSecretEngineResponse{
+			Success: false,
+			Error:   "Failed to get/create repository: " + err.Error(),
+		}, nil
+	}
+
+	// Engines change what a tag's downloads return, so protected tags refuse them like direct uploads; an
+	// existing engine's current tag counts too, as the change removes or moves it
+	existing, err := GetSecretEngine(repo.ID, req.Name)
+	if err == nil && !req.Delete {
+		err = checkTagWritable(repo.ID, req.Tag)
+	}
+	if err == nil && existing != nil {
+		err = checkTagWritable(repo.ID, existing.Tag)
+	}
+	if err != nil {
+		LogAuditEvent(operation, &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.SetSecretEngineResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 3. On delete, revoke every active lease first so no credential outlives its engine
+	if req.Delete {
+		engine := existing
+		if engine == nil {
+			err := fmt.Errorf("secret engine %s not found", req.Name)
+			LogAuditEvent(operation, &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.SetSecretEngineResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+
+		leases, err := ListActiveLeases(engine.ID)
+		if err != nil {
+			LogAuditEvent(operation, &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.SetSecretEngineResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+		for i := range leases {
+			if err := revokeLease(engine, &leases[i]); err != nil {
+				LogLeaseAuditEvent("LEASE_REVOKE", &leases[i], serviceName, requestID, req.UserLogin, false, err.Error())
+				LogAuditEvent(operation, &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to revoke lease "+leases[i].LeaseID+": "+err.Error())
+				return &secretsservice.SetSecretEngineResponse{
+					Success: false,
+					Error:   "Failed to revoke lease " + leases[i].LeaseID + ": " + err.Error(),
+				}, nil
+			}
+			LogLeaseAuditEvent("LEASE_REVOKE", &leases[i], serviceName, requestID, req.UserLogin, true, "")
+		}
+
+		if err := DeleteSecretEngine(engine.ID); err != nil {
+			LogAuditEvent(operation, &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.SetSecretEngineResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+
+		LogAuditEvent(operation, &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+		emitWebhookEvent(&repo.ID, engineWebhookPayload(webhookEventEngineRemoved, req, engine.Tag, requestID))
+		return &secretsservice.SetSecretEngineResponse{
+			Success: true,
+		}, nil
+	}
+
+	// 4. Validate the engine, its configuration and TTLs
+	implementation, ok := secretEngines[req.Engine]
+	if !ok {
+		LogAuditEvent(operation, &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Unsupported engine: "+req.Engine)
+		return &secretsservice.SetSecretEngineResponse{
+			Success: false,
+			Error:   "Unsupported engine: " + req.Engine,
+		}, nil
+	}
+	if err := implementation.validate(req.Config); err != nil {
+		LogAuditEvent(operation, &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.SetSecretEngineResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	defaultTTL, maxTTL := int(req.DefaultTtlSeconds), int(req.MaxTtlSeconds)
+	if defaultTTL == 0 {
+		defaultTTL = defaultLeaseTTL
+	}
+	if maxTTL == 0 {
+		maxTTL = max(defaultMaxTTL, defaultTTL)
+	}
+	if defaultTTL < minLeaseTTL || defaultTTL > maxTTL {
+		errMsg := fmt.Sprintf("Default TTL must be between %d seconds and the max TTL", minLeaseTTL)
+		LogAuditEvent(operation, &repo.ID, nil, serviceName, requestID, req.UserLogin, false, errMsg)
+		return &secretsservice.SetSecretEngineResponse{
+			Success: false,
+			Error:   errMsg,
+		}, nil
+	}
+
+	// 5. Store the engine with its configuration encrypted
+	if err := SetSecretEngine(repo.ID, req.Name, req.Engine, req.Tag, req.Config, defaultTTL, maxTTL, req.UserLogin); err != nil {
+		LogAuditEvent(operation, &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.SetSecretEngineResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 6. Log successful operation and notify webhooks, engines change what the tag downloads
+	LogAuditEvent(operation, &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+	emitWebhookEvent(&repo.ID, engineWebhookPayload(webhookEventEngineSet, req, req.Tag, requestID))
+
+	return &secretsservice.SetSecretEngineResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *Server) ListSecretEngines(ctx context.Context, req *secretsservice.ListSecretEnginesRequest) (*secretsservice.ListSecretEnginesResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Check if user has access to the repository
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("LIST_SECRET_ENGINES", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.ListSecretEnginesResponse{
+			Error: "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	if !HasRepoAccess(listResp.Repos, req.OwnerLogin, req.RepoName) {
+		LogAuditEvent("LIST_SECRET_ENGINES", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.ListSecretEnginesResponse{
+			Error: "No access to repository",
+		}, nil
+	}
+
+	// 2. Load the repository's engines; a repository without secrets yet has none
+	var repo Repository
+	if result := DB.Where("owner_login = ? AND repo_name = ?", req.OwnerLogin, req.RepoName).First(&repo); result.Error != nil {
+		LogAuditEvent("LIST_SECRET_ENGINES", nil, nil, serviceName, requestID, req.UserLogin, true, "")
+		return &secretsservice.ListSecretEnginesResponse{}, nil
+	}
+
+	engines, err := ListSecretEngines(repo.ID)
+	if err != nil {
+		LogAuditEvent("LIST_SECRET_ENGINES", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListSecretEnginesResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	// 3. Convert to proto format; only the keys are derived from the configuration, which stays server-side
+	var infos []*secretsservice.SecretEngineInfo
+	for i := range engines {
+		engine := &engines[i]
+		info := &secretsservice.SecretEngineInfo{
+			Name:              engine.Name,
+			Engine:            engine.Engine,
+			Tag:               engine.Tag,
+			DefaultTtlSeconds: int32(engine.DefaultTTL),
+			MaxTtlSeconds:     int32(engine.MaxTTL),
+			UpdatedBy:         engine.UpdatedBy,
+			UpdatedAt:         engine.UpdatedAt.Format(time.RFC3339),
+		}
+		if implementation, ok := secretEngines[engine.Engine]; ok {
+			if config, err := DecryptSecretEngineConfig(engine); err == nil {
+				info.Keys = implementation.keys(config)
+			}
+		}
+		leases, err := ListActiveLeases(engine.ID)
+		if err != nil {
+			LogAuditEvent("LIST_SECRET_ENGINES", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.ListSecretEnginesResponse{
+				Error: err.Error(),
+			}, nil
+		}
+		info.ActiveLeases = int32(len(leases))
+		infos = append(infos, info)
+	}
+
+	// 4. Log successful operation
+	LogAuditEvent("LIST_SECRET_ENGINES", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.ListSecretEnginesResponse{
+		Engines: infos,
+	}, nil
+}
+
+func (s *Server) ListAuditEvents(ctx context.Context, req *secretsservice.ListAuditEventsRequest) (*secretsservice.ListAuditEventsResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Get the repositories the user has access to, or check access to the requested one
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("LIST_AUDIT_EVENTS", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.ListAuditEventsResponse{
+			Error: "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	repos := listResp.Repos
+	if req.OwnerLogin != "" || req.RepoName != "" {
+		if !HasRepoAccess(listResp.Repos, req.OwnerLogin, req.RepoName) {
+			LogAuditEvent("LIST_AUDIT_EVENTS", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+			return &secretsservice.ListAuditEventsResponse{
+				Error: "No access to repository",
+			}, nil
+		}
+		repos = []*secretsservice.Repo{{OwnerLogin: req.OwnerLogin, Name: req.RepoName}}
+	}
+
+	accessible, err := accessibleRepositories(repos)
+	if err != nil {
+		LogAuditEvent("LIST_AUDIT_EVENTS", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListAuditEventsResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	// 2. Build the filter
+	filter := AuditLogFilter{
+		Username:  req.Username,
+		Operation: strings.ToUpper(req.Operation),
+		Success:   req.Success,
+	}
+	for id := range accessible {
+		filter.RepoIDs = append(filter.RepoIDs, id)
+	}
+	if filter.Since, err = parseAuditTime(req.Since); err == nil {
+		filter.Until, err = parseAuditTime(req.Until)
+	}
+	if err == nil && req.Cursor != "" {
+		filter.BeforeID, err = decodeAuditCursor(req.Cursor)
+	}
+	if err != nil {
+		LogAuditEvent("LIST_AUDIT_EVENTS", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListAuditEventsResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	pageSize := int(req.PageSize)
+	if pageSize <= 0 {
+		pageSize = defaultAuditPageSize
+	}
+	pageSize = min(pageSize, maxAuditPageSize)
+
+	// 3. Load one event more than the page holds to know whether another page follows
+	logs, err := ListAuditLogs(filter, pageSize+1)
+	if err != nil {
+		LogAuditEvent("LIST_AUDIT_EVENTS", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListAuditEventsResponse{
+			Error: err.Error(),
+		}, nil
+	}
+	var nextCursor string
+	if len(logs) > pageSize {
+		logs = logs[:pageSize]
+		nextCursor = encodeAuditCursor(logs[pageSize-1].ID)
+	}
+
+	secrets, err := auditedSecrets(logs)
+	if err != nil {
+		LogAuditEvent("LIST_AUDIT_EVENTS", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListAuditEventsResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	// 4. Convert to proto format
+	events := make([]*secretsservice.AuditEvent, len(logs))
+	for i, entry := range logs {
+		event := &secretsservice.AuditEvent{
+			Id:           uint64(entry.ID),
+			Operation:    entry.Operation,
+			Username:     entry.Username,
+			ServiceName:  entry.ServiceName,
+			RequestId:    entry.RequestID,
+			Success:      entry.Success,
+			ErrorMessage: entry.ErrorMessage,
+			LeaseId:      entry.LeaseID,
+			CreatedAt:    entry.CreatedAt.Format(time.RFC3339),
+		}
+		if repo, ok := accessible[*entry.RepoID]; ok {
+			event.OwnerLogin = repo.OwnerLogin
+			event.RepoName = repo.RepoName
+		}
+		if entry.SecretID != nil {
+			if secret, ok := secrets[*entry.SecretID]; ok {
+				event.Tag = secret.Tag
+				event.Version = int32(secret.Version)
+			}
+		}
+		events[i] = event
+	}
+
+	// 5. Log successful operation; on the repository when a single one was queried
+	var repoID *uint
+	if len(filter.RepoIDs) == 1 && req.OwnerLogin != "" {
+		repoID = &filter.RepoIDs[0]
+	}
+	LogAuditEvent("LIST_AUDIT_EVENTS", repoID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.ListAuditEventsResponse{
+		Events:     events,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+func (s *Server) VerifyAuditChain(ctx context.Context, req *secretsservice.VerifyAuditChainRequest) (*secretsservice.VerifyAuditChainResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Check the token is valid
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("VERIFY_AUDIT_CHAIN", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.VerifyAuditChainResponse{
+			Error: "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	// 2. The whole log spans every repository, so only audit admins may verify it
+	if !isAuditAdmin(req.UserLogin) {
+		LogAuditEvent("VERIFY_AUDIT_CHAIN", nil, nil, serviceName, requestID, req.UserLogin, false, "Only audit admins can verify the audit log")
+		return &secretsservice.VerifyAuditChainResponse{
+			Error: "Only audit admins can verify the audit log",
+		}, nil
+	}
+
+	// 3. Walk the chain
+	report, err := VerifyAuditChain()
+	if err != nil {
+		LogAuditEvent("VERIFY_AUDIT_CHAIN", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.VerifyAuditChainResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	// 4. Sign the verified head when asked
+	if report.Valid && req.Checkpoint {
+		if _, err := createAuditCheckpoint(); err != nil {
+			LogAuditEvent("VERIFY_AUDIT_CHAIN", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to create checkpoint: "+err.Error())
+			return &secretsservice.VerifyAuditChainResponse{
+				Error: "Failed to create checkpoint: " + err.Error(),
+			}, nil
+		}
+	}
+
+	// 5. Log the outcome; a broken chain is recorded as a failed verification
+	LogAuditEvent("VERIFY_AUDIT_CHAIN", nil, nil, serviceName, requestID, req.UserLogin, report.Valid, report.Reason)
+
+	return &secretsservice.VerifyAuditChainResponse{
+		Valid:               report.Valid,
+		CheckedEntries:      report.CheckedEntries,
+		LegacyEntries:       report.LegacyEntries,
+		FirstBrokenId:       uint64(report.FirstBrokenID),
+		Reason:              report.Reason,
+		CheckpointsVerified: int32(report.CheckpointsVerified),
+		LastCheckpointId:    uint64(report.LastCheckpointID),
+		CheckpointGapId:     uint64(report.CheckpointGapID),
+	}, nil
+}
+
+func (s *Server) CreateWebhook(ctx context.Context, req *secretsservice.CreateWebhookRequest) (*secretsservice.CreateWebhookResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Check the user may manage webhooks of the repository or organization
+	scope, err := s.authorizeWebhookScope(ctx, req.AccessToken, req.OwnerLogin, req.RepoName, req.OrgLogin)
+	if err != nil {
+		LogAuditEvent("CREATE_WEBHOOK", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.CreateWebhookResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 2. Validate the URL and events
+	if err := validateWebhookURL(req.Url); err != nil {
+		LogAuditEvent("CREATE_WEBHOOK", scope.repoID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.CreateWebhookResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	events, err := parseWebhookEvents(req.Events)
+	if err != nil {
+		LogAuditEvent("CREATE_WEBHOOK", scope.repoID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.CreateWebhookResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	secret, generated, err := webhookSecret(req.Secret)
+	if err != nil {
+		LogAuditEvent("CREATE_WEBHOOK", scope.repoID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.CreateWebhookResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 3. Store the webhook with its HMAC key encrypted
+	subscription := &WebhookSubscription{
+		RepoID:    scope.repoID,
+		URL:       req.Url,
+		Events:    events,
+		Active:    true,
+		CreatedBy: req.UserLogin,
+	}
+	if scope.repoID == nil {
+		subscription.OrgLogin = scope.ownerLogin
+	}
+	if err := CreateWebhookSubscription(subscription, secret); err != nil {
+		LogAuditEvent("CREATE_WEBHOOK", scope.repoID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.CreateWebhookResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 4. Log successful operation
+	LogAuditEvent("CREATE_WEBHOOK", scope.repoID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.CreateWebhookResponse{
+		Success:   true,
+		WebhookId: uint64(subscription.ID),
+		Secret:    generated,
+	}, nil
+}
+
+func (s *Server) ListWebhooks(ctx context.Context, req *secretsservice.ListWebhooksRequest) (*secretsservice.ListWebhooksResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Check the user may manage webhooks of the repository or organization
+	scope, err := s.authorizeWebhookScope(ctx, req.AccessToken, req.OwnerLogin, req.RepoName, req.OrgLogin)
+	if err != nil {
+		LogAuditEvent("LIST_WEBHOOKS", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListWebhooksResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	// 2. List the webhooks; their keys are never returned
+	subscriptions, err := ListWebhookSubscriptions(scope.repoID, scope.ownerLogin)
+	if err != nil {
+		LogAuditEvent("LIST_WEBHOOKS", scope.repoID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListWebhooksResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	webhooks := make([]*secretsservice.Webhook, len(subscriptions))
+	for i := range subscriptions {
+		webhooks[i] = webhookToProto(&subscriptions[i], scope)
+	}
+
+	// 3. Log successful operation
+	LogAuditEvent("LIST_WEBHOOKS", scope.repoID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.ListWebhooksResponse{
+		Webhooks: webhooks,
+	}, nil
+}
+
+func (s *Server) UpdateWebhook(ctx context.Context, req *secretsservice.UpdateWebhookRequest) (*secretsservice.UpdateWebhookResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Load the webhook and check the user may manage it
+	subscription, scope, err := s.authorizeWebhook(ctx, req.AccessToken, req.WebhookId)
+	if err != nil {
+		LogAuditEvent("UPDATE_WEBHOOK", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.UpdateWebhookResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 2. Apply and validate the changes; fields that were not sent keep their value
+	if req.Url != nil {
+		if err := validateWebhookURL(*req.Url); err != nil {
+			LogAuditEvent("UPDATE_WEBHOOK", scope.repoID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.UpdateWebhookResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+		subscription.URL = *req.Url
+	}
+	if len(req.Events) > 0 {
+		events, err := parseWebhookEvents(req.Events)
+		if err != nil {
+			LogAuditEvent("UPDATE_WEBHOOK", scope.repoID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.UpdateWebhookResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+		subscription.Events = events
+	}
+	if req.Active != nil {
+		subscription.Active = *req.Active
+	}
+
+	var secret, generated string
+	if req.RotateSecret {
+		secret, generated, err = webhookSecret(req.Secret)
+		if err != nil {
+			LogAuditEvent("UPDATE_WEBHOOK", scope.repoID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.UpdateWebhookResponse{
+				Success: false,
+				Error:   err.Error(),
+			}, nil
+		}
+	}
+
+	// 3. Store the webhook
+	if err := UpdateWebhookSubscription(subscription, secret); err != nil {
+		LogAuditEvent("UPDATE_WEBHOOK", scope.repoID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.UpdateWebhookResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 4. Log successful operation
+	LogAuditEvent("UPDATE_WEBHOOK", scope.repoID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.UpdateWebhookResponse{
+		Success: true,
+		Secret:  generated,
+	}, nil
+}
+
+func (s *Server) DeleteWebhook(ctx context.Context, req *secretsservice.DeleteWebhookRequest) (*secretsservice.DeleteWebhookResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Load the webhook and check the user may manage it
+	subscription, scope, err := s.authorizeWebhook(ctx, req.AccessToken, req.WebhookId)
+	if err != nil {
+		LogAuditEvent("DELETE_WEBHOOK", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.DeleteWebhookResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 2. Delete it with its delivery log and pending deliveries
+	if err := DeleteWebhookSubscription(subscription.ID); err != nil {
+		LogAuditEvent("DELETE_WEBHOOK", scope.repoID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.DeleteWebhookResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 3. Log successful operation
+	LogAuditEvent("DELETE_WEBHOOK", scope.repoID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.DeleteWebhookResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *Server) ListWebhookDeliveries(ctx context.Context, req *secretsservice.ListWebhookDeliveriesRequest) (*secretsservice.ListWebhookDeliveriesResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Load the webhook and check the user may manage it
+	subscription, scope, err := s.authorizeWebhook(ctx, req.AccessToken, req.WebhookId)
+	if err != nil {
+		LogAuditEvent("LIST_WEBHOOK_DELIVERIES", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListWebhookDeliveriesResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	// 2. List the newest deliveries
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultWebhookDeliveryPage
+	}
+	if limit > maxWebhookDeliveryPage {
+		limit = maxWebhookDeliveryPage
+	}
+	deliveries, err := ListWebhookDeliveries(subscription.ID, limit)
+	if err != nil {
+		LogAuditEvent("LIST_WEBHOOK_DELIVERIES", scope.repoID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListWebhookDeliveriesResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	converted := make([]*secretsservice.WebhookDelivery, len(deliveries))
+	for i := range deliveries {
+		converted[i] = webhookDeliveryToProto(&deliveries[i])
+	}
+
+	// 3. Log successful operation
+	LogAuditEvent("LIST_WEBHOOK_DELIVERIES", scope.repoID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.ListWebhookDeliveriesResponse{
+		Deliveries: converted,
+	}, nil
+}
+
+func (s *Server) RedeliverWebhook(ctx context.Context, req *secretsservice.RedeliverWebhookRequest) (*secretsservice.RedeliverWebhookResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Load the delivery and check the user may manage its webhook
+	delivery, err := GetWebhookDelivery(uint(req.DeliveryId))
+	if err == nil && delivery == nil {
+		err = fmt.Errorf("webhook delivery %d not found", req.DeliveryId)
+	}
+	if err != nil {
+		LogAuditEvent("REDELIVER_WEBHOOK", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.RedeliverWebhookResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	_, scope, err := s.authorizeWebhook(ctx, req.AccessToken, uint64(delivery.SubscriptionID))
+	if err != nil {
+		LogAuditEvent("REDELIVER_WEBHOOK", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.RedeliverWebhookResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 2. Queue the same payload again under a new delivery ID
+	redelivery, err := redeliverWebhook(delivery)
+	if err != nil {
+		LogAuditEvent("REDELIVER_WEBHOOK", scope.repoID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.RedeliverWebhookResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 3. Log successful operation
+	LogAuditEvent("REDELIVER_WEBHOOK", scope.repoID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.RedeliverWebhookResponse{
+		Success:    true,
+		DeliveryId: uint64(redelivery.ID),
+	}, nil
+}
+
+func (s *Server) SetTagProtection(ctx context.Context, req *secretsservice.SetTagProtectionRequest) (*secretsservice.SetTagProtectionResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Validate the request
+	ttlHours := int(req.RequestTtlHours)
+	if ttlHours == 0 {
+		ttlHours = defaultChangeRequestTTLHours
+	}
+	var errMsg string
+	switch {
+	case req.Tag == "":
+		errMsg = "Tag is required"
+	case ttlHours < 0 || ttlHours > maxChangeRequestTTLHours:
+		errMsg = fmt.Sprintf("Request TTL must be between 1 and %d hours", maxChangeRequestTTLHours)
+	}
+	if errMsg != "" {
+		LogAuditEvent("SET_TAG_PROTECTION", nil, nil, serviceName, requestID, req.UserLogin, false, errMsg)
+		return &secretsservice.SetTagProtectionResponse{
+			Success: false,
+			Error:   errMsg,
+		}, nil
+	}
+
+	// 2. Only repository admins decide which tags need a review
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("SET_TAG_PROTECTION", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.SetTagProtectionResponse{
+			Success: false,
+			Error:   "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	var targetRepo *secretsservice.Repo
+	for _, repo := range listResp.Repos {
+		if repo.OwnerLogin == req.OwnerLogin && repo.Name == req.RepoName {
+			targetRepo = repo
+			break
+		}
+	}
+	if targetRepo == nil {
+		LogAuditEvent("SET_TAG_PROTECTION", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.SetTagProtectionResponse{
+			Success: false,
+			Error:   "No access to repository",
+		}, nil
+	}
+
+	if !HasRepoPermission(listResp.Repos, req.OwnerLogin, req.RepoName, repoPermissionAdmin) {
+		LogAuditEvent("SET_TAG_PROTECTION", nil, nil, serviceName, requestID, req.UserLogin, false, "Only repository admins can change tag protection")
+		return &secretsservice.SetTagProtectionResponse{
+			Success: false,
+			Error:   "Only repository admins can change tag protection",
+		}, nil
+	}
+
+	// 3. Get or create repository in database
+	repo, err := GetOrCreateRepository(
+		req.OwnerLogin,
+		req.RepoName,
+		targetRepo.Id,
+		targetRepo.FullName,
+		targetRepo.HtmlUrl,
+		targetRepo.Description,
+		targetRepo.Private,
+	)
+	if err != nil {
+		LogAuditEvent("SET_TAG_PROTECTION", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to get/create repository: "+err.Error())
+		return &secretsservice.SetTagProtectionResponse{
+			Success: false,
+			Error:   "Failed to get/create repository: " + err.Error(),
+		}, nil
+	}
+
+	// 4. Protect the tag, or lift its protection
+	if req.Protected {
+		err = SetProtectedTag(repo.ID, req.Tag, ttlHours, req.UserLogin)
+	} else {
+		err = DeleteProtectedTag(repo.ID, req.Tag)
+	}
+	if err != nil {
+		LogAuditEvent("SET_TAG_PROTECTION", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.SetTagProtectionResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 5. Log successful operation
+	LogAuditEvent("SET_TAG_PROTECTION", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.SetTagProtectionResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *Server) ListChangeRequests(ctx context.Context, req *secretsservice.ListChangeRequestsRequest) (*secretsservice.ListChangeRequestsResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Check repository access
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("LIST_CHANGE_REQUESTS", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.ListChangeRequestsResponse{
+			Error: "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+	if !HasRepoAccess(listResp.Repos, req.OwnerLogin, req.RepoName) {
+		LogAuditEvent("LIST_CHANGE_REQUESTS", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.ListChangeRequestsResponse{
+			Error: "No access to repository",
+		}, nil
+	}
+
+	// 2. Validate the status filter
+	status := req.Status
+	switch status {
+	case "":
+		status = changeRequestPending
+	case "all":
+		status = ""
+	case changeRequestPending, changeRequestApproved, changeRequestRejected, changeRequestExpired:
+	default:
+		LogAuditEvent("LIST_CHANGE_REQUESTS", nil, nil, serviceName, requestID, req.UserLogin, false, "Invalid status: "+req.Status)
+		return &secretsservice.ListChangeRequestsResponse{
+			Error: "Invalid status: " + req.Status + " (expected pending, approved, rejected, expired or all)",
+		}, nil
+	}
+
+	// 3. Get repository from database; without one there is nothing to review
+	var repos []Repository
+	if result := DB.Where("owner_login = ? AND repo_name = ?", req.OwnerLogin, req.RepoName).Limit(1).Find(&repos); result.Error != nil || len(repos) == 0 {
+		LogAuditEvent("LIST_CHANGE_REQUESTS", nil, nil, serviceName, requestID, req.UserLogin, true, "")
+		return &secretsservice.ListChangeRequestsResponse{}, nil
+	}
+	repo := repos[0]
+
+	// 4. Expire overdue requests, then load the requests and protected tags
+	if err := ExpireChangeRequests(); err != nil {
+		log.Printf("Failed to expire change requests: %v", err)
+	}
+	requests, err := ListChangeRequests(repo.ID, status)
+	if err != nil {
+		LogAuditEvent("LIST_CHANGE_REQUESTS", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListChangeRequestsResponse{
+			Error: err.Error(),
+		}, nil
+	}
+	protected, err := ListProtectedTags(repo.ID)
+	if err != nil {
+		LogAuditEvent("LIST_CHANGE_REQUESTS", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListChangeRequestsResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	// 5. Convert to proto format
+	response := &secretsservice.ListChangeRequestsResponse{}
+	for i := range requests {
+		response.ChangeRequests = append(response.ChangeRequests, changeRequestToProto(&requests[i]))
+	}
+	for i := range protected {
+		response.ProtectedTags = append(response.ProtectedTags, protectedTagToProto(&protected[i]))
+	}
+
+	// 6. Log successful operation
+	LogAuditEvent("LIST_CHANGE_REQUESTS", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return response, nil
+}
+
+func (s *Server) ApproveChange(ctx context.Context, req *secretsservice.ApproveChangeRequest) (*secretsservice.ApproveChangeResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Load the change request and its repository
+	request, repo, repos, err := s.loadChangeRequest(ctx, req.AccessToken, req.ChangeId)
+	if err != nil {
+		LogAuditEvent("APPROVE_CHANGE", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ApproveChangeResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 2. Only another collaborator with write access may approve
+	var errMsg string
+	switch {
+	case !HasRepoPermission(repos, repo.OwnerLogin, repo.RepoName, repoPermissionWrite):
+		errMsg = "Approving requires write access to the repository"
+	case request.Status != changeRequestPending:
+		errMsg = fmt.Sprintf("Change request %d is %s", request.ID, request.Status)
+	case request.RequestedBy == req.UserLogin:
+		errMsg = "A change request must be approved by someone other than its author"
+	case len(req.Comment) > maxChangeCommentLength:
+		errMsg = fmt.Sprintf("Comment must be at most %d characters", maxChangeCommentLength)
+	}
+	if errMsg != "" {
+		LogAuditEvent("APPROVE_CHANGE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, errMsg)
+		return &secretsservice.ApproveChangeResponse{
+			Success: false,
+			Error:   errMsg,
+		}, nil
+	}
+
+	// 3. Claim the request and create the proposed version, or store the proposed parent, together
+	secret, err := approveChangeRequest(request, req.UserLogin, req.Comment)
+	if err != nil {
+		LogAuditEvent("APPROVE_CHANGE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to apply change request: "+err.Error())
+		return &secretsservice.ApproveChangeResponse{
+			Success: false,
+			Error:   "Failed to apply change request: " + err.Error(),
+		}, nil
+	}
+
+	// 4. Log successful operation and notify webhooks
+	if secret == nil {
+		LogAuditEvent("APPROVE_CHANGE", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+		emitWebhookEvent(&repo.ID, tagParentWebhookPayload(&secretsservice.SetTagParentRequest{
+			OwnerLogin:    repo.OwnerLogin,
+			RepoName:      repo.RepoName,
+			UserLogin:     req.UserLogin,
+			Tag:           request.Tag,
+			ParentTag:     request.ParentTag,
+			ParentVersion: int32(request.ParentVersion),
+		}, SecretScope{Path: request.Path, FileName: request.FileName}, requestID))
+		return &secretsservice.ApproveChangeResponse{Success: true}, nil
+	}
+
+	LogAuditEvent("APPROVE_CHANGE", &repo.ID, &secret.ID, serviceName, requestID, req.UserLogin, true, "")
+	emitWebhookEvent(&repo.ID, secretWebhookPayload(webhookEventSecretUploaded, repo.OwnerLogin, repo.RepoName, req.UserLogin, requestID, secret))
+	publishSecretEvent(secretVersionEvent(secret, req.UserLogin))
+
+	return &secretsservice.ApproveChangeResponse{
+		Success:  true,
+		Version:  int32(secret.Version),
+		Checksum: secret.Checksum,
+	}, nil
+}
+
+func (s *Server) RejectChange(ctx context.Context, req *secretsservice.RejectChangeRequest) (*secretsservice.RejectChangeResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Load the change request and its repository
+	request, repo, repos, err := s.loadChangeRequest(ctx, req.AccessToken, req.ChangeId)
+	if err != nil {
+		LogAuditEvent("REJECT_CHANGE", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.RejectChangeResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 2. Collaborators with write access reject, the author may withdraw their own request
+	var errMsg string
+	switch {
+	case !HasRepoPermission(repos, repo.OwnerLogin, repo.RepoName, repoPermissionWrite) && request.RequestedBy != req.UserLogin:
+		errMsg = "Rejecting requires write access to the repository"
+	case request.Status != changeRequestPending:
+		errMsg = fmt.Sprintf("Change request %d is %s", request.ID, request.Status)
+	case len(req.Comment) > maxChangeCommentLength:
+		errMsg = fmt.Sprintf("Comment must be at most %d characters", maxChangeCommentLength)
+	}
+	if errMsg != "" {
+		LogAuditEvent("REJECT_CHANGE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, errMsg)
+		return &secretsservice.RejectChangeResponse{
+			Success: false,
+			Error:   errMsg,
+		}, nil
+	}
+
+	// 3. Close the request and drop the proposed content
+	if err := ResolveChangeRequest(request, changeRequestRejected, req.UserLogin, req.Comment); err != nil {
+		LogAuditEvent("REJECT_CHANGE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.RejectChangeResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 4. Log successful operation
+	LogAuditEvent("REJECT_CHANGE", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.RejectChangeResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *Server) CommentChange(ctx context.Context, req *secretsservice.CommentChangeRequest) (*secretsservice.CommentChangeResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Load the change request and its repository
+	request, repo, repos, err := s.loadChangeRequest(ctx, req.AccessToken, req.ChangeId)
+	if err != nil {
+		LogAuditEvent("COMMENT_CHANGE", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.CommentChangeResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 2. Reviewers, who need write access, and the author discuss a request while it is pending
+	comment := strings.TrimSpace(req.Comment)
+	var errMsg string
+	switch {
+	case !HasRepoPermission(repos, repo.OwnerLogin, repo.RepoName, repoPermissionWrite) && request.RequestedBy != req.UserLogin:
+		errMsg = "Commenting requires write access to the repository"
+	case request.Status != changeRequestPending:
+		errMsg = fmt.Sprintf("Change request %d is %s", request.ID, request.Status)
+	case comment == "":
+		errMsg = "Comment is required"
+	case len(comment) > maxChangeCommentLength:
+		errMsg = fmt.Sprintf("Comment must be at most %d characters", maxChangeCommentLength)
+	}
+	if errMsg != "" {
+		LogAuditEvent("COMMENT_CHANGE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, errMsg)
+		return &secretsservice.CommentChangeResponse{
+			Success: false,
+			Error:   errMsg,
+		}, nil
+	}
+
+	// 3. Store the comment
+	if err := AddChangeRequestComment(request, req.UserLogin, comment); err != nil {
+		LogAuditEvent("COMMENT_CHANGE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.CommentChangeResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 4. Log successful operation
+	LogAuditEvent("COMMENT_CHANGE", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.CommentChangeResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *Server) CreateShareLink(ctx context.Context, req *secretsservice.CreateShareLinkRequest) (*secretsservice.CreateShareLinkResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Check if user has access to the repository
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("CREATE_SHARE_LINK", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.CreateShareLinkResponse{
+			Success: false,
+			Error:   "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	if !HasRepoAccess(listResp.Repos, req.OwnerLogin, req.RepoName) {
+		LogAuditEvent("CREATE_SHARE_LINK", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.CreateShareLinkResponse{
+			Success: false,
+			Error:   "No access to repository",
+		}, nil
+	}
+
+	if !HasRepoPermission(listResp.Repos, req.OwnerLogin, req.RepoName, downloadPermission()) {
+		LogAuditEvent("CREATE_SHARE_LINK", nil, nil, serviceName, requestID, req.UserLogin, false, downloadPermissionError())
+		return &secretsservice.CreateShareLinkResponse{
+			Success: false,
+			Error:   downloadPermissionError(),
+		}, nil
+	}
+
+	// 2. Get repository from database
+	var repo Repository
+	result := DB.Where("owner_login = ? AND repo_name = ?", req.OwnerLogin, req.RepoName).First(&repo)
+	if result.Error != nil {
+		LogAuditEvent("CREATE_SHARE_LINK", nil, nil, serviceName, requestID, req.UserLogin, false, "Repository not found in database")
+		return &secretsservice.CreateShareLinkResponse{
+			Success: false,
+			Error:   "Repository not found in database",
+		}, nil
+	}
+
+	// 3. Validate the limits
+	maxViews := int(req.MaxViews)
+	if maxViews == 0 {
+		maxViews = 1
+	}
+	ttl, err := shareLinkTTL(req.ExpiresInSeconds)
+	if err == nil && (maxViews < 0 || maxViews > maxShareLinkViews) {
+		err = fmt.Errorf("Max views must be between 1 and %d", maxShareLinkViews)
+	}
+	if err == nil && req.File && req.Key != "" {
+		err = fmt.Errorf("A file secret is shared whole, a key cannot be selected")
+	}
+	if err != nil {
+		LogAuditEvent("CREATE_SHARE_LINK", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.CreateShareLinkResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 4. Snapshot the key, version or file
+	link := &ShareLink{
+		RepoID:    repo.ID,
+		Key:       req.Key,
+		MaxViews:  maxViews,
+		ExpiresAt: time.Now().UTC().Add(ttl),
+		CreatedBy: req.UserLogin,
+	}
+	var content []byte
+	if req.File {
+		var fileName string
+		fileName, err = normalizeFileName(req.FileName)
+		var file *SecretFile
+		if err == nil {
+			file, err = GetSecretFile(repo.ID, fileName, req.Tag, int(req.Version))
+		}
+		if err == nil {
+			content, err = DecryptSecretFile(file)
+		}
+		if err == nil {
+			link.Kind, link.FileID, link.Tag, link.Version = shareLinkKindFile, &file.ID, file.Tag, file.Version
+			link.FileName, link.ContentType = file.FileName, file.ContentType
+		}
+	} else {
+		var scopePath string
+		scopePath, err = normalizePath(req.Path)
+		fileName := req.FileName
+		if err == nil && fileName != "" {
+			fileName, err = normalizeFileName(fileName)
+		}
+		var branch string
+		if err == nil {
+			branch, err = normalizeBranch(req.Branch)
+		}
+		var secret, overlay *Secret
+		if err == nil {
+			secret, overlay, content, err = s.snapshotSecret(repo.ID, SecretScope{Path: scopePath, FileName: fileName, Branch: branch}, req.Tag, int(req.Version), req.Key)
+		}
+		if err == nil {
+			link.Kind, link.SecretID, link.Tag, link.Version = shareLinkKindVersion, &secret.ID, secret.Tag, secret.Version
+			link.Path, link.FileName, link.ContentType = secret.Path, secret.FileName, "text/plain"
+			if overlay != nil {
+				link.Branch = overlay.Branch
+			}
+			if req.Key != "" {
+				link.Kind = shareLinkKindKey
+			}
+		}
+	}
+	if err != nil {
+		LogAuditEvent("CREATE_SHARE_LINK", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to snapshot secret: "+err.Error())
+		return &secretsservice.CreateShareLinkResponse{
+			Success: false,
+			Error:   "Failed to snapshot secret: " + err.Error(),
+		}, nil
+	}
+
+	// 5. Encrypt the snapshot under a fresh link key that is returned but never stored
+	linkID, linkKey, err := newShareLinkCredentials()
+	if err == nil {
+		link.LinkID = linkID
+		link.Data, err = sealShareLink(content, linkKey)
+	}
+	if err == nil {
+		err = CreateShareLink(link)
+	}
+	if err != nil {
+		LogAuditEvent("CREATE_SHARE_LINK", &repo.ID, link.SecretID, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.CreateShareLinkResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// Expired snapshots are wiped on the way, there is no separate sweeper
+	if err := PurgeShareLinks(); err != nil {
+		log.Printf("Failed to purge share links: %v", err)
+	}
+
+	// 6. Log successful operation
+	LogAuditEvent("CREATE_SHARE_LINK", &repo.ID, link.SecretID, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.CreateShareLinkResponse{
+		Success:   true,
+		LinkId:    link.LinkID,
+		Token:     shareLinkToken(link.LinkID, linkKey),
+		ExpiresAt: link.ExpiresAt.Format(time.RFC3339),
+		MaxViews:  int32(link.MaxViews),
+	}, nil
+}
+
+// RedeemShareLink is called without a GitHub identity: the token alone grants access, so audit events name
+// the link instead of a user
+func (s *Server) RedeemShareLink(ctx context.Context, req *secretsservice.RedeemShareLinkRequest) (*secretsservice.RedeemShareLinkResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Parse the token and load the link
+	linkID, linkKey, err := parseShareLinkToken(req.Token)
+	if err != nil {
+		LogAuditEvent("REDEEM_SHARE_LINK", nil, nil, serviceName, requestID, "share-link", false, err.Error())
+		return &secretsservice.RedeemShareLinkResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	actor := "share-link:" + linkID
+
+	link, err := GetShareLink(linkID)
+	if err == nil && link == nil {
+		err = fmt.Errorf("Share link not found")
+	}
+	if err != nil {
+		LogAuditEvent("REDEEM_SHARE_LINK", nil, nil, serviceName, requestID, actor, false, err.Error())
+		return &secretsservice.RedeemShareLinkResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 2. Refuse used up and expired links
+	var errMsg string
+	switch {
+	case link.Views >= link.MaxViews:
+		errMsg = "Share link was already used"
+	case !time.Now().Before(link.ExpiresAt):
+		errMsg = "Share link has expired"
+	}
+	if errMsg != "" {
+		LogAuditEvent("REDEEM_SHARE_LINK", &link.RepoID, link.SecretID, serviceName, requestID, actor, false, errMsg)
+		return &secretsservice.RedeemShareLinkResponse{
+			Success: false,
+			Error:   errMsg,
+		}, nil
+	}
+
+	// 3. Decrypt before counting the view, so a wrong key cannot burn the link
+	content, err := openShareLink(link, linkKey)
+	if err != nil {
+		LogAuditEvent("REDEEM_SHARE_LINK", &link.RepoID, link.SecretID, serviceName, requestID, actor, false, "Invalid share link key")
+		return &secretsservice.RedeemShareLinkResponse{
+			Success: false,
+			Error:   "Share link not found",
+		}, nil
+	}
+
+	// 4. Count the view; the last one wipes the snapshot
+	consumed, err := ConsumeShareLinkView(link)
+	if err == nil && !consumed {
+		err = fmt.Errorf("Share link was already used")
+	}
+	if err != nil {
+		LogAuditEvent("REDEEM_SHARE_LINK", &link.RepoID, link.SecretID, serviceName, requestID, actor, false, err.Error())
+		return &secretsservice.RedeemShareLinkResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 5. Log successful operation
+	LogAuditEvent("REDEEM_SHARE_LINK", &link.RepoID, link.SecretID, serviceName, requestID, actor, true, "")
+
+	return &secretsservice.RedeemShareLinkResponse{
+		Success:        true,
+		Kind:           link.Kind,
+		Key:            link.Key,
+		Content:        content,
+		FileName:       link.FileName,
+		ContentType:    link.ContentType,
+		SharedBy:       link.CreatedBy,
+		RemainingViews: int32(link.MaxViews - link.Views),
+		ExpiresAt:      link.ExpiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+func (s *Server) ListSecretKeys(ctx context.Context, req *secretsservice.ListSecretKeysRequest) (*secretsservice.ListSecretKeysResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Check repository access; read permission is enough as no value is revealed
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("LIST_SECRET_KEYS", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.ListSecretKeysResponse{
+			Error: "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	if !HasRepoPermission(listResp.Repos, req.OwnerLogin, req.RepoName, listKeysPermission) {
+		LogAuditEvent("LIST_SECRET_KEYS", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.ListSecretKeysResponse{
+			Error: "No access to repository",
+		}, nil
+	}
+
+	// 2. Get repository from database
+	var repo Repository
+	result := DB.Where("owner_login = ? AND repo_name = ?", req.OwnerLogin, req.RepoName).First(&repo)
+	if result.Error != nil {
+		LogAuditEvent("LIST_SECRET_KEYS", nil, nil, serviceName, requestID, req.UserLogin, false, "Repository not found in database")
+		return &secretsservice.ListSecretKeysResponse{
+			Error: "Repository not found in database",
+		}, nil
+	}
+
+	// 3. Describe the keys of the selected version
+	scopePath, err := normalizePath(req.Path)
+	if err != nil {
+		LogAuditEvent("LIST_SECRET_KEYS", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListSecretKeysResponse{
+			Error: err.Error(),
+		}, nil
+	}
+	if req.FileName != "" {
+		if req.FileName, err = normalizeFileName(req.FileName); err != nil {
+			LogAuditEvent("LIST_SECRET_KEYS", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.ListSecretKeysResponse{
+				Error: err.Error(),
+			}, nil
+		}
+	}
+	branch, err := normalizeBranch(req.Branch)
+	if err != nil {
+		LogAuditEvent("LIST_SECRET_KEYS", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListSecretKeysResponse{
+			Error: err.Error(),
+		}, nil
+	}
+	secret, overlay, keys, err := listSecretKeys(repo.ID, SecretScope{Path: scopePath, FileName: req.FileName, Branch: branch}, req.Tag, int(req.Version))
+	if err != nil {
+		LogAuditEvent("LIST_SECRET_KEYS", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to list keys: "+err.Error())
+		return &secretsservice.ListSecretKeysResponse{
+			Error: "Failed to list keys: " + err.Error(),
+		}, nil
+	}
+
+	// 4. Log successful operation
+	LogAuditEvent("LIST_SECRET_KEYS", &repo.ID, &secret.ID, serviceName, requestID, req.UserLogin, true, "")
+
+	response := &secretsservice.ListSecretKeysResponse{
+		Keys:    keys,
+		Tag:     secret.Tag,
+		Version: int32(secret.Version),
+	}
+	if overlay != nil {
+		response.Branch = overlay.Branch
+		response.BranchVersion = int32(overlay.Version)
+	}
+	return response, nil
+}
+
+func (s *Server) SearchSecretKeys(ctx context.Context, req *secretsservice.SearchSecretKeysRequest) (*secretsservice.SearchSecretKeysResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Validate the query
+	mode := req.Mode
+	if mode == "" {
+		mode = keySearchSubstring
+	}
+	if req.Query == "" || len(req.Query) > maxKeySearchQuery {
+		LogAuditEvent("SEARCH_SECRET_KEYS", nil, nil, serviceName, requestID, req.UserLogin, false, "Invalid query")
+		return &secretsservice.SearchSecretKeysResponse{
+			Error: fmt.Sprintf("Query must be between 1 and %d characters", maxKeySearchQuery),
+		}, nil
+	}
+	limit, err := keySearchLimit(req.Limit)
+	if err != nil {
+		LogAuditEvent("SEARCH_SECRET_KEYS", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.SearchSecretKeysResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	// 2. Get the repositories the user has access to; key names only need read permission, like ListSecretKeys
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("SEARCH_SECRET_KEYS", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.SearchSecretKeysResponse{
+			Error: "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+	var repos []*secretsservice.Repo
+	for _, repo := range listResp.Repos {
+		if repoPermissionRanks[repo.Permission] >= repoPermissionRanks[listKeysPermission] {
+			repos = append(repos, repo)
+		}
+	}
+
+	// 3. Search the key name index of the latest versions
+	names, err := searchSecretKeys(repos, req.Query, mode, limit)
+	if err != nil {
+		LogAuditEvent("SEARCH_SECRET_KEYS", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to search keys: "+err.Error())
+		return &secretsservice.SearchSecretKeysResponse{
+			Error: "Failed to search keys: " + err.Error(),
+		}, nil
+	}
+	truncated := len(names) > limit
+	if truncated {
+		names = names[:limit]
+	}
+
+	// 4. Convert to proto format
+	matches := make([]*secretsservice.SecretKeyMatch, len(names))
+	for i, name := range names {
+		matches[i] = &secretsservice.SecretKeyMatch{
+			OwnerLogin: name.OwnerLogin,
+			RepoName:   name.RepoName,
+			Path:       name.Path,
+			FileName:   name.FileName,
+			Branch:     name.Branch,
+			Tag:        name.Tag,
+			Version:    int32(name.Version),
+			Key:        name.Key,
+		}
+	}
+
+	// 5. Log successful operation
+	LogAuditEvent("SEARCH_SECRET_KEYS", nil, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.SearchSecretKeysResponse{
+		Matches:   matches,
+		Truncated: truncated,
+	}, nil
+}
+
+func (s *Server) ListFileVersions(ctx context.Context, req *secretsservice.ListFileVersionsRequest) (*secretsservice.ListFileVersionsResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	var fileName string
+	if req.FileName != "" {
+		var err error
+		fileName, err = normalizeFileName(req.FileName)
+		if err != nil {
+			LogAuditEvent("LIST_FILE_VERSIONS", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return &secretsservice.ListFileVersionsResponse{
+				Error: err.Error(),
+			}, nil
+		}
+	}
+
+	// 1. Check if user has access to the repository
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("LIST_FILE_VERSIONS", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.ListFileVersionsResponse{
+			Error: "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	if !HasRepoAccess(listResp.Repos, req.OwnerLogin, req.RepoName) {
+		LogAuditEvent("LIST_FILE_VERSIONS", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.ListFileVersionsResponse{
+			Error: "No access to repository",
+		}, nil
+	}
+
+	// 2. Get repository from database
+	var repo Repository
+	result := DB.Where("owner_login = ? AND repo_name = ?", req.OwnerLogin, req.RepoName).First(&repo)
+	if result.Error != nil {
+		LogAuditEvent("LIST_FILE_VERSIONS", nil, nil, serviceName, requestID, req.UserLogin, false, "Repository not found in database")
+		return &secretsservice.ListFileVersionsResponse{
+			Error: "Repository not found in database",
+		}, nil
+	}
+
+	// 3. List the file versions, without their content
+	files, err := ListSecretFileVersions(repo.ID, fileName)
+	if err != nil {
+		LogAuditEvent("LIST_FILE_VERSIONS", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.ListFileVersionsResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	versions := make([]*secretsservice.FileVersion, len(files))
+	for i, file := range files {
+		versions[i] = &secretsservice.FileVersion{
+			FileName:    file.FileName,
+			Tag:         file.Tag,
+			Version:     int32(file.Version),
+			ContentType: file.ContentType,
+			Size:        file.Size,
+			Checksum:    file.Checksum,
+			UploadedBy:  file.UploadedBy,
+			CreatedAt:   file.CreatedAt.Format(time.RFC3339),
+		}
+	}
+
+	// 4. Log successful operation
+	LogAuditEvent("LIST_FILE_VERSIONS", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+
+	return &secretsservice.ListFileVersionsResponse{
+		Versions: versions,
+	}, nil
+}
+
+func (s *Server) DeleteFile(ctx context.Context, req *secretsservice.DeleteFileRequest) (*secretsservice.DeleteFileResponse, error) {
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	fileName, err := normalizeFileName(req.FileName)
+	if err != nil {
+		LogAuditEvent("DELETE_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.DeleteFileResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	if req.Version != nil && req.Tag == nil {
+		LogAuditEvent("DELETE_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, "Version requires a tag")
+		return &secretsservice.DeleteFileResponse{
+			Success: false,
+			Error:   "Deleting a file version requires its tag, versions are numbered per tag",
+		}, nil
+	}
+
+	// 1. Check if user has access to the repository
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("DELETE_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return &secretsservice.DeleteFileResponse{
+			Success: false,
+			Error:   "Failed to list repos: " + listResp.Error,
+		}, nil
+	}
+
+	if !HasRepoAccess(listResp.Repos, req.OwnerLogin, req.RepoName) {
+		LogAuditEvent("DELETE_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, "No access to repository")
+		return &secretsservice.DeleteFileResponse{
+			Success: false,
+			Error:   "No access to repository",
+		}, nil
+	}
+
+	if !HasRepoPermission(listResp.Repos, req.OwnerLogin, req.RepoName, writePermission()) {
+		LogAuditEvent("DELETE_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, writePermissionError())
+		return &secretsservice.DeleteFileResponse{
+			Success: false,
+			Error:   writePermissionError(),
+		}, nil
+	}
+
+	// 2. Get repository from database
+	var repo Repository
+	result := DB.Where("owner_login = ? AND repo_name = ?", req.OwnerLogin, req.RepoName).First(&repo)
+	if result.Error != nil {
+		LogAuditEvent("DELETE_FILE", nil, nil, serviceName, requestID, req.UserLogin, false, "Repository not found in database")
+		return &secretsservice.DeleteFileResponse{
+			Success: false,
+			Error:   "Repository not found in database",
+		}, nil
+	}
+
+	if path.Dir(fileName) != "." && codeownersEnforced() {
+		if err := checkCodeowners(ctx, req.AccessToken, req.OwnerLogin, req.RepoName, req.UserLogin, fileName); err != nil {
+			LogAuditEvent("DELETE_FILE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "CODEOWNERS check failed: "+err.Error())
+			return &secretsservice.DeleteFileResponse{
+				Success: false,
+				Error:   "CODEOWNERS check failed: " + err.Error(),
+			}, nil
+		}
+	}
+
+	// Without a tag every tag is deleted, so any protected tag refuses it
+	if err := checkTagWritable(repo.ID, req.GetTag()); err != nil {
+		LogAuditEvent("DELETE_FILE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+		return &secretsservice.DeleteFileResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	// 3. Delete the file versions
+	deleted, err := DeleteSecretFiles(repo.ID, fileName, req.Tag, int(req.GetVersion()))
+	if err != nil {
+		LogAuditEvent("DELETE_FILE", &repo.ID, nil, serviceName, requestID, req.UserLogin, false, "Failed to delete file(s): "+err.Error())
+		return &secretsservice.DeleteFileResponse{
+			Success: false,
+			Error:   "Failed to delete file(s): " + err.Error(),
+		}, nil
+	}
+
+	// 4. Log successful operation and notify webhooks
+	LogAuditEvent("DELETE_FILE", &repo.ID, nil, serviceName, requestID, req.UserLogin, true, "")
+	payload := newWebhookPayload(webhookEventFileDeleted, req.OwnerLogin, req.RepoName, req.UserLogin, requestID)
+	payload.Tag = req.GetTag()
+	payload.Version = int(req.GetVersion())
+	payload.FileName = fileName
+	payload.DeletedVersions = deleted
+	emitWebhookEvent(&repo.ID, payload)
+	if deleted > 0 {
+		publishSecretEvent(&SecretEvent{
+			RepoID:          repo.ID,
+			Type:            secretEventVersionsDeleted,
+			Tag:             req.GetTag(),
+			Version:         int(req.GetVersion()),
+			FileName:        fileName,
+			File:            true,
+			DeletedVersions: deleted,
+			Actor:           req.UserLogin,
+		})
+	}
+
+	return &secretsservice.DeleteFileResponse{
+		Success:         true,
+		DeletedVersions: int32(deleted),
+	}, nil
+}
+
+func RunGRPCServer() {
+	// Initialize database
+	if err := InitDatabase(); err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	// Revoke dynamic credentials once their lease expires
+	go runLeaseReaper(leaseReaperInterval())
+	// Sign the head of the audit chain so rewriting it is detectable
+	go runAuditCheckpointer(auditCheckpointInterval())
+	// Forward audit events to the sinks in AUDIT_SINKS
+	startAuditExport()
+	// Send queued webhook deliveries
+	go runWebhookDispatcher(webhookPollInterval())
+	// Feed WatchSecrets streams from every replica's changes and expire old resume tokens
+	startSecretEventListener()
+	go runSecretEventPruner(secretEventRetention())
+	// Index the key names of versions stored before SearchSecretKeys existed
+	go backfillSecretKeyNames()
+
+	lis, err := net.Listen("tcp", ":50053")
+	if err != nil {
+		log.Fatalf("failed to listen: %v", err)
+	}
+	// Leave headroom above the file size limit for the rest of the request
+	grpcServer := grpc.NewServer(grpc.MaxRecvMsgSize(int(maxSecretFileSize()) + 1024*1024))
+	secretsservice.RegisterSecretsServiceServer(grpcServer, NewServer())
+	log.Println("gRPC SecretsService server listening on :50053")
+	if err := grpcServer.Serve(lis); err != nil {
+		log.Fatalf("failed to serve: %v", err)
+	}
+}
+
+func HasRepoAccess(repos []*secretsservice.Repo, ownerLogin, name string) bool {
+	for _, repo := range repos {
+		if repo.OwnerLogin == ownerLogin && repo.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper functions
+
+func (s *Server) getAuditInfo(ctx context.Context) (serviceName, requestID string) {
+	// Extract service name and request ID from context
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		if serviceNames := md.Get("service-name"); len(serviceNames) > 0 {
+			serviceName = serviceNames[0]
+		}
+		if requestIDs := md.Get("request-id"); len(requestIDs) > 0 {
+			requestID = requestIDs[0]
+		}
+	}
+
+	// Set defaults if not provided
+	if serviceName == "" {
+		serviceName = "BackendGate" // Default service name
+	}
+	if requestID == "" {
+		requestID = generateRequestID() // Generate a unique request ID
 	}
 
 	return serviceName, requestID
@@ -579,16 +4066,99 @@ func (s *Server) parseEnvFile(content []byte) (map[string]string, error) {
 	return envData, nil
 }
 
+const defaultMaxSecretFileSize = 1024 * 1024 // 1 MiB
+
+// maxSecretFileSize reads MAX_SECRET_FILE_SIZE (bytes), falling back to 1 MiB
+func maxSecretFileSize() int64 {
+	if value := os.Getenv("MAX_SECRET_FILE_SIZE"); value != "" {
+		if size, err := strconv.ParseInt(value, 10, 64); err == nil && size > 0 {
+			return size
+		}
+		log.Printf("Invalid MAX_SECRET_FILE_SIZE %q, using default", value)
+	}
+	return defaultMaxSecretFileSize
+}
+
+// normalizeFileName cleans a relative file name and rejects absolute or escaping paths
+func normalizeFileName(fileName string) (string, error) {
+	fileName = strings.TrimSpace(strings.ReplaceAll(fileName, "\\", "/"))
+	if fileName == "" {
+		return "", fmt.Errorf("file name is required")
+	}
+
+	cleaned := path.Clean(fileName)
+	if path.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("invalid file name: %s", fileName)
+	}
+	if len(cleaned) > 500 {
+		return "", fmt.Errorf("file name is too long")
+	}
+	return cleaned, nil
+}
+
+// normalizePath cleans a monorepo path scope; "", "." and "/" all mean the repository root
+func normalizePath(scopePath string) (string, error) {
+	scopePath = strings.TrimSpace(strings.ReplaceAll(scopePath, "\\", "/"))
+	if scopePath == "" {
+		return "", nil
+	}
+
+	// Paths are always relative to the repository root, so a leading slash is accepted
+	cleaned := strings.TrimPrefix(path.Clean("/"+scopePath), "/")
+	if cleaned == "" {
+		return "", nil
+	}
+	if strings.HasPrefix(path.Clean(scopePath), "../") || path.Clean(scopePath) == ".." {
+		return "", fmt.Errorf("invalid path: %s", scopePath)
+	}
+	if len(cleaned) > 500 {
+		return "", fmt.Errorf("path is too long")
+	}
+	return cleaned, nil
+}
+
+// normalizeBranch validates a git branch name; refs/heads/ prefixes are accepted
+func normalizeBranch(branch string) (string, error) {
+	branch = strings.TrimPrefix(strings.TrimSpace(branch), "refs/heads/")
+	if branch == "" {
+		return "", nil
+	}
+
+	if len(branch) > 255 || strings.HasPrefix(branch, "-") || strings.HasPrefix(branch, "/") ||
+		strings.HasSuffix(branch, "/") || strings.HasSuffix(branch, ".lock") || strings.Contains(branch, "..") ||
+		strings.ContainsAny(branch, " ~^:?*[\\") {
+		return "", fmt.Errorf("invalid branch name: %s", branch)
+	}
+	for _, r := range branch {
+		if r < 0x20 || r == 0x7f {
+			return "", fmt.Errorf("invalid branch name: %s", branch)
+		}
+	}
+	return branch, nil
+}
+
+// resolveContentType validates a client supplied MIME type or sniffs one from the content
+func resolveContentType(contentType string, content []byte) (string, error) {
+	if contentType == "" {
+		return http.DetectContentType(content), nil
+	}
+	if _, _, err := mime.ParseMediaType(contentType); err != nil {
+		return "", fmt.Errorf("invalid content type: %s", contentType)
+	}
+	return contentType, nil
+}
+
 func (s *Server) calculateChecksum(content []byte) string {
 	hash := sha256.Sum256(content)
 	return hex.EncodeToString(hash[:])
 }
 
+// convertToEnvFormat renders KEY="value" lines in key order, so equal data always gives equal bytes
 func (s *Server) convertToEnvFormat(envData map[string]string) string {
 	var lines []string
-	for key, value := range envData {
+	for _, key := range sortedKeys(envData) {
 		// Escape special characters in value
-		escapedValue := strings.ReplaceAll(value, "\"", "\\\"")
+		escapedValue := strings.ReplaceAll(envData[key], "\"", "\\\"")
 		lines = append(lines, fmt.Sprintf("%s=\"%s\"", key, escapedValue))
 	}
 	return strings.Join(lines, "\n")
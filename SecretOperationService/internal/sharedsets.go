@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+)
+
+// Organization roles returned by the GitHub memberships API
+const (
+	orgRoleAdmin  = "admin"
+	orgRoleMember = "member"
+)
+
+var sharedSetNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+
+// sharedLayer is a shared secret set attached to a repository, decrypted for a download
+type sharedLayer struct {
+	attachment SharedSecretSetAttachmentInfo
+	version    *SharedSecretSetVersion
+	data       map[string]string
+}
+
+// label names the set as org/name
+func (l *sharedLayer) label() string {
+	return l.attachment.OrgLogin + "/" + l.attachment.SetName
+}
+
+// getOrgRole returns the authenticated user's role in an organization, or "" when they are not an active member
+func getOrgRole(ctx context.Context, accessToken, orgLogin string) (string, error) {
+	client := &http.Client{}
+
+	url := fmt.Sprintf("%s/user/memberships/orgs/%s", os.Getenv("GITHUB_API_URL"), orgLogin)
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+
+	r.Header.Add("Authorization", "Bearer "+accessToken)
+	r.Header.Add("Accept", "application/vnd.github+json")
+	r.Header.Add("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := client.Do(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to check organization membership: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+
+	var membership struct {
+		State string `json:"state"`
+		Role  string `json:"role"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&membership); err != nil {
+		return "", fmt.Errorf("failed to decode membership: %v", err)
+	}
+
+	if membership.State != "active" {
+		return "", nil
+	}
+	return membership.Role, nil
+}
+
+// loadSharedLayers decrypts the shared secret sets attached to a repository, in the order they were attached
+func loadSharedLayers(repoID uint) ([]sharedLayer, error) {
+	attachments, err := ListSharedSecretSetAttachments("", repoID)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := make([]sharedLayer, len(attachments))
+	for i, attachment := range attachments {
+		setVersion, err := GetSharedSecretSetVersion(attachment.SetID, attachment.PinnedVersion)
+		if err != nil {
+			return nil, fmt.Errorf("shared secret set %s/%s: %v", attachment.OrgLogin, attachment.SetName, err)
+		}
+
+		decryptedData, err := DecryptSharedSecretSetVersion(setVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt shared secret set %s/%s: %v", attachment.OrgLogin, attachment.SetName, err)
+		}
+
+		var data map[string]string
+		if err := json.Unmarshal([]byte(decryptedData), &data); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal shared secret set %s/%s: %v", attachment.OrgLogin, attachment.SetName, err)
+		}
+
+		layers[i] = sharedLayer{attachment: attachment, version: setVersion, data: data}
+	}
+	return layers, nil
+}
+
+// mergeSharedLayers adds the shared keys the repository does not set itself. Between shared sets,
+// later attachments override earlier ones. It returns the layer each added key came from.
+func mergeSharedLayers(envData map[string]string, layers []sharedLayer) map[string]*sharedLayer {
+	origins := make(map[string]*sharedLayer)
+	for i := range layers {
+		for key := range layers[i].data {
+			if _, own := envData[key]; !own {
+				origins[key] = &layers[i]
+			}
+		}
+	}
+
+	for key, layer := range origins {
+		envData[key] = layer.data[key]
+	}
+	return origins
+}
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// What a share link hands out
+const (
+	shareLinkKindKey     = "key"
+	shareLinkKindVersion = "version"
+	shareLinkKindFile    = "file"
+)
+
+// Limits of share links
+const (
+	defaultShareLinkTTL = 24 * time.Hour
+	maxShareLinkTTL     = 7 * 24 * time.Hour
+	maxShareLinkViews   = 100
+)
+
+// newShareLinkCredentials returns a random public link id and the key the snapshot is encrypted under
+func newShareLinkCredentials() (string, []byte, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", nil, fmt.Errorf("failed to generate link id: %v", err)
+	}
+	key, err := generateSecretKey()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate link key: %v", err)
+	}
+	return hex.EncodeToString(id), key, nil
+}
+
+// shareLinkToken joins the link id and key into the token handed to the recipient
+func shareLinkToken(linkID string, key []byte) string {
+	return linkID + "." + base64.RawURLEncoding.EncodeToString(key)
+}
+
+// parseShareLinkToken splits a token made by shareLinkToken
+func parseShareLinkToken(token string) (string, []byte, error) {
+	linkID, encodedKey, found := strings.Cut(strings.TrimSpace(token), ".")
+	if !found || linkID == "" {
+		return "", nil, fmt.Errorf("malformed share link token")
+	}
+	key, err := base64.RawURLEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) != 32 {
+		return "", nil, fmt.Errorf("malformed share link token")
+	}
+	return linkID, key, nil
+}
+
+// sealShareLink encrypts a snapshot under the link key; unlike sealWithNewKey the key is not stored
+func sealShareLink(content []byte, key []byte) (string, error) {
+	encrypted, err := encryptData(content, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt snapshot: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// openShareLink decrypts a link's snapshot; it fails when the key does not belong to the link
+func openShareLink(link *ShareLink, key []byte) ([]byte, error) {
+	encrypted, err := base64.StdEncoding.DecodeString(link.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %v", err)
+	}
+	return decryptData(encrypted, key)
+}
+
+// snapshotSecret selects a version like DownloadSecret, with the overlay of scope.Branch, and returns its
+// keys merged with the inherited tags, rendered as dotenv, or the value of a single key, together with the
+// overlay applied. Shared sets, references and engines are not resolved: a link hands out what is stored,
+// not credentials issued for someone else.
+func (s *Server) snapshotSecret(repoID uint, scope SecretScope, tag string, version int, key string) (*Secret, *Secret, []byte, error) {
+	secret, overlay, chain, err := resolveSecretChain(repoID, scope, scope.Branch, tag, version)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	layers, err := loadLayers(chain)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	envData, _ := mergeLayers(layers)
+
+	if key == "" {
+		return secret, overlay, []byte(s.convertToEnvFormat(envData)), nil
+	}
+	value, ok := envData[key]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("key %s not found in %s version %d", key, secret.Tag, secret.Version)
+	}
+	return secret, overlay, []byte(value), nil
+}
+
+// shareLinkTTL validates the requested lifetime of a link, 0 meaning the default
+func shareLinkTTL(seconds int64) (time.Duration, error) {
+	if seconds == 0 {
+		return defaultShareLinkTTL, nil
+	}
+	ttl := time.Duration(seconds) * time.Second
+	if seconds < 0 || ttl > maxShareLinkTTL {
+		return 0, fmt.Errorf("Expiry must be between 1 second and %s", maxShareLinkTTL)
+	}
+	return ttl, nil
+}
@@ -0,0 +1,420 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	secretsservice "github.com/kurs0n/SecretOperationService/proto"
+	"google.golang.org/grpc"
+)
+
+// Secret event types, and the stream-only events WatchSecrets sends besides them
+const (
+	secretEventVersionCreated  = "version_created"
+	secretEventVersionsDeleted = "versions_deleted"
+
+	watchEventReady     = "ready"
+	watchEventHeartbeat = "heartbeat"
+	watchEventLagged    = "lagged" // The stream ends; resuming replays what was missed from the database
+	watchEventError     = "error"
+)
+
+// Streaming and retention of secret events
+const (
+	watchReplayPageSize         = 500
+	watchWatcherBuffer          = 256
+	watchHeartbeatInterval      = 30 * time.Second
+	watchAccessRecheckInterval  = 10 * time.Minute
+	secretEventListenerRetry    = time.Second
+	secretEventListenerMaxRetry = time.Minute
+	defaultSecretEventRetention = 24 * time.Hour
+	secretEventPruneInterval    = time.Hour
+)
+
+// secretWatcher is one WatchSecrets stream registered with the hub
+type secretWatcher struct {
+	repoIDs map[uint]bool
+	tags    map[string]bool // Empty for every tag
+	events  chan *SecretEvent
+	lagged  chan struct{} // Closed when the hub dropped the watcher because its buffer was full
+}
+
+func (w *secretWatcher) matches(event *SecretEvent) bool {
+	if !w.repoIDs[event.RepoID] {
+		return false
+	}
+	return len(w.tags) == 0 || event.Tag == "" || w.tags[event.Tag]
+}
+
+// secretEventHub fans the events announced on secretEventChannel out to the watchers of this replica
+type secretEventHub struct {
+	mu       sync.Mutex
+	watchers map[*secretWatcher]struct{}
+	lastID   uint // Newest event broadcast, to catch up after the listener reconnects
+}
+
+var secretEvents = &secretEventHub{watchers: make(map[*secretWatcher]struct{})}
+
+func (h *secretEventHub) subscribe(watcher *secretWatcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.watchers[watcher] = struct{}{}
+}
+
+func (h *secretEventHub) unsubscribe(watcher *secretWatcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.watchers, watcher)
+}
+
+// broadcast never blocks: a watcher that cannot keep up is dropped and resumes from the database
+func (h *secretEventHub) broadcast(event *SecretEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if event.ID > h.lastID {
+		h.lastID = event.ID
+	}
+	for watcher := range h.watchers {
+		if !watcher.matches(event) {
+			continue
+		}
+		select {
+		case watcher.events <- event:
+		default:
+			delete(h.watchers, watcher)
+			close(watcher.lagged)
+		}
+	}
+}
+
+// catchUp broadcasts the events stored while the listener was not connected
+func (h *secretEventHub) catchUp() error {
+	h.mu.Lock()
+	lastID := h.lastID
+	h.mu.Unlock()
+
+	for {
+		events, err := ListSecretEvents(nil, nil, lastID, watchReplayPageSize)
+		if err != nil {
+			return err
+		}
+		for i := range events {
+			h.broadcast(&events[i])
+			lastID = events[i].ID
+		}
+		if len(events) < watchReplayPageSize {
+			return nil
+		}
+	}
+}
+
+// startSecretEventListener keeps a dedicated connection listening on secretEventChannel, so watchers of
+// this replica see versions created through any replica
+func startSecretEventListener() {
+	// Only events stored from now on are caught up after a reconnect
+	latest, err := LatestSecretEventID()
+	if err != nil {
+		log.Printf("Secret event listener: %v", err)
+	}
+	secretEvents.lastID = latest
+
+	go runSecretEventListener()
+}
+
+func runSecretEventListener() {
+	retry := secretEventListenerRetry
+	for {
+		connected, err := listenForSecretEvents(context.Background())
+		if connected {
+			retry = secretEventListenerRetry
+		}
+		log.Printf("Secret event listener: %v, reconnecting in %s", err, retry)
+		time.Sleep(retry)
+		if retry *= 2; retry > secretEventListenerMaxRetry {
+			retry = secretEventListenerMaxRetry
+		}
+	}
+}
+
+func listenForSecretEvents(ctx context.Context) (bool, error) {
+	conn, err := pgx.Connect(ctx, databaseDSN())
+	if err != nil {
+		return false, fmt.Errorf("failed to connect: %v", err)
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, "LISTEN "+secretEventChannel); err != nil {
+		return false, fmt.Errorf("failed to listen: %v", err)
+	}
+	// Events committed while disconnected were announced to nobody
+	if err := secretEvents.catchUp(); err != nil {
+		return true, err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return true, err
+		}
+		var event SecretEvent
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			log.Printf("Secret event listener: ignoring malformed notification: %v", err)
+			continue
+		}
+		secretEvents.broadcast(&event)
+	}
+}
+
+// secretEventRetention reads WATCH_EVENT_RETENTION, how long events can be replayed with a resume token
+func secretEventRetention() time.Duration {
+	if value := os.Getenv("WATCH_EVENT_RETENTION"); value != "" {
+		if retention, err := time.ParseDuration(value); err == nil && retention > 0 {
+			return retention
+		}
+		log.Printf("Invalid WATCH_EVENT_RETENTION %q, using default", value)
+	}
+	return defaultSecretEventRetention
+}
+
+// runSecretEventPruner deletes events older than retention until the process exits
+func runSecretEventPruner(retention time.Duration) {
+	ticker := time.NewTicker(secretEventPruneInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		pruned, err := DeleteSecretEventsBefore(time.Now().Add(-retention))
+		if err != nil {
+			log.Printf("Secret events: %v", err)
+			continue
+		}
+		if pruned > 0 {
+			log.Printf("Secret events: pruned %d events older than %s", pruned, retention)
+		}
+	}
+}
+
+// publishSecretEvent stores and announces an event. The operation already succeeded, so failures are only
+// logged.
+func publishSecretEvent(event *SecretEvent) {
+	if err := CreateSecretEvent(event); err != nil {
+		log.Printf("Secret events: %v", err)
+	}
+}
+
+// secretVersionEvent describes a newly stored secret version
+func secretVersionEvent(secret *Secret, actor string) *SecretEvent {
+	return &SecretEvent{
+		RepoID:   secret.RepoID,
+		Type:     secretEventVersionCreated,
+		Tag:      secret.Tag,
+		Version:  secret.Version,
+		Path:     secret.Path,
+		FileName: secret.FileName,
+		Branch:   secret.Branch,
+		Checksum: secret.Checksum,
+		Actor:    actor,
+	}
+}
+
+// formatResumeToken and parseResumeToken convert event IDs; clients treat tokens as opaque
+func formatResumeToken(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func parseResumeToken(token string) (uint, error) {
+	id, err := strconv.ParseUint(token, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid resume token")
+	}
+	return uint(id), nil
+}
+
+func watchErrorEvent(message string) *secretsservice.WatchSecretsEvent {
+	return &secretsservice.WatchSecretsEvent{Type: watchEventError, Error: message}
+}
+
+func secretEventToProto(event *SecretEvent, repo *secretsservice.WatchedRepository) *secretsservice.WatchSecretsEvent {
+	return &secretsservice.WatchSecretsEvent{
+		Type:            event.Type,
+		ResumeToken:     formatResumeToken(event.ID),
+		OwnerLogin:      repo.OwnerLogin,
+		RepoName:        repo.RepoName,
+		Tag:             event.Tag,
+		Version:         int32(event.Version),
+		Path:            event.Path,
+		FileName:        event.FileName,
+		Branch:          event.Branch,
+		File:            event.File,
+		Checksum:        event.Checksum,
+		DeletedVersions: int32(event.DeletedVersions),
+		Actor:           event.Actor,
+		CreatedAt:       event.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// WatchSecrets streams new and deleted versions of the watched repositories. Events after the resume token
+// are replayed from the database first, then a ready event marks the switch to live events. Errors are
+// sent as an error event that ends the stream.
+func (s *Server) WatchSecrets(req *secretsservice.WatchSecretsRequest, stream grpc.ServerStreamingServer[secretsservice.WatchSecretsEvent]) error {
+	ctx := stream.Context()
+	serviceName, requestID := s.getAuditInfo(ctx)
+
+	// 1. Validate the request
+	if len(req.Repositories) == 0 {
+		LogAuditEvent("WATCH_SECRETS", nil, nil, serviceName, requestID, req.UserLogin, false, "No repositories to watch")
+		return stream.Send(watchErrorEvent("No repositories to watch"))
+	}
+	var afterID uint
+	if req.ResumeToken != "" {
+		var err error
+		if afterID, err = parseResumeToken(req.ResumeToken); err != nil {
+			LogAuditEvent("WATCH_SECRETS", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return stream.Send(watchErrorEvent(err.Error()))
+		}
+	}
+
+	// 2. Check access to every watched repository
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+	if err != nil || listResp.Error != "" {
+		LogAuditEvent("WATCH_SECRETS", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to list repos: "+listResp.Error)
+		return stream.Send(watchErrorEvent("Failed to list repos: " + listResp.Error))
+	}
+	repos := make(map[uint]*secretsservice.WatchedRepository)
+	for _, watched := range req.Repositories {
+		var targetRepo *secretsservice.Repo
+		for _, repo := range listResp.Repos {
+			if repo.OwnerLogin == watched.OwnerLogin && repo.Name == watched.RepoName {
+				targetRepo = repo
+				break
+			}
+		}
+		if targetRepo == nil {
+			message := fmt.Sprintf("No access to repository %s/%s", watched.OwnerLogin, watched.RepoName)
+			LogAuditEvent("WATCH_SECRETS", nil, nil, serviceName, requestID, req.UserLogin, false, message)
+			return stream.Send(watchErrorEvent(message))
+		}
+
+		// Repositories may be watched before their first upload
+		repo, err := GetOrCreateRepository(watched.OwnerLogin, watched.RepoName, targetRepo.Id, targetRepo.FullName, targetRepo.HtmlUrl, targetRepo.Description, targetRepo.Private)
+		if err != nil {
+			LogAuditEvent("WATCH_SECRETS", nil, nil, serviceName, requestID, req.UserLogin, false, "Failed to get/create repository: "+err.Error())
+			return stream.Send(watchErrorEvent("Failed to get/create repository: " + err.Error()))
+		}
+		repos[repo.ID] = watched
+	}
+	repoIDs := make([]uint, 0, len(repos))
+	for repoID := range repos {
+		repoIDs = append(repoIDs, repoID)
+	}
+
+	// 3. Start after the resume token, as long as its event is still retained, or after the newest event
+	if req.ResumeToken != "" && afterID > 0 {
+		exists, err := SecretEventExists(afterID)
+		if err != nil {
+			LogAuditEvent("WATCH_SECRETS", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return stream.Send(watchErrorEvent(err.Error()))
+		}
+		if !exists {
+			LogAuditEvent("WATCH_SECRETS", nil, nil, serviceName, requestID, req.UserLogin, false, "Resume token expired")
+			return stream.Send(watchErrorEvent("Resume token expired, list the versions again and watch without a token"))
+		}
+	} else if req.ResumeToken == "" {
+		if afterID, err = LatestSecretEventID(); err != nil {
+			LogAuditEvent("WATCH_SECRETS", nil, nil, serviceName, requestID, req.UserLogin, false, err.Error())
+			return stream.Send(watchErrorEvent(err.Error()))
+		}
+	}
+
+	// 4. Subscribe before replaying so no event falls between the replay and the live events
+	watcher := &secretWatcher{
+		repoIDs: make(map[uint]bool),
+		tags:    make(map[string]bool),
+		events:  make(chan *SecretEvent, watchWatcherBuffer),
+		lagged:  make(chan struct{}),
+	}
+	for _, repoID := range repoIDs {
+		watcher.repoIDs[repoID] = true
+	}
+	for _, tag := range req.Tags {
+		watcher.tags[tag] = true
+	}
+	secretEvents.subscribe(watcher)
+	defer secretEvents.unsubscribe(watcher)
+
+	for _, repoID := range repoIDs {
+		LogAuditEvent("WATCH_SECRETS", &repoID, nil, serviceName, requestID, req.UserLogin, true, "")
+	}
+
+	// 5. Replay the events after the resume token
+	lastID := afterID
+	replayed := make(map[uint]bool)
+	for {
+		events, err := ListSecretEvents(repoIDs, req.Tags, lastID, watchReplayPageSize)
+		if err != nil {
+			return stream.Send(watchErrorEvent(err.Error()))
+		}
+		for i := range events {
+			if err := stream.Send(secretEventToProto(&events[i], repos[events[i].RepoID])); err != nil {
+				return err
+			}
+			replayed[events[i].ID] = true
+			lastID = events[i].ID
+		}
+		if len(events) < watchReplayPageSize {
+			break
+		}
+	}
+	if err := stream.Send(&secretsservice.WatchSecretsEvent{Type: watchEventReady, ResumeToken: formatResumeToken(lastID)}); err != nil {
+		return err
+	}
+
+	// 6. Stream live events until the client disconnects
+	heartbeat := time.NewTicker(watchHeartbeatInterval)
+	defer heartbeat.Stop()
+	recheck := time.NewTicker(watchAccessRecheckInterval)
+	defer recheck.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-watcher.lagged:
+			return stream.Send(&secretsservice.WatchSecretsEvent{Type: watchEventLagged, ResumeToken: formatResumeToken(lastID)})
+		case event := <-watcher.events:
+			if event.ID <= afterID || replayed[event.ID] {
+				continue
+			}
+			if err := stream.Send(secretEventToProto(event, repos[event.RepoID])); err != nil {
+				return err
+			}
+			if event.ID > lastID {
+				lastID = event.ID
+			}
+		case <-heartbeat.C:
+			if err := stream.Send(&secretsservice.WatchSecretsEvent{Type: watchEventHeartbeat, ResumeToken: formatResumeToken(lastID)}); err != nil {
+				return err
+			}
+		case <-recheck.C:
+			// Streams can outlive the user's access to a repository
+			listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: req.AccessToken})
+			if err != nil || listResp.Error != "" {
+				continue
+			}
+			for _, watched := range repos {
+				if !HasRepoAccess(listResp.Repos, watched.OwnerLogin, watched.RepoName) {
+					message := fmt.Sprintf("No access to repository %s/%s", watched.OwnerLogin, watched.RepoName)
+					LogAuditEvent("WATCH_SECRETS", nil, nil, serviceName, requestID, req.UserLogin, false, message)
+					return stream.Send(watchErrorEvent(message))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,642 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	secretsservice "github.com/kurs0n/SecretOperationService/proto"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Webhook events, named after what happened to the secret
+const (
+	webhookEventSecretUploaded    = "secret.uploaded"
+	webhookEventSecretGenerated   = "secret.generated"
+	webhookEventSecretDeleted     = "secret.deleted"
+	webhookEventFileUploaded      = "file.uploaded"
+	webhookEventFileDeleted       = "file.deleted"
+	webhookEventTagParentChanged  = "tag.parent_changed"
+	webhookEventSharedSetUploaded = "shared_set.uploaded"
+	webhookEventSharedSetAttached = "shared_set.attached"
+	webhookEventSharedSetDetached = "shared_set.detached"
+	webhookEventEngineSet         = "engine.set"
+	webhookEventEngineRemoved     = "engine.removed"
+)
+
+var webhookEvents = []string{
+	webhookEventSecretUploaded,
+	webhookEventSecretGenerated,
+	webhookEventSecretDeleted,
+	webhookEventFileUploaded,
+	webhookEventFileDeleted,
+	webhookEventTagParentChanged,
+	webhookEventSharedSetUploaded,
+	webhookEventSharedSetAttached,
+	webhookEventSharedSetDetached,
+	webhookEventEngineSet,
+	webhookEventEngineRemoved,
+}
+
+// webhookAllEvents subscribes a webhook to every event, including ones added later
+const webhookAllEvents = "*"
+
+// Delivery states
+const (
+	webhookDeliveryPending   = "pending"
+	webhookDeliveryDelivered = "delivered"
+	webhookDeliveryFailed    = "failed"
+)
+
+// Delivery of queued webhooks
+const (
+	defaultWebhookPollInterval = 5 * time.Second
+	webhookBatchSize           = 20
+	webhookMaxAttempts         = 10
+	webhookInitialBackoff      = 30 * time.Second
+	webhookMaxBackoff          = 6 * time.Hour
+	webhookTimeout             = 10 * time.Second
+	// A claimed delivery is retried after this if its instance died; a batch is sent sequentially, so the
+	// claim outlasts every delivery of the batch timing out
+	webhookClaimTimeout        = webhookBatchSize*webhookTimeout + time.Minute
+	webhookSecretLength        = 40
+	maxWebhookErrorLength      = 500
+	defaultWebhookDeliveryPage = 20
+	maxWebhookDeliveryPage     = 100
+)
+
+// webhookPayload is the JSON body of a delivery. It never contains secret values.
+type webhookPayload struct {
+	EventID         string `json:"eventId"` // Shared by redeliveries of the same event
+	Event           string `json:"event"`
+	CreatedAt       string `json:"createdAt"`
+	Actor           string `json:"actor"`
+	RequestID       string `json:"requestId,omitempty"`
+	OwnerLogin      string `json:"ownerLogin"`
+	RepoName        string `json:"repoName,omitempty"`
+	Tag             string `json:"tag,omitempty"`
+	Version         int    `json:"version,omitempty"`
+	Path            string `json:"path,omitempty"`
+	FileName        string `json:"fileName,omitempty"`
+	Branch          string `json:"branch,omitempty"`
+	Checksum        string `json:"checksum,omitempty"`
+	DeletedVersions int    `json:"deletedVersions,omitempty"`
+	ParentTag       string `json:"parentTag,omitempty"`
+	ParentVersion   int    `json:"parentVersion,omitempty"`
+	SharedSet       string `json:"sharedSet,omitempty"`
+	Engine          string `json:"engine,omitempty"` // Name of the secret engine
+}
+
+// webhookScope is the repository or organization a webhook belongs to
+type webhookScope struct {
+	repoID     *uint  // Set for repositories
+	ownerLogin string // Repository owner, or the organization
+	repoName   string
+}
+
+// authorizeWebhookScope resolves the scope of a webhook request. Repository webhooks need access to the
+// repository, organization webhooks an organization admin.
+func (s *Server) authorizeWebhookScope(ctx context.Context, accessToken, ownerLogin, repoName, orgLogin string) (*webhookScope, error) {
+	if orgLogin != "" {
+		if ownerLogin != "" || repoName != "" {
+			return nil, fmt.Errorf("specify either a repository or an organization")
+		}
+		role, err := getOrgRole(ctx, accessToken, orgLogin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check organization membership: %v", err)
+		}
+		if role != orgRoleAdmin {
+			return nil, fmt.Errorf("only organization admins can manage organization webhooks")
+		}
+		return &webhookScope{ownerLogin: orgLogin}, nil
+	}
+
+	if ownerLogin == "" || repoName == "" {
+		return nil, fmt.Errorf("specify a repository or an organization")
+	}
+	listResp, err := s.ListRepos(ctx, &secretsservice.ListReposRequest{AccessToken: accessToken})
+	if err != nil || listResp.Error != "" {
+		return nil, fmt.Errorf("failed to list repos: %s", listResp.Error)
+	}
+	var targetRepo *secretsservice.Repo
+	for _, repo := range listResp.Repos {
+		if repo.OwnerLogin == ownerLogin && repo.Name == repoName {
+			targetRepo = repo
+			break
+		}
+	}
+	if targetRepo == nil {
+		return nil, fmt.Errorf("no access to repository")
+	}
+
+	// Webhooks may be added before the first upload
+	repo, err := GetOrCreateRepository(ownerLogin, repoName, targetRepo.Id, targetRepo.FullName, targetRepo.HtmlUrl, targetRepo.Description, targetRepo.Private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get/create repository: %v", err)
+	}
+	return &webhookScope{repoID: &repo.ID, ownerLogin: ownerLogin, repoName: repoName}, nil
+}
+
+// authorizeWebhook loads a webhook and checks the user may manage it
+func (s *Server) authorizeWebhook(ctx context.Context, accessToken string, webhookID uint64) (*WebhookSubscription, *webhookScope, error) {
+	subscription, err := GetWebhookSubscription(uint(webhookID))
+	if err != nil {
+		return nil, nil, err
+	}
+	if subscription == nil {
+		return nil, nil, fmt.Errorf("webhook %d not found", webhookID)
+	}
+
+	if subscription.RepoID == nil {
+		scope, err := s.authorizeWebhookScope(ctx, accessToken, "", "", subscription.OrgLogin)
+		return subscription, scope, err
+	}
+
+	var repo Repository
+	if result := DB.First(&repo, *subscription.RepoID); result.Error != nil {
+		return nil, nil, fmt.Errorf("failed to get repository: %v", result.Error)
+	}
+	scope, err := s.authorizeWebhookScope(ctx, accessToken, repo.OwnerLogin, repo.RepoName, "")
+	return subscription, scope, err
+}
+
+func webhookToProto(subscription *WebhookSubscription, scope *webhookScope) *secretsservice.Webhook {
+	webhook := &secretsservice.Webhook{
+		Id:        uint64(subscription.ID),
+		Url:       subscription.URL,
+		Events:    strings.Split(subscription.Events, ","),
+		Active:    subscription.Active,
+		CreatedBy: subscription.CreatedBy,
+		CreatedAt: subscription.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: subscription.UpdatedAt.Format(time.RFC3339),
+	}
+	if scope.repoID != nil {
+		webhook.OwnerLogin, webhook.RepoName = scope.ownerLogin, scope.repoName
+	} else {
+		webhook.OrgLogin = scope.ownerLogin
+	}
+	return webhook
+}
+
+func webhookDeliveryToProto(delivery *WebhookDelivery) *secretsservice.WebhookDelivery {
+	converted := &secretsservice.WebhookDelivery{
+		Id:             uint64(delivery.ID),
+		DeliveryId:     delivery.DeliveryID,
+		Event:          delivery.Event,
+		Status:         delivery.Status,
+		Attempts:       int32(delivery.Attempts),
+		LastStatusCode: int32(delivery.LastStatusCode),
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt.Format(time.RFC3339),
+	}
+	if delivery.Status == webhookDeliveryPending {
+		converted.NextAttemptAt = delivery.NextAttemptAt.Format(time.RFC3339)
+	}
+	if delivery.DeliveredAt != nil {
+		converted.DeliveredAt = delivery.DeliveredAt.Format(time.RFC3339)
+	}
+	if delivery.RedeliveryOf != nil {
+		converted.RedeliveryOf = uint64(*delivery.RedeliveryOf)
+	}
+	return converted
+}
+
+// webhookSecret returns the HMAC key given by the user, or a generated one that is also returned so it
+// can be shown once
+func webhookSecret(requested string) (secret string, generated string, err error) {
+	if requested != "" {
+		if len(requested) < 16 {
+			return "", "", fmt.Errorf("webhook secret must be at least 16 characters")
+		}
+		return requested, "", nil
+	}
+	secret, err = generateWebhookSecret()
+	return secret, secret, err
+}
+
+// webhookWakeup shortens the wait for the next dispatch after events were queued
+var webhookWakeup = make(chan struct{}, 1)
+
+// newWebhookPayload starts the payload of an event on a repository
+func newWebhookPayload(event, ownerLogin, repoName, actor, requestID string) *webhookPayload {
+	return &webhookPayload{
+		Event:      event,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+		Actor:      actor,
+		RequestID:  requestID,
+		OwnerLogin: ownerLogin,
+		RepoName:   repoName,
+	}
+}
+
+// secretWebhookPayload starts the payload of an event on a secret version
+func secretWebhookPayload(event, ownerLogin, repoName, actor, requestID string, secret *Secret) *webhookPayload {
+	payload := newWebhookPayload(event, ownerLogin, repoName, actor, requestID)
+	payload.Tag = secret.Tag
+	payload.Version = secret.Version
+	payload.Path = secret.Path
+	payload.FileName = secret.FileName
+	payload.Branch = secret.Branch
+	payload.Checksum = secret.Checksum
+	return payload
+}
+
+// tagParentWebhookPayload is the payload of a changed tag parent; an empty parent tag means it was removed
+func tagParentWebhookPayload(req *secretsservice.SetTagParentRequest, scope SecretScope, requestID string) *webhookPayload {
+	payload := newWebhookPayload(webhookEventTagParentChanged, req.OwnerLogin, req.RepoName, req.UserLogin, requestID)
+	payload.Tag = req.Tag
+	payload.ParentTag = req.ParentTag
+	payload.ParentVersion = int(req.ParentVersion)
+	payload.Path = scope.Path
+	payload.FileName = secretFileName(scope.FileName)
+	return payload
+}
+
+// engineWebhookPayload starts the payload of an event on a secret engine; tag is empty for engines of every tag
+func engineWebhookPayload(event string, req *secretsservice.SetSecretEngineRequest, tag, requestID string) *webhookPayload {
+	payload := newWebhookPayload(event, req.OwnerLogin, req.RepoName, req.UserLogin, requestID)
+	payload.Engine = req.Name
+	payload.Tag = tag
+	return payload
+}
+
+// emitWebhookEvent queues the event for every active webhook of the repository, when repoID is set, and
+// of its owner's organization. The operation already succeeded, so failures are only logged.
+func emitWebhookEvent(repoID *uint, payload *webhookPayload) {
+	subscriptions, err := ListActiveWebhookSubscriptions(repoID, payload.OwnerLogin)
+	if err != nil {
+		log.Printf("Webhooks: %v", err)
+		return
+	}
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	if payload.EventID, err = generateUUID(); err != nil {
+		log.Printf("Webhooks: %v", err)
+		return
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Webhooks: failed to encode %s payload: %v", payload.Event, err)
+		return
+	}
+
+	queued := false
+	for _, subscription := range subscriptions {
+		if !webhookSubscribed(subscription.Events, payload.Event) {
+			continue
+		}
+		if err := queueWebhookDelivery(subscription.ID, payload.Event, string(body), nil); err != nil {
+			log.Printf("Webhooks: %v", err)
+			continue
+		}
+		queued = true
+	}
+	if queued {
+		wakeWebhookDispatcher()
+	}
+}
+
+func queueWebhookDelivery(subscriptionID uint, event, payload string, redeliveryOf *uint) error {
+	deliveryID, err := generateUUID()
+	if err != nil {
+		return err
+	}
+	return CreateWebhookDelivery(&WebhookDelivery{
+		SubscriptionID: subscriptionID,
+		DeliveryID:     deliveryID,
+		Event:          event,
+		Payload:        payload,
+		Status:         webhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+		RedeliveryOf:   redeliveryOf,
+	})
+}
+
+// redeliverWebhook queues a new delivery of the same event payload
+func redeliverWebhook(delivery *WebhookDelivery) (*WebhookDelivery, error) {
+	deliveryID, err := generateUUID()
+	if err != nil {
+		return nil, err
+	}
+	redelivery := &WebhookDelivery{
+		SubscriptionID: delivery.SubscriptionID,
+		DeliveryID:     deliveryID,
+		Event:          delivery.Event,
+		Payload:        delivery.Payload,
+		Status:         webhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+		RedeliveryOf:   &delivery.ID,
+	}
+	if err := CreateWebhookDelivery(redelivery); err != nil {
+		return nil, err
+	}
+	wakeWebhookDispatcher()
+	return redelivery, nil
+}
+
+func wakeWebhookDispatcher() {
+	select {
+	case webhookWakeup <- struct{}{}:
+	default:
+	}
+}
+
+func webhookSubscribed(events, event string) bool {
+	for _, subscribed := range strings.Split(events, ",") {
+		if subscribed == webhookAllEvents || subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWebhookEvents validates event names; no events subscribes to all of them
+func parseWebhookEvents(events []string) (string, error) {
+	if len(events) == 0 {
+		return webhookAllEvents, nil
+	}
+
+	seen := make(map[string]bool)
+	var parsed []string
+	for _, event := range events {
+		event = strings.TrimSpace(event)
+		if event == webhookAllEvents {
+			return webhookAllEvents, nil
+		}
+		known := false
+		for _, name := range webhookEvents {
+			if event == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return "", fmt.Errorf("unknown event %q, expected one of %s or *", event, strings.Join(webhookEvents, ", "))
+		}
+		if !seen[event] {
+			seen[event] = true
+			parsed = append(parsed, event)
+		}
+	}
+	return strings.Join(parsed, ","), nil
+}
+
+// webhookSharedAddressSpace is the carrier-grade NAT range, internal to the provider's network
+var webhookSharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// webhookAddressAllowed refuses loopback, private, link-local and other internal addresses, so webhooks
+// cannot reach services next to SecretOperationService, unless WEBHOOK_ALLOW_PRIVATE_NETWORKS is set
+func webhookAddressAllowed(ip net.IP) bool {
+	if os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS") == "true" {
+		return true
+	}
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified() && !webhookSharedAddressSpace.Contains(ip)
+}
+
+// webhookDialControl runs for every address a delivery connects to, after DNS resolution, so a host name
+// that resolves to an internal address is refused even if its DNS changed after the webhook was created
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !webhookAddressAllowed(ip) {
+		return fmt.Errorf("webhook address %s is in an internal network", host)
+	}
+	return nil
+}
+
+// validateWebhookURL requires https, unless WEBHOOK_ALLOW_HTTP is set, and a host allowed by
+// WEBHOOK_ALLOWED_HOSTS, a comma separated list of host names; every host is allowed when it is unset.
+// Internal addresses are refused here when given literally and again when a delivery connects.
+func validateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("invalid webhook URL")
+	}
+	if parsed.User != nil {
+		return fmt.Errorf("webhook URL must not contain credentials")
+	}
+	switch parsed.Scheme {
+	case "https":
+	case "http":
+		if os.Getenv("WEBHOOK_ALLOW_HTTP") != "true" {
+			return fmt.Errorf("webhook URL must use https")
+		}
+	default:
+		return fmt.Errorf("webhook URL must use https")
+	}
+
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil && !webhookAddressAllowed(ip) {
+		return fmt.Errorf("webhook URL must not point to an internal network")
+	}
+	if strings.EqualFold(parsed.Hostname(), "localhost") && !webhookAddressAllowed(net.IPv6loopback) {
+		return fmt.Errorf("webhook URL must not point to an internal network")
+	}
+
+	allowed := os.Getenv("WEBHOOK_ALLOWED_HOSTS")
+	if allowed == "" {
+		return nil
+	}
+	for _, host := range strings.Split(allowed, ",") {
+		if strings.EqualFold(strings.TrimSpace(host), parsed.Hostname()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("host %s is not in WEBHOOK_ALLOWED_HOSTS", parsed.Hostname())
+}
+
+// generateWebhookSecret returns a random HMAC key for a webhook created without one
+func generateWebhookSecret() (string, error) {
+	return generateRandomString("alphanumeric", webhookSecretLength)
+}
+
+// signWebhookPayload returns the X-Envini-Signature-256 header: the hex HMAC-SHA256 of the body
+func signWebhookPayload(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is the wait after the given number of failed attempts: 30s, 1m, 2m, ... up to 6h
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookInitialBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > webhookMaxBackoff {
+		backoff = webhookMaxBackoff
+	}
+	return backoff
+}
+
+// webhookPollInterval reads WEBHOOK_POLL_INTERVAL, e.g. "10s"
+func webhookPollInterval() time.Duration {
+	if value := os.Getenv("WEBHOOK_POLL_INTERVAL"); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil && interval > 0 {
+			return interval
+		}
+		log.Printf("Invalid WEBHOOK_POLL_INTERVAL %q, using default", value)
+	}
+	return defaultWebhookPollInterval
+}
+
+// runWebhookDispatcher delivers due webhooks until the process exits
+func runWebhookDispatcher(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	dialer := &net.Dialer{Timeout: webhookTimeout, Control: webhookDialControl}
+	client := &http.Client{
+		Timeout: webhookTimeout,
+		// No proxy: the dialer must see the webhook's own address to refuse internal ones
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+			ForceAttemptHTTP2:   true,
+		},
+		// A redirect could point the signed payload anywhere
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	for {
+		select {
+		case <-ticker.C:
+		case <-webhookWakeup:
+		}
+		dispatchWebhooks(client)
+	}
+}
+
+// dispatchWebhooks sends claimed deliveries until none are due
+func dispatchWebhooks(client *http.Client) {
+	for {
+		deliveries, err := claimWebhookDeliveries()
+		if err != nil {
+			log.Printf("Webhooks: %v", err)
+			return
+		}
+		for i := range deliveries {
+			sendWebhookDelivery(client, &deliveries[i])
+		}
+		if len(deliveries) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// claimWebhookDeliveries locks due deliveries of active webhooks and pushes their next attempt back, so
+// other instances skip them while they are sent
+func claimWebhookDeliveries() ([]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", webhookDeliveryPending, time.Now()).
+			Where("subscription_id IN (?)", tx.Model(&WebhookSubscription{}).Select("id").Where("active = ?", true)).
+			Order("next_attempt_at ASC").
+			Limit(webhookBatchSize).
+			Find(&deliveries)
+		if result.Error != nil || len(deliveries) == 0 {
+			return result.Error
+		}
+
+		ids := make([]uint, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+		}
+		return tx.Model(&WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", time.Now().Add(webhookClaimTimeout)).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %v", err)
+	}
+	return deliveries, nil
+}
+
+// sendWebhookDelivery makes one attempt and records its outcome; failures are retried with exponential
+// backoff until webhookMaxAttempts
+func sendWebhookDelivery(client *http.Client, delivery *WebhookDelivery) {
+	statusCode, err := postWebhook(client, delivery)
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"attempts":         delivery.Attempts + 1,
+		"last_status_code": statusCode,
+		"last_error":       "",
+	}
+	switch {
+	case err == nil:
+		updates["status"] = webhookDeliveryDelivered
+		updates["delivered_at"] = &now
+	case delivery.Attempts+1 >= webhookMaxAttempts:
+		updates["status"] = webhookDeliveryFailed
+		updates["last_error"] = truncateWebhookError(err.Error())
+	default:
+		updates["next_attempt_at"] = now.Add(webhookBackoff(delivery.Attempts + 1))
+		updates["last_error"] = truncateWebhookError(err.Error())
+	}
+
+	if result := DB.Model(&WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(updates); result.Error != nil {
+		log.Printf("Webhooks: failed to record delivery %s: %v", delivery.DeliveryID, result.Error)
+	}
+}
+
+// postWebhook sends the signed payload; any 2xx response is a success
+func postWebhook(client *http.Client, delivery *WebhookDelivery) (int, error) {
+	subscription, err := GetWebhookSubscription(delivery.SubscriptionID)
+	if err != nil {
+		return 0, err
+	}
+	if subscription == nil {
+		return 0, fmt.Errorf("webhook was deleted")
+	}
+	secret, err := openWithKey(subscription.Secret, subscription.EncryptedKey)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decrypt webhook secret: %v", err)
+	}
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Envini-Webhooks/1")
+	req.Header.Set("X-Envini-Event", delivery.Event)
+	req.Header.Set("X-Envini-Delivery", delivery.DeliveryID)
+	req.Header.Set("X-Envini-Signature-256", signWebhookPayload(secret, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// The body is not recorded: an endpoint inside a private network could otherwise be read through it
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func truncateWebhookError(message string) string {
+	if len(message) > maxWebhookErrorLength {
+		return message[:maxWebhookErrorLength]
+	}
+	return message
+}
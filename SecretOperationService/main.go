@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/joho/godotenv"
 	"github.com/kurs0n/SecretOperationService/internal"
@@ -18,6 +20,11 @@ func main() {
 		log.Fatal("Failed to initialize database:", err)
 	}
 
+	// Admin command: verify the audit chain and exit
+	if len(os.Args) > 1 && os.Args[1] == "verify-audit" {
+		os.Exit(verifyAudit())
+	}
+
 	// Start gRPC server
 	internal.RunGRPCServer()
 }
@@ -29,3 +36,27 @@ func loadEnv() error {
 	}
 	return nil
 }
+
+// verifyAudit prints the audit chain verification report and returns the exit code
+func verifyAudit() int {
+	report, err := internal.VerifyAuditChain()
+	if err != nil {
+		log.Println("Failed to verify audit log:", err)
+		return 2
+	}
+
+	fmt.Printf("Checked entries: %d\n", report.CheckedEntries)
+	if report.LegacyEntries > 0 {
+		fmt.Printf("Unchained legacy entries: %d\n", report.LegacyEntries)
+	}
+	fmt.Printf("Verified checkpoints: %d (last covers entry %d)\n", report.CheckpointsVerified, report.LastCheckpointID)
+	if report.CheckpointGapID > 0 {
+		fmt.Printf("WARNING: entries from %d on were not signed on schedule; compare with the exported checkpoints\n", report.CheckpointGapID)
+	}
+	if !report.Valid {
+		fmt.Printf("BROKEN at entry %d: %s\n", report.FirstBrokenID, report.Reason)
+		return 1
+	}
+	fmt.Println("Audit chain is intact")
+	return 0
+}